@@ -1,8 +1,13 @@
 package analyzer
 
 import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
 	"github.com/oasislabs/oasis-indexer/storage"
 )
 
@@ -18,11 +23,33 @@ type Analyzer interface {
 	Name() string
 }
 
+// Reindexer is an analyzer which can roll back its indexed data
+// to reprocess blocks.
+type Reindexer interface {
+	Analyzer
+
+	// Rollback rolls back indexed data to the provided height, so that
+	// analysis resumes from the following height. It is intended to be
+	// called after SetConfig and before Start.
+	Rollback(ctx context.Context, height int64) error
+}
+
+// ShardProgressInfix separates the name of an analyzer from the range of a
+// shard in the name under which progress of the shard is recorded, e.g.
+// `consensus_main_damask_shard_1_1000000`.
+const ShardProgressInfix = "_shard_"
+
+// ShardProgressPattern is a LIKE pattern, escaped with backslashes, matching
+// the names under which progress of shards is recorded. Shards, e.g. backfill
+// shards, may be far ahead of indexed state, so the latest height of indexed
+// state is the latest height processed by any analyzer other than a shard.
+var ShardProgressPattern = "%" + strings.ReplaceAll(ShardProgressInfix, "_", `\_`) + "%"
+
 // Config specifies configuration parameters
 // for processing the network.
 type Config struct {
-	// ChainID is the chain ID for the underlying network.
-	ChainID string
+	// Chain is the chain of the underlying network.
+	Chain *Chain
 
 	// BlockRange is the range of blocks to process.
 	// If this is set, the analyzer analyzes blocks in the provided range.
@@ -35,6 +62,71 @@ type Config struct {
 	// Source is the storage source from which to fetch block data
 	// when processing blocks in this range.
 	Source storage.SourceStorage
+
+	// Options are the type-specific options of the analyzer.
+	Options map[string]interface{}
+
+	// Lease is the lease coordinating this analyzer across indexer
+	// instances. If this is not set, the analyzer runs uncoordinated.
+	Lease *coordination.Lease
+
+	// Quarantine is the policy for blocks which repeatedly fail to process.
+	// If this is not set, failing blocks are retried indefinitely.
+	Quarantine *QuarantinePolicy
+
+	// Invariants is the policy for checking accounting invariants.
+	// If this is not set, invariants are not checked.
+	Invariants *InvariantPolicy
+}
+
+// QuarantinePolicy is the policy for blocks which repeatedly fail to process.
+type QuarantinePolicy struct {
+	// MaxAttempts is the number of attempts to process a block
+	// before it is quarantined.
+	MaxAttempts int
+
+	// Halt is true iff the analyzer should stop once a block is quarantined.
+	Halt bool
+}
+
+// InvariantPolicy is the policy for checking accounting invariants.
+type InvariantPolicy struct {
+	// PerEpoch is true iff invariants should only be checked at the
+	// first block of each epoch, rather than after every block.
+	PerEpoch bool
+
+	// Halt is true iff the analyzer should stop once an invariant is violated.
+	Halt bool
+}
+
+// Int64Option returns the value of the provided integer option, and
+// whether it was set.
+func (cfg *Config) Int64Option(name string) (int64, bool, error) {
+	v, ok := cfg.Options[name]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := v.(type) {
+	case int:
+		return int64(v), true, nil
+	case int64:
+		return v, true, nil
+	case uint64:
+		return int64(v), true, nil
+	case float64:
+		if v != float64(int64(v)) {
+			return 0, true, fmt.Errorf("option '%s' is not an integer", name)
+		}
+		return int64(v), true, nil
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, true, fmt.Errorf("option '%s': %w", name, err)
+		}
+		return i, true, nil
+	default:
+		return 0, true, fmt.Errorf("option '%s' has unsupported type %T", name, v)
+	}
 }
 
 // Range is a range of blocks.
@@ -165,24 +257,3 @@ func (e *Event) String() string {
 		return "Unknown"
 	}
 }
-
-// ChainID is the ID of a chain.
-type ChainID string
-
-// FromHeight returns the ChainID for the provided height.
-func FromHeight(height int64) ChainID {
-	switch {
-	case height < 702000:
-		return "mainnet_beta_2020_10_01_1601568000"
-	case height < 3027601:
-		return "oasis_1"
-	case height < 8048956:
-		return "oasis_2"
-	}
-	return "oasis_3"
-}
-
-// String returns the string representation of a ChainID.
-func (c ChainID) String() string {
-	return string(c)
-}
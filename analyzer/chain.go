@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+
+	"github.com/oasislabs/oasis-indexer/config"
+)
+
+// ErrChainNotFound is returned when no chain in the registry
+// matches the provided height or chain ID.
+var ErrChainNotFound = errors.New("chain not found")
+
+// ChainID is the ID of a chain.
+type ChainID string
+
+// String returns the string representation of a ChainID.
+func (c ChainID) String() string {
+	return string(c)
+}
+
+// Chain is a chain known to the indexer.
+type Chain struct {
+	// ID is the chain ID of the chain.
+	ID ChainID
+
+	// ChainContext is the domain separation context of the chain.
+	ChainContext string
+
+	// Schema is the target storage schema for the chain.
+	Schema string
+
+	// MigrationsTable is the table tracking migrations for the chain's schema.
+	MigrationsTable string
+
+	// Range is the range of blocks belonging to the chain.
+	// A zero `To` means the chain is ongoing.
+	Range Range
+}
+
+// Contains returns true iff the provided height belongs to the chain.
+func (c *Chain) Contains(height int64) bool {
+	return height >= c.Range.From && (c.Range.To == 0 || height <= c.Range.To)
+}
+
+// ChainRegistry is a registry of chains known to the indexer,
+// ordered by height.
+type ChainRegistry struct {
+	chains []*Chain
+}
+
+// DefaultChainConfigs are the chains used when none are configured,
+// i.e. the Oasis Network Mainnet.
+var DefaultChainConfigs = []*config.ChainConfig{
+	{
+		ChainID: "mainnet-beta-2020-10-01-1601568000",
+		Schema:  "mainnet_beta_2020_10_01_1601568000",
+		From:    1,
+		To:      701999,
+	},
+	{
+		ChainID: "oasis-1",
+		From:    702000,
+		To:      3027600,
+	},
+	{
+		ChainID: "oasis-2",
+		From:    3027601,
+		To:      8048955,
+	},
+	{
+		ChainID:      "oasis-3",
+		ChainContext: "b11b369e0da5bb230b220127f5e7b242d385ef8c6f54906243f30af63c815535",
+		// Migrations for this chain were tracked in the default
+		// table before the chain registry was introduced.
+		MigrationsTable: "schema_migrations",
+		From:            8048956,
+	},
+}
+
+// NewChainRegistry creates a new chain registry from the provided
+// chain configs. If none are provided, DefaultChainConfigs are used.
+func NewChainRegistry(cfgs []*config.ChainConfig) (*ChainRegistry, error) {
+	if len(cfgs) == 0 {
+		cfgs = DefaultChainConfigs
+	}
+
+	chains := make([]*Chain, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		schema := cfg.Schema
+		if schema == "" {
+			schema = strcase.ToSnake(cfg.ChainID)
+		}
+		migrationsTable := cfg.MigrationsTable
+		if migrationsTable == "" {
+			migrationsTable = fmt.Sprintf("schema_migrations_%s", schema)
+		}
+		chains = append(chains, &Chain{
+			ID:              ChainID(cfg.ChainID),
+			ChainContext:    cfg.ChainContext,
+			Schema:          schema,
+			MigrationsTable: migrationsTable,
+			Range: Range{
+				From: cfg.From,
+				To:   cfg.To,
+			},
+		})
+	}
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].Range.From < chains[j].Range.From
+	})
+
+	for i := 1; i < len(chains); i++ {
+		prev := chains[i-1]
+		if prev.Range.To == 0 || prev.Range.To >= chains[i].Range.From {
+			return nil, fmt.Errorf("chain %s overlaps chain %s", prev.ID, chains[i].ID)
+		}
+	}
+
+	return &ChainRegistry{chains}, nil
+}
+
+// FromHeight returns the chain containing the provided height.
+func (r *ChainRegistry) FromHeight(height int64) (*Chain, error) {
+	for _, c := range r.chains {
+		if c.Contains(height) {
+			return c, nil
+		}
+	}
+	return nil, ErrChainNotFound
+}
+
+// FromID returns the chain with the provided chain ID.
+func (r *ChainRegistry) FromID(id string) (*Chain, error) {
+	for _, c := range r.chains {
+		if string(c.ID) == id {
+			return c, nil
+		}
+	}
+	return nil, ErrChainNotFound
+}
+
+// Latest returns the most recent chain.
+func (r *ChainRegistry) Latest() *Chain {
+	return r.chains[len(r.chains)-1]
+}
+
+// All returns all chains, ordered by height.
+func (r *ChainRegistry) All() []*Chain {
+	return r.chains
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/config"
+)
+
+// TestChainRegistryDefault tests that the default registry
+// resolves chains from heights.
+func TestChainRegistryDefault(t *testing.T) {
+	chains, err := NewChainRegistry(nil)
+	require.Nil(t, err)
+
+	for height, expected := range map[int64]ChainID{
+		1:        "mainnet-beta-2020-10-01-1601568000",
+		701999:   "mainnet-beta-2020-10-01-1601568000",
+		702000:   "oasis-1",
+		3027601:  "oasis-2",
+		8048955:  "oasis-2",
+		8048956:  "oasis-3",
+		10000000: "oasis-3",
+	} {
+		chain, err := chains.FromHeight(height)
+		require.Nil(t, err)
+		require.Equal(t, expected, chain.ID)
+	}
+
+	_, err = chains.FromHeight(0)
+	require.Equal(t, ErrChainNotFound, err)
+
+	latest := chains.Latest()
+	require.Equal(t, ChainID("oasis-3"), latest.ID)
+	require.Equal(t, "oasis_3", latest.Schema)
+	require.Equal(t, "schema_migrations", latest.MigrationsTable)
+}
+
+// TestChainRegistryConfigured tests that configured chains
+// replace the default chains.
+func TestChainRegistryConfigured(t *testing.T) {
+	chains, err := NewChainRegistry([]*config.ChainConfig{
+		{ChainID: "test-2", From: 101},
+		{ChainID: "test-1", Schema: "legacy", From: 1, To: 100},
+	})
+	require.Nil(t, err)
+	require.Len(t, chains.All(), 2)
+
+	chain, err := chains.FromID("test-2")
+	require.Nil(t, err)
+	require.Equal(t, "test_2", chain.Schema)
+	require.Equal(t, "schema_migrations_test_2", chain.MigrationsTable)
+	require.Equal(t, chain, chains.Latest())
+
+	chain, err = chains.FromHeight(50)
+	require.Nil(t, err)
+	require.Equal(t, "legacy", chain.Schema)
+
+	_, err = chains.FromID("oasis-3")
+	require.Equal(t, ErrChainNotFound, err)
+}
+
+// TestChainRegistryOverlap tests that overlapping chains are rejected.
+func TestChainRegistryOverlap(t *testing.T) {
+	_, err := NewChainRegistry([]*config.ChainConfig{
+		{ChainID: "test-1", From: 1},
+		{ChainID: "test-2", From: 101},
+	})
+	require.NotNil(t, err)
+}
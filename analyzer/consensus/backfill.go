@@ -0,0 +1,171 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	// backfillToOption is the option setting the (inclusive) last height
+	// to backfill in parallel before processing blocks sequentially.
+	backfillToOption = "backfill_to"
+
+	// backfillShardsOption is the option setting the number of shards
+	// into which the backfill range is split.
+	backfillShardsOption = "backfill_shards"
+
+	defaultBackfillShards = 4
+)
+
+// backfillConfig is the configuration for backfilling a historical range.
+type backfillConfig struct {
+	// To is the (inclusive) last height to backfill.
+	// If this is zero, backfilling is disabled.
+	To int64
+
+	// Shards is the number of shards into which to split the range.
+	Shards int64
+}
+
+// parseBackfillConfig parses the backfill configuration from the analyzer options.
+func parseBackfillConfig(cfg *analyzer.Config) (backfillConfig, error) {
+	to, ok, err := cfg.Int64Option(backfillToOption)
+	if err != nil || !ok {
+		return backfillConfig{}, err
+	}
+	if to < cfg.BlockRange.From || (cfg.BlockRange.To != 0 && to > cfg.BlockRange.To) {
+		return backfillConfig{}, fmt.Errorf("backfill height %d outside of analysis range", to)
+	}
+
+	shards, ok, err := cfg.Int64Option(backfillShardsOption)
+	if err != nil {
+		return backfillConfig{}, err
+	}
+	if !ok {
+		shards = defaultBackfillShards
+	}
+	if shards <= 0 {
+		return backfillConfig{}, fmt.Errorf("malformed backfill shards %d", shards)
+	}
+
+	return backfillConfig{
+		To:     to,
+		Shards: shards,
+	}, nil
+}
+
+// shardRanges splits the provided range into at most n contiguous shards
+// of near-equal size.
+func shardRanges(r analyzer.Range, n int64) []analyzer.Range {
+	size := r.To - r.From + 1
+	if size <= 0 {
+		return nil
+	}
+	if n > size {
+		n = size
+	}
+
+	shards := make([]analyzer.Range, 0, n)
+	from := r.From
+	for i := int64(0); i < n; i++ {
+		// Spread the remainder over the first shards.
+		length := size / n
+		if i < size%n {
+			length++
+		}
+		shards = append(shards, analyzer.Range{
+			From: from,
+			To:   from + length - 1,
+		})
+		from += length
+	}
+	return shards
+}
+
+// shardName returns the name under which progress of the provided
+// backfill shard is recorded.
+func (m *Main) shardName(shard analyzer.Range) string {
+	return fmt.Sprintf("%s%s%d_%d", m.name, analyzer.ShardProgressInfix, shard.From, shard.To)
+}
+
+// shardPattern returns a LIKE pattern, escaped with backslashes, matching
+// the names under which progress of backfill shards is recorded.
+func (m *Main) shardPattern() string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(m.name + analyzer.ShardProgressInfix)
+	return escaped + `%`
+}
+
+// runBackfill backfills the configured historical range. Append-only data,
+// i.e. blocks, transactions and events, is ingested by shards in parallel,
+// with progress recorded and leases held per shard. State updates are replayed in order,
+// with progress recorded under the analyzer name.
+func (m *Main) runBackfill(ctx context.Context) error {
+	r := analyzer.Range{
+		From: m.cfg.BlockRange.From,
+		To:   m.backfill.To,
+	}
+
+	latest, err := m.latestBlock(ctx, m.name)
+	switch {
+	case err == nil && latest >= r.To:
+		m.logger.Info("backfill already completed",
+			"to", r.To,
+		)
+		return nil
+	case err != nil && err != storage.ErrNoRows:
+		return err
+	}
+
+	shards := shardRanges(r, m.backfill.Shards)
+	m.logger.Info("starting backfill",
+		"from", r.From,
+		"to", r.To,
+		"shards", len(shards),
+	)
+
+	// Partitions for the whole range are created up front, rather
+	// than by each shard as it advances.
+	if err := m.ensurePartitions(ctx, r.From, r.To); err != nil {
+		return err
+	}
+
+	m.backfilling = true
+	var wg sync.WaitGroup
+	for _, shard := range shards {
+		wg.Add(1)
+		go func(shard analyzer.Range) {
+			defer wg.Done()
+			name := m.shardName(shard)
+			m.run(ctx, name, shard, m.appendOnlyPrepareFuncs())
+			m.releaseLease(ctx, name)
+		}(shard)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		m.run(ctx, m.name, r, m.statePrepareFuncs())
+	}()
+	wg.Wait()
+	m.backfilling = false
+
+	// Shards stop early if their data source becomes unavailable.
+	for _, shard := range shards {
+		if latest, err := m.latestBlock(ctx, m.shardName(shard)); err != nil || latest < shard.To {
+			return fmt.Errorf("backfill shard %s incomplete", m.shardName(shard))
+		}
+	}
+	if latest, err := m.latestBlock(ctx, m.name); err != nil || latest < r.To {
+		return fmt.Errorf("backfill state replay incomplete")
+	}
+
+	m.logger.Info("backfill completed",
+		"from", r.From,
+		"to", r.To,
+	)
+	return nil
+}
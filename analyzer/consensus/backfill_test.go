@@ -0,0 +1,56 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+)
+
+// TestShardRanges tests that ranges are split into contiguous shards
+// covering the whole range.
+func TestShardRanges(t *testing.T) {
+	shards := shardRanges(analyzer.Range{From: 10, To: 20}, 3)
+	require.Equal(t, []analyzer.Range{
+		{From: 10, To: 13},
+		{From: 14, To: 17},
+		{From: 18, To: 20},
+	}, shards)
+
+	shards = shardRanges(analyzer.Range{From: 1, To: 2}, 4)
+	require.Equal(t, []analyzer.Range{
+		{From: 1, To: 1},
+		{From: 2, To: 2},
+	}, shards)
+
+	require.Empty(t, shardRanges(analyzer.Range{From: 2, To: 1}, 4))
+}
+
+// TestParseBackfillConfig tests parsing backfill options.
+func TestParseBackfillConfig(t *testing.T) {
+	cfg := analyzer.Config{
+		BlockRange: analyzer.Range{From: 100},
+	}
+	backfill, err := parseBackfillConfig(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, backfillConfig{}, backfill)
+
+	cfg.Options = map[string]interface{}{backfillToOption: 200}
+	backfill, err = parseBackfillConfig(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, backfillConfig{To: 200, Shards: defaultBackfillShards}, backfill)
+
+	cfg.Options = map[string]interface{}{backfillToOption: "200", backfillShardsOption: 8}
+	backfill, err = parseBackfillConfig(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, backfillConfig{To: 200, Shards: 8}, backfill)
+
+	cfg.Options = map[string]interface{}{backfillToOption: 50}
+	_, err = parseBackfillConfig(&cfg)
+	require.NotNil(t, err)
+
+	cfg.Options = map[string]interface{}{backfillToOption: 200, backfillShardsOption: 0}
+	_, err = parseBackfillConfig(&cfg)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,121 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/generator"
+)
+
+// bootstrapHeightOption is the option setting the height at which to load
+// the full consensus state from the node, if the analyzer has not yet
+// made any progress. Analysis then resumes at the following height.
+const bootstrapHeightOption = "bootstrap_height"
+
+// parseBootstrapHeight parses the bootstrap height from the analyzer options.
+// It returns zero if bootstrapping is disabled.
+func parseBootstrapHeight(cfg *analyzer.Config) (int64, error) {
+	height, ok, err := cfg.Int64Option(bootstrapHeightOption)
+	if err != nil || !ok {
+		return 0, err
+	}
+	if height < cfg.BlockRange.From || (cfg.BlockRange.To != 0 && height > cfg.BlockRange.To) {
+		return 0, fmt.Errorf("bootstrap height %d outside of analysis range", height)
+	}
+	if _, ok := cfg.Options[backfillToOption]; ok {
+		return 0, fmt.Errorf("bootstrapping is incompatible with backfilling")
+	}
+	return height, nil
+}
+
+// bootstrap loads the registry, staking, scheduler and governance state at
+// the configured bootstrap height into target storage, and records the
+// height as processed. It is a no-op if the analyzer has already made
+// progress.
+func (m *Main) bootstrap(ctx context.Context) error {
+	height := m.bootstrapHeight
+
+	if done, err := m.bootstrapped(ctx); err != nil || done {
+		return err
+	}
+	waited, err := m.holdLease(ctx, m.name)
+	if err != nil {
+		return err
+	}
+	if waited {
+		// The previous holder may have bootstrapped in the meantime.
+		if done, err := m.bootstrapped(ctx); err != nil || done {
+			return err
+		}
+	}
+
+	m.logger.Info("bootstrapping from node state",
+		"height", height,
+	)
+	batch := &storage.QueryBatch{}
+	if err := m.queueStateSnapshot(ctx, height, batch); err != nil {
+		return err
+	}
+	batch.Queue(fmt.Sprintf(`
+		INSERT INTO %s.processed_blocks (height, analyzer, processed_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP);
+	`, m.cfg.Chain.Schema),
+		height,
+		m.name,
+	)
+
+	opName := "bootstrap"
+	timer := m.metrics.DatabaseTimer(m.target.Name(), opName)
+	defer timer.ObserveDuration()
+
+	if err := m.target.SendBatch(ctx, batch); err != nil {
+		m.metrics.DatabaseCounter(m.target.Name(), opName, "failure").Inc()
+		return err
+	}
+	m.metrics.DatabaseCounter(m.target.Name(), opName, "success").Inc()
+
+	m.logger.Info("bootstrap completed",
+		"height", height,
+		"queries", batch.Len(),
+	)
+	return nil
+}
+
+// bootstrapped returns true iff the analyzer has made progress, e.g. if
+// it has already been bootstrapped, in which case bootstrapping is skipped.
+func (m *Main) bootstrapped(ctx context.Context) (bool, error) {
+	latest, err := m.latestBlock(ctx, m.name)
+	switch err {
+	case nil:
+		m.logger.Info("progress found, skipping bootstrap",
+			"latest", latest,
+		)
+		return true, nil
+	case storage.ErrNoRows:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// queueStateSnapshot adds queries to the batch that replace the registry,
+// staking, scheduler and governance state with the node state at the
+// provided height.
+func (m *Main) queueStateSnapshot(ctx context.Context, height int64, batch *storage.QueryBatch) error {
+	source, err := m.source(height)
+	if err != nil {
+		return err
+	}
+	document, err := source.GenesisDocumentAtHeight(ctx, height)
+	if err != nil {
+		return err
+	}
+
+	if err := generator.NewMigrationGenerator(m.logger).QueueGenesisDocument(batch, m.cfg.Chain.Schema, document); err != nil {
+		return err
+	}
+	// Validator voting power and committees are not part of the genesis document.
+	return m.prepareSchedulerData(ctx, height, batch)
+}
@@ -0,0 +1,99 @@
+package consensus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+// TestParseBootstrapHeight tests parsing the bootstrap option.
+func TestParseBootstrapHeight(t *testing.T) {
+	cfg := analyzer.Config{
+		BlockRange: analyzer.Range{From: 100, To: 1000},
+	}
+	height, err := parseBootstrapHeight(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, int64(0), height)
+
+	cfg.Options = map[string]interface{}{bootstrapHeightOption: 500}
+	height, err = parseBootstrapHeight(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, int64(500), height)
+
+	cfg.Options = map[string]interface{}{bootstrapHeightOption: 2000}
+	_, err = parseBootstrapHeight(&cfg)
+	require.NotNil(t, err)
+
+	cfg.Options = map[string]interface{}{bootstrapHeightOption: 500, backfillToOption: 600}
+	_, err = parseBootstrapHeight(&cfg)
+	require.NotNil(t, err)
+}
+
+// leaderStorage is target storage in which another instance bootstraps
+// once this instance first tries to acquire the lease.
+type leaderStorage struct {
+	storage.TargetStorage
+	once      sync.Once
+	bootstrap func()
+}
+
+// QueryRow implements storage.TargetStorage.
+func (s *leaderStorage) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	r := s.TargetStorage.QueryRow(ctx, sql, args...)
+	if strings.Contains(sql, "leases") {
+		s.once.Do(s.bootstrap)
+	}
+	return r
+}
+
+// TestBootstrapAfterWaiting tests that bootstrapping is skipped if
+// another instance bootstrapped while this instance waited for the lease.
+func TestBootstrapAfterWaiting(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+	logger := log.NewDefaultLogger("consensus-test")
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	client, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer client.Shutdown()
+
+	// Another instance holds the lease, and bootstraps before it expires.
+	other := coordination.NewLease(client, chain.Schema, "consensus_bootstrap_test", "leader", 100*time.Millisecond)
+	require.Nil(t, other.Acquire(ctx))
+	target := &leaderStorage{
+		TargetStorage: client,
+		bootstrap: func() {
+			batch := &storage.QueryBatch{}
+			batch.Queue(`
+				INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+					VALUES ($1, $2, CURRENT_TIMESTAMP);
+			`, int64(500), "consensus_bootstrap_test")
+			require.Nil(t, client.SendBatch(ctx, batch))
+		},
+	}
+
+	m := NewMain("consensus_bootstrap_test", target, logger)
+	m.cfg.Chain = chain
+	m.cfg.Lease = coordination.NewLease(target, chain.Schema, m.name, "standby", 100*time.Millisecond)
+	m.bootstrapHeight = 500
+
+	// Bootstrapping without a source would fail if it were not skipped.
+	require.Nil(t, m.bootstrap(ctx))
+	require.True(t, m.cfg.Lease.Held())
+}
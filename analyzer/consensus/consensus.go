@@ -7,10 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/iancoleman/strcase"
-	"github.com/jackc/pgx/v4"
 	"github.com/oasisprotocol/oasis-core/go/common/cbor"
 	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction"
 	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction/results"
@@ -20,7 +19,9 @@ import (
 	registry "github.com/oasisprotocol/metadata-registry-tools"
 
 	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
 	"github.com/oasislabs/oasis-indexer/analyzer/util"
+	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/metrics"
 	"github.com/oasislabs/oasis-indexer/storage"
@@ -29,6 +30,10 @@ import (
 const (
 	consensusMainDamaskName = "consensus_main_damask"
 	registryUpdateFrequency = 100 // once per n block
+
+	// maxConcurrentPrepares is the maximum number of prepare functions
+	// fetching data from the source concurrently.
+	maxConcurrentPrepares = 32
 )
 
 var (
@@ -39,22 +44,80 @@ var (
 	// ErrLatestBlockNotFound is returned if the analyzer has not indexed any
 	// blocks yet. This indicates to begin from the start of its range.
 	ErrLatestBlockNotFound = errors.New("latest block not found")
+
+	// errLeaseLost is returned if the analyzer lease expired while
+	// preparing updates, which are then not applied.
+	errLeaseLost = errors.New("lease lost before commit")
 )
 
+func init() {
+	analyzer.Register(
+		consensusMainDamaskName,
+		config.AnalyzerSchema{
+			RequiresRPC: true,
+			Options: []string{
+				backfillToOption,
+				backfillShardsOption,
+				bootstrapHeightOption,
+				batchBlocksOption,
+			},
+			ValidateOptions: validateOptions,
+		},
+		func(name string, target storage.TargetStorage, logger *log.Logger) (analyzer.Analyzer, error) {
+			return NewMain(name, target, logger), nil
+		},
+	)
+}
+
+// validateOptions validates the backfill, bootstrap and batch options.
+func validateOptions(cfg *config.AnalyzerConfig) error {
+	analyzerCfg := analyzer.OptionsConfig(cfg)
+	if _, err := parseBackfillConfig(analyzerCfg); err != nil {
+		return err
+	}
+	if _, err := parseBootstrapHeight(analyzerCfg); err != nil {
+		return err
+	}
+	if _, err := parseBatchBlocks(analyzerCfg); err != nil {
+		return err
+	}
+	return nil
+}
+
 // Main is the main Analyzer for the consensus layer.
 type Main struct {
-	cfg     analyzer.Config
-	target  storage.TargetStorage
-	logger  *log.Logger
-	metrics metrics.DatabaseMetrics
+	name     string
+	cfg      analyzer.Config
+	backfill backfillConfig
+
+	bootstrapHeight int64
+	batchBlocks     int64
+
+	// backfilling is true while backfill shards are running.
+	backfilling bool
+
+	target          storage.TargetStorage
+	logger          *log.Logger
+	metrics         metrics.DatabaseMetrics
+	analysisMetrics metrics.AnalysisMetrics
+
+	leasesMu sync.Mutex
+	leases   map[string]*coordination.Lease
+
+	partitionsMu sync.Mutex
+	// partitionedTo is the height up to which partitions exist.
+	partitionedTo int64
 }
 
-// NewMain returns a new main analyzer for the consensus layer.
-func NewMain(target storage.TargetStorage, logger *log.Logger) *Main {
+// NewMain returns a new main analyzer for the consensus layer
+// with the provided name.
+func NewMain(name string, target storage.TargetStorage, logger *log.Logger) *Main {
 	return &Main{
-		target:  target,
-		logger:  logger.With("analyzer", consensusMainDamaskName),
-		metrics: metrics.NewDefaultDatabaseMetrics(consensusMainDamaskName),
+		name:            name,
+		target:          target,
+		logger:          logger.With("analyzer", name),
+		metrics:         metrics.NewDefaultDatabaseMetrics(name),
+		analysisMetrics: metrics.NewDefaultAnalysisMetrics(name),
 	}
 }
 
@@ -62,29 +125,73 @@ func NewMain(target storage.TargetStorage, logger *log.Logger) *Main {
 // this analyzer. It is intended to be called before Start.
 func (m *Main) SetConfig(cfg analyzer.Config) {
 	m.cfg = cfg
-	m.cfg.ChainID = strcase.ToSnake(m.cfg.ChainID)
+
+	backfill, err := parseBackfillConfig(&cfg)
+	if err != nil {
+		m.logger.Error("invalid backfill options, backfilling disabled",
+			"err", err.Error(),
+		)
+	}
+	m.backfill = backfill
+
+	bootstrapHeight, err := parseBootstrapHeight(&cfg)
+	if err != nil {
+		m.logger.Error("invalid bootstrap options, bootstrapping disabled",
+			"err", err.Error(),
+		)
+	}
+	m.bootstrapHeight = bootstrapHeight
+
+	batchBlocks, err := parseBatchBlocks(&cfg)
+	if err != nil {
+		m.logger.Error("invalid batch options, batching disabled",
+			"err", err.Error(),
+		)
+	}
+	m.batchBlocks = batchBlocks
 }
 
 // Start starts the main consensus analyzer.
 func (m *Main) Start() {
 	ctx := context.Background()
 
-	// Get block to be indexed.
-	var height int64
-
-	latest, err := m.latestBlock(ctx)
-	if err != nil {
-		if err != pgx.ErrNoRows {
-			m.logger.Error("last block height not found",
+	blockRange := m.cfg.BlockRange
+	if m.bootstrapHeight != 0 {
+		if err := m.bootstrap(ctx); err != nil {
+			m.logger.Error("bootstrap failed",
 				"err", err.Error(),
 			)
+			m.releaseLease(ctx, m.name)
 			return
 		}
-		m.logger.Debug("setting height using range config")
-		height = m.cfg.BlockRange.From
-	} else {
-		m.logger.Debug("setting height using latest block")
-		height = latest + 1
+	}
+	if m.backfill.To != 0 {
+		if err := m.runBackfill(ctx); err != nil {
+			m.logger.Error("backfill failed",
+				"err", err.Error(),
+			)
+			m.releaseLease(ctx, m.name)
+			return
+		}
+		blockRange.From = m.backfill.To + 1
+	}
+
+	m.run(ctx, m.name, blockRange, m.prepareFuncs())
+	m.releaseLease(ctx, m.name)
+}
+
+// run processes blocks in the provided range using the provided prepare
+// functions, recording progress under the provided analyzer name.
+func (m *Main) run(ctx context.Context, name string, r analyzer.Range, fs []prepareFunc) {
+	logger := m.logger.With("progress", name)
+
+	// Get block to be indexed.
+	height, err := m.startHeight(ctx, name, r)
+	if err != nil {
+		logger.Error("last block height not found",
+			"err", err.Error(),
+		)
+		return
 	}
 
 	backoff := util.NewBackoff(
@@ -93,30 +200,232 @@ func (m *Main) Start() {
 		// ^cap the timeout at the expected
 		// consensus block time
 	)
-	for m.cfg.BlockRange.To == 0 || height <= m.cfg.BlockRange.To {
-		if err := m.processBlock(ctx, height); err != nil {
+	var attempts int
+	var lastRedrive time.Time
+	// Blocks up to this height are processed individually,
+	// after a batch including them failed.
+	var unbatchedTo int64
+	for r.To == 0 || height <= r.To {
+		acquired, err := m.holdLease(ctx, name)
+		if err != nil {
+			logger.Error("error holding lease",
+				"err", err.Error(),
+			)
+			backoff.Wait()
+			continue
+		}
+		if acquired {
+			// Another instance may have made progress while
+			// this instance was on standby.
+			if height, err = m.startHeight(ctx, name, r); err != nil {
+				logger.Error("last block height not found",
+					"err", err.Error(),
+				)
+				backoff.Wait()
+				continue
+			}
+			attempts = 0
+		}
+
+		if m.cfg.Quarantine != nil && time.Since(lastRedrive) > redriveInterval {
+			rolledBack, err := m.redrive(ctx, name, fs)
+			if err != nil {
+				logger.Error("error re-driving quarantined blocks",
+					"err", err.Error(),
+				)
+			}
+			lastRedrive = time.Now()
+			if rolledBack {
+				if height, err = m.startHeight(ctx, name, r); err != nil {
+					logger.Error("last block height not found",
+						"err", err.Error(),
+					)
+					backoff.Wait()
+					continue
+				}
+				attempts = 0
+				unbatchedTo = 0
+			}
+		}
+
+		to := height
+		if height > unbatchedTo {
+			to = m.batchEnd(ctx, height, r)
+		}
+		if err := m.ensurePartitions(ctx, height, to); err != nil {
+			logger.Error("error ensuring partitions",
+				"height", to,
+				"err", err.Error(),
+			)
+			backoff.Wait()
+			continue
+		}
+		if err := m.processBlocks(ctx, height, to, name, fs); err != nil {
 			if err == ErrOutOfRange {
-				m.logger.Info("no data source available at this height",
+				logger.Info("no data source available at this height",
 					"height", height,
 				)
 				return
 			}
+			if err == errLeaseLost {
+				logger.Warn("lease lost while processing blocks, retrying",
+					"from", height,
+					"to", to,
+				)
+				continue
+			}
+			// Another instance may have applied the blocks, e.g. if it took
+			// over while a batch of this instance was being committed, so
+			// that progress for them already exists.
+			latest, lerr := m.startHeight(ctx, name, r)
+			if lerr == nil && latest > height {
+				logger.Info("blocks already processed, skipping ahead",
+					"from", height,
+					"height", latest,
+				)
+				attempts = 0
+				height = latest
+				continue
+			}
+			if to > height {
+				// Retry individually, so that failing blocks
+				// can be identified and quarantined.
+				logger.Warn("error processing blocks, processing individually",
+					"from", height,
+					"to", to,
+					"err", err.Error(),
+				)
+				unbatchedTo = to
+				continue
+			}
 
-			m.logger.Error("error processing block",
+			attempts++
+			logger.Error("error processing block",
+				"height", height,
+				"attempts", attempts,
 				"err", err.Error(),
 			)
-			backoff.Wait()
-			continue
+			if !m.shouldQuarantine(ctx, height, attempts) {
+				backoff.Wait()
+				continue
+			}
+			if err := m.quarantine(ctx, height, name, attempts, err); err != nil {
+				logger.Error("error quarantining block",
+					"height", height,
+					"err", err.Error(),
+				)
+				backoff.Wait()
+				continue
+			}
+			if m.cfg.Quarantine.Halt {
+				logger.Error("halting after quarantining block",
+					"height", height,
+				)
+				return
+			}
+		} else if name == m.name && m.enforceInvariants(ctx, height, to) {
+			// Only progress under the analyzer name includes state updates.
+			logger.Error("halting after invariant violation",
+				"height", height,
+			)
+			return
 		}
 
+		attempts = 0
 		backoff.Reset()
-		height++
+		height = to + 1
+	}
+}
+
+// startHeight returns the height from which to start processing blocks
+// in the provided range, given progress recorded under the provided name.
+func (m *Main) startHeight(ctx context.Context, name string, r analyzer.Range) (int64, error) {
+	latest, err := m.latestBlock(ctx, name)
+	if err != nil {
+		if err != storage.ErrNoRows {
+			return 0, err
+		}
+		m.logger.Debug("setting height using range config")
+		return r.From, nil
+	}
+	if latest < r.From {
+		return r.From, nil
+	}
+	m.logger.Debug("setting height using latest block")
+	return latest + 1, nil
+}
+
+// lease returns the lease coordinating progress under the provided name,
+// if configured. Backfill shards have leases of their own, derived from
+// the analyzer lease, so that instances may process different shards
+// concurrently.
+func (m *Main) lease(name string) *coordination.Lease {
+	if m.cfg.Lease == nil || name == m.name {
+		return m.cfg.Lease
+	}
+
+	m.leasesMu.Lock()
+	defer m.leasesMu.Unlock()
+	if m.leases == nil {
+		m.leases = make(map[string]*coordination.Lease)
+	}
+	l, ok := m.leases[name]
+	if !ok {
+		l = m.cfg.Lease.Derive(name)
+		m.leases[name] = l
+	}
+	return l
+}
+
+// holdLease ensures this instance holds the lease of progress under the
+// provided name, if configured, blocking while another instance holds it.
+// It returns true iff the lease had to be taken over from another instance.
+func (m *Main) holdLease(ctx context.Context, name string) (bool, error) {
+	lease := m.lease(name)
+	if lease == nil {
+		return false, nil
+	}
+
+	switch err := lease.Acquire(ctx); err {
+	case nil:
+		return false, nil
+	case coordination.ErrLeaseHeld:
+	default:
+		return false, err
+	}
+
+	m.logger.Info("lease held by another instance, waiting on standby",
+		"lease", lease.Name(),
+		"holder", lease.Holder(),
+	)
+	if err := lease.Wait(ctx); err != nil {
+		return false, err
+	}
+	m.logger.Info("acquired lease",
+		"lease", lease.Name(),
+		"holder", lease.Holder(),
+	)
+	return true, nil
+}
+
+// releaseLease releases the lease of progress under the provided name,
+// if configured.
+func (m *Main) releaseLease(ctx context.Context, name string) {
+	lease := m.lease(name)
+	if lease == nil {
+		return
+	}
+	if err := lease.Release(ctx); err != nil {
+		m.logger.Error("failed to release lease",
+			"lease", lease.Name(),
+			"err", err.Error(),
+		)
 	}
 }
 
 // Name returns the name of the Main.
 func (m *Main) Name() string {
-	return consensusMainDamaskName
+	return m.name
 }
 
 // source returns the source storage for the provided block height.
@@ -129,73 +438,149 @@ func (m *Main) source(height int64) (storage.SourceStorage, error) {
 	return nil, ErrOutOfRange
 }
 
-// latestBlock returns the latest block processed by the consensus analyzer.
-func (m *Main) latestBlock(ctx context.Context) (int64, error) {
+// latestBlock returns the latest block processed under the provided name,
+// including blocks compacted to ranges by pruning.
+func (m *Main) latestBlock(ctx context.Context, name string) (int64, error) {
 	var latest int64
 	if err := m.target.QueryRow(
 		ctx,
 		fmt.Sprintf(`
-			SELECT height FROM %s.processed_blocks
+			SELECT height FROM %[1]s.processed_blocks
+				WHERE analyzer = $1
+			UNION ALL
+			SELECT to_height FROM %[1]s.processed_block_ranges
 				WHERE analyzer = $1
-				ORDER BY height DESC
-				LIMIT 1
-		`, m.cfg.ChainID),
+			ORDER BY height DESC
+			LIMIT 1
+		`, m.cfg.Chain.Schema),
 		// ^analyzers should only analyze for a single chain ID, and we anchor this
 		// at the starting block.
-		consensusMainDamaskName,
+		name,
 	).Scan(&latest); err != nil {
 		return 0, err
 	}
 	return latest, nil
 }
 
-// processBlock processes the block at the provided block height.
-func (m *Main) processBlock(ctx context.Context, height int64) error {
-	m.logger.Info("processing block",
-		"height", height,
-	)
-	chainID := m.cfg.ChainID
-
-	group, groupCtx := errgroup.WithContext(ctx)
+// prepareFunc adds queries for the block at the provided height to the batch.
+type prepareFunc = func(context.Context, int64, *storage.QueryBatch) error
+
+// blockQueueFunc adds queries for the provided block data to the batch.
+type blockQueueFunc = func(*storage.QueryBatch, *storage.BlockData) error
+
+// prepareFuncs returns the functions preparing all updates for a block.
+func (m *Main) prepareFuncs() []prepareFunc {
+	return []prepareFunc{
+		m.prepareBlockData(
+			m.queueBlockInserts,
+			m.queueEpochInserts,
+			m.queueTransactionInserts,
+			m.queueTransactionUpdates,
+			m.queueEventInserts,
+		),
+		m.prepareRegistryData,
+		m.prepareStakingData,
+		m.prepareSchedulerData,
+		m.prepareGovernanceData,
+	}
+}
 
-	// Prepare and perform updates.
-	batch := &storage.QueryBatch{}
+// appendOnlyPrepareFuncs returns the functions preparing updates for a
+// block which do not depend on updates for preceding blocks.
+func (m *Main) appendOnlyPrepareFuncs() []prepareFunc {
+	return []prepareFunc{
+		m.prepareBlockData(
+			m.queueBlockInserts,
+			m.queueTransactionInserts,
+			m.queueEventInserts,
+		),
+	}
+}
 
-	type prepareFunc = func(context.Context, int64, *storage.QueryBatch) error
-	for _, f := range []prepareFunc{
-		m.prepareBlockData,
+// statePrepareFuncs returns the functions preparing updates for a block
+// which mutate state, and thus must be applied in order.
+func (m *Main) statePrepareFuncs() []prepareFunc {
+	return []prepareFunc{
+		m.prepareBlockData(
+			m.queueEpochInserts,
+			m.queueTransactionUpdates,
+		),
 		m.prepareRegistryData,
 		m.prepareStakingData,
 		m.prepareSchedulerData,
 		m.prepareGovernanceData,
-	} {
-		func(f prepareFunc) {
-			group.Go(func() error {
-				if err := f(groupCtx, height, batch); err != nil {
-					return err
-				}
-				return nil
-			})
-		}(f)
 	}
+}
+
+// processBlock processes the block at the provided block height using the
+// provided prepare functions, recording progress under the provided name.
+func (m *Main) processBlock(ctx context.Context, height int64, name string, fs []prepareFunc) error {
+	return m.processBlocks(ctx, height, height, name, fs)
+}
+
+// processBlocks processes the blocks in the provided (inclusive) height range
+// using the provided prepare functions in a single database transaction,
+// recording progress under the provided name.
+func (m *Main) processBlocks(ctx context.Context, from int64, to int64, name string, fs []prepareFunc) error {
+	if from == to {
+		m.logger.Info("processing block",
+			"height", from,
+			"progress", name,
+		)
+	} else {
+		m.logger.Info("processing blocks",
+			"from", from,
+			"to", to,
+			"progress", name,
+		)
+	}
+
+	// Prepare updates for each block concurrently, and apply
+	// them in order.
+	batches := make([]*storage.QueryBatch, to-from+1)
+	group, groupCtx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, maxConcurrentPrepares)
+	for height := from; height <= to; height++ {
+		batch := &storage.QueryBatch{}
+		batches[height-from] = batch
+
+		for _, f := range fs {
+			func(height int64, f prepareFunc) {
+				group.Go(func() error {
+					sem <- struct{}{}
+					defer func() { <-sem }()
+					return f(groupCtx, height, batch)
+				})
+			}(height, f)
+		}
 
-	// Update indexing progress.
-	group.Go(func() error {
+		// Update indexing progress.
 		batch.Queue(fmt.Sprintf(`
 			INSERT INTO %s.processed_blocks (height, analyzer, processed_time)
 			VALUES
 				($1, $2, CURRENT_TIMESTAMP);
-		`, chainID),
+		`, m.cfg.Chain.Schema),
 			height,
-			consensusMainDamaskName,
+			name,
 		)
-		return nil
-	})
-
+	}
 	if err := group.Wait(); err != nil {
 		return err
 	}
 
+	batch := batches[0]
+	for _, b := range batches[1:] {
+		batch.Append(b)
+	}
+
+	// A standby may have taken over if the lease expired while preparing
+	// updates, in which case they must not be applied. This is best effort,
+	// as the lease may expire before the batch commits; processed_blocks
+	// rows recorded in the batch prevent applying a block twice.
+	if lease := m.lease(name); lease != nil && !lease.Held() {
+		return errLeaseLost
+	}
+
 	opName := "process_block"
 	timer := m.metrics.DatabaseTimer(m.target.Name(), opName)
 	defer timer.ObserveDuration()
@@ -208,39 +593,36 @@ func (m *Main) processBlock(ctx context.Context, height int64) error {
 	return nil
 }
 
-// prepareBlockData adds block data queries to the batch.
-func (m *Main) prepareBlockData(ctx context.Context, height int64, batch *storage.QueryBatch) error {
-	source, err := m.source(height)
-	if err != nil {
-		return err
-	}
-
-	data, err := source.BlockData(ctx, height)
-	if err != nil {
-		return err
-	}
+// prepareBlockData returns a function adding block data queries
+// from the provided queue functions to the batch.
+func (m *Main) prepareBlockData(fs ...blockQueueFunc) prepareFunc {
+	return func(ctx context.Context, height int64, batch *storage.QueryBatch) error {
+		source, err := m.source(height)
+		if err != nil {
+			return err
+		}
 
-	for _, f := range []func(*storage.QueryBatch, *storage.BlockData) error{
-		m.queueBlockInserts,
-		m.queueEpochInserts,
-		m.queueTransactionInserts,
-		m.queueEventInserts,
-	} {
-		if err := f(batch, data); err != nil {
+		data, err := source.BlockData(ctx, height)
+		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		for _, f := range fs {
+			if err := f(batch, data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
 }
 
 func (m *Main) queueBlockInserts(batch *storage.QueryBatch, data *storage.BlockData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
-	batch.Queue(fmt.Sprintf(`
-		INSERT INTO %s.blocks (height, block_hash, time, namespace, version, type, root_hash)
-			VALUES ($1, $2, $3, $4, $5, $6, $7);
-	`, chainID),
+	batch.Copy(
+		fmt.Sprintf("%s.blocks", chainID),
+		[]string{"height", "block_hash", "time", "namespace", "version", "type", "root_hash"},
 		data.BlockHeader.Height,
 		data.BlockHeader.Hash.Hex(),
 		data.BlockHeader.Time.UTC(),
@@ -254,7 +636,7 @@ func (m *Main) queueBlockInserts(batch *storage.QueryBatch, data *storage.BlockD
 }
 
 func (m *Main) queueEpochInserts(batch *storage.QueryBatch, data *storage.BlockData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	batch.Queue(fmt.Sprintf(`
 		INSERT INTO %s.epochs (id, start_height)
@@ -277,7 +659,7 @@ func (m *Main) queueEpochInserts(batch *storage.QueryBatch, data *storage.BlockD
 }
 
 func (m *Main) queueTransactionInserts(batch *storage.QueryBatch, data *storage.BlockData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for i := range data.Transactions {
 		signedTx := data.Transactions[i]
@@ -292,10 +674,9 @@ func (m *Main) queueTransactionInserts(batch *storage.QueryBatch, data *storage.
 			signedTx.Signature.PublicKey,
 		).String()
 
-		batch.Queue(fmt.Sprintf(`
-			INSERT INTO %s.transactions (block, txn_hash, txn_index, nonce, fee_amount, max_gas, method, sender, body, module, code, message)
-				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
-		`, chainID),
+		batch.Copy(
+			fmt.Sprintf("%s.transactions", chainID),
+			[]string{"block", "txn_hash", "txn_index", "nonce", "fee_amount", "max_gas", "method", "sender", "body", "module", "code", "message"},
 			data.BlockHeader.Height,
 			signedTx.Hash().Hex(),
 			i,
@@ -309,6 +690,26 @@ func (m *Main) queueTransactionInserts(batch *storage.QueryBatch, data *storage.
 			result.Error.Code,
 			result.Error.Message,
 		)
+	}
+
+	return nil
+}
+
+func (m *Main) queueTransactionUpdates(batch *storage.QueryBatch, data *storage.BlockData) error {
+	chainID := m.cfg.Chain.Schema
+
+	for i := range data.Transactions {
+		signedTx := data.Transactions[i]
+
+		var tx transaction.Transaction
+		if err := signedTx.Open(&tx); err != nil {
+			continue
+		}
+
+		sender := staking.NewAddress(
+			signedTx.Signature.PublicKey,
+		).String()
+
 		batch.Queue(fmt.Sprintf(`
 		UPDATE %s.accounts
 		SET
@@ -349,7 +750,7 @@ func (m *Main) queueTransactionInserts(batch *storage.QueryBatch, data *storage.
 }
 
 func (m *Main) queueEventInserts(batch *storage.QueryBatch, data *storage.BlockData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for i := 0; i < len(data.Results); i++ {
 		for j := 0; j < len(data.Results[i].Events); j++ {
@@ -358,16 +759,16 @@ func (m *Main) queueEventInserts(batch *storage.QueryBatch, data *storage.BlockD
 				return err
 			}
 
-			batch.Queue(fmt.Sprintf(`
-				INSERT INTO %s.events (backend, type, body, txn_block, txn_hash, txn_index)
-					VALUES ($1, $2, $3, $4, $5, $6);
-			`, chainID),
+			batch.Copy(
+				fmt.Sprintf("%s.events", chainID),
+				[]string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
 				backend.String(),
 				ty.String(),
 				string(body),
 				data.BlockHeader.Height,
 				data.Transactions[i].Hash().Hex(),
 				i,
+				extractEventAccounts(data.Results[i].Events[j]),
 			)
 		}
 	}
@@ -408,7 +809,7 @@ func (m *Main) prepareRegistryData(ctx context.Context, height int64, batch *sto
 }
 
 func (m *Main) queueRuntimeRegistrations(batch *storage.QueryBatch, data *storage.RegistryData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 	for _, runtimeEvent := range data.RuntimeEvents {
 		keyManager := "none"
 
@@ -438,7 +839,7 @@ func (m *Main) queueRuntimeRegistrations(batch *storage.QueryBatch, data *storag
 }
 
 func (m *Main) queueRuntimeStatusUpdates(batch *storage.QueryBatch, data *storage.RegistryData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 	for _, runtime := range data.RuntimeSuspensions {
 		batch.Queue(fmt.Sprintf(`
 			UPDATE %s.runtimes
@@ -462,7 +863,7 @@ func (m *Main) queueRuntimeStatusUpdates(batch *storage.QueryBatch, data *storag
 }
 
 func (m *Main) queueEntityEvents(batch *storage.QueryBatch, data *storage.RegistryData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, entityEvent := range data.EntityEvents {
 		entityID := entityEvent.Entity.ID.String()
@@ -491,7 +892,7 @@ func (m *Main) queueEntityEvents(batch *storage.QueryBatch, data *storage.Regist
 }
 
 func (m *Main) queueNodeEvents(batch *storage.QueryBatch, data *storage.RegistryData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, nodeEvent := range data.NodeEvents {
 		vrfPubkey := ""
@@ -582,7 +983,7 @@ func (m *Main) queueMetadataRegistry(ctx context.Context, batch *storage.QueryBa
 		UPDATE %s.entities
 		SET meta = $2
 			WHERE id = $1
-	`, m.cfg.ChainID),
+	`, m.cfg.Chain.Schema),
 			id,
 			meta,
 		)
@@ -607,6 +1008,7 @@ func (m *Main) prepareStakingData(ctx context.Context, height int64, batch *stor
 		m.queueBurns,
 		m.queueEscrows,
 		m.queueAllowanceChanges,
+		m.queueBalanceHistory,
 	} {
 		if err := f(batch, data); err != nil {
 			return err
@@ -617,7 +1019,7 @@ func (m *Main) prepareStakingData(ctx context.Context, height int64, batch *stor
 }
 
 func (m *Main) queueTransfers(batch *storage.QueryBatch, data *storage.StakingData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, transfer := range data.Transfers {
 		from := transfer.From.String()
@@ -647,7 +1049,7 @@ func (m *Main) queueTransfers(batch *storage.QueryBatch, data *storage.StakingDa
 }
 
 func (m *Main) queueBurns(batch *storage.QueryBatch, data *storage.StakingData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, burn := range data.Burns {
 		batch.Queue(fmt.Sprintf(`
@@ -665,7 +1067,7 @@ func (m *Main) queueBurns(batch *storage.QueryBatch, data *storage.StakingData)
 }
 
 func (m *Main) queueEscrows(batch *storage.QueryBatch, data *storage.StakingData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, escrow := range data.Escrows {
 		switch e := escrow; {
@@ -774,8 +1176,63 @@ func (m *Main) queueEscrows(batch *storage.QueryBatch, data *storage.StakingData
 	return nil
 }
 
+// queueBalanceHistory records the balances of accounts whose balances
+// changed in the block, after they are updated.
+func (m *Main) queueBalanceHistory(batch *storage.QueryBatch, data *storage.StakingData) error {
+	chainID := m.cfg.Chain.Schema
+
+	var addresses []string
+	seen := make(map[string]bool)
+	touch := func(address staking.Address) {
+		if a := address.String(); !seen[a] {
+			seen[a] = true
+			addresses = append(addresses, a)
+		}
+	}
+	for _, transfer := range data.Transfers {
+		touch(transfer.From)
+		touch(transfer.To)
+	}
+	for _, burn := range data.Burns {
+		touch(burn.Owner)
+	}
+	for _, escrow := range data.Escrows {
+		switch e := escrow; {
+		case e.Add != nil:
+			touch(e.Add.Owner)
+			touch(e.Add.Escrow)
+		case e.Take != nil:
+			touch(e.Take.Owner)
+		case e.DebondingStart != nil:
+			touch(e.DebondingStart.Escrow)
+		case e.Reclaim != nil:
+			touch(e.Reclaim.Owner)
+			touch(e.Reclaim.Escrow)
+		}
+	}
+
+	for _, address := range addresses {
+		batch.Queue(fmt.Sprintf(`
+			INSERT INTO %[1]s.account_balance_history (address, height, general_balance, escrow_balance_active, escrow_balance_debonding)
+				SELECT address, $2, COALESCE(general_balance, 0), COALESCE(escrow_balance_active, 0), COALESCE(escrow_balance_debonding, 0)
+					FROM %[1]s.accounts
+					WHERE address = $1
+			ON CONFLICT (address, height) DO
+				UPDATE SET
+					general_balance = excluded.general_balance,
+					escrow_balance_active = excluded.escrow_balance_active,
+					escrow_balance_debonding = excluded.escrow_balance_debonding;
+		`, chainID),
+			address,
+			data.Height,
+		)
+	}
+
+	return nil
+}
+
 func (m *Main) queueAllowanceChanges(batch *storage.QueryBatch, data *storage.StakingData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, allowanceChange := range data.AllowanceChanges {
 		allowance := allowanceChange.Allowance.ToBigInt().Uint64()
@@ -829,7 +1286,7 @@ func (m *Main) prepareSchedulerData(ctx context.Context, height int64, batch *st
 }
 
 func (m *Main) queueValidatorUpdates(batch *storage.QueryBatch, data *storage.SchedulerData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, validator := range data.Validators {
 		batch.Queue(fmt.Sprintf(`
@@ -845,7 +1302,7 @@ func (m *Main) queueValidatorUpdates(batch *storage.QueryBatch, data *storage.Sc
 }
 
 func (m *Main) queueCommitteeUpdates(batch *storage.QueryBatch, data *storage.SchedulerData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	batch.Queue(fmt.Sprintf(`
 		TRUNCATE %s.committee_members;
@@ -900,7 +1357,7 @@ func (m *Main) prepareGovernanceData(ctx context.Context, height int64, batch *s
 }
 
 func (m *Main) queueSubmissions(batch *storage.QueryBatch, data *storage.GovernanceData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, submission := range data.ProposalSubmissions {
 		if submission.Content.Upgrade != nil {
@@ -940,7 +1397,7 @@ func (m *Main) queueSubmissions(batch *storage.QueryBatch, data *storage.Governa
 }
 
 func (m *Main) queueExecutions(batch *storage.QueryBatch, data *storage.GovernanceData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, execution := range data.ProposalExecutions {
 		batch.Queue(fmt.Sprintf(`
@@ -956,7 +1413,7 @@ func (m *Main) queueExecutions(batch *storage.QueryBatch, data *storage.Governan
 }
 
 func (m *Main) queueFinalizations(batch *storage.QueryBatch, data *storage.GovernanceData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, finalization := range data.ProposalFinalizations {
 		batch.Queue(fmt.Sprintf(`
@@ -981,7 +1438,7 @@ func (m *Main) queueFinalizations(batch *storage.QueryBatch, data *storage.Gover
 }
 
 func (m *Main) queueVotes(batch *storage.QueryBatch, data *storage.GovernanceData) error {
-	chainID := m.cfg.ChainID
+	chainID := m.cfg.Chain.Schema
 
 	for _, vote := range data.Votes {
 		batch.Queue(fmt.Sprintf(`
@@ -997,6 +1454,49 @@ func (m *Main) queueVotes(batch *storage.QueryBatch, data *storage.GovernanceDat
 	return nil
 }
 
+// extractEventAccounts extracts the staking addresses mentioned in the body
+// of an event, without duplicates, so that events can be looked up by the
+// accounts they relate to.
+func extractEventAccounts(event *results.Event) []string {
+	var addresses []staking.Address
+	switch {
+	case event.Staking != nil:
+		switch e := event.Staking; {
+		case e.Transfer != nil:
+			addresses = append(addresses, e.Transfer.From, e.Transfer.To)
+		case e.Burn != nil:
+			addresses = append(addresses, e.Burn.Owner)
+		case e.Escrow != nil && e.Escrow.Add != nil:
+			addresses = append(addresses, e.Escrow.Add.Owner, e.Escrow.Add.Escrow)
+		case e.Escrow != nil && e.Escrow.Take != nil:
+			addresses = append(addresses, e.Escrow.Take.Owner)
+		case e.Escrow != nil && e.Escrow.DebondingStart != nil:
+			addresses = append(addresses, e.Escrow.DebondingStart.Owner, e.Escrow.DebondingStart.Escrow)
+		case e.Escrow != nil && e.Escrow.Reclaim != nil:
+			addresses = append(addresses, e.Escrow.Reclaim.Owner, e.Escrow.Reclaim.Escrow)
+		case e.AllowanceChange != nil:
+			addresses = append(addresses, e.AllowanceChange.Owner, e.AllowanceChange.Beneficiary)
+		}
+	case event.Governance != nil:
+		switch e := event.Governance; {
+		case e.ProposalSubmitted != nil:
+			addresses = append(addresses, e.ProposalSubmitted.Submitter)
+		case e.Vote != nil:
+			addresses = append(addresses, e.Vote.Submitter)
+		}
+	}
+
+	accounts := []string{}
+	seen := make(map[staking.Address]bool)
+	for _, address := range addresses {
+		if !seen[address] {
+			seen[address] = true
+			accounts = append(accounts, address.String())
+		}
+	}
+	return accounts
+}
+
 // extractEventData extracts the type of an event.
 //
 // TODO: Eliminate this if possible.
@@ -1085,7 +1585,7 @@ func extractEventData(event *results.Event) (backend analyzer.Backend, ty analyz
 			body, err = json.Marshal(event.Governance.ProposalExecuted)
 			return
 		case b.ProposalFinalized != nil:
-			ty = analyzer.EventGovernanceProposalExecuted
+			ty = analyzer.EventGovernanceProposalFinalized
 			body, err = json.Marshal(event.Governance.ProposalFinalized)
 			return
 		case b.Vote != nil:
@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"testing"
+
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
+	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction/results"
+	governance "github.com/oasisprotocol/oasis-core/go/governance/api"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+// TestValidateOptions tests that malformed options are rejected
+// when the analysis config is validated.
+func TestValidateOptions(t *testing.T) {
+	for _, tc := range []struct {
+		options map[string]interface{}
+		valid   bool
+	}{
+		{nil, true},
+		{map[string]interface{}{backfillToOption: 500, backfillShardsOption: 4, batchBlocksOption: 100}, true},
+		{map[string]interface{}{bootstrapHeightOption: 500}, true},
+		{map[string]interface{}{backfillToOption: "yesterday"}, false},
+		{map[string]interface{}{backfillToOption: 2000}, false},
+		{map[string]interface{}{backfillToOption: 500, backfillShardsOption: 0}, false},
+		{map[string]interface{}{bootstrapHeightOption: 50}, false},
+		{map[string]interface{}{bootstrapHeightOption: 500, backfillToOption: 600}, false},
+		{map[string]interface{}{batchBlocksOption: -1}, false},
+		{map[string]interface{}{batchBlocksOption: 1.5}, false},
+	} {
+		cfg := config.AnalyzerConfig{
+			Name:    consensusMainDamaskName,
+			ChainID: "oasis-3",
+			RPC:     "unix:/node/data/internal.sock",
+			From:    100,
+			To:      1000,
+			Options: tc.options,
+		}
+		err := cfg.Validate()
+		if tc.valid {
+			require.Nil(t, err, "options %v", tc.options)
+		} else {
+			require.NotNil(t, err, "options %v", tc.options)
+		}
+	}
+}
+
+// TestQueueBalanceHistory tests that the balances of each account touched
+// by staking events are recorded once per block.
+func TestQueueBalanceHistory(t *testing.T) {
+	m := NewMain("consensus_history_test", storagetest.NewStorage(), log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+
+	a := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001"))
+	b := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002"))
+	c := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000003"))
+	amount := *quantity.NewFromUint64(10)
+	data := &storage.StakingData{
+		Height: 100,
+		Transfers: []*staking.TransferEvent{
+			{From: a, To: b, Amount: amount},
+			{From: b, To: a, Amount: amount},
+		},
+		Escrows: []*staking.EscrowEvent{
+			{DebondingStart: &staking.DebondingStartEscrowEvent{Owner: a, Escrow: c, Amount: amount}},
+		},
+	}
+
+	batch := &storage.QueryBatch{}
+	require.Nil(t, m.queueBalanceHistory(batch, data))
+	items := batch.Items()
+	require.Len(t, items, 3)
+	for i, address := range []staking.Address{a, b, c} {
+		require.Contains(t, items[i].SQL, "oasis_3.account_balance_history")
+		require.Equal(t, []interface{}{address.String(), int64(100)}, items[i].Args)
+	}
+}
+
+// TestExtractEventAccounts tests that the addresses mentioned in events
+// are extracted without duplicates.
+func TestExtractEventAccounts(t *testing.T) {
+	a := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001"))
+	b := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002"))
+
+	for _, tt := range []struct {
+		event    *results.Event
+		accounts []string
+	}{
+		{
+			&results.Event{Staking: &staking.Event{Transfer: &staking.TransferEvent{From: a, To: b}}},
+			[]string{a.String(), b.String()},
+		},
+		{
+			&results.Event{Staking: &staking.Event{Escrow: &staking.EscrowEvent{Add: &staking.AddEscrowEvent{Owner: a, Escrow: a}}}},
+			[]string{a.String()},
+		},
+		{
+			&results.Event{Staking: &staking.Event{AllowanceChange: &staking.AllowanceChangeEvent{Owner: b, Beneficiary: a}}},
+			[]string{b.String(), a.String()},
+		},
+		{
+			&results.Event{Governance: &governance.Event{Vote: &governance.VoteEvent{ID: 1, Submitter: b}}},
+			[]string{b.String()},
+		},
+		{
+			&results.Event{Governance: &governance.Event{ProposalExecuted: &governance.ProposalExecutedEvent{ID: 1}}},
+			[]string{},
+		},
+	} {
+		require.Equal(t, tt.accounts, extractEventAccounts(tt.event))
+	}
+}
+
+// TestExtractEventDataGovernance tests that governance events are indexed
+// under their own types.
+func TestExtractEventDataGovernance(t *testing.T) {
+	for _, tt := range []struct {
+		event *governance.Event
+		ty    analyzer.Event
+	}{
+		{&governance.Event{ProposalExecuted: &governance.ProposalExecutedEvent{ID: 1}}, analyzer.EventGovernanceProposalExecuted},
+		{&governance.Event{ProposalFinalized: &governance.ProposalFinalizedEvent{ID: 1, State: governance.StatePassed}}, analyzer.EventGovernanceProposalFinalized},
+	} {
+		backend, ty, _, err := extractEventData(&results.Event{Governance: tt.event})
+		require.Nil(t, err)
+		require.Equal(t, analyzer.BackendGovernance, backend)
+		require.Equal(t, tt.ty, ty)
+	}
+}
@@ -0,0 +1,59 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+)
+
+// batchBlocksOption is the option setting the maximum number of blocks
+// applied per database transaction while catching up to the latest block.
+const batchBlocksOption = "batch_blocks"
+
+// parseBatchBlocks parses the maximum number of blocks per database
+// transaction from the analyzer options. It defaults to one.
+func parseBatchBlocks(cfg *analyzer.Config) (int64, error) {
+	n, ok, err := cfg.Int64Option(batchBlocksOption)
+	if err != nil || !ok {
+		return 1, err
+	}
+	if n <= 0 {
+		return 1, fmt.Errorf("malformed batch blocks %d", n)
+	}
+	return n, nil
+}
+
+// batchEnd returns the (inclusive) last height to process in a single
+// database transaction starting at the provided height. Blocks are only
+// batched while catching up, i.e. if they are all already available.
+func (m *Main) batchEnd(ctx context.Context, height int64, r analyzer.Range) int64 {
+	if m.batchBlocks <= 1 {
+		return height
+	}
+
+	source, err := m.source(height)
+	if err != nil {
+		return height
+	}
+	latest, err := source.LatestHeight(ctx)
+	if err != nil {
+		m.logger.Warn("failed to get latest height, not batching blocks",
+			"height", height,
+			"err", err.Error(),
+		)
+		return height
+	}
+
+	end := height + m.batchBlocks - 1
+	if end > latest {
+		end = latest
+	}
+	if r.To != 0 && end > r.To {
+		end = r.To
+	}
+	if end < height {
+		return height
+	}
+	return end
+}
@@ -0,0 +1,91 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+// TestParseBatchBlocks tests parsing the batch option.
+func TestParseBatchBlocks(t *testing.T) {
+	cfg := analyzer.Config{}
+	n, err := parseBatchBlocks(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, int64(1), n)
+
+	cfg.Options = map[string]interface{}{batchBlocksOption: 100}
+	n, err = parseBatchBlocks(&cfg)
+	require.Nil(t, err)
+	require.Equal(t, int64(100), n)
+
+	cfg.Options = map[string]interface{}{batchBlocksOption: 0}
+	_, err = parseBatchBlocks(&cfg)
+	require.NotNil(t, err)
+}
+
+// TestProcessBlocks tests that blocks in a range are applied in a single
+// batch, in order, along with their progress.
+func TestProcessBlocks(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	m := NewMain("consensus_ingest_test", target, log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+
+	fs := []prepareFunc{
+		func(ctx context.Context, height int64, batch *storage.QueryBatch) error {
+			batch.Copy("oasis_3.blocks", []string{"height"}, height)
+			batch.Copy("oasis_3.transactions", []string{"block"}, height)
+			return nil
+		},
+	}
+	require.Nil(t, m.processBlocks(ctx, 10, 12, m.name, fs))
+
+	batches := target.Batches()
+	require.Len(t, batches, 1)
+
+	items := batches[0].Items()
+	require.Len(t, items, 3)
+	for i, item := range items {
+		require.Contains(t, item.SQL, "oasis_3.processed_blocks")
+		require.Equal(t, []interface{}{int64(10 + i), m.name}, item.Args)
+	}
+
+	copies := batches[0].Copies()
+	require.Len(t, copies, 2)
+	require.Equal(t, "oasis_3.blocks", copies[0].Table)
+	require.Equal(t, [][]interface{}{{int64(10)}, {int64(11)}, {int64(12)}}, copies[0].Rows)
+	require.Equal(t, "oasis_3.transactions", copies[1].Table)
+
+	// Nothing is applied if preparing any block fails.
+	errPrepare := errors.New("prepare failed")
+	fs = append(fs, func(ctx context.Context, height int64, batch *storage.QueryBatch) error {
+		if height == 14 {
+			return errPrepare
+		}
+		return nil
+	})
+	require.Equal(t, errPrepare, m.processBlocks(ctx, 13, 15, m.name, fs))
+	require.Len(t, target.Batches(), 1)
+}
+
+// TestProcessBlocksLeaseLost tests that blocks are not applied if the
+// lease is not held when committing.
+func TestProcessBlocksLeaseLost(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	m := NewMain("consensus_lease_test", target, log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+	m.cfg.Lease = coordination.NewLease(target, "oasis_3", m.name, "holder", time.Minute)
+
+	require.Equal(t, errLeaseLost, m.processBlocks(ctx, 10, 10, m.name, nil))
+	require.Empty(t, target.Batches())
+}
@@ -0,0 +1,228 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// Names of the checked accounting invariants.
+const (
+	invariantTotalSupply      = "total_supply"
+	invariantDelegationShares = "delegation_shares"
+	invariantNonNegative      = "non_negative"
+)
+
+// maxReportedViolations is the maximum number of offending rows
+// reported per invariant.
+const maxReportedViolations = 10
+
+// violation is a violated accounting invariant.
+type violation struct {
+	invariant string
+	details   string
+}
+
+// accountedSupply returns the part of the total supply which is expected
+// to be held by accounts, i.e. excluding the reserved pools.
+func accountedSupply(data *storage.SupplyData) *big.Int {
+	accounted := new(big.Int).Set(data.TotalSupply.ToBigInt())
+	accounted.Sub(accounted, data.CommonPool.ToBigInt())
+	accounted.Sub(accounted, data.LastBlockFees.ToBigInt())
+	accounted.Sub(accounted, data.GovernanceDeposits.ToBigInt())
+	return accounted
+}
+
+// shouldCheckInvariants returns true iff invariants should be checked
+// after the blocks in the provided (inclusive) height range.
+func (m *Main) shouldCheckInvariants(ctx context.Context, from int64, to int64) (bool, error) {
+	if m.cfg.Invariants == nil {
+		return false, nil
+	}
+	if !m.cfg.Invariants.PerEpoch {
+		return true, nil
+	}
+
+	var epochStart bool
+	if err := m.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT EXISTS(SELECT 1 FROM %s.epochs WHERE start_height BETWEEN $1 AND $2)
+	`, m.cfg.Chain.Schema),
+		from,
+		to,
+	).Scan(&epochStart); err != nil {
+		return false, err
+	}
+	return epochStart, nil
+}
+
+// checkInvariants checks accounting invariants of the indexed state
+// after the block at the provided height, returning all violations.
+func (m *Main) checkInvariants(ctx context.Context, height int64) ([]violation, error) {
+	var violations []violation
+	for _, f := range []func(context.Context, int64) ([]violation, error){
+		m.checkTotalSupply,
+		m.checkDelegationShares,
+		m.checkNonNegative,
+	} {
+		vs, err := f(ctx, height)
+		if err != nil {
+			return nil, err
+		}
+		violations = append(violations, vs...)
+	}
+	return violations, nil
+}
+
+// checkTotalSupply checks that the total supply equals the sum of general,
+// escrow and debonding balances plus the reserved pools.
+func (m *Main) checkTotalSupply(ctx context.Context, height int64) ([]violation, error) {
+	source, err := m.source(height)
+	if err != nil {
+		return nil, err
+	}
+	data, err := source.SupplyData(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reserved addresses only receive transfers in indexed state, and
+	// are accounted for by the pool balances instead.
+	var sum string
+	if err := m.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT COALESCE(SUM(general_balance + escrow_balance_active + escrow_balance_debonding), 0)::TEXT
+			FROM %s.accounts
+			WHERE address NOT IN ($1, $2, $3)
+	`, m.cfg.Chain.Schema),
+		staking.CommonPoolAddress.String(),
+		staking.FeeAccumulatorAddress.String(),
+		staking.GovernanceDepositsAddress.String(),
+	).Scan(&sum); err != nil {
+		return nil, err
+	}
+	actual, ok := new(big.Int).SetString(sum, 10)
+	if !ok {
+		return nil, fmt.Errorf("malformed balance sum '%s'", sum)
+	}
+
+	expected := accountedSupply(data)
+	if actual.Cmp(expected) == 0 {
+		return nil, nil
+	}
+	return []violation{{
+		invariant: invariantTotalSupply,
+		details:   fmt.Sprintf("account balances sum to %s, expected %s", actual, expected),
+	}}, nil
+}
+
+// checkDelegationShares checks that active and debonding delegation shares
+// sum to the total shares of each escrow pool.
+func (m *Main) checkDelegationShares(ctx context.Context, height int64) ([]violation, error) {
+	return m.queryViolations(ctx, invariantDelegationShares, fmt.Sprintf(`
+		SELECT format('%%s: active shares %%s, delegated %%s; debonding shares %%s, delegated %%s',
+				a.address, a.escrow_total_shares_active, COALESCE(d.shares, 0),
+				a.escrow_total_shares_debonding, COALESCE(dd.shares, 0))
+			FROM %[1]s.accounts a
+			LEFT JOIN (
+				SELECT delegatee, SUM(shares) AS shares FROM %[1]s.delegations GROUP BY delegatee
+			) d ON d.delegatee = a.address
+			LEFT JOIN (
+				SELECT delegatee, SUM(shares) AS shares FROM %[1]s.debonding_delegations GROUP BY delegatee
+			) dd ON dd.delegatee = a.address
+			WHERE a.escrow_total_shares_active <> COALESCE(d.shares, 0)
+				OR a.escrow_total_shares_debonding <> COALESCE(dd.shares, 0)
+			LIMIT %[2]d
+	`, m.cfg.Chain.Schema, maxReportedViolations))
+}
+
+// checkNonNegative checks that no balance, share or allowance is negative.
+func (m *Main) checkNonNegative(ctx context.Context, height int64) ([]violation, error) {
+	// Subqueries are limited individually, and selected from so that
+	// the union is supported by all target storage backends.
+	return m.queryViolations(ctx, invariantNonNegative, fmt.Sprintf(`
+		SELECT * FROM (SELECT format('account %%s has a negative balance', address)
+			FROM %[1]s.accounts
+			WHERE general_balance < 0
+				OR escrow_balance_active < 0 OR escrow_total_shares_active < 0
+				OR escrow_balance_debonding < 0 OR escrow_total_shares_debonding < 0
+			LIMIT %[2]d) AS a
+		UNION ALL
+		SELECT * FROM (SELECT format('delegation from %%s to %%s has negative shares', delegator, delegatee)
+			FROM %[1]s.delegations
+			WHERE shares < 0
+			LIMIT %[2]d) AS d
+		UNION ALL
+		SELECT * FROM (SELECT format('debonding delegation from %%s to %%s has negative shares', delegator, delegatee)
+			FROM %[1]s.debonding_delegations
+			WHERE shares < 0
+			LIMIT %[2]d) AS dd
+		UNION ALL
+		SELECT * FROM (SELECT format('allowance from %%s to %%s is negative', owner, beneficiary)
+			FROM %[1]s.allowances
+			WHERE allowance < 0
+			LIMIT %[2]d) AS al
+	`, m.cfg.Chain.Schema, maxReportedViolations))
+}
+
+// queryViolations returns a violation of the provided invariant
+// for each row returned by the provided query.
+func (m *Main) queryViolations(ctx context.Context, invariant string, query string) ([]violation, error) {
+	details, err := storage.QueryStrings(ctx, m.target, query)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]violation, 0, len(details))
+	for _, d := range details {
+		violations = append(violations, violation{
+			invariant: invariant,
+			details:   d,
+		})
+	}
+	return violations, nil
+}
+
+// enforceInvariants checks accounting invariants after the blocks in the
+// provided (inclusive) height range if due, reporting violations. It returns
+// true iff the analyzer should halt.
+func (m *Main) enforceInvariants(ctx context.Context, from int64, to int64) bool {
+	// Indexed state is only available as of the last block.
+	height := to
+	check, err := m.shouldCheckInvariants(ctx, from, to)
+	if err != nil {
+		m.logger.Error("error scheduling invariant checks",
+			"height", height,
+			"err", err.Error(),
+		)
+		return false
+	}
+	if !check {
+		return false
+	}
+
+	violations, err := m.checkInvariants(ctx, height)
+	if err != nil {
+		m.logger.Error("error checking invariants",
+			"height", height,
+			"err", err.Error(),
+		)
+		return false
+	}
+	if len(violations) == 0 {
+		return false
+	}
+
+	for _, v := range violations {
+		m.analysisMetrics.InvariantViolations.WithLabelValues(v.invariant).Inc()
+		m.logger.Error("invariant violated",
+			"height", height,
+			"invariant", v.invariant,
+			"details", v.details,
+		)
+	}
+	m.analysisMetrics.InvariantViolationHeight.Set(float64(height))
+	return m.cfg.Invariants.Halt
+}
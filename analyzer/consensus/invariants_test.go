@@ -0,0 +1,148 @@
+package consensus
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+// TestAccountedSupply tests that reserved pools are excluded from
+// the supply expected to be held by accounts.
+func TestAccountedSupply(t *testing.T) {
+	data := storage.SupplyData{
+		TotalSupply:        *quantity.NewFromUint64(1000),
+		CommonPool:         *quantity.NewFromUint64(100),
+		LastBlockFees:      *quantity.NewFromUint64(10),
+		GovernanceDeposits: *quantity.NewFromUint64(1),
+	}
+	require.Equal(t, 0, accountedSupply(&data).Cmp(big.NewInt(889)))
+	require.Equal(t, 0, data.TotalSupply.ToBigInt().Cmp(big.NewInt(1000)))
+}
+
+// supplySource is source storage providing only supply data.
+type supplySource struct {
+	storage.SourceStorage
+
+	supply storage.SupplyData
+}
+
+func (s *supplySource) SupplyData(ctx context.Context, height int64) (*storage.SupplyData, error) {
+	data := s.supply
+	data.Height = height
+	return &data, nil
+}
+
+// newInvariantsTest returns an analyzer with the provided name and
+// invariant policy, on SQLite target storage seeded with the provided
+// queries, and with source storage reporting the provided total supply.
+func newInvariantsTest(t *testing.T, name string, policy *analyzer.InvariantPolicy, totalSupply uint64, queries ...string) (*Main, storage.TargetStorage) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("consensus-test")
+
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	target, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+
+	batch := &storage.QueryBatch{}
+	for _, q := range queries {
+		batch.Queue(q)
+	}
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	m := NewMain(name, target, logger)
+	m.cfg.Chain = chain
+	m.cfg.BlockRange = analyzer.Range{From: 1}
+	m.cfg.Source = &supplySource{supply: storage.SupplyData{TotalSupply: *quantity.NewFromUint64(totalSupply)}}
+	m.cfg.Invariants = policy
+	return m, target
+}
+
+// processedHeights returns the heights processed by the provided analyzer.
+func processedHeights(t *testing.T, m *Main) []int64 {
+	rows, err := m.target.Query(context.Background(), `
+		SELECT height FROM oasis_3.processed_blocks WHERE analyzer = $1 ORDER BY height
+	`, m.name)
+	require.Nil(t, err)
+	defer rows.Close()
+
+	var heights []int64
+	for rows.Next() {
+		var height int64
+		require.Nil(t, rows.Scan(&height))
+		heights = append(heights, height)
+	}
+	return heights
+}
+
+// TestCheckInvariants tests that violations of each invariant are
+// detected in indexed state.
+func TestCheckInvariants(t *testing.T) {
+	ctx := context.Background()
+	m, target := newInvariantsTest(t, "consensus_invariants_check_test", &analyzer.InvariantPolicy{}, 100,
+		`INSERT INTO oasis_3.accounts (address, general_balance, escrow_balance_active, escrow_total_shares_active)
+			VALUES ('oasis1a', 60, 40, 40), ('oasis1b', 0, 0, 0)`,
+		`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ('oasis1a', 'oasis1b', 40)`,
+	)
+	defer target.Shutdown()
+
+	violations, err := m.checkInvariants(ctx, 1)
+	require.Nil(t, err)
+	require.Empty(t, violations)
+
+	batch := &storage.QueryBatch{}
+	batch.Queue(`UPDATE oasis_3.accounts SET general_balance = -1 WHERE address = 'oasis1b'`)
+	batch.Queue(`UPDATE oasis_3.delegations SET shares = 30`)
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	violations, err = m.checkInvariants(ctx, 1)
+	require.Nil(t, err)
+	require.Equal(t, []violation{
+		{invariantTotalSupply, "account balances sum to 99, expected 100"},
+		{invariantDelegationShares, "oasis1a: active shares 40, delegated 30; debonding shares 0, delegated 0"},
+		{invariantNonNegative, "account oasis1b has a negative balance"},
+	}, violations)
+}
+
+// TestEnforceInvariants tests that the analyzer reports violations, and
+// halts on them iff configured to.
+func TestEnforceInvariants(t *testing.T) {
+	ctx := context.Background()
+	seed := `INSERT INTO oasis_3.accounts (address, general_balance) VALUES ('oasis1a', 90)`
+
+	m, target := newInvariantsTest(t, "consensus_invariants_log_test", &analyzer.InvariantPolicy{}, 100, seed)
+	defer target.Shutdown()
+	m.run(ctx, m.name, analyzer.Range{From: 1, To: 3}, nil)
+	require.Equal(t, []int64{1, 2, 3}, processedHeights(t, m))
+	require.Equal(t, float64(3), testutil.ToFloat64(m.analysisMetrics.InvariantViolations.WithLabelValues(invariantTotalSupply)))
+	require.Equal(t, float64(3), testutil.ToFloat64(m.analysisMetrics.InvariantViolationHeight))
+
+	m, target = newInvariantsTest(t, "consensus_invariants_halt_test", &analyzer.InvariantPolicy{Halt: true}, 100, seed)
+	defer target.Shutdown()
+	m.run(ctx, m.name, analyzer.Range{From: 1, To: 3}, nil)
+	require.Equal(t, []int64{1}, processedHeights(t, m))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.analysisMetrics.InvariantViolations.WithLabelValues(invariantTotalSupply)))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.analysisMetrics.InvariantViolationHeight))
+
+	// Invariants are only checked at epoch boundaries if configured to.
+	m, target = newInvariantsTest(t, "consensus_invariants_epoch_test", &analyzer.InvariantPolicy{PerEpoch: true, Halt: true}, 100, seed,
+		`INSERT INTO oasis_3.epochs (id, start_height) VALUES (1, 3)`,
+	)
+	defer target.Shutdown()
+	m.run(ctx, m.name, analyzer.Range{From: 1, To: 4}, nil)
+	require.Equal(t, []int64{1, 2, 3}, processedHeights(t, m))
+}
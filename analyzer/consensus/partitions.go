@@ -0,0 +1,62 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// partitionCheckInterval is the number of heights after which the
+// analyzer ensures that partitions for upcoming blocks exist.
+//
+// ensure_partitions creates the partition following the one containing
+// the provided height, so this must be less than the partition size.
+const partitionCheckInterval = 10000
+
+// ensurePartitions creates the height partitions of blocks, transactions
+// and events for the provided (inclusive) height range and beyond, if
+// missing. Partitions are created by a single caller at a time, and only
+// for heights above those already guaranteed to be partitioned. It is a
+// no-op if target storage does not partition by height, e.g. CockroachDB.
+func (m *Main) ensurePartitions(ctx context.Context, from int64, to int64) error {
+	if !storage.PartitionsByHeight(m.target) {
+		return nil
+	}
+
+	m.partitionsMu.Lock()
+	defer m.partitionsMu.Unlock()
+
+	if to <= m.partitionedTo {
+		return nil
+	}
+	if from <= m.partitionedTo {
+		from = m.partitionedTo + 1
+	}
+
+	batch := &storage.QueryBatch{}
+	for height := from; height < to; height += partitionCheckInterval {
+		batch.Queue(fmt.Sprintf(`
+			SELECT %s.ensure_partitions($1);
+		`, m.cfg.Chain.Schema),
+			height,
+		)
+	}
+	batch.Queue(fmt.Sprintf(`
+		SELECT %s.ensure_partitions($1);
+	`, m.cfg.Chain.Schema),
+		to,
+	)
+
+	opName := "ensure_partitions"
+	timer := m.metrics.DatabaseTimer(m.target.Name(), opName)
+	defer timer.ObserveDuration()
+
+	if err := m.target.SendBatch(ctx, batch); err != nil {
+		m.metrics.DatabaseCounter(m.target.Name(), opName, "failure").Inc()
+		return err
+	}
+	m.metrics.DatabaseCounter(m.target.Name(), opName, "success").Inc()
+	m.partitionedTo = to + partitionCheckInterval
+	return nil
+}
@@ -0,0 +1,60 @@
+package consensus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+// TestEnsurePartitions tests that partitions are ensured for the provided
+// height range, and are guaranteed for the following check interval.
+func TestEnsurePartitions(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	m := NewMain("consensus_partitions_test", target, log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+
+	require.Nil(t, m.ensurePartitions(ctx, 8048956, 8048956))
+	items := target.Items()
+	require.Len(t, items, 1)
+	require.Contains(t, items[0].SQL, "oasis_3.ensure_partitions($1)")
+	require.Equal(t, []interface{}{int64(8048956)}, items[0].Args)
+
+	// Guaranteed heights are not checked again.
+	require.Nil(t, m.ensurePartitions(ctx, 8048957, 8048956+partitionCheckInterval))
+	require.Len(t, target.Items(), 1)
+
+	// Ranges are ensured at every check interval in a single batch.
+	from := int64(8048956 + partitionCheckInterval + 1)
+	to := from + 2*partitionCheckInterval + 1
+	require.Nil(t, m.ensurePartitions(ctx, 1, to))
+	batches := target.Batches()
+	require.Len(t, batches, 2)
+	var heights []interface{}
+	for _, item := range batches[1].Items() {
+		heights = append(heights, item.Args[0])
+	}
+	require.Equal(t, []interface{}{from, from + partitionCheckInterval, from + 2*partitionCheckInterval, to}, heights)
+
+	errBatch := errors.New("batch failed")
+	target.SetBatchError(errBatch)
+	require.Equal(t, errBatch, m.ensurePartitions(ctx, to+1, to+partitionCheckInterval+1))
+}
+
+// TestEnsurePartitionsUnpartitioned tests that partitions are not managed
+// in target storage which does not partition by height.
+func TestEnsurePartitionsUnpartitioned(t *testing.T) {
+	target := storagetest.NewStorage()
+	target.SetPartitionsByHeight(false)
+	m := NewMain("consensus_unpartitioned_test", target, log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+
+	require.Nil(t, m.ensurePartitions(context.Background(), 8048956, 8048956))
+	require.Empty(t, target.Batches())
+}
@@ -0,0 +1,197 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// redriveInterval is the interval at which the analyzer checks
+// for quarantined blocks that should be re-driven.
+const redriveInterval = 10 * time.Second
+
+// shouldQuarantine returns true iff the block at the provided height has
+// failed to process too often and should be quarantined. Failures for blocks
+// beyond the latest available block never lead to quarantine.
+func (m *Main) shouldQuarantine(ctx context.Context, height int64, attempts int) bool {
+	if m.cfg.Quarantine == nil || attempts < m.cfg.Quarantine.MaxAttempts {
+		return false
+	}
+
+	source, err := m.source(height)
+	if err != nil {
+		return false
+	}
+	latest, err := source.LatestHeight(ctx)
+	if err != nil {
+		return false
+	}
+	return height <= latest
+}
+
+// quarantine records the block at the provided height as quarantined
+// for progress recorded under the provided name.
+func (m *Main) quarantine(ctx context.Context, height int64, name string, attempts int, cause error) error {
+	batch := &storage.QueryBatch{}
+	batch.Queue(fmt.Sprintf(`
+		INSERT INTO %s.quarantined_blocks (height, analyzer, error, attempts, quarantined_time)
+			VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (height, analyzer) DO
+			UPDATE SET
+				error = excluded.error,
+				attempts = quarantined_blocks.attempts + excluded.attempts,
+				quarantined_time = excluded.quarantined_time,
+				redrive_requested = FALSE;
+	`, m.cfg.Chain.Schema),
+		height,
+		name,
+		cause.Error(),
+		attempts,
+	)
+	if err := m.target.SendBatch(ctx, batch); err != nil {
+		return err
+	}
+
+	m.analysisMetrics.QuarantinedBlocks.Inc()
+	m.logger.Error("quarantined block",
+		"height", height,
+		"progress", name,
+		"attempts", attempts,
+		"err", cause.Error(),
+	)
+	return nil
+}
+
+// unquarantine returns a function removing the block at the provided
+// height from quarantine, to be applied along with its updates.
+func (m *Main) unquarantine(name string) prepareFunc {
+	return func(ctx context.Context, height int64, batch *storage.QueryBatch) error {
+		batch.Queue(fmt.Sprintf(`
+			DELETE FROM %s.quarantined_blocks
+				WHERE height = $1 AND analyzer = $2;
+		`, m.cfg.Chain.Schema),
+			height,
+			name,
+		)
+		return nil
+	}
+}
+
+// redrive reprocesses quarantined blocks for which a re-drive was requested,
+// under the provided progress name. Blocks that fail again are returned to
+// quarantine. It returns true iff progress was rolled back, in which case
+// processing must resume from the recorded progress.
+//
+// A block is only replayed if it is the next block to process, i.e. if the
+// analyzer halted at it, or if only append-only data is processed under the
+// name, as for backfill shards. Otherwise, state updates of later blocks were
+// already applied and cannot be reordered, so indexed data is rolled back to
+// the preceding block and reindexed from there instead.
+func (m *Main) redrive(ctx context.Context, name string, fs []prepareFunc) (bool, error) {
+	rows, err := m.target.Query(ctx, fmt.Sprintf(`
+		SELECT height FROM %s.quarantined_blocks
+			WHERE analyzer = $1 AND redrive_requested
+			ORDER BY height
+	`, m.cfg.Chain.Schema),
+		name,
+	)
+	if err != nil {
+		return false, err
+	}
+	var heights []int64
+	for rows.Next() {
+		var height int64
+		if err := rows.Scan(&height); err != nil {
+			rows.Close()
+			return false, err
+		}
+		heights = append(heights, height)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+
+	for _, height := range heights {
+		processed, err := m.processed(ctx, name, height)
+		if err != nil {
+			return false, err
+		}
+		if processed {
+			// The block was processed since it was quarantined.
+			batch := &storage.QueryBatch{}
+			if err := m.unquarantine(name)(ctx, height, batch); err != nil {
+				return false, err
+			}
+			if err := m.target.SendBatch(ctx, batch); err != nil {
+				return false, err
+			}
+			continue
+		}
+
+		if name == m.name {
+			latest, err := m.latestBlock(ctx, name)
+			switch {
+			case err == storage.ErrNoRows:
+				latest = height - 1
+			case err != nil:
+				return false, err
+			}
+
+			if latest < height-1 {
+				// The block is yet to be processed in order.
+				continue
+			}
+			if latest >= height {
+				if m.backfilling {
+					// Rolling back would discard data ingested by
+					// backfill shards concurrently.
+					m.logger.Warn("deferring re-drive of quarantined block until backfill completes",
+						"height", height,
+						"progress", name,
+					)
+					return false, nil
+				}
+				m.logger.Info("reindexing from quarantined block",
+					"height", height,
+					"progress", name,
+					"latest", latest,
+				)
+				if err := m.rollback(ctx, height-1); err != nil {
+					return false, err
+				}
+				return true, nil
+			}
+		}
+
+		m.logger.Info("re-driving quarantined block",
+			"height", height,
+			"progress", name,
+		)
+		redriveFs := append(append([]prepareFunc{}, fs...), m.unquarantine(name))
+		if err := m.processBlock(ctx, height, name, redriveFs); err != nil {
+			if err := m.quarantine(ctx, height, name, 1, err); err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// processed returns true iff the block at the provided height was processed
+// under the provided progress name, including blocks compacted to ranges.
+func (m *Main) processed(ctx context.Context, name string, height int64) (bool, error) {
+	var processed bool
+	if err := m.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT EXISTS(SELECT 1 FROM %[1]s.processed_blocks WHERE height = $1 AND analyzer = $2)
+			OR EXISTS(SELECT 1 FROM %[1]s.processed_block_ranges WHERE analyzer = $2 AND $1 BETWEEN from_height AND to_height)
+	`, m.cfg.Chain.Schema),
+		height,
+		name,
+	).Scan(&processed); err != nil {
+		return false, err
+	}
+	return processed, nil
+}
@@ -0,0 +1,74 @@
+package consensus
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+// newRedriveTest returns an analyzer with the provided name, with a block
+// at height 10 requested to be re-driven, and the provided progress.
+func newRedriveTest(name string, processed bool, latest int64) (*Main, *storagetest.Storage) {
+	target := storagetest.NewStorage()
+	target.AddRows("redrive_requested", []interface{}{int64(10)})
+	target.AddRows("BETWEEN from_height AND to_height", []interface{}{processed})
+	target.AddRows("ORDER BY height DESC", []interface{}{latest})
+
+	m := NewMain(name, target, log.NewDefaultLogger("consensus-test"))
+	m.cfg.Chain = &analyzer.Chain{Schema: "oasis_3"}
+	m.cfg.BlockRange = analyzer.Range{From: 20}
+	return m, target
+}
+
+// TestRedrive tests that quarantined blocks are only replayed if they are
+// the next block to process, and are reindexed otherwise.
+func TestRedrive(t *testing.T) {
+	ctx := context.Background()
+
+	// The analyzer halted at the block.
+	m, target := newRedriveTest("consensus_redrive_halted_test", false, 9)
+	rolledBack, err := m.redrive(ctx, m.name, nil)
+	require.Nil(t, err)
+	require.False(t, rolledBack)
+	items := target.Items()
+	require.Len(t, items, 2)
+	sqls := items[0].SQL + items[1].SQL
+	require.Contains(t, sqls, "DELETE FROM oasis_3.quarantined_blocks")
+	require.Contains(t, sqls, "INSERT INTO oasis_3.processed_blocks")
+
+	// The block was processed since it was quarantined.
+	m, target = newRedriveTest("consensus_redrive_processed_test", true, 12)
+	rolledBack, err = m.redrive(ctx, m.name, nil)
+	require.Nil(t, err)
+	require.False(t, rolledBack)
+	items = target.Items()
+	require.Len(t, items, 1)
+	require.Contains(t, items[0].SQL, "DELETE FROM oasis_3.quarantined_blocks")
+
+	// Later blocks were applied, so the block is reindexed from node
+	// state, which is unavailable outside of the analysis range.
+	m, target = newRedriveTest("consensus_redrive_skipped_test", false, 12)
+	_, err = m.redrive(ctx, m.name, nil)
+	require.Equal(t, ErrOutOfRange, err)
+	require.Empty(t, target.Items())
+
+	// Reindexing is deferred while backfilling.
+	m, target = newRedriveTest("consensus_redrive_backfill_test", false, 12)
+	m.backfilling = true
+	rolledBack, err = m.redrive(ctx, m.name, nil)
+	require.Nil(t, err)
+	require.False(t, rolledBack)
+	require.Empty(t, target.Items())
+
+	// Append-only data of backfill shards is replayed regardless.
+	m, target = newRedriveTest("consensus_redrive_shard_test", false, 12)
+	rolledBack, err = m.redrive(ctx, m.shardName(analyzer.Range{From: 1, To: 100}), nil)
+	require.Nil(t, err)
+	require.False(t, rolledBack)
+	require.Len(t, target.Items(), 2)
+}
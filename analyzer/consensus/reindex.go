@@ -0,0 +1,113 @@
+package consensus
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+var _ analyzer.Reindexer = (*Main)(nil)
+
+// Rollback rolls back indexed data to the provided height. Block data above
+// the height is deleted, and state is replaced with the node state at the
+// height, since state tables are mutated by deltas and cannot be reverted.
+func (m *Main) Rollback(ctx context.Context, height int64) error {
+	if _, err := m.holdLease(ctx, m.name); err != nil {
+		return err
+	}
+	defer m.releaseLease(ctx, m.name)
+
+	return m.rollback(ctx, height)
+}
+
+// rollback rolls back indexed data to the provided height, while the
+// analyzer lease is held.
+func (m *Main) rollback(ctx context.Context, height int64) error {
+	chainID := m.cfg.Chain.Schema
+
+	m.logger.Info("rolling back to node state",
+		"height", height,
+	)
+	batch := &storage.QueryBatch{}
+
+	// Delete block data above the height, in dependency order.
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.events WHERE txn_block > $1;
+	`, chainID), height)
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.transactions WHERE block > $1;
+	`, chainID), height)
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.blocks WHERE height > $1;
+	`, chainID), height)
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.epochs WHERE start_height > $1;
+	`, chainID), height)
+	batch.Queue(fmt.Sprintf(`
+		UPDATE %s.epochs SET end_height = NULL WHERE end_height > $1;
+	`, chainID), height)
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.account_balance_history WHERE height > $1;
+	`, chainID), height)
+
+	// Replace state.
+	if err := m.queueStateSnapshot(ctx, height, batch); err != nil {
+		return err
+	}
+
+	// Reset progress, including that of backfill shards. Progress of other
+	// analyzers in the schema is left untouched.
+	for _, table := range []string{"quarantined_blocks", "processed_blocks"} {
+		batch.Queue(fmt.Sprintf(`
+			DELETE FROM %s.%s
+				WHERE height > $1 AND (analyzer = $2 OR analyzer LIKE $3 ESCAPE '\')
+		`, chainID, table),
+			height,
+			m.name,
+			m.shardPattern(),
+		)
+	}
+	// Compacted ranges are trimmed below the rollback height, since the
+	// progress row inserted below must not be covered by a range.
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.processed_block_ranges
+			WHERE from_height >= $1 AND (analyzer = $2 OR analyzer LIKE $3 ESCAPE '\')
+	`, chainID),
+		height,
+		m.name,
+		m.shardPattern(),
+	)
+	batch.Queue(fmt.Sprintf(`
+		UPDATE %s.processed_block_ranges SET to_height = $1 - 1
+			WHERE to_height >= $1 AND (analyzer = $2 OR analyzer LIKE $3 ESCAPE '\')
+	`, chainID),
+		height,
+		m.name,
+		m.shardPattern(),
+	)
+	batch.Queue(fmt.Sprintf(`
+		INSERT INTO %s.processed_blocks (height, analyzer, processed_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (height, analyzer) DO NOTHING;
+	`, chainID),
+		height,
+		m.name,
+	)
+
+	opName := "rollback"
+	timer := m.metrics.DatabaseTimer(m.target.Name(), opName)
+	defer timer.ObserveDuration()
+
+	if err := m.target.SendBatch(ctx, batch); err != nil {
+		m.metrics.DatabaseCounter(m.target.Name(), opName, "failure").Inc()
+		return err
+	}
+	m.metrics.DatabaseCounter(m.target.Name(), opName, "success").Inc()
+
+	m.logger.Info("rollback completed",
+		"height", height,
+	)
+	return nil
+}
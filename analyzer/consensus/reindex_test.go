@@ -0,0 +1,140 @@
+package consensus
+
+import (
+	"context"
+	"testing"
+
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+// snapshotSource is source storage providing empty node state.
+type snapshotSource struct {
+	storage.SourceStorage
+}
+
+func (s *snapshotSource) GenesisDocumentAtHeight(ctx context.Context, height int64) (*genesis.Document, error) {
+	return &genesis.Document{Height: height}, nil
+}
+
+func (s *snapshotSource) SchedulerData(ctx context.Context, height int64) (*storage.SchedulerData, error) {
+	return &storage.SchedulerData{Height: height}, nil
+}
+
+// TestShardPattern tests that the shard pattern only matches names of
+// backfill shards of the analyzer.
+func TestShardPattern(t *testing.T) {
+	m := NewMain("consensus_shard_pattern_test", nil, log.NewDefaultLogger("consensus-test"))
+	require.Equal(t, `consensus\_shard\_pattern\_test\_shard\_%`, m.shardPattern())
+}
+
+// TestRollbackProgress tests that rolling back only resets progress of
+// the analyzer and its backfill shards.
+func TestRollbackProgress(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("consensus-test")
+
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	target, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer target.Shutdown()
+
+	m := NewMain("consensus_rollback_test", target, logger)
+	m.cfg.Chain = chain
+	m.cfg.BlockRange = analyzer.Range{From: 1}
+	m.cfg.Source = &snapshotSource{}
+	shard := m.shardName(analyzer.Range{From: 1, To: 10})
+
+	batch := &storage.QueryBatch{}
+	for height := int64(1); height <= 10; height++ {
+		for _, name := range []string{m.name, shard, "consensus_rollbackXtest_shard_1_10", "exporter"} {
+			batch.Queue(`
+				INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+					VALUES ($1, $2, CURRENT_TIMESTAMP)
+			`, height, name)
+		}
+	}
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	require.Nil(t, m.rollback(ctx, 5))
+
+	rows, err := target.Query(ctx, `
+		SELECT analyzer, MAX(height) FROM oasis_3.processed_blocks GROUP BY analyzer ORDER BY analyzer
+	`)
+	require.Nil(t, err)
+	defer rows.Close()
+	latest := make(map[string]int64)
+	for rows.Next() {
+		var name string
+		var height int64
+		require.Nil(t, rows.Scan(&name, &height))
+		latest[name] = height
+	}
+	require.Equal(t, map[string]int64{
+		m.name:                               5,
+		shard:                                5,
+		"consensus_rollbackXtest_shard_1_10": 10,
+		"exporter":                           10,
+	}, latest)
+}
+
+// TestRollbackCompacted tests that progress covered by compacted ranges
+// guards against applying a block twice, and that rollbacks trim ranges.
+func TestRollbackCompacted(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("consensus-test")
+
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	target, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer target.Shutdown()
+
+	m := NewMain("consensus_rollback_compacted_test", target, logger)
+	m.cfg.Chain = chain
+	m.cfg.BlockRange = analyzer.Range{From: 1}
+	m.cfg.Source = &snapshotSource{}
+
+	batch := &storage.QueryBatch{}
+	batch.Queue(`
+		INSERT INTO oasis_3.processed_block_ranges (analyzer, from_height, to_height)
+			VALUES ($1, 1, 8)
+	`, m.name)
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	// Inserting the progress of a compacted height fails.
+	batch = &storage.QueryBatch{}
+	batch.Queue(`
+		INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP)
+	`, int64(5), m.name)
+	require.NotNil(t, target.SendBatch(ctx, batch))
+
+	require.Nil(t, m.rollback(ctx, 5))
+
+	var from, to int64
+	require.Nil(t, target.QueryRow(ctx, `
+		SELECT from_height, to_height FROM oasis_3.processed_block_ranges WHERE analyzer = $1
+	`, m.name).Scan(&from, &to))
+	require.Equal(t, int64(1), from)
+	require.Equal(t, int64(4), to)
+
+	height, err := m.startHeight(ctx, m.name, m.cfg.BlockRange)
+	require.Nil(t, err)
+	require.Equal(t, int64(6), height)
+}
@@ -0,0 +1,158 @@
+// Package coordination implements coordination between multiple
+// indexer instances sharing the same target storage.
+package coordination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// ErrLeaseHeld is returned when a lease is held by another holder.
+var ErrLeaseHeld = errors.New("lease held by another instance")
+
+// DefaultHolder returns a holder identity for this process,
+// derived from the hostname and process ID.
+func DefaultHolder() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", hostname, os.Getpid())
+}
+
+// Lease is an expiring lease stored in target storage. Exactly one holder
+// may hold a lease at a time; other holders act as hot standbys and take
+// over once the lease expires without being renewed.
+//
+// A lease only provides liveness. Analyzers must still write their progress
+// in the same transaction as their updates, so that a holder which loses its
+// lease mid-block cannot double-apply updates.
+//
+// Whether the lease is held is not checked within that transaction. Checking
+// Held before committing only narrows the window in which a holder whose lease
+// expired commits updates; the real guard is the primary key of progress, e.g.
+// (height, analyzer) of processed_blocks, on which the transaction of the
+// second holder to process a block fails and rolls back.
+type Lease struct {
+	target   storage.TargetStorage
+	schema   string
+	name     string
+	holder   string
+	duration time.Duration
+
+	mu     sync.Mutex
+	expiry time.Time
+}
+
+// NewLease creates a new lease with the provided name in the provided schema.
+func NewLease(target storage.TargetStorage, schema, name, holder string, duration time.Duration) *Lease {
+	return &Lease{
+		target:   target,
+		schema:   schema,
+		name:     name,
+		holder:   holder,
+		duration: duration,
+	}
+}
+
+// Derive returns a new lease with the provided name, held by the same
+// holder for the same duration.
+func (l *Lease) Derive(name string) *Lease {
+	return NewLease(l.target, l.schema, name, l.holder, l.duration)
+}
+
+// Name returns the name of the lease.
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// Holder returns the identity of this lease holder.
+func (l *Lease) Holder() string {
+	return l.holder
+}
+
+// Acquire acquires the lease, or renews it if it is already held.
+// It returns ErrLeaseHeld if the lease is held by another holder.
+func (l *Lease) Acquire(ctx context.Context) error {
+	// Conservatively start the lease before the request is sent.
+	expiry := time.Now().Add(l.duration)
+
+	var holder string
+	err := l.target.QueryRow(
+		ctx,
+		fmt.Sprintf(`
+			INSERT INTO %s.leases (name, holder, expires_at)
+				VALUES ($1, $2, CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond')
+			ON CONFLICT (name) DO UPDATE
+				SET holder = excluded.holder, expires_at = excluded.expires_at
+				WHERE leases.holder = excluded.holder OR leases.expires_at < CURRENT_TIMESTAMP
+			RETURNING holder
+		`, l.schema),
+		l.name,
+		l.holder,
+		l.duration.Milliseconds(),
+	).Scan(&holder)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	switch {
+	case err == storage.ErrNoRows:
+		l.expiry = time.Time{}
+		return ErrLeaseHeld
+	case err != nil:
+		return err
+	}
+	l.expiry = expiry
+	return nil
+}
+
+// Wait blocks until the lease is acquired or the context is done.
+func (l *Lease) Wait(ctx context.Context) error {
+	for {
+		err := l.Acquire(ctx)
+		if err == nil {
+			return nil
+		}
+		if err != ErrLeaseHeld {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.duration / 2):
+		}
+	}
+}
+
+// Held returns true iff the lease is held according to the last
+// successful acquisition.
+func (l *Lease) Held() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Now().Before(l.expiry)
+}
+
+// Release releases the lease if it is held, allowing a standby
+// to take over immediately.
+func (l *Lease) Release(ctx context.Context) error {
+	l.mu.Lock()
+	l.expiry = time.Time{}
+	l.mu.Unlock()
+
+	batch := &storage.QueryBatch{}
+	batch.Queue(fmt.Sprintf(`
+		DELETE FROM %s.leases
+			WHERE name = $1 AND holder = $2
+	`, l.schema),
+		l.name,
+		l.holder,
+	)
+	return l.target.SendBatch(ctx, batch)
+}
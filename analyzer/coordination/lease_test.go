@@ -0,0 +1,81 @@
+package coordination
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+const testSchema = "coordination_test"
+
+// newTarget returns SQLite target storage with a leases table, so that
+// leases are tested without a live PostgreSQL instance.
+func newTarget(t *testing.T) storage.TargetStorage {
+	logger, err := log.NewLogger("coordination-test", ioutil.Discard, log.FmtJSON, log.LevelInfo)
+	require.Nil(t, err)
+
+	client, err := sqlite.NewClient(sqlite.Scheme+t.TempDir(), []string{testSchema}, logger)
+	require.Nil(t, err)
+
+	batch := &storage.QueryBatch{}
+	batch.Queue(`
+		CREATE TABLE ` + testSchema + `.leases
+		(
+			name       TEXT PRIMARY KEY,
+			holder     TEXT NOT NULL,
+			expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+		)
+	`)
+	require.Nil(t, client.SendBatch(context.Background(), batch))
+	return client
+}
+
+func TestLeaseTakeover(t *testing.T) {
+	ctx := context.Background()
+	target := newTarget(t)
+	defer target.Shutdown()
+
+	leader := NewLease(target, testSchema, "test", "leader", time.Second)
+	standby := NewLease(target, testSchema, "test", "standby", time.Second)
+
+	require.Nil(t, leader.Acquire(ctx))
+	require.True(t, leader.Held())
+	require.Nil(t, leader.Acquire(ctx))
+
+	require.Equal(t, ErrLeaseHeld, standby.Acquire(ctx))
+	require.False(t, standby.Held())
+
+	// The standby takes over once the leader stops renewing.
+	require.Nil(t, standby.Wait(ctx))
+	require.True(t, standby.Held())
+	require.Equal(t, ErrLeaseHeld, leader.Acquire(ctx))
+	require.False(t, leader.Held())
+
+	// Releasing allows immediate takeover.
+	require.Nil(t, standby.Release(ctx))
+	require.Nil(t, leader.Acquire(ctx))
+}
+
+func TestLeaseDerive(t *testing.T) {
+	ctx := context.Background()
+	target := newTarget(t)
+	defer target.Shutdown()
+
+	leader := NewLease(target, testSchema, "test", "leader", time.Minute)
+	standby := NewLease(target, testSchema, "test", "standby", time.Minute)
+	require.Nil(t, leader.Acquire(ctx))
+
+	// Derived leases are held independently of the original lease.
+	shard := standby.Derive("test_shard")
+	require.Equal(t, "test_shard", shard.Name())
+	require.Equal(t, "standby", shard.Holder())
+	require.Nil(t, shard.Acquire(ctx))
+	require.Equal(t, ErrLeaseHeld, leader.Derive("test_shard").Acquire(ctx))
+}
@@ -0,0 +1,554 @@
+// Package exporter implements continuous export of indexed tables
+// as Parquet files for analytics.
+package exporter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	exporterName = "parquet_exporter"
+
+	// dirOption is the option setting the directory to export to.
+	dirOption = "dir"
+
+	// progressOption is the option setting the analyzer whose progress
+	// determines the height at which to snapshot state. If omitted, the
+	// latest height processed by any analyzer other than a shard is used.
+	progressOption = "progress"
+
+	// blocksPerFileOption is the option setting the maximum number
+	// of blocks of history exported to each file.
+	blocksPerFileOption = "blocks_per_file"
+
+	defaultBlocksPerFile = 10000
+
+	// parallelism is the number of goroutines marshalling rows
+	// of each exported file.
+	parallelism = 4
+)
+
+// ErrNoDir is returned when no export directory is configured.
+var ErrNoDir = errors.New("no export directory configured")
+
+func init() {
+	analyzer.Register(
+		exporterName,
+		config.AnalyzerSchema{
+			RequiresInterval: true,
+			Options: []string{
+				dirOption,
+				progressOption,
+				blocksPerFileOption,
+			},
+			ValidateOptions: func(cfg *config.AnalyzerConfig) error {
+				_, err := parseOptions(analyzer.OptionsConfig(cfg))
+				return err
+			},
+		},
+		func(name string, target storage.TargetStorage, logger *log.Logger) (analyzer.Analyzer, error) {
+			return NewAnalyzer(name, target, logger), nil
+		},
+	)
+}
+
+// options are the options of an exporting analyzer.
+type options struct {
+	dir           string
+	progress      string
+	blocksPerFile int64
+}
+
+// parseOptions parses the options of an exporting analyzer.
+func parseOptions(cfg *analyzer.Config) (options, error) {
+	dir, ok := cfg.Options[dirOption].(string)
+	if !ok || dir == "" {
+		return options{}, ErrNoDir
+	}
+	var progress string
+	if v, ok := cfg.Options[progressOption]; ok {
+		if progress, ok = v.(string); !ok || progress == "" {
+			return options{}, fmt.Errorf("option '%s' must name an analyzer", progressOption)
+		}
+	}
+	blocksPerFile, ok, err := cfg.Int64Option(blocksPerFileOption)
+	if err != nil {
+		return options{}, err
+	}
+	if !ok {
+		blocksPerFile = defaultBlocksPerFile
+	}
+	if blocksPerFile <= 0 {
+		return options{}, fmt.Errorf("option '%s' must be positive", blocksPerFileOption)
+	}
+	return options{
+		dir:           dir,
+		progress:      progress,
+		blocksPerFile: blocksPerFile,
+	}, nil
+}
+
+// Analyzer periodically exports newly indexed history, and snapshots
+// of indexed state once per epoch, as Parquet files.
+type Analyzer struct {
+	name string
+	cfg  analyzer.Config
+
+	target  storage.TargetStorage
+	logger  *log.Logger
+	metrics metrics.DatabaseMetrics
+}
+
+// NewAnalyzer returns a new exporting analyzer with the provided name.
+func NewAnalyzer(name string, target storage.TargetStorage, logger *log.Logger) *Analyzer {
+	return &Analyzer{
+		name:    name,
+		target:  target,
+		logger:  logger.With("analyzer", name),
+		metrics: metrics.NewDefaultDatabaseMetrics(name),
+	}
+}
+
+// SetConfig sets the configuration of this analyzer.
+// It is intended to be called before Start.
+func (a *Analyzer) SetConfig(cfg analyzer.Config) {
+	a.cfg = cfg
+}
+
+// Start starts the exporting analyzer.
+func (a *Analyzer) Start() {
+	ctx := context.Background()
+
+	// Options are validated with the config, so this only fails if the
+	// analyzer is configured without validation.
+	e, err := a.exporter()
+	if err != nil {
+		a.logger.Error("exporter misconfigured",
+			"err", err.Error(),
+		)
+		return
+	}
+
+	for {
+		if err := a.export(ctx, e); err != nil {
+			a.logger.Error("export failed",
+				"err", err.Error(),
+			)
+		}
+		time.Sleep(a.cfg.Interval)
+	}
+}
+
+// Name returns the name of the analyzer.
+func (a *Analyzer) Name() string {
+	return a.name
+}
+
+// Rollback removes exported files above the provided height, so that
+// reindexed history and state are exported again.
+func (a *Analyzer) Rollback(ctx context.Context, height int64) error {
+	e, err := a.exporter()
+	if err != nil {
+		return err
+	}
+	return e.Rollback(height)
+}
+
+// exporter returns the exporter configured by the analyzer's options.
+func (a *Analyzer) exporter() (*Exporter, error) {
+	opts, err := parseOptions(&a.cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewExporter(a.target, a.cfg.Chain, opts.dir, opts.progress, opts.blocksPerFile, a.logger), nil
+}
+
+// export runs a single export, coordinated by the analyzer lease if configured.
+func (a *Analyzer) export(ctx context.Context, e *Exporter) error {
+	if a.cfg.Lease != nil {
+		switch err := a.cfg.Lease.Acquire(ctx); err {
+		case nil:
+			defer func() {
+				if err := a.cfg.Lease.Release(ctx); err != nil {
+					a.logger.Error("failed to release lease",
+						"err", err.Error(),
+					)
+				}
+			}()
+		case coordination.ErrLeaseHeld:
+			a.logger.Debug("export lease held by another instance",
+				"holder", a.cfg.Lease.Holder(),
+			)
+			return nil
+		default:
+			return err
+		}
+	}
+
+	opName := "export"
+	timer := a.metrics.DatabaseTimer(a.target.Name(), opName)
+	defer timer.ObserveDuration()
+
+	if err := e.Export(ctx); err != nil {
+		a.metrics.DatabaseCounter(a.target.Name(), opName, "failure").Inc()
+		return err
+	}
+	a.metrics.DatabaseCounter(a.target.Name(), opName, "success").Inc()
+	return nil
+}
+
+// Exporter exports the indexed history and state of a chain to a
+// directory of Parquet files, along with a manifest of exported files.
+//
+// History of blocks, transactions and events is exported in height
+// ranges of at most a configured number of blocks, as blocks are
+// indexed. State of accounts and delegations is snapshotted at the
+// latest height processed by the progress analyzer once per epoch;
+// epochs during which the exporter did not run are skipped.
+type Exporter struct {
+	target        storage.TargetStorage
+	chain         *analyzer.Chain
+	dir           string
+	progress      string
+	blocksPerFile int64
+	logger        *log.Logger
+
+	// now returns the current time.
+	now func() time.Time
+}
+
+// NewExporter creates a new exporter of the provided chain's indexed
+// data to the provided directory, snapshotting state at the progress
+// recorded under the provided analyzer name. If the name is empty, the
+// progress of all analyzers other than shards is used.
+func NewExporter(target storage.TargetStorage, chain *analyzer.Chain, dir, progress string, blocksPerFile int64, logger *log.Logger) *Exporter {
+	return &Exporter{
+		target:        target,
+		chain:         chain,
+		dir:           dir,
+		progress:      progress,
+		blocksPerFile: blocksPerFile,
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Export exports history indexed since the last export, and a snapshot
+// of indexed state if the epoch changed since the last snapshot.
+// The manifest is updated after each exported range and snapshot, so
+// that an interrupted export resumes where it stopped.
+func (e *Exporter) Export(ctx context.Context) error {
+	m, err := LoadManifest(e.dir, string(e.chain.ID))
+	if err != nil {
+		return err
+	}
+
+	if err := e.exportHistory(ctx, m); err != nil {
+		return err
+	}
+	return e.exportSnapshot(ctx, m)
+}
+
+// Rollback removes files exported above the provided height from the
+// manifest and the export directory, so that history and state reindexed
+// above the height are exported again. Files of history ranges including
+// the height are removed as well, and exported again in full.
+func (e *Exporter) Rollback(height int64) error {
+	m, err := LoadManifest(e.dir, string(e.chain.ID))
+	if err != nil {
+		return err
+	}
+
+	var kept, removed []File
+	for _, f := range m.Files {
+		if f.ToHeight > height {
+			removed = append(removed, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	// Files are only removed once they are no longer in the manifest.
+	m.Files = append([]File{}, kept...)
+	if err := m.Save(e.dir); err != nil {
+		return err
+	}
+	if err := e.removeFiles(removed); err != nil {
+		return err
+	}
+	e.logger.Info("rolled back exported files",
+		"height", height,
+		"files", len(removed),
+	)
+	return nil
+}
+
+// removeFiles removes the provided files from the export directory.
+func (e *Exporter) removeFiles(files []File) error {
+	for _, f := range files {
+		if err := os.Remove(filepath.Join(e.dir, filepath.FromSlash(f.Path))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// latestHeight returns the latest height processed by the progress
+// analyzer, or zero if it has not processed any.
+func (e *Exporter) latestHeight(ctx context.Context, q storage.Querier) (int64, error) {
+	filter, arg := "analyzer = $1", e.progress
+	if e.progress == "" {
+		filter, arg = `analyzer NOT LIKE $1 ESCAPE '\'`, analyzer.ShardProgressPattern
+	}
+	return storage.QueryInt64(ctx, q, fmt.Sprintf(`
+		SELECT COALESCE(MAX(height), 0) FROM (
+			SELECT height FROM %[1]s.processed_blocks
+				WHERE %[2]s
+			UNION ALL
+			SELECT to_height FROM %[1]s.processed_block_ranges
+				WHERE %[2]s
+		) p
+	`, e.chain.Schema, filter), arg)
+}
+
+// indexedRanges returns the contiguous height ranges of indexed blocks,
+// in ascending order. Blocks are indexed along with their transactions
+// and events, so their history is complete.
+func (e *Exporter) indexedRanges(ctx context.Context) ([]analyzer.Range, error) {
+	rows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT MIN(height), MAX(height) FROM (
+			SELECT height, height - ROW_NUMBER() OVER (ORDER BY height) AS island
+				FROM %s.blocks
+		) b
+		GROUP BY island
+		ORDER BY 1
+	`, e.chain.Schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ranges []analyzer.Range
+	for rows.Next() {
+		var r analyzer.Range
+		if err := rows.Scan(&r.From, &r.To); err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, rows.Err()
+}
+
+// unexportedRanges splits the provided indexed ranges, less the provided
+// exported ranges, into ranges of at most the provided number of blocks.
+// Both indexed and exported ranges must be disjoint and in ascending order.
+func unexportedRanges(indexed, exported []analyzer.Range, blocksPerFile int64) []analyzer.Range {
+	var ranges []analyzer.Range
+	split := func(from, to int64) {
+		for ; from <= to; from += blocksPerFile {
+			end := from + blocksPerFile - 1
+			if end > to {
+				end = to
+			}
+			ranges = append(ranges, analyzer.Range{From: from, To: end})
+		}
+	}
+	for _, r := range indexed {
+		from := r.From
+		for _, x := range exported {
+			if x.To < from || x.From > r.To {
+				continue
+			}
+			split(from, x.From-1)
+			if x.To+1 > from {
+				from = x.To + 1
+			}
+		}
+		split(from, r.To)
+	}
+	return ranges
+}
+
+// exportHistory exports indexed history which has not been exported yet.
+// Indexed heights need not be contiguous, e.g. while backfilling or after
+// pruning, so each range of indexed blocks missing from the manifest is
+// exported, including ranges below previously exported history.
+func (e *Exporter) exportHistory(ctx context.Context, m *Manifest) error {
+	indexed, err := e.indexedRanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range unexportedRanges(indexed, m.HistoryRanges(), e.blocksPerFile) {
+		from, to := r.From, r.To
+		for _, t := range historyTables {
+			path := historyPath(t.name, from, to)
+			n, err := e.writeTable(ctx, e.target, path, t, 0, 0, from, to)
+			if err != nil {
+				return fmt.Errorf("exporting %s: %w", t.name, err)
+			}
+			m.Files = append(m.Files, File{
+				Table:        t.name,
+				Path:         path,
+				FromHeight:   from,
+				ToHeight:     to,
+				Rows:         n,
+				ExportedTime: e.now().UTC(),
+			})
+		}
+		if err := m.Save(e.dir); err != nil {
+			return err
+		}
+		e.logger.Info("exported history",
+			"from", from,
+			"to", to,
+		)
+	}
+	return nil
+}
+
+// exportSnapshot exports a snapshot of indexed state at the latest
+// processed height, unless the epoch of the height has already been
+// snapshotted. State is read with several queries, so they are served
+// from a single snapshot of target storage if it supports snapshots.
+// Otherwise, the snapshot is discarded and retried later if analysis
+// progressed in the meantime.
+func (e *Exporter) exportSnapshot(ctx context.Context, m *Manifest) error {
+	var latest, epoch int64
+	var files []File
+	consistent, err := storage.ReadSnapshot(ctx, e.target, func(q storage.Querier) error {
+		// Reads may be retried.
+		files = nil
+
+		var err error
+		if latest, err = e.latestHeight(ctx, q); err != nil || latest == 0 {
+			return err
+		}
+		switch err = q.QueryRow(ctx, fmt.Sprintf(`
+			SELECT id FROM %s.epochs
+				WHERE start_height <= $1
+				ORDER BY id DESC LIMIT 1
+		`, e.chain.Schema),
+			latest,
+		).Scan(&epoch); err {
+		case nil:
+		case storage.ErrNoRows:
+			return nil
+		default:
+			return err
+		}
+		if snapshotted, ok := m.SnapshotEpoch(); ok && snapshotted >= epoch {
+			return nil
+		}
+
+		for _, t := range snapshotTables {
+			path := snapshotPath(t.name, epoch)
+			n, err := e.writeTable(ctx, q, path, t, epoch, latest)
+			if err != nil {
+				return fmt.Errorf("exporting %s: %w", t.name, err)
+			}
+			files = append(files, File{
+				Table:        t.name,
+				Path:         path,
+				FromHeight:   latest,
+				ToHeight:     latest,
+				Epoch:        &epoch,
+				Rows:         n,
+				ExportedTime: e.now().UTC(),
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		// Files written before the failure are not in the manifest.
+		if rmErr := e.removeFiles(files); rmErr != nil {
+			e.logger.Error("failed to remove snapshot files",
+				"err", rmErr.Error(),
+			)
+		}
+		return err
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	if !consistent {
+		current, err := e.latestHeight(ctx, e.target)
+		if err != nil {
+			return err
+		}
+		if current != latest {
+			e.logger.Debug("analysis progressed during snapshot, retrying later",
+				"epoch", epoch,
+				"height", latest,
+			)
+			return e.removeFiles(files)
+		}
+	}
+
+	m.Files = append(m.Files, files...)
+	if err := m.Save(e.dir); err != nil {
+		return err
+	}
+	e.logger.Info("exported snapshot",
+		"epoch", epoch,
+		"height", latest,
+	)
+	return nil
+}
+
+// writeTable atomically writes the rows of the provided table to a
+// Parquet file at the provided path, and returns the number of rows.
+// Rows are read with the provided querier. Snapshot rows are written
+// with the provided epoch and height, and the query is passed the
+// provided arguments.
+func (e *Exporter) writeTable(ctx context.Context, q storage.Querier, path string, t table, epoch, height int64, args ...interface{}) (int64, error) {
+	rows, err := q.Query(ctx, fmt.Sprintf(t.query, e.chain.Schema), args...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var n int64
+	err = writeAtomic(filepath.Join(e.dir, filepath.FromSlash(path)), func(f *os.File) error {
+		pw, err := writer.NewParquetWriterFromWriter(f, t.row, parallelism)
+		if err != nil {
+			return err
+		}
+		pw.CompressionType = parquet.CompressionCodec_SNAPPY
+
+		for rows.Next() {
+			row, err := t.scan(rows, epoch, height)
+			if err != nil {
+				return err
+			}
+			if err := pw.Write(row); err != nil {
+				return err
+			}
+			n++
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return pw.WriteStop()
+	})
+	return n, err
+}
@@ -0,0 +1,463 @@
+package exporter
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/types"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+const progress = "consensus_main_damask"
+
+var blockTime = time.Date(2022, 4, 11, 8, 30, 0, 0, time.UTC)
+
+// newTarget migrates a new SQLite database for the provided chain,
+// and returns a client to it.
+func newTarget(t *testing.T, chain *analyzer.Chain) storage.TargetStorage {
+	logger := log.NewDefaultLogger("exporter-test")
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+
+	client, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	t.Cleanup(client.Shutdown)
+	return client
+}
+
+// indexBlock indexes a block at the provided height with a transaction
+// and an event, and records it as processed.
+func indexBlock(t *testing.T, target storage.TargetStorage, height int64) {
+	batch := &storage.QueryBatch{}
+	batch.Copy("oasis_3.blocks", []string{"height", "block_hash", "time", "namespace", "version", "type", "root_hash"},
+		height, "hash", blockTime.Add(time.Duration(height)*time.Second), "ns", int64(1), "state", "root")
+	batch.Copy("oasis_3.transactions", []string{"block", "txn_hash", "txn_index", "nonce", "fee_amount", "max_gas", "method", "sender", "body", "code"},
+		height, "txn", int64(0), "7", "1000", nil, "staking.Transfer", "oasis1sender", []byte{0xa1}, int64(0))
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index"},
+		"staking", "Transfer", `{"amount":"1000"}`, height, "txn", int64(0))
+	batch.Queue(`
+		INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP);
+	`, height, progress)
+	require.Nil(t, target.SendBatch(context.Background(), batch))
+}
+
+// readFile reads all rows of the provided exported file into the
+// provided slice, which has the length of the file.
+func readFile(t *testing.T, dir string, f File, rows interface{}, row interface{}) {
+	pf, err := local.NewLocalFileReader(filepath.Join(dir, f.Path))
+	require.Nil(t, err)
+	defer pf.Close()
+
+	pr, err := reader.NewParquetReader(pf, row, 1)
+	require.Nil(t, err)
+	defer pr.ReadStop()
+	require.Equal(t, f.Rows, pr.GetNumRows())
+	require.Nil(t, pr.Read(rows))
+}
+
+// TestExport tests that history is exported incrementally as blocks
+// are indexed, and state once per epoch.
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+	target := newTarget(t, chain)
+	dir := t.TempDir()
+	e := NewExporter(target, chain, dir, progress, 2, log.NewDefaultLogger("exporter-test"))
+	exportedTime := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return exportedTime }
+
+	// Nothing is exported before any block is processed.
+	require.Nil(t, e.Export(ctx))
+	m, err := LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Empty(t, m.Files)
+
+	for _, height := range []int64{10, 11, 12} {
+		indexBlock(t, target, height)
+	}
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height) VALUES (1, 10)`)
+	batch.Queue(`INSERT INTO oasis_3.accounts (address, general_balance, nonce) VALUES ('oasis1a', 9000000000000000000, 1)`)
+	batch.Queue(`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ('oasis1a', 'oasis1b', 500)`)
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	require.Nil(t, e.Export(ctx))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Equal(t, int64(12), m.ExportedHeight())
+	epoch, ok := m.SnapshotEpoch()
+	require.True(t, ok)
+	require.Equal(t, int64(1), epoch)
+
+	var paths []string
+	for _, f := range m.Files {
+		paths = append(paths, f.Path)
+	}
+	require.Equal(t, []string{
+		"blocks/000000000010-000000000011.parquet",
+		"transactions/000000000010-000000000011.parquet",
+		"events/000000000010-000000000011.parquet",
+		"blocks/000000000012-000000000012.parquet",
+		"transactions/000000000012-000000000012.parquet",
+		"events/000000000012-000000000012.parquet",
+		"account_snapshots/epoch-000000000001.parquet",
+		"delegation_snapshots/epoch-000000000001.parquet",
+	}, paths)
+
+	blocks := make([]Block, m.Files[0].Rows)
+	readFile(t, dir, m.Files[0], &blocks, new(Block))
+	require.Equal(t, []Block{
+		{Height: 10, BlockHash: "hash", Time: types.TimeToTIMESTAMP_MILLIS(blockTime.Add(10*time.Second), true), Namespace: "ns", Version: 1, Type: "state", RootHash: "root"},
+		{Height: 11, BlockHash: "hash", Time: types.TimeToTIMESTAMP_MILLIS(blockTime.Add(11*time.Second), true), Namespace: "ns", Version: 1, Type: "state", RootHash: "root"},
+	}, blocks)
+
+	txs := make([]Transaction, m.Files[4].Rows)
+	readFile(t, dir, m.Files[4], &txs, new(Transaction))
+	require.Len(t, txs, 1)
+	require.Equal(t, int64(12), txs[0].Block)
+	require.Equal(t, "7", types.DECIMAL_BYTE_ARRAY_ToString([]byte(txs[0].Nonce), 38, 0))
+	require.Equal(t, "1000", types.DECIMAL_BYTE_ARRAY_ToString([]byte(*txs[0].FeeAmount), 38, 0))
+	require.Nil(t, txs[0].MaxGas)
+	require.Equal(t, "\xa1", *txs[0].Body)
+	require.Nil(t, txs[0].Module)
+
+	events := make([]Event, m.Files[5].Rows)
+	readFile(t, dir, m.Files[5], &events, new(Event))
+	require.Len(t, events, 1)
+	require.Equal(t, `{"amount":"1000"}`, *events[0].Body)
+
+	accounts := make([]AccountSnapshot, m.Files[6].Rows)
+	readFile(t, dir, m.Files[6], &accounts, new(AccountSnapshot))
+	require.Len(t, accounts, 1)
+	require.Equal(t, int64(1), accounts[0].Epoch)
+	require.Equal(t, int64(12), accounts[0].Height)
+	require.Equal(t, "9000000000000000000", types.DECIMAL_BYTE_ARRAY_ToString([]byte(accounts[0].GeneralBalance), 38, 0))
+
+	// Exports resume from the manifest, and snapshot state once per epoch.
+	indexBlock(t, target, 13)
+	require.Nil(t, e.Export(ctx))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, m.Files, 11)
+	require.Equal(t, int64(13), m.ExportedHeight())
+
+	batch = &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height) VALUES (2, 13)`)
+	require.Nil(t, target.SendBatch(ctx, batch))
+	require.Nil(t, e.Export(ctx))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, m.Files, 13)
+	epoch, _ = m.SnapshotEpoch()
+	require.Equal(t, int64(2), epoch)
+	require.Equal(t, int64(13), m.Files[12].FromHeight)
+	require.Equal(t, exportedTime, m.Files[12].ExportedTime)
+}
+
+// historyPaths returns the paths of the exported block files
+// of the provided manifest.
+func historyPaths(m *Manifest) []string {
+	var paths []string
+	for _, f := range m.Files {
+		if f.Table == "blocks" {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
+// TestExportBackfill tests that history indexed below exported
+// history, e.g. by backfilling, is exported.
+func TestExportBackfill(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+	target := newTarget(t, chain)
+	dir := t.TempDir()
+	e := NewExporter(target, chain, dir, progress, 2, log.NewDefaultLogger("exporter-test"))
+
+	for _, height := range []int64{20, 21, 22} {
+		indexBlock(t, target, height)
+	}
+	require.Nil(t, e.Export(ctx))
+
+	for _, height := range []int64{10, 11, 12, 18, 19} {
+		indexBlock(t, target, height)
+	}
+	require.Nil(t, e.Export(ctx))
+	m, err := LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Equal(t, []string{
+		"blocks/000000000020-000000000021.parquet",
+		"blocks/000000000022-000000000022.parquet",
+		"blocks/000000000010-000000000011.parquet",
+		"blocks/000000000012-000000000012.parquet",
+		"blocks/000000000018-000000000019.parquet",
+	}, historyPaths(m))
+	require.Equal(t, []analyzer.Range{{From: 10, To: 12}, {From: 18, To: 22}}, m.HistoryRanges())
+
+	// Exported history is not exported again.
+	require.Nil(t, e.Export(ctx))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, historyPaths(m), 5)
+}
+
+// TestExportRollback tests that files exported above a rollback height
+// are removed, and exported again once reindexed.
+func TestExportRollback(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+	target := newTarget(t, chain)
+	dir := t.TempDir()
+	e := NewExporter(target, chain, dir, progress, 2, log.NewDefaultLogger("exporter-test"))
+
+	for _, height := range []int64{10, 11, 12, 13} {
+		indexBlock(t, target, height)
+	}
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height) VALUES (1, 10)`)
+	require.Nil(t, target.SendBatch(ctx, batch))
+	require.Nil(t, e.Export(ctx))
+	m, err := LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, m.Files, 8)
+
+	// Rolling back to 10 removes the range 10-11, which includes
+	// reindexed heights, and the snapshot at 13.
+	require.Nil(t, e.Rollback(10))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Empty(t, m.Files)
+	require.NoFileExists(t, filepath.Join(dir, "blocks", "000000000012-000000000013.parquet"))
+	require.NoFileExists(t, filepath.Join(dir, "account_snapshots", "epoch-000000000001.parquet"))
+
+	batch = &storage.QueryBatch{}
+	batch.Queue(`DELETE FROM oasis_3.events WHERE txn_block > 10`)
+	batch.Queue(`DELETE FROM oasis_3.transactions WHERE block > 10`)
+	batch.Queue(`DELETE FROM oasis_3.blocks WHERE height > 10`)
+	batch.Queue(`DELETE FROM oasis_3.processed_blocks WHERE height > 10`)
+	require.Nil(t, target.SendBatch(ctx, batch))
+	indexBlock(t, target, 11)
+
+	require.Nil(t, e.Export(ctx))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Equal(t, []string{"blocks/000000000010-000000000011.parquet"}, historyPaths(m))
+	require.FileExists(t, filepath.Join(dir, "blocks", "000000000010-000000000011.parquet"))
+	require.Equal(t, int64(11), m.Files[len(m.Files)-1].ToHeight)
+
+	// Rolling back above all exported files is a no-op.
+	require.Nil(t, e.Rollback(11))
+	m, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, m.Files, 5)
+}
+
+// TestExportDefaultProgress tests that state is snapshotted at the
+// progress of analyzers other than shards if no progress is configured.
+func TestExportDefaultProgress(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+	target := newTarget(t, chain)
+	dir := t.TempDir()
+	e := NewExporter(target, chain, dir, "", 2, log.NewDefaultLogger("exporter-test"))
+
+	indexBlock(t, target, 10)
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height) VALUES (1, 10)`)
+	batch.Queue(`
+		INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+			VALUES (500, $1, CURRENT_TIMESTAMP);
+	`, progress+analyzer.ShardProgressInfix+"400_500")
+	require.Nil(t, target.SendBatch(ctx, batch))
+
+	require.Nil(t, e.Export(ctx))
+	m, err := LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Len(t, m.Files, 5)
+	require.NotNil(t, m.Files[3].Epoch)
+	require.Equal(t, int64(10), m.Files[3].ToHeight)
+}
+
+// TestUnexportedRanges tests splitting indexed ranges less exported
+// ranges into files.
+func TestUnexportedRanges(t *testing.T) {
+	indexed := []analyzer.Range{{From: 1, To: 10}, {From: 20, To: 25}}
+	require.Equal(t, []analyzer.Range{
+		{From: 1, To: 4}, {From: 5, To: 8}, {From: 9, To: 10}, {From: 20, To: 23}, {From: 24, To: 25},
+	}, unexportedRanges(indexed, nil, 4))
+	require.Equal(t, []analyzer.Range{
+		{From: 1, To: 2}, {From: 7, To: 10}, {From: 24, To: 25},
+	}, unexportedRanges(indexed, []analyzer.Range{{From: 3, To: 6}, {From: 15, To: 23}}, 4))
+	require.Empty(t, unexportedRanges(indexed, []analyzer.Range{{From: 0, To: 30}}, 4))
+}
+
+// TestValidateOptions tests that malformed options are rejected
+// when the analysis config is validated.
+func TestValidateOptions(t *testing.T) {
+	for _, tc := range []struct {
+		options map[string]interface{}
+		valid   bool
+	}{
+		{map[string]interface{}{dirOption: "/tmp/export"}, true},
+		{map[string]interface{}{dirOption: "/tmp/export", progressOption: progress, blocksPerFileOption: 100}, true},
+		{nil, false},
+		{map[string]interface{}{dirOption: ""}, false},
+		{map[string]interface{}{dirOption: "/tmp/export", progressOption: 1}, false},
+		{map[string]interface{}{dirOption: "/tmp/export", blocksPerFileOption: 0}, false},
+		{map[string]interface{}{dirOption: "/tmp/export", blocksPerFileOption: "many"}, false},
+	} {
+		cfg := config.AnalyzerConfig{
+			Name:     exporterName,
+			ChainID:  "oasis-3",
+			Interval: "5m",
+			Options:  tc.options,
+		}
+		err := cfg.Validate()
+		if tc.valid {
+			require.Nil(t, err, "options %v", tc.options)
+		} else {
+			require.NotNil(t, err, "options %v", tc.options)
+		}
+	}
+}
+
+// racingQuerier runs the provided function before querying delegations,
+// as if analysis progressed while snapshotting state.
+type racingQuerier struct {
+	storage.Querier
+	race func()
+}
+
+// Query implements storage.Querier.
+func (q racingQuerier) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	if strings.Contains(sql, ".delegations") {
+		q.race()
+	}
+	return q.Querier.Query(ctx, sql, args...)
+}
+
+// racingStorage is target storage without snapshot support, in which
+// analysis progresses while snapshotting state.
+type racingStorage struct {
+	storage.TargetStorage
+	race func()
+}
+
+// Query implements storage.TargetStorage.
+func (s *racingStorage) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	return racingQuerier{s.TargetStorage, s.race}.Query(ctx, sql, args...)
+}
+
+// racingSnapshotStorage is target storage with snapshot support, in
+// which analysis progresses while snapshotting state.
+type racingSnapshotStorage struct {
+	*sqlite.Client
+	race func()
+}
+
+// ReadSnapshot implements storage.SnapshotReader.
+func (s *racingSnapshotStorage) ReadSnapshot(ctx context.Context, read func(storage.Querier) error) error {
+	return s.Client.ReadSnapshot(ctx, func(q storage.Querier) error {
+		return read(racingQuerier{q, s.race})
+	})
+}
+
+// TestExportSnapshotRace tests that state is snapshotted consistently
+// while analysis progresses if target storage supports snapshots, and
+// that inconsistent snapshots are discarded along with their files
+// otherwise.
+func TestExportSnapshotRace(t *testing.T) {
+	ctx := context.Background()
+	chain := &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+
+	for _, snapshots := range []bool{true, false} {
+		client := newTarget(t, chain)
+		for _, height := range []int64{10, 11, 12, 13} {
+			indexBlock(t, client, height)
+		}
+		batch := &storage.QueryBatch{}
+		batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height) VALUES (1, 10)`)
+		batch.Queue(`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ('oasis1a', 'oasis1b', 500)`)
+		require.Nil(t, client.SendBatch(ctx, batch))
+
+		var once sync.Once
+		race := func() {
+			once.Do(func() {
+				indexBlock(t, client, 14)
+				batch := &storage.QueryBatch{}
+				batch.Queue(`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ('oasis1a', 'oasis1c', 1)`)
+				require.Nil(t, client.SendBatch(ctx, batch))
+			})
+		}
+		var target storage.TargetStorage = &racingStorage{client, race}
+		if snapshots {
+			target = &racingSnapshotStorage{client.(*sqlite.Client), race}
+		}
+
+		dir := t.TempDir()
+		e := NewExporter(target, chain, dir, progress, 10, log.NewDefaultLogger("exporter-test"))
+		require.Nil(t, e.exportSnapshot(ctx, &Manifest{Version: ManifestVersion, ChainID: "oasis-3", Files: []File{}}))
+		m, err := LoadManifest(dir, "oasis-3")
+		if !snapshots {
+			// The manifest is not saved, and no files are left behind.
+			require.Nil(t, err)
+			require.Empty(t, m.Files)
+			require.NoFileExists(t, filepath.Join(dir, "account_snapshots", "epoch-000000000001.parquet"))
+			require.NoFileExists(t, filepath.Join(dir, "delegation_snapshots", "epoch-000000000001.parquet"))
+			continue
+		}
+		require.Nil(t, err)
+		require.Len(t, m.Files, 2)
+		require.Equal(t, int64(13), m.Files[1].ToHeight)
+		require.Equal(t, int64(1), m.Files[1].Rows)
+	}
+}
+
+// TestLoadManifest tests that manifests of other chains are rejected.
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	m, err := LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	require.Equal(t, ManifestVersion, m.Version)
+	require.Nil(t, m.Save(dir))
+
+	_, err = LoadManifest(dir, "oasis-3")
+	require.Nil(t, err)
+	_, err = LoadManifest(dir, "oasis-4")
+	require.NotNil(t, err)
+}
@@ -0,0 +1,181 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+)
+
+const (
+	// ManifestName is the name of the manifest in the export directory.
+	ManifestName = "manifest.json"
+
+	// ManifestVersion is the version of the manifest format and of the
+	// schemas of exported tables. It is incremented whenever either
+	// changes incompatibly.
+	ManifestVersion = 1
+)
+
+// Manifest lists the files exported to an export directory, so that
+// downstream jobs can load them incrementally.
+type Manifest struct {
+	// Version is the version of the manifest format and table schemas.
+	Version int `json:"version"`
+
+	// ChainID is the chain ID of the exported chain.
+	ChainID string `json:"chain_id"`
+
+	// Files are the exported files, in the order in which they were exported.
+	Files []File `json:"files"`
+}
+
+// File is an exported Parquet file.
+type File struct {
+	// Table is the exported table, e.g. `blocks` or `account_snapshots`.
+	Table string `json:"table"`
+
+	// Path is the path of the file relative to the export directory.
+	Path string `json:"path"`
+
+	// FromHeight is the first height of the exported rows, inclusive.
+	FromHeight int64 `json:"from_height"`
+
+	// ToHeight is the last height of the exported rows, inclusive.
+	// Snapshots are taken at a single height.
+	ToHeight int64 `json:"to_height"`
+
+	// Epoch is the epoch of a snapshot, and is omitted for history.
+	Epoch *int64 `json:"epoch,omitempty"`
+
+	// Rows is the number of exported rows.
+	Rows int64 `json:"rows"`
+
+	// ExportedTime is the time at which the file was exported.
+	ExportedTime time.Time `json:"exported_time"`
+}
+
+// LoadManifest loads the manifest of the provided export directory.
+// A new manifest is returned if the directory has none.
+func LoadManifest(dir, chainID string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if errors.Is(err, os.ErrNotExist) {
+		return &Manifest{
+			Version: ManifestVersion,
+			ChainID: chainID,
+			Files:   []File{},
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("malformed manifest: %w", err)
+	}
+	if m.Version != ManifestVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if m.ChainID != chainID {
+		return nil, fmt.Errorf("manifest is of chain '%s', not '%s'", m.ChainID, chainID)
+	}
+	return &m, nil
+}
+
+// Save atomically saves the manifest to the provided export directory.
+func (m *Manifest) Save(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(filepath.Join(dir, ManifestName), func(f *os.File) error {
+		_, err := f.Write(b)
+		return err
+	})
+}
+
+// ExportedHeight returns the last height up to which history has been
+// exported, or zero if none has been.
+func (m *Manifest) ExportedHeight() int64 {
+	var height int64
+	for _, f := range m.Files {
+		if f.Epoch == nil && f.ToHeight > height {
+			height = f.ToHeight
+		}
+	}
+	return height
+}
+
+// HistoryRanges returns the height ranges of exported history, merged
+// and in ascending order.
+func (m *Manifest) HistoryRanges() []analyzer.Range {
+	var ranges []analyzer.Range
+	for _, f := range m.Files {
+		if f.Epoch == nil {
+			ranges = append(ranges, analyzer.Range{From: f.FromHeight, To: f.ToHeight})
+		}
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From < ranges[j].From
+	})
+
+	var merged []analyzer.Range
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r.From <= merged[n-1].To+1 {
+			if r.To > merged[n-1].To {
+				merged[n-1].To = r.To
+			}
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+// SnapshotEpoch returns the last epoch of which state has been
+// snapshotted, and whether any has been.
+func (m *Manifest) SnapshotEpoch() (int64, bool) {
+	var epoch int64
+	var ok bool
+	for _, f := range m.Files {
+		if f.Epoch != nil && (!ok || *f.Epoch > epoch) {
+			epoch = *f.Epoch
+			ok = true
+		}
+	}
+	return epoch, ok
+}
+
+// writeAtomic writes a file at the provided path using the provided
+// function, such that readers either see the whole file or none of it.
+func writeAtomic(path string, write func(*os.File) error) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
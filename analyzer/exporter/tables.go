@@ -0,0 +1,254 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xitongsys/parquet-go/types"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// Rows of exported tables. Their Parquet schemas are stable, and only
+// change along with ManifestVersion. Amounts are exported as decimals,
+// and JSON bodies as strings.
+
+// Block is an exported row of the blocks table.
+type Block struct {
+	Height    int64  `parquet:"name=height, type=INT64"`
+	BlockHash string `parquet:"name=block_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Time      int64  `parquet:"name=time, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Namespace string `parquet:"name=namespace, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Version   int64  `parquet:"name=version, type=INT64"`
+	Type      string `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	RootHash  string `parquet:"name=root_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+}
+
+// Transaction is an exported row of the transactions table.
+type Transaction struct {
+	Block     int64   `parquet:"name=block, type=INT64"`
+	TxnHash   string  `parquet:"name=txn_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TxnIndex  *int32  `parquet:"name=txn_index, type=INT32, repetitiontype=OPTIONAL"`
+	Nonce     string  `parquet:"name=nonce, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+	FeeAmount *string `parquet:"name=fee_amount, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38, repetitiontype=OPTIONAL"`
+	MaxGas    *string `parquet:"name=max_gas, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38, repetitiontype=OPTIONAL"`
+	Method    string  `parquet:"name=method, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Sender    string  `parquet:"name=sender, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Body      *string `parquet:"name=body, type=BYTE_ARRAY, repetitiontype=OPTIONAL"`
+	Module    *string `parquet:"name=module, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Code      *int64  `parquet:"name=code, type=INT64, repetitiontype=OPTIONAL"`
+	Message   *string `parquet:"name=message, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+// Event is an exported row of the events table.
+type Event struct {
+	TxnBlock int64   `parquet:"name=txn_block, type=INT64"`
+	TxnHash  string  `parquet:"name=txn_hash, type=BYTE_ARRAY, convertedtype=UTF8"`
+	TxnIndex *int32  `parquet:"name=txn_index, type=INT32, repetitiontype=OPTIONAL"`
+	Backend  string  `parquet:"name=backend, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Type     string  `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Body     *string `parquet:"name=body, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+}
+
+// AccountSnapshot is an exported row of a snapshot of the accounts table.
+type AccountSnapshot struct {
+	Epoch                      int64  `parquet:"name=epoch, type=INT64"`
+	Height                     int64  `parquet:"name=height, type=INT64"`
+	Address                    string `parquet:"name=address, type=BYTE_ARRAY, convertedtype=UTF8"`
+	GeneralBalance             string `parquet:"name=general_balance, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+	Nonce                      int64  `parquet:"name=nonce, type=INT64"`
+	EscrowBalanceActive        string `parquet:"name=escrow_balance_active, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+	EscrowTotalSharesActive    string `parquet:"name=escrow_total_shares_active, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+	EscrowBalanceDebonding     string `parquet:"name=escrow_balance_debonding, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+	EscrowTotalSharesDebonding string `parquet:"name=escrow_total_shares_debonding, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+}
+
+// DelegationSnapshot is an exported row of a snapshot of the delegations table.
+type DelegationSnapshot struct {
+	Epoch     int64  `parquet:"name=epoch, type=INT64"`
+	Height    int64  `parquet:"name=height, type=INT64"`
+	Delegatee string `parquet:"name=delegatee, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Delegator string `parquet:"name=delegator, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Shares    string `parquet:"name=shares, type=BYTE_ARRAY, convertedtype=DECIMAL, scale=0, precision=38"`
+}
+
+// table is an exported table.
+type table struct {
+	// name is the name of the exported table.
+	name string
+
+	// row is a row of the table, which determines its Parquet schema.
+	row interface{}
+
+	// query is the query of the table's rows, formatted with the schema.
+	// History is queried in the height range from $1 to $2, inclusive.
+	query string
+
+	// scan scans a row of the table from the query results. Snapshot
+	// rows are scanned with their epoch and height.
+	scan func(rows storage.QueryResults, epoch, height int64) (interface{}, error)
+}
+
+// historyTables are the exported tables of indexed history.
+var historyTables = []table{
+	{
+		name: "blocks",
+		row:  new(Block),
+		query: `
+			SELECT height, block_hash, time, namespace, version, type, root_hash
+				FROM %s.blocks
+				WHERE height >= $1 AND height <= $2
+				ORDER BY height`,
+		scan: func(rows storage.QueryResults, _, _ int64) (interface{}, error) {
+			var b Block
+			var t time.Time
+			if err := rows.Scan(&b.Height, &b.BlockHash, &t, &b.Namespace, &b.Version, &b.Type, &b.RootHash); err != nil {
+				return nil, err
+			}
+			b.Time = types.TimeToTIMESTAMP_MILLIS(t, true)
+			return b, nil
+		},
+	},
+	{
+		name: "transactions",
+		row:  new(Transaction),
+		query: `
+			SELECT block, txn_hash, txn_index, nonce::TEXT, fee_amount::TEXT, max_gas::TEXT,
+					method, sender, body, module, code, message
+				FROM %s.transactions
+				WHERE block >= $1 AND block <= $2
+				ORDER BY block, txn_index`,
+		scan: func(rows storage.QueryResults, _, _ int64) (interface{}, error) {
+			var t Transaction
+			var body []byte
+			if err := rows.Scan(
+				&t.Block,
+				&t.TxnHash,
+				&t.TxnIndex,
+				&t.Nonce,
+				&t.FeeAmount,
+				&t.MaxGas,
+				&t.Method,
+				&t.Sender,
+				&body,
+				&t.Module,
+				&t.Code,
+				&t.Message,
+			); err != nil {
+				return nil, err
+			}
+			t.Nonce = decimal(t.Nonce)
+			t.FeeAmount = nullDecimal(t.FeeAmount)
+			t.MaxGas = nullDecimal(t.MaxGas)
+			if body != nil {
+				s := string(body)
+				t.Body = &s
+			}
+			return t, nil
+		},
+	},
+	{
+		name: "events",
+		row:  new(Event),
+		query: `
+			SELECT txn_block, txn_hash, txn_index, backend, type, body::TEXT
+				FROM %s.events
+				WHERE txn_block >= $1 AND txn_block <= $2
+				ORDER BY txn_block, txn_index`,
+		scan: func(rows storage.QueryResults, _, _ int64) (interface{}, error) {
+			var e Event
+			if err := rows.Scan(&e.TxnBlock, &e.TxnHash, &e.TxnIndex, &e.Backend, &e.Type, &e.Body); err != nil {
+				return nil, err
+			}
+			return e, nil
+		},
+	},
+}
+
+// snapshotTables are the exported snapshots of indexed state.
+var snapshotTables = []table{
+	{
+		name: "account_snapshots",
+		row:  new(AccountSnapshot),
+		query: `
+			SELECT address,
+					COALESCE(general_balance, 0)::TEXT,
+					COALESCE(nonce, 0),
+					COALESCE(escrow_balance_active, 0)::TEXT,
+					COALESCE(escrow_total_shares_active, 0)::TEXT,
+					COALESCE(escrow_balance_debonding, 0)::TEXT,
+					COALESCE(escrow_total_shares_debonding, 0)::TEXT
+				FROM %s.accounts
+				ORDER BY address`,
+		scan: func(rows storage.QueryResults, epoch, height int64) (interface{}, error) {
+			a := AccountSnapshot{
+				Epoch:  epoch,
+				Height: height,
+			}
+			if err := rows.Scan(
+				&a.Address,
+				&a.GeneralBalance,
+				&a.Nonce,
+				&a.EscrowBalanceActive,
+				&a.EscrowTotalSharesActive,
+				&a.EscrowBalanceDebonding,
+				&a.EscrowTotalSharesDebonding,
+			); err != nil {
+				return nil, err
+			}
+			a.GeneralBalance = decimal(a.GeneralBalance)
+			a.EscrowBalanceActive = decimal(a.EscrowBalanceActive)
+			a.EscrowTotalSharesActive = decimal(a.EscrowTotalSharesActive)
+			a.EscrowBalanceDebonding = decimal(a.EscrowBalanceDebonding)
+			a.EscrowTotalSharesDebonding = decimal(a.EscrowTotalSharesDebonding)
+			return a, nil
+		},
+	},
+	{
+		name: "delegation_snapshots",
+		row:  new(DelegationSnapshot),
+		query: `
+			SELECT delegatee, delegator, shares::TEXT
+				FROM %s.delegations
+				ORDER BY delegatee, delegator`,
+		scan: func(rows storage.QueryResults, epoch, height int64) (interface{}, error) {
+			d := DelegationSnapshot{
+				Epoch:  epoch,
+				Height: height,
+			}
+			if err := rows.Scan(&d.Delegatee, &d.Delegator, &d.Shares); err != nil {
+				return nil, err
+			}
+			d.Shares = decimal(d.Shares)
+			return d, nil
+		},
+	},
+}
+
+// historyPath returns the path of the file of the provided table's
+// history in the provided height range, relative to the export directory.
+// Heights are padded so that files sort by height.
+func historyPath(table string, from, to int64) string {
+	return fmt.Sprintf("%s/%012d-%012d.parquet", table, from, to)
+}
+
+// snapshotPath returns the path of the file of the provided table's
+// snapshot at the provided epoch, relative to the export directory.
+func snapshotPath(table string, epoch int64) string {
+	return fmt.Sprintf("%s/epoch-%012d.parquet", table, epoch)
+}
+
+// decimal returns the Parquet decimal encoding of the provided integer.
+func decimal(s string) string {
+	return types.StrIntToBinary(s, "BigEndian", 0, true)
+}
+
+// nullDecimal returns the Parquet decimal encoding of the provided
+// nullable integer.
+func nullDecimal(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	d := decimal(*s)
+	return &d
+}
@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// Factory creates a new analyzer instance with the provided name.
+type Factory func(name string, target storage.TargetStorage, logger *log.Logger) (Analyzer, error)
+
+// factories are the factories of registered analyzer types.
+var factories = make(map[string]Factory)
+
+// Register registers an analyzer type with the provided config schema and
+// factory. It is not safe for concurrent use and is intended to be called
+// from the init function of the package implementing the analyzer.
+func Register(analyzerType string, schema config.AnalyzerSchema, factory Factory) {
+	if _, ok := factories[analyzerType]; ok {
+		panic(fmt.Sprintf("analyzer: type '%s' registered twice", analyzerType))
+	}
+	config.RegisterAnalyzerSchema(analyzerType, schema)
+	factories[analyzerType] = factory
+}
+
+// New creates a new analyzer instance of the provided type.
+func New(analyzerType, name string, target storage.TargetStorage, logger *log.Logger) (Analyzer, error) {
+	factory, ok := factories[analyzerType]
+	if !ok {
+		return nil, fmt.Errorf("analyzer: unknown type '%s'", analyzerType)
+	}
+	return factory(name, target, logger)
+}
+
+// OptionsConfig returns the analyzer config with the block range and
+// options of the provided config, for validating options before the
+// analyzer is created. The block range is not yet bounded by the chain.
+func OptionsConfig(cfg *config.AnalyzerConfig) *Config {
+	return &Config{
+		BlockRange: Range{
+			From: cfg.From,
+			To:   cfg.To,
+		},
+		Options: cfg.Options,
+	}
+}
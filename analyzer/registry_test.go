@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const testAnalyzerType = "test_registry"
+
+type testAnalyzer struct {
+	name string
+}
+
+func (a *testAnalyzer) SetConfig(Config) {}
+
+func (a *testAnalyzer) Start() {}
+
+func (a *testAnalyzer) Name() string {
+	return a.name
+}
+
+func init() {
+	Register(
+		testAnalyzerType,
+		config.AnalyzerSchema{
+			RequiresInterval: true,
+			Options:          []string{"depth"},
+		},
+		func(name string, _ storage.TargetStorage, _ *log.Logger) (Analyzer, error) {
+			return &testAnalyzer{name}, nil
+		},
+	)
+}
+
+// TestRegistryNew tests that multiple instances of a registered
+// type can be created.
+func TestRegistryNew(t *testing.T) {
+	logger := log.NewDefaultLogger("test")
+
+	a, err := New(testAnalyzerType, "test_a", nil, logger)
+	require.Nil(t, err)
+	require.Equal(t, "test_a", a.Name())
+
+	b, err := New(testAnalyzerType, "test_b", nil, logger)
+	require.Nil(t, err)
+	require.Equal(t, "test_b", b.Name())
+
+	_, err = New("unknown", "test_c", nil, logger)
+	require.NotNil(t, err)
+}
+
+// TestRegistryValidate tests that analyzer configs are validated
+// against the schema of their type.
+func TestRegistryValidate(t *testing.T) {
+	for _, tc := range []struct {
+		cfg   config.AnalyzerConfig
+		valid bool
+	}{
+		{config.AnalyzerConfig{Name: "test_a", Type: testAnalyzerType, ChainID: "oasis-3", Interval: "1m"}, true},
+		{config.AnalyzerConfig{Name: testAnalyzerType, ChainID: "oasis-3", Interval: "1m"}, true},
+		{config.AnalyzerConfig{Name: "test_a", Type: testAnalyzerType, ChainID: "oasis-3", Interval: "1m", Options: map[string]interface{}{"depth": 1}}, true},
+		{config.AnalyzerConfig{Name: "test_a", Type: testAnalyzerType, ChainID: "oasis-3", Interval: "1m", Options: map[string]interface{}{"width": 1}}, false},
+		{config.AnalyzerConfig{Name: "test_a", Type: testAnalyzerType, ChainID: "oasis-3"}, false},
+		{config.AnalyzerConfig{Name: "test_a", Type: "unknown", ChainID: "oasis-3", Interval: "1m"}, false},
+		{config.AnalyzerConfig{Name: "unknown", ChainID: "oasis-3", Interval: "1m"}, false},
+		{config.AnalyzerConfig{Name: "Test-A", Type: testAnalyzerType, ChainID: "oasis-3", Interval: "1m"}, false},
+	} {
+		err := tc.cfg.Validate()
+		if tc.valid {
+			require.Nil(t, err, "config %+v", tc.cfg)
+		} else {
+			require.NotNil(t, err, "config %+v", tc.cfg)
+		}
+	}
+}
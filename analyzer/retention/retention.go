@@ -0,0 +1,315 @@
+// Package retention implements pruning of indexed history
+// according to retention rules.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	moduleName = "retention"
+
+	// LeaseName is the name of the lease coordinating pruning
+	// across indexer instances.
+	LeaseName = "retention_pruner"
+)
+
+// table is a table of indexed history which may be pruned.
+type table struct {
+	name   string
+	column string
+}
+
+// tables are the tables of indexed history, such that each table
+// only references tables preceding it.
+var tables = []table{
+	{name: "blocks", column: "height"},
+	{name: "transactions", column: "block"},
+	{name: "events", column: "txn_block"},
+}
+
+// Rule is the retention rule of a table. Exactly one of KeepBlocks
+// and KeepEpochs is set.
+type Rule struct {
+	// Table is the pruned table.
+	Table string
+
+	// KeepBlocks is the number of most recent blocks for which rows are kept.
+	KeepBlocks int64
+
+	// KeepEpochs is the number of most recent epochs for which rows are kept.
+	KeepEpochs int64
+}
+
+// Policy is the policy for pruning indexed history.
+type Policy struct {
+	// Interval is the interval at which history is pruned.
+	Interval time.Duration
+
+	// Rules are the retention rules of pruned tables.
+	Rules []Rule
+
+	// CompactProcessedBlocks is true iff processed_blocks bookkeeping
+	// is compacted to ranges of processed heights.
+	CompactProcessedBlocks bool
+}
+
+// Pruner prunes the indexed history of a chain according to a policy.
+type Pruner struct {
+	chain  *analyzer.Chain
+	policy Policy
+
+	// lease coordinates pruning across indexer instances. If this is not
+	// set, the pruner runs uncoordinated.
+	lease *coordination.Lease
+
+	target  storage.TargetStorage
+	logger  *log.Logger
+	metrics metrics.DatabaseMetrics
+}
+
+// NewPruner creates a new pruner of the provided chain's indexed history.
+func NewPruner(chain *analyzer.Chain, policy Policy, lease *coordination.Lease, target storage.TargetStorage, logger *log.Logger) *Pruner {
+	return &Pruner{
+		chain:   chain,
+		policy:  policy,
+		lease:   lease,
+		target:  target,
+		logger:  logger.WithModule(moduleName).With("chain_id", chain.ID),
+		metrics: metrics.NewDefaultDatabaseMetrics(fmt.Sprintf("%s_%s", moduleName, chain.Schema)),
+	}
+}
+
+// Start prunes history once per interval until the context is done.
+func (p *Pruner) Start(ctx context.Context) {
+	ticker := time.NewTicker(p.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Prune(ctx); err != nil {
+			p.logger.Error("pruning failed",
+				"err", err.Error(),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Prune prunes history below the horizons of the retention rules, and
+// compacts processed_blocks bookkeeping if configured, in a single
+// transaction. It is a no-op if another instance holds the pruning lease.
+func (p *Pruner) Prune(ctx context.Context) error {
+	if p.lease != nil {
+		switch err := p.lease.Acquire(ctx); err {
+		case nil:
+			defer func() {
+				if err := p.lease.Release(ctx); err != nil {
+					p.logger.Error("failed to release lease",
+						"err", err.Error(),
+					)
+				}
+			}()
+		case coordination.ErrLeaseHeld:
+			p.logger.Debug("pruning lease held by another instance",
+				"holder", p.lease.Holder(),
+			)
+			return nil
+		default:
+			return err
+		}
+	}
+
+	horizons := make(map[string]int64, len(p.policy.Rules))
+	for _, rule := range p.policy.Rules {
+		horizon, err := p.horizon(ctx, rule)
+		if err != nil {
+			return err
+		}
+		if horizon > 0 {
+			horizons[rule.Table] = horizon
+		}
+	}
+	horizons = effectiveHorizons(horizons)
+
+	batch := &storage.QueryBatch{}
+	p.queuePrune(batch, horizons)
+	if p.policy.CompactProcessedBlocks {
+		p.queueCompaction(batch)
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	opName := "prune"
+	timer := p.metrics.DatabaseTimer(p.target.Name(), opName)
+	defer timer.ObserveDuration()
+
+	if err := p.target.SendBatch(ctx, batch); err != nil {
+		p.metrics.DatabaseCounter(p.target.Name(), opName, "failure").Inc()
+		return err
+	}
+	p.metrics.DatabaseCounter(p.target.Name(), opName, "success").Inc()
+
+	for t, horizon := range horizons {
+		p.logger.Info("pruned history",
+			"table", t,
+			"horizon", horizon,
+		)
+	}
+	return nil
+}
+
+// horizon returns the lowest height retained by the provided rule,
+// or zero if nothing is to be pruned.
+func (p *Pruner) horizon(ctx context.Context, rule Rule) (int64, error) {
+	if rule.KeepEpochs > 0 {
+		var start int64
+		switch err := p.target.QueryRow(ctx, fmt.Sprintf(`
+			SELECT start_height FROM %[1]s.epochs
+				WHERE id = (SELECT MAX(id) FROM %[1]s.epochs) - $1 + 1
+		`, p.chain.Schema),
+			rule.KeepEpochs,
+		).Scan(&start); err {
+		case nil:
+			return start, nil
+		case storage.ErrNoRows:
+			// Fewer epochs than retained have been indexed.
+			return 0, nil
+		default:
+			return 0, err
+		}
+	}
+
+	latest, err := storage.QueryInt64(ctx, p.target, fmt.Sprintf(`
+		SELECT COALESCE(MAX(height), 0) FROM %s.blocks
+	`, p.chain.Schema))
+	if err != nil {
+		return 0, err
+	}
+	if horizon := latest - rule.KeepBlocks + 1; horizon > 0 {
+		return horizon, nil
+	}
+	return 0, nil
+}
+
+// effectiveHorizons returns the horizons at which tables are pruned given
+// the horizons of their rules, such that rows referencing pruned rows of
+// another table are pruned as well.
+func effectiveHorizons(horizons map[string]int64) map[string]int64 {
+	effective := make(map[string]int64, len(tables))
+	var referenced int64
+	for _, t := range tables {
+		horizon := horizons[t.name]
+		if referenced > horizon {
+			horizon = referenced
+		}
+		if horizon > 0 {
+			effective[t.name] = horizon
+		}
+		referenced = horizon
+	}
+	return effective
+}
+
+// queuePrune adds queries pruning the provided tables below their
+// horizons to the batch, along with recording the horizons.
+func (p *Pruner) queuePrune(batch *storage.QueryBatch, horizons map[string]int64) {
+	// Referencing tables are pruned first.
+	for i := len(tables) - 1; i >= 0; i-- {
+		t := tables[i]
+		horizon, ok := horizons[t.name]
+		if !ok {
+			continue
+		}
+
+		// Whole partitions are dropped, which is cheaper than deleting
+		// their rows and leaves nothing to vacuum.
+		if storage.PartitionsByHeight(p.target) {
+			batch.Queue(fmt.Sprintf(`
+				SELECT %s.drop_partitions_below($1, $2);
+			`, p.chain.Schema),
+				t.name,
+				horizon,
+			)
+		}
+		batch.Queue(fmt.Sprintf(`
+			DELETE FROM %s.%s WHERE %s < $1;
+		`, p.chain.Schema, t.name, t.column),
+			horizon,
+		)
+		batch.Queue(fmt.Sprintf(`
+			INSERT INTO %s.retention_horizons (table_name, height, pruned_time)
+				VALUES ($1, $2, CURRENT_TIMESTAMP)
+			ON CONFLICT (table_name) DO
+				UPDATE SET
+					height = GREATEST(retention_horizons.height, excluded.height),
+					pruned_time = excluded.pruned_time;
+		`, p.chain.Schema),
+			t.name,
+			horizon,
+		)
+	}
+}
+
+// queueCompaction adds queries compacting processed_blocks bookkeeping
+// to ranges of processed heights to the batch. The latest processed
+// block of each analyzer is kept.
+func (p *Pruner) queueCompaction(batch *storage.QueryBatch) {
+	batch.Queue(fmt.Sprintf(`
+		WITH compacted AS (
+			DELETE FROM %[1]s.processed_blocks p
+				USING (
+					SELECT analyzer, MAX(height) AS latest
+						FROM %[1]s.processed_blocks
+						GROUP BY analyzer
+				) l
+				WHERE p.analyzer = l.analyzer AND p.height < l.latest
+				RETURNING p.analyzer, p.height
+		)
+		INSERT INTO %[1]s.processed_block_ranges (analyzer, from_height, to_height)
+			SELECT analyzer, MIN(height), MAX(height)
+				FROM (
+					SELECT analyzer, height,
+							height - ROW_NUMBER() OVER (PARTITION BY analyzer ORDER BY height) AS run
+						FROM compacted
+				) c
+				GROUP BY analyzer, run;
+	`, p.chain.Schema))
+
+	// Merge adjacent and overlapping ranges.
+	batch.Queue(fmt.Sprintf(`
+		WITH ranges AS (
+			DELETE FROM %[1]s.processed_block_ranges
+				RETURNING analyzer, from_height, to_height
+		), islands AS (
+			SELECT analyzer, from_height, to_height,
+					SUM(CASE WHEN from_height <= prev_to + 1 THEN 0 ELSE 1 END)
+						OVER (PARTITION BY analyzer ORDER BY from_height) AS island
+				FROM (
+					SELECT analyzer, from_height, to_height,
+							MAX(to_height) OVER (
+								PARTITION BY analyzer ORDER BY from_height
+								ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+							) AS prev_to
+						FROM ranges
+				) r
+		)
+		INSERT INTO %[1]s.processed_block_ranges (analyzer, from_height, to_height)
+			SELECT analyzer, MIN(from_height), MAX(to_height)
+				FROM islands
+				GROUP BY analyzer, island;
+	`, p.chain.Schema))
+}
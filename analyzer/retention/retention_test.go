@@ -0,0 +1,106 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+// TestEffectiveHorizons tests that tables referencing pruned rows
+// are pruned at least as far.
+func TestEffectiveHorizons(t *testing.T) {
+	require.Empty(t, effectiveHorizons(map[string]int64{}))
+
+	require.Equal(t, map[string]int64{
+		"events": 100,
+	}, effectiveHorizons(map[string]int64{"events": 100}))
+
+	require.Equal(t, map[string]int64{
+		"blocks":       100,
+		"transactions": 100,
+		"events":       200,
+	}, effectiveHorizons(map[string]int64{"blocks": 100, "events": 200}))
+
+	require.Equal(t, map[string]int64{
+		"blocks":       300,
+		"transactions": 300,
+		"events":       300,
+	}, effectiveHorizons(map[string]int64{"blocks": 300, "transactions": 100}))
+}
+
+// TestPrune tests that rows below rule horizons are pruned in dependency
+// order, and that horizons are recorded.
+func TestPrune(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	target.AddRows("MAX(height)", []interface{}{int64(1000)})
+	target.AddRows("FROM oasis_3.epochs", []interface{}{int64(950)})
+
+	chain := &analyzer.Chain{ID: "oasis-3", Schema: "oasis_3"}
+	p := NewPruner(chain, Policy{
+		Interval: time.Hour,
+		Rules: []Rule{
+			{Table: "transactions", KeepBlocks: 100},
+			{Table: "events", KeepEpochs: 2},
+		},
+		CompactProcessedBlocks: true,
+	}, nil, target, log.NewDefaultLogger("retention-test"))
+	require.Nil(t, p.Prune(ctx))
+
+	items := target.Items()
+	// Events and transactions are each dropped, deleted and recorded,
+	// and processed_blocks is compacted and merged.
+	require.Len(t, items, 8)
+	require.Equal(t, []interface{}{"events", int64(950)}, items[0].Args)
+	require.Contains(t, items[1].SQL, "DELETE FROM oasis_3.events WHERE txn_block < $1")
+	require.Equal(t, []interface{}{int64(950)}, items[1].Args)
+	require.Contains(t, items[2].SQL, "oasis_3.retention_horizons")
+	require.Equal(t, []interface{}{"events", int64(950)}, items[2].Args)
+	require.Equal(t, []interface{}{"transactions", int64(901)}, items[3].Args)
+	require.Contains(t, items[4].SQL, "DELETE FROM oasis_3.transactions WHERE block < $1")
+	require.Contains(t, items[6].SQL, "oasis_3.processed_block_ranges")
+	require.Contains(t, items[7].SQL, "oasis_3.processed_block_ranges")
+}
+
+// TestPruneUnpartitioned tests that rows are only deleted in target
+// storage which does not partition by height.
+func TestPruneUnpartitioned(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	target.SetPartitionsByHeight(false)
+	target.AddRows("MAX(height)", []interface{}{int64(1000)})
+
+	chain := &analyzer.Chain{ID: "oasis-3", Schema: "oasis_3_unpartitioned"}
+	p := NewPruner(chain, Policy{
+		Interval: time.Hour,
+		Rules:    []Rule{{Table: "events", KeepBlocks: 100}},
+	}, nil, target, log.NewDefaultLogger("retention-test"))
+	require.Nil(t, p.Prune(ctx))
+
+	items := target.Items()
+	require.Len(t, items, 2)
+	require.Contains(t, items[0].SQL, "DELETE FROM oasis_3_unpartitioned.events WHERE txn_block < $1")
+	require.Contains(t, items[1].SQL, "oasis_3_unpartitioned.retention_horizons")
+}
+
+// TestPruneNothing tests that nothing is pruned while fewer blocks
+// than retained have been indexed.
+func TestPruneNothing(t *testing.T) {
+	ctx := context.Background()
+	target := storagetest.NewStorage()
+	target.AddRows("MAX(height)", []interface{}{int64(50)})
+
+	chain := &analyzer.Chain{ID: "oasis-3", Schema: "oasis_3_nothing"}
+	p := NewPruner(chain, Policy{
+		Interval: time.Hour,
+		Rules:    []Rule{{Table: "blocks", KeepBlocks: 100}},
+	}, nil, target, log.NewDefaultLogger("retention-test"))
+	require.Nil(t, p.Prune(ctx))
+	require.Empty(t, target.Batches())
+}
@@ -0,0 +1,132 @@
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	verifierName = "verifier"
+
+	// progressOption is the option setting the analyzer whose
+	// progress determines the height at which to verify state.
+	progressOption = "progress"
+
+	defaultProgress = "consensus_main_damask"
+)
+
+func init() {
+	analyzer.Register(
+		verifierName,
+		config.AnalyzerSchema{
+			RequiresRPC:      true,
+			RequiresInterval: true,
+			Options: []string{
+				progressOption,
+			},
+		},
+		func(name string, target storage.TargetStorage, logger *log.Logger) (analyzer.Analyzer, error) {
+			return NewAnalyzer(name, target, logger), nil
+		},
+	)
+}
+
+// Analyzer periodically verifies indexed state against node state.
+type Analyzer struct {
+	name string
+	cfg  analyzer.Config
+
+	target  storage.TargetStorage
+	logger  *log.Logger
+	metrics metrics.VerificationMetrics
+}
+
+// NewAnalyzer returns a new verifying analyzer with the provided name.
+func NewAnalyzer(name string, target storage.TargetStorage, logger *log.Logger) *Analyzer {
+	return &Analyzer{
+		name:    name,
+		target:  target,
+		logger:  logger.With("analyzer", name),
+		metrics: metrics.NewDefaultVerificationMetrics(name),
+	}
+}
+
+// SetConfig sets the configuration of this analyzer.
+// It is intended to be called before Start.
+func (a *Analyzer) SetConfig(cfg analyzer.Config) {
+	a.cfg = cfg
+}
+
+// Start starts the verifying analyzer.
+func (a *Analyzer) Start() {
+	ctx := context.Background()
+
+	progress := defaultProgress
+	if v, ok := a.cfg.Options[progressOption]; ok {
+		progress = fmt.Sprint(v)
+	}
+	v := NewVerifier(a.cfg.Source, a.target, a.cfg.Chain.Schema, progress, a.logger)
+
+	for {
+		report, err := v.Verify(ctx, 0)
+		if err != nil {
+			a.logger.Error("verification failed to run",
+				"err", err.Error(),
+			)
+		} else {
+			Record(a.metrics, report)
+			Log(a.logger, report)
+		}
+		time.Sleep(a.cfg.Interval)
+	}
+}
+
+// Name returns the name of the analyzer.
+func (a *Analyzer) Name() string {
+	return a.name
+}
+
+// Record records the provided verification report in metrics.
+func Record(m metrics.VerificationMetrics, report *Report) {
+	if report.Passed() {
+		m.Passed.Set(1)
+	} else {
+		m.Passed.Set(0)
+	}
+	m.Height.Set(float64(report.Height))
+
+	m.Mismatches.Reset()
+	for kind, count := range report.Counts() {
+		m.Mismatches.WithLabelValues(kind).Set(float64(count))
+	}
+}
+
+// Log logs the provided verification report.
+func Log(logger *log.Logger, report *Report) {
+	if report.Passed() {
+		logger.Info("verification passed",
+			"height", report.Height,
+		)
+		return
+	}
+	for _, m := range report.Mismatches {
+		logger.Error("verification mismatch",
+			"height", report.Height,
+			"kind", m.Kind,
+			"key", m.Key,
+			"expected", m.Expected,
+			"actual", m.Actual,
+		)
+	}
+	logger.Error("verification failed",
+		"height", report.Height,
+		"mismatches", len(report.Mismatches),
+	)
+}
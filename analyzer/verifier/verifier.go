@@ -0,0 +1,817 @@
+// Package verifier implements verification of indexed state
+// against the state of an Oasis node.
+package verifier
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/oasisprotocol/oasis-core/go/common/entity"
+	"github.com/oasisprotocol/oasis-core/go/common/node"
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+	registry "github.com/oasisprotocol/oasis-core/go/registry/api"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// Kinds of verified records.
+const (
+	KindEntity              = "entity"
+	KindNode                = "node"
+	KindRuntime             = "runtime"
+	KindAccount             = "account"
+	KindDelegation          = "delegation"
+	KindDebondingDelegation = "debonding_delegation"
+	KindProposal            = "proposal"
+	KindVote                = "vote"
+)
+
+// checkpointTables are the tables checkpointed for verification.
+var checkpointTables = []string{
+	// Registry backend.
+	"entities",
+	"claimed_nodes",
+	"nodes",
+	"runtimes",
+	// Staking backend.
+	"accounts",
+	"allowances",
+	"delegations",
+	"debonding_delegations",
+	// Governance backend.
+	"proposals",
+	"votes",
+}
+
+// Mismatch is a difference between indexed and node state.
+// Expected is nil for unexpected records, and Actual is nil
+// for missing records.
+type Mismatch struct {
+	Kind     string      `json:"kind"`
+	Key      string      `json:"key"`
+	Expected interface{} `json:"expected,omitempty"`
+	Actual   interface{} `json:"actual,omitempty"`
+}
+
+// Report is the result of verifying indexed state at a height.
+type Report struct {
+	Height     int64      `json:"height"`
+	Mismatches []Mismatch `json:"mismatches"`
+}
+
+// Passed returns true iff indexed state matches node state.
+func (r *Report) Passed() bool {
+	return len(r.Mismatches) == 0
+}
+
+// Counts returns the number of mismatches of each kind.
+func (r *Report) Counts() map[string]int {
+	counts := make(map[string]int)
+	for _, m := range r.Mismatches {
+		counts[m.Kind]++
+	}
+	return counts
+}
+
+// Verifier verifies indexed state against node state.
+type Verifier struct {
+	source   storage.SourceStorage
+	target   storage.TargetStorage
+	schema   string
+	progress string
+	logger   *log.Logger
+}
+
+// NewVerifier creates a new verifier for the state indexed in the
+// provided schema, as of the progress recorded under the provided
+// analyzer name.
+func NewVerifier(source storage.SourceStorage, target storage.TargetStorage, schema string, progress string, logger *log.Logger) *Verifier {
+	return &Verifier{
+		source:   source,
+		target:   target,
+		schema:   schema,
+		progress: progress,
+		logger:   logger,
+	}
+}
+
+// Verify checkpoints indexed state and compares it with node state at the
+// checkpointed height. If height is nonzero, indexed state must be at that
+// height, e.g. because analysis was stopped there.
+func (v *Verifier) Verify(ctx context.Context, height int64) (*Report, error) {
+	checkpointHeight, err := v.checkpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if height != 0 && height != checkpointHeight {
+		return nil, fmt.Errorf("indexed state is at height %d, not %d", checkpointHeight, height)
+	}
+
+	v.logger.Info("verifying indexed state",
+		"height", checkpointHeight,
+	)
+	document, err := v.source.GenesisDocumentAtHeight(ctx, checkpointHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	report := Report{
+		Height:     checkpointHeight,
+		Mismatches: []Mismatch{},
+	}
+	for _, f := range []func(context.Context, *genesis.Document) ([]Mismatch, error){
+		v.verifyEntities,
+		v.verifyNodes,
+		v.verifyRuntimes,
+		v.verifyAccounts,
+		v.verifyDelegations,
+		v.verifyDebondingDelegations,
+		v.verifyProposals,
+		v.verifyVotes,
+	} {
+		mismatches, err := f(ctx, document)
+		if err != nil {
+			return nil, err
+		}
+		report.Mismatches = append(report.Mismatches, mismatches...)
+	}
+
+	return &report, nil
+}
+
+// checkpoint copies indexed state into checkpoint tables, so that it can be
+// compared while analysis continues, and returns the checkpointed height.
+func (v *Verifier) checkpoint(ctx context.Context) (int64, error) {
+	batch := &storage.QueryBatch{}
+
+	// All tables must be copied from the same snapshot.
+	batch.Queue(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ;`)
+	for _, t := range checkpointTables {
+		batch.Queue(fmt.Sprintf(`
+			DROP TABLE IF EXISTS %s.%s_checkpoint CASCADE;
+		`, v.schema, t))
+		batch.Queue(fmt.Sprintf(`
+			CREATE TABLE %s.%s_checkpoint AS TABLE %s.%s;
+		`, v.schema, t, v.schema, t))
+	}
+	batch.Queue(fmt.Sprintf(`
+		INSERT INTO %s.checkpointed_heights (height)
+			SELECT height FROM %s.processed_blocks
+				WHERE analyzer = $1
+				ORDER BY height DESC LIMIT 1
+		ON CONFLICT (height) DO UPDATE SET checkpoint_time = CURRENT_TIMESTAMP;
+	`, v.schema, v.schema),
+		v.progress,
+	)
+
+	if err := v.target.SendBatch(ctx, batch); err != nil {
+		return 0, err
+	}
+
+	var height int64
+	if err := v.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT height FROM %s.checkpointed_heights
+			ORDER BY checkpoint_time DESC LIMIT 1
+	`, v.schema)).Scan(&height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// diff returns mismatches between expected and actual records of the
+// provided kind. Records are keyed, and missing records are compared
+// against the provided zero value if it is not nil.
+func diff(kind string, expected, actual map[string]interface{}, zero func(key string) interface{}) []Mismatch {
+	keys := make(map[string]bool, len(expected))
+	for k := range expected {
+		keys[k] = true
+	}
+	for k := range actual {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var mismatches []Mismatch
+	for _, k := range sorted {
+		e, eok := expected[k]
+		a, aok := actual[k]
+		if zero != nil {
+			if !eok {
+				e, eok = zero(k), true
+			}
+			if !aok {
+				a, aok = zero(k), true
+			}
+		}
+		if eok && aok && reflect.DeepEqual(e, a) {
+			continue
+		}
+		m := Mismatch{
+			Kind: kind,
+			Key:  k,
+		}
+		if eok {
+			m.Expected = e
+		}
+		if aok {
+			m.Actual = a
+		}
+		mismatches = append(mismatches, m)
+	}
+	return mismatches
+}
+
+// Entity is a verified entity.
+type Entity struct {
+	ID    string   `json:"id"`
+	Nodes []string `json:"nodes"`
+}
+
+func (v *Verifier) verifyEntities(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for _, se := range document.Registry.Entities {
+		if se == nil {
+			continue
+		}
+		var e entity.Entity
+		if err := se.Open(registry.RegisterEntitySignatureContext, &e); err != nil {
+			return nil, err
+		}
+
+		te := Entity{
+			ID:    e.ID.String(),
+			Nodes: make([]string, 0, len(e.Nodes)),
+		}
+		for _, n := range e.Nodes {
+			te.Nodes = append(te.Nodes, n.String())
+		}
+		sort.Strings(te.Nodes)
+		expected[te.ID] = te
+	}
+
+	ids, err := storage.QueryStrings(ctx, v.target, fmt.Sprintf(`
+		SELECT id FROM %s.entities_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+
+	actual := make(map[string]interface{})
+	for _, id := range ids {
+		// Entities can claim nodes, and nodes can claim to belong to an
+		// entity. The registry backend returns the union of these nodes.
+		nodes, err := storage.QueryStrings(ctx, v.target, fmt.Sprintf(`
+			SELECT node_id FROM %[1]s.claimed_nodes_checkpoint WHERE entity_id = $1
+			UNION
+			SELECT id FROM %[1]s.nodes_checkpoint WHERE entity_id = $1
+		`, v.schema),
+			id,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(nodes)
+		if nodes == nil {
+			nodes = []string{}
+		}
+		actual[id] = Entity{
+			ID:    id,
+			Nodes: nodes,
+		}
+	}
+
+	return diff(KindEntity, expected, actual, nil), nil
+}
+
+// Node is a verified node.
+type Node struct {
+	ID              string `json:"id"`
+	EntityID        string `json:"entity_id"`
+	Expiration      uint64 `json:"expiration"`
+	TLSPubkey       string `json:"tls_pubkey"`
+	TLSNextPubkey   string `json:"tls_next_pubkey"`
+	P2PPubkey       string `json:"p2p_pubkey"`
+	ConsensusPubkey string `json:"consensus_pubkey"`
+	VRFPubkey       string `json:"vrf_pubkey"`
+	Roles           string `json:"roles"`
+	SoftwareVersion string `json:"software_version"`
+}
+
+func (v *Verifier) verifyNodes(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for _, sn := range document.Registry.Nodes {
+		if sn == nil {
+			continue
+		}
+		var n node.Node
+		if err := sn.Open(registry.RegisterNodeSignatureContext, &n); err != nil {
+			return nil, err
+		}
+
+		vrfPubkey := ""
+		if n.VRF != nil {
+			vrfPubkey = n.VRF.ID.String()
+		}
+		expected[n.ID.String()] = Node{
+			ID:              n.ID.String(),
+			EntityID:        n.EntityID.String(),
+			Expiration:      n.Expiration,
+			TLSPubkey:       n.TLS.PubKey.String(),
+			TLSNextPubkey:   n.TLS.NextPubKey.String(),
+			P2PPubkey:       n.P2P.ID.String(),
+			ConsensusPubkey: n.Consensus.ID.String(),
+			VRFPubkey:       vrfPubkey,
+			Roles:           n.Roles.String(),
+			SoftwareVersion: n.SoftwareVersion,
+		}
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, entity_id, expiration, tls_pubkey, COALESCE(tls_next_pubkey, ''), p2p_pubkey,
+				consensus_pubkey, COALESCE(vrf_pubkey, ''), COALESCE(roles, ''), COALESCE(software_version, '')
+			FROM %s.nodes_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var n Node
+		if err := rows.Scan(
+			&n.ID,
+			&n.EntityID,
+			&n.Expiration,
+			&n.TLSPubkey,
+			&n.TLSNextPubkey,
+			&n.P2PPubkey,
+			&n.ConsensusPubkey,
+			&n.VRFPubkey,
+			&n.Roles,
+			&n.SoftwareVersion,
+		); err != nil {
+			return nil, err
+		}
+		actual[n.ID] = n
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindNode, expected, actual, nil), nil
+}
+
+// Runtime is a verified runtime.
+type Runtime struct {
+	ID          string `json:"id"`
+	Suspended   bool   `json:"suspended"`
+	Kind        string `json:"kind"`
+	TEEHardware string `json:"tee_hardware"`
+	KeyManager  string `json:"key_manager"`
+}
+
+func (v *Verifier) verifyRuntimes(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for suspended, runtimes := range map[bool][]*registry.Runtime{
+		false: document.Registry.Runtimes,
+		true:  document.Registry.SuspendedRuntimes,
+	} {
+		for _, r := range runtimes {
+			if r == nil {
+				continue
+			}
+			keyManager := "none"
+			if r.KeyManager != nil {
+				keyManager = r.KeyManager.String()
+			}
+			expected[r.ID.String()] = Runtime{
+				ID:          r.ID.String(),
+				Suspended:   suspended,
+				Kind:        r.Kind.String(),
+				TEEHardware: r.TEEHardware.String(),
+				KeyManager:  keyManager,
+			}
+		}
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, suspended, kind, tee_hardware, COALESCE(key_manager, 'none')
+			FROM %s.runtimes_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var r Runtime
+		if err := rows.Scan(
+			&r.ID,
+			&r.Suspended,
+			&r.Kind,
+			&r.TEEHardware,
+			&r.KeyManager,
+		); err != nil {
+			return nil, err
+		}
+		actual[r.ID] = r
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindRuntime, expected, actual, nil), nil
+}
+
+// Account is a verified account. Amounts are base 10 strings.
+type Account struct {
+	Address         string            `json:"address"`
+	Nonce           uint64            `json:"nonce"`
+	Available       string            `json:"available"`
+	Escrow          string            `json:"escrow"`
+	EscrowShares    string            `json:"escrow_shares"`
+	Debonding       string            `json:"debonding"`
+	DebondingShares string            `json:"debonding_shares"`
+	Allowances      map[string]string `json:"allowances"`
+}
+
+// zeroAccount returns the account with the provided address
+// which has never been used.
+func zeroAccount(address string) interface{} {
+	return Account{
+		Address:         address,
+		Available:       "0",
+		Escrow:          "0",
+		EscrowShares:    "0",
+		Debonding:       "0",
+		DebondingShares: "0",
+		Allowances:      map[string]string{},
+	}
+}
+
+func (v *Verifier) verifyAccounts(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for address, acct := range document.Staking.Ledger {
+		allowances := make(map[string]string, len(acct.General.Allowances))
+		for beneficiary, amount := range acct.General.Allowances {
+			allowances[beneficiary.String()] = amount.String()
+		}
+		expected[address.String()] = Account{
+			Address:         address.String(),
+			Nonce:           acct.General.Nonce,
+			Available:       acct.General.Balance.String(),
+			Escrow:          acct.Escrow.Active.Balance.String(),
+			EscrowShares:    acct.Escrow.Active.TotalShares.String(),
+			Debonding:       acct.Escrow.Debonding.Balance.String(),
+			DebondingShares: acct.Escrow.Debonding.TotalShares.String(),
+			Allowances:      allowances,
+		}
+	}
+
+	// Reserved addresses only receive transfers in indexed state,
+	// and are not part of the ledger.
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT address, nonce, general_balance::TEXT,
+				escrow_balance_active::TEXT, escrow_total_shares_active::TEXT,
+				escrow_balance_debonding::TEXT, escrow_total_shares_debonding::TEXT
+			FROM %s.accounts_checkpoint
+			WHERE address NOT IN ($1, $2, $3)
+	`, v.schema),
+		staking.CommonPoolAddress.String(),
+		staking.FeeAccumulatorAddress.String(),
+		staking.GovernanceDepositsAddress.String(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		a := Account{
+			Allowances: map[string]string{},
+		}
+		if err := rows.Scan(
+			&a.Address,
+			&a.Nonce,
+			&a.Available,
+			&a.Escrow,
+			&a.EscrowShares,
+			&a.Debonding,
+			&a.DebondingShares,
+		); err != nil {
+			return nil, err
+		}
+		actual[a.Address] = a
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	allowanceRows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT owner, beneficiary, allowance::TEXT
+			FROM %s.allowances_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer allowanceRows.Close()
+
+	for allowanceRows.Next() {
+		var owner, beneficiary, amount string
+		if err := allowanceRows.Scan(
+			&owner,
+			&beneficiary,
+			&amount,
+		); err != nil {
+			return nil, err
+		}
+		a, ok := actual[owner]
+		if !ok {
+			a = zeroAccount(owner)
+			actual[owner] = a
+		}
+		a.(Account).Allowances[beneficiary] = amount
+	}
+	if err := allowanceRows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindAccount, expected, actual, zeroAccount), nil
+}
+
+// Delegation is a verified delegation. Shares are base 10 strings.
+type Delegation struct {
+	Delegatee string `json:"delegatee"`
+	Delegator string `json:"delegator"`
+	Shares    string `json:"shares"`
+}
+
+func (v *Verifier) verifyDelegations(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for delegatee, escrows := range document.Staking.Delegations {
+		for delegator, delegation := range escrows {
+			d := Delegation{
+				Delegatee: delegatee.String(),
+				Delegator: delegator.String(),
+				Shares:    delegation.Shares.String(),
+			}
+			expected[d.Delegatee+"."+d.Delegator] = d
+		}
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT delegatee, delegator, shares::TEXT
+			FROM %s.delegations_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var d Delegation
+		if err := rows.Scan(
+			&d.Delegatee,
+			&d.Delegator,
+			&d.Shares,
+		); err != nil {
+			return nil, err
+		}
+		actual[d.Delegatee+"."+d.Delegator] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindDelegation, expected, actual, nil), nil
+}
+
+// DebondingDelegation is a verified debonding delegation.
+// Shares are base 10 strings.
+type DebondingDelegation struct {
+	Delegatee string `json:"delegatee"`
+	Delegator string `json:"delegator"`
+	Shares    string `json:"shares"`
+	DebondEnd uint64 `json:"debond_end"`
+}
+
+func (v *Verifier) verifyDebondingDelegations(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	// Debonding delegations ending in the same epoch may be merged,
+	// so these are compared by total shares.
+	key := func(d DebondingDelegation) string {
+		return fmt.Sprintf("%s.%s.%d", d.Delegatee, d.Delegator, d.DebondEnd)
+	}
+
+	totals := make(map[string]*staking.DebondingDelegation)
+	expected := make(map[string]interface{})
+	for delegatee, escrows := range document.Staking.DebondingDelegations {
+		for delegator, debondingDelegations := range escrows {
+			for _, debondingDelegation := range debondingDelegations {
+				d := DebondingDelegation{
+					Delegatee: delegatee.String(),
+					Delegator: delegator.String(),
+					DebondEnd: uint64(debondingDelegation.DebondEndTime),
+				}
+				total, ok := totals[key(d)]
+				if !ok {
+					total = &staking.DebondingDelegation{}
+					totals[key(d)] = total
+				}
+				if err := total.Shares.Add(&debondingDelegation.Shares); err != nil {
+					return nil, err
+				}
+				d.Shares = total.Shares.String()
+				expected[key(d)] = d
+			}
+		}
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT delegatee, delegator, SUM(shares)::TEXT, debond_end
+			FROM %s.debonding_delegations_checkpoint
+			GROUP BY delegatee, delegator, debond_end
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var d DebondingDelegation
+		if err := rows.Scan(
+			&d.Delegatee,
+			&d.Delegator,
+			&d.Shares,
+			&d.DebondEnd,
+		); err != nil {
+			return nil, err
+		}
+		actual[key(d)] = d
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindDebondingDelegation, expected, actual, nil), nil
+}
+
+// Proposal is a verified proposal. Amounts are base 10 strings.
+type Proposal struct {
+	ID               uint64  `json:"id"`
+	Submitter        string  `json:"submitter"`
+	State            string  `json:"state"`
+	Deposit          string  `json:"deposit"`
+	Handler          *string `json:"handler,omitempty"`
+	CpTargetVersion  *string `json:"cp_target_version,omitempty"`
+	RhpTargetVersion *string `json:"rhp_target_version,omitempty"`
+	RcpTargetVersion *string `json:"rcp_target_version,omitempty"`
+	UpgradeEpoch     *uint64 `json:"upgrade_epoch,omitempty"`
+	Cancels          *uint64 `json:"cancels,omitempty"`
+	CreatedAt        uint64  `json:"created_at"`
+	ClosesAt         uint64  `json:"closes_at"`
+	InvalidVotes     string  `json:"invalid_votes"`
+}
+
+func (v *Verifier) verifyProposals(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	expected := make(map[string]interface{})
+	for _, p := range document.Governance.Proposals {
+		if p == nil {
+			continue
+		}
+		ep := Proposal{
+			ID:           p.ID,
+			Submitter:    p.Submitter.String(),
+			State:        p.State.String(),
+			Deposit:      p.Deposit.String(),
+			CreatedAt:    uint64(p.CreatedAt),
+			ClosesAt:     uint64(p.ClosesAt),
+			InvalidVotes: fmt.Sprintf("%d", p.InvalidVotes),
+		}
+
+		switch {
+		case p.Content.Upgrade != nil:
+			handler := string(p.Content.Upgrade.Handler)
+			cpTargetVersion := p.Content.Upgrade.Target.ConsensusProtocol.String()
+			rhpTargetVersion := p.Content.Upgrade.Target.RuntimeHostProtocol.String()
+			rcpTargetVersion := p.Content.Upgrade.Target.RuntimeCommitteeProtocol.String()
+			upgradeEpoch := uint64(p.Content.Upgrade.Epoch)
+
+			ep.Handler = &handler
+			ep.CpTargetVersion = &cpTargetVersion
+			ep.RhpTargetVersion = &rhpTargetVersion
+			ep.RcpTargetVersion = &rcpTargetVersion
+			ep.UpgradeEpoch = &upgradeEpoch
+		case p.Content.CancelUpgrade != nil:
+			cancels := p.Content.CancelUpgrade.ProposalID
+			ep.Cancels = &cancels
+		default:
+			return nil, fmt.Errorf("malformed proposal %d", p.ID)
+		}
+
+		expected[fmt.Sprintf("%d", ep.ID)] = ep
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, submitter, state, deposit::TEXT,
+				handler, cp_target_version, rhp_target_version, rcp_target_version, upgrade_epoch, cancels,
+				created_at, closes_at, invalid_votes::TEXT
+			FROM %s.proposals_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var p Proposal
+		if err := rows.Scan(
+			&p.ID,
+			&p.Submitter,
+			&p.State,
+			&p.Deposit,
+			&p.Handler,
+			&p.CpTargetVersion,
+			&p.RhpTargetVersion,
+			&p.RcpTargetVersion,
+			&p.UpgradeEpoch,
+			&p.Cancels,
+			&p.CreatedAt,
+			&p.ClosesAt,
+			&p.InvalidVotes,
+		); err != nil {
+			return nil, err
+		}
+		actual[fmt.Sprintf("%d", p.ID)] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindProposal, expected, actual, nil), nil
+}
+
+// Vote is a verified vote.
+type Vote struct {
+	Proposal uint64 `json:"proposal"`
+	Voter    string `json:"voter"`
+	Vote     string `json:"vote"`
+}
+
+func (v *Verifier) verifyVotes(ctx context.Context, document *genesis.Document) ([]Mismatch, error) {
+	key := func(vote Vote) string {
+		return fmt.Sprintf("%d.%s", vote.Proposal, vote.Voter)
+	}
+
+	expected := make(map[string]interface{})
+	for proposal, entries := range document.Governance.VoteEntries {
+		for _, entry := range entries {
+			vote := Vote{
+				Proposal: proposal,
+				Voter:    entry.Voter.String(),
+				Vote:     entry.Vote.String(),
+			}
+			expected[key(vote)] = vote
+		}
+	}
+
+	rows, err := v.target.Query(ctx, fmt.Sprintf(`
+		SELECT proposal, voter, COALESCE(vote, '')
+			FROM %s.votes_checkpoint
+	`, v.schema))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actual := make(map[string]interface{})
+	for rows.Next() {
+		var vote Vote
+		if err := rows.Scan(
+			&vote.Proposal,
+			&vote.Voter,
+			&vote.Vote,
+		); err != nil {
+			return nil, err
+		}
+		actual[key(vote)] = vote
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return diff(KindVote, expected, actual, nil), nil
+}
@@ -0,0 +1,50 @@
+package verifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiff tests that mismatched, missing and unexpected records are reported.
+func TestDiff(t *testing.T) {
+	expected := map[string]interface{}{
+		"a": Delegation{Delegatee: "x", Delegator: "a", Shares: "10"},
+		"b": Delegation{Delegatee: "x", Delegator: "b", Shares: "20"},
+		"c": Delegation{Delegatee: "x", Delegator: "c", Shares: "30"},
+	}
+	actual := map[string]interface{}{
+		"a": Delegation{Delegatee: "x", Delegator: "a", Shares: "10"},
+		"b": Delegation{Delegatee: "x", Delegator: "b", Shares: "21"},
+		"d": Delegation{Delegatee: "x", Delegator: "d", Shares: "40"},
+	}
+
+	require.Equal(t, []Mismatch{
+		{Kind: KindDelegation, Key: "b", Expected: expected["b"], Actual: actual["b"]},
+		{Kind: KindDelegation, Key: "c", Expected: expected["c"]},
+		{Kind: KindDelegation, Key: "d", Actual: actual["d"]},
+	}, diff(KindDelegation, expected, actual, nil))
+}
+
+// TestDiffZero tests that missing records are compared against zero records.
+func TestDiffZero(t *testing.T) {
+	expected := map[string]interface{}{
+		"a": zeroAccount("a"),
+	}
+	actual := map[string]interface{}{
+		"b": zeroAccount("b"),
+	}
+	require.Empty(t, diff(KindAccount, expected, actual, zeroAccount))
+
+	used := zeroAccount("c").(Account)
+	used.Nonce = 1
+	actual["c"] = used
+	mismatches := diff(KindAccount, expected, actual, zeroAccount)
+	require.Len(t, mismatches, 1)
+	require.Equal(t, "c", mismatches[0].Key)
+	require.Equal(t, zeroAccount("c"), mismatches[0].Expected)
+
+	report := Report{Mismatches: mismatches}
+	require.False(t, report.Passed())
+	require.Equal(t, map[string]int{KindAccount: 1}, report.Counts())
+}
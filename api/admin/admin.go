@@ -0,0 +1,197 @@
+// Package admin implements the Oasis Indexer admin API, used by operators
+// to inspect and repair indexing. It should not be exposed publicly.
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/api/common"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	moduleName = "api_admin"
+)
+
+// Handler is the Oasis Indexer admin API handler.
+type Handler struct {
+	db      storage.TargetStorage
+	chains  *analyzer.ChainRegistry
+	logger  *log.Logger
+	metrics metrics.RequestMetrics
+}
+
+// NewHandler creates a new admin API handler.
+func NewHandler(db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
+	return &Handler{
+		db:      db,
+		chains:  chains,
+		logger:  l.WithModule(moduleName),
+		metrics: metrics.NewDefaultRequestMetrics(moduleName),
+	}
+}
+
+// RegisterMiddlewares implements the APIHandler interface.
+func (h *Handler) RegisterMiddlewares(r chi.Router) {}
+
+// RegisterRoutes implements the APIHandler interface.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Route("/quarantine", func(r chi.Router) {
+			r.Get("/", h.ListQuarantinedBlocks)
+			r.Post("/{analyzer}/{height}/redrive", h.RedriveQuarantinedBlock)
+		})
+	})
+}
+
+// Name implements the APIHandler interface.
+func (h *Handler) Name() string {
+	return "admin"
+}
+
+// chain returns the chain selected by the `chain_id` query parameter,
+// or the latest chain if none is provided.
+func (h *Handler) chain(r *http.Request) (*analyzer.Chain, error) {
+	chainID := r.URL.Query().Get("chain_id")
+	if chainID == "" {
+		return h.chains.Latest(), nil
+	}
+	chain, err := h.chains.FromID(chainID)
+	if err != nil {
+		return nil, common.ErrBadChainID
+	}
+	return chain, nil
+}
+
+// ListQuarantinedBlocks lists quarantined blocks.
+func (h *Handler) ListQuarantinedBlocks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chain, err := h.chain(r)
+	if err != nil {
+		h.logAndReply("failed to resolve chain", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+		return
+	}
+
+	query := fmt.Sprintf(`
+		SELECT height, analyzer, error, attempts, quarantined_time, redrive_requested
+			FROM %s.quarantined_blocks`,
+		chain.Schema)
+	var args []interface{}
+	if analyzerName := r.URL.Query().Get("analyzer"); analyzerName != "" {
+		query += "\n\tWHERE analyzer = $1"
+		args = append(args, analyzerName)
+	}
+	query += "\n\tORDER BY analyzer, height"
+
+	rows, err := h.db.Query(ctx, query, args...)
+	if err != nil {
+		h.logAndReply("failed to list quarantined blocks", w, common.ErrStorageError)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "database_error").Inc()
+		return
+	}
+	defer rows.Close()
+
+	qs := QuarantinedBlockList{
+		QuarantinedBlocks: []QuarantinedBlock{},
+	}
+	for rows.Next() {
+		var q QuarantinedBlock
+		if err := rows.Scan(
+			&q.Height,
+			&q.Analyzer,
+			&q.Error,
+			&q.Attempts,
+			&q.QuarantinedTime,
+			&q.RedriveRequested,
+		); err != nil {
+			h.logAndReply("failed to scan quarantined block", w, common.ErrStorageError)
+			h.metrics.RequestCounter(r.URL.Path, "failure", "database_error").Inc()
+			return
+		}
+		q.QuarantinedTime = q.QuarantinedTime.UTC()
+
+		qs.QuarantinedBlocks = append(qs.QuarantinedBlocks, q)
+	}
+
+	h.reply(w, r, qs)
+}
+
+// RedriveQuarantinedBlock requests that a quarantined block is re-driven
+// by its analyzer.
+func (h *Handler) RedriveQuarantinedBlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	chain, err := h.chain(r)
+	if err != nil {
+		h.logAndReply("failed to resolve chain", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+		return
+	}
+	height, err := strconv.ParseInt(chi.URLParam(r, "height"), 10, 64)
+	if err != nil {
+		h.logAndReply("failed to parse height", w, common.ErrBadRequest)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+		return
+	}
+	analyzerName := chi.URLParam(r, "analyzer")
+
+	var redriveRequested bool
+	if err := h.db.QueryRow(
+		ctx,
+		fmt.Sprintf(`
+			UPDATE %s.quarantined_blocks
+				SET redrive_requested = TRUE
+				WHERE height = $1 AND analyzer = $2
+			RETURNING redrive_requested`,
+			chain.Schema),
+		height,
+		analyzerName,
+	).Scan(&redriveRequested); err != nil {
+		h.logAndReply("failed to request re-drive", w, common.ErrNotFound)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "not_found").Inc()
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+}
+
+func (h *Handler) reply(w http.ResponseWriter, r *http.Request, v interface{}) {
+	resp, err := json.Marshal(v)
+	if err != nil {
+		h.logAndReply("failed to marshal response", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		h.logger.Error("failed to write response",
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
+
+func (h *Handler) logAndReply(msg string, w http.ResponseWriter, err error) {
+	h.logger.Error(msg,
+		"error", err,
+	)
+	if err = common.ReplyWithError(w, err); err != nil {
+		h.logger.Error("failed to reply with error",
+			"error", err,
+		)
+	}
+}
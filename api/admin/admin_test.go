@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+)
+
+func newRouter(t *testing.T) *chi.Mux {
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+
+	r := chi.NewRouter()
+	NewHandler(nil, chains, log.NewDefaultLogger("admin-test")).RegisterRoutes(r)
+	return r
+}
+
+// TestRedriveInvalidRequests tests that malformed re-drive
+// requests are rejected before reaching storage.
+func TestRedriveInvalidRequests(t *testing.T) {
+	r := newRouter(t)
+
+	for path, code := range map[string]int{
+		"/admin/quarantine/consensus_main_damask/abc/redrive":                  http.StatusBadRequest,
+		"/admin/quarantine/consensus_main_damask/100/redrive?chain_id=unknown": http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		require.Equal(t, code, w.Code, path)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/quarantine/consensus_main_damask/100/redrive", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,21 @@
+// Types for admin API responses.
+package admin
+
+import (
+	"time"
+)
+
+// QuarantinedBlockList is the API response for ListQuarantinedBlocks.
+type QuarantinedBlockList struct {
+	QuarantinedBlocks []QuarantinedBlock `json:"quarantined_blocks"`
+}
+
+// QuarantinedBlock is a block that repeatedly failed to process.
+type QuarantinedBlock struct {
+	Height           int64     `json:"height"`
+	Analyzer         string    `json:"analyzer"`
+	Error            string    `json:"error"`
+	Attempts         int       `json:"attempts"`
+	QuarantinedTime  time.Time `json:"quarantined_time"`
+	RedriveRequested bool      `json:"redrive_requested"`
+}
@@ -7,7 +7,12 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/api/admin"
+	"github.com/oasislabs/oasis-indexer/api/graphql"
+	"github.com/oasislabs/oasis-indexer/api/rosetta"
 	v1 "github.com/oasislabs/oasis-indexer/api/v1"
+	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/storage"
 )
@@ -36,13 +41,20 @@ type IndexerAPI struct {
 }
 
 // NewIndexerAPI creates a new Indexer API.
-func NewIndexerAPI(db storage.TargetStorage, l *log.Logger) *IndexerAPI {
+func NewIndexerAPI(cfg *config.ServerConfig, db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *IndexerAPI {
 	r := chi.NewRouter()
 
 	// Register handlers.
-	v1Handler := v1.NewHandler(db, l)
+	v1Handler := v1.NewHandler(db, chains, l)
 	handlers := []Handler{
 		v1Handler,
+		rosetta.NewHandler(db, chains, l),
+	}
+	if cfg.Admin {
+		handlers = append(handlers, admin.NewHandler(db, chains, l))
+	}
+	if cfg.GraphQL != nil {
+		handlers = append(handlers, graphql.NewHandler(cfg.GraphQL, db, chains, l))
 	}
 	for _, handler := range handlers {
 		handler.RegisterMiddlewares(r)
@@ -13,9 +13,15 @@ var (
 	// ErrBadChainID is returned when a malformed or missing chain ID
 	// is provided.
 	ErrBadChainID = errors.New("unable to resolve chain ID")
+	// ErrNotFound is returned when the requested resource
+	// does not exist.
+	ErrNotFound = errors.New("not found")
 	// ErrStorageError is returned when the underlying storage suffers
 	// from an internal error.
 	ErrStorageError = errors.New("internal storage error")
+	// ErrPruned is returned when the requested data is below the
+	// retention horizon, and has been pruned.
+	ErrPruned = errors.New("requested data has been pruned")
 )
 
 // ErrorResponse is a JSON error.
@@ -35,9 +41,15 @@ func ReplyWithError(w http.ResponseWriter, err error) error {
 	case ErrBadChainID:
 		response = ErrorResponse{err.Error()}
 		code = http.StatusNotFound
+	case ErrNotFound:
+		response = ErrorResponse{err.Error()}
+		code = http.StatusNotFound
 	case ErrStorageError:
 		response = ErrorResponse{err.Error()}
 		code = http.StatusInternalServerError
+	case ErrPruned:
+		response = ErrorResponse{err.Error()}
+		code = http.StatusGone
 	default:
 		response = ErrorResponse{err.Error()}
 		code = http.StatusInternalServerError
@@ -0,0 +1,41 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrTooComplex is returned when a query exceeds the complexity limit.
+var ErrTooComplex = errors.New("query exceeds complexity limit")
+
+// budget is the complexity budget of a request. Resolvers charge it
+// before fetching from storage: lookups cost one, and lists cost the
+// number of items requested. As nested fields are resolved once per
+// parent item, the cost of nested lists multiplies.
+type budget struct {
+	limit int64
+	spent int64
+}
+
+// charge charges the provided cost to the budget, and returns
+// ErrTooComplex if the budget is exceeded.
+func (b *budget) charge(cost int64) error {
+	if atomic.AddInt64(&b.spent, cost) > b.limit {
+		return ErrTooComplex
+	}
+	return nil
+}
+
+type budgetContextKey struct{}
+
+// withBudget returns a context with a new complexity budget
+// of the provided limit for a request.
+func withBudget(ctx context.Context, limit int64) context.Context {
+	return context.WithValue(ctx, budgetContextKey{}, &budget{limit: limit})
+}
+
+// charge charges the provided cost to the complexity budget of the request.
+func charge(ctx context.Context, cost int64) error {
+	return ctx.Value(budgetContextKey{}).(*budget).charge(cost)
+}
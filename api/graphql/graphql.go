@@ -0,0 +1,153 @@
+// Package graphql implements the Oasis Indexer GraphQL API, which serves
+// the consensus data of the latest chain with its relationships, e.g.
+// the transactions of a block and the accounts of their senders, in a
+// single request.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/api/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	moduleName = "api_graphql"
+
+	// maxRequestBytes is the maximum size of a request body.
+	maxRequestBytes = 1 << 20
+)
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler is the Oasis Indexer GraphQL API handler.
+type Handler struct {
+	schema        *graphql.Schema
+	chains        *analyzer.ChainRegistry
+	maxComplexity int64
+	logger        *log.Logger
+	metrics       metrics.RequestMetrics
+}
+
+// NewHandler creates a new GraphQL API handler.
+func NewHandler(cfg *config.GraphQLConfig, db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
+	h := newHandler(cfg, db, chains, l)
+	h.metrics = metrics.NewDefaultRequestMetrics(moduleName)
+	return h
+}
+
+// newHandler creates a new GraphQL API handler without metrics.
+func newHandler(cfg *config.GraphQLConfig, db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
+	logger := l.WithModule(moduleName)
+	return &Handler{
+		schema: graphql.MustParseSchema(schema, &Query{
+			r: &resolver{
+				db:     db,
+				chains: chains,
+				logger: logger,
+			},
+		},
+			graphql.UseFieldResolvers(),
+			graphql.MaxDepth(cfg.Depth()),
+			// Items of a list are resolved concurrently up to this limit,
+			// so that their relationships are loaded in a single batch.
+			graphql.MaxParallelism(maxBatchKeys),
+		),
+		chains:        chains,
+		maxComplexity: cfg.Complexity(),
+		logger:        logger,
+	}
+}
+
+// RegisterMiddlewares implements the APIHandler interface.
+func (h *Handler) RegisterMiddlewares(r chi.Router) {}
+
+// RegisterRoutes implements the APIHandler interface.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Get("/graphql", h.Serve)
+	r.Post("/graphql", h.Serve)
+}
+
+// Name implements the APIHandler interface.
+func (h *Handler) Name() string {
+	return "graphql"
+}
+
+// Serve serves a GraphQL request, provided either as the JSON body of
+// a POST request or as the `query` parameter of a GET request.
+func (h *Handler) Serve(w http.ResponseWriter, r *http.Request) {
+	timer := h.metrics.RequestTimer(r.URL.Path)
+	defer timer.ObserveDuration()
+
+	var req Request
+	if r.Method == http.MethodGet {
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				h.logAndReply("failed to parse variables", w, common.ErrBadRequest)
+				h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+				return
+			}
+		}
+	} else if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		h.logAndReply("failed to parse request", w, common.ErrBadRequest)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+		return
+	}
+	if req.Query == "" {
+		h.logAndReply("no query provided", w, common.ErrBadRequest)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "bad_request").Inc()
+		return
+	}
+
+	resp, err := json.Marshal(h.Exec(r.Context(), &req))
+	if err != nil {
+		h.logAndReply("failed to marshal response", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		h.logger.Error("failed to write response",
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
+
+// Exec executes the provided GraphQL request against the latest chain.
+func (h *Handler) Exec(ctx context.Context, req *Request) *graphql.Response {
+	ctx = withSchema(ctx, h.chains.Latest().Schema)
+	ctx = withBudget(ctx, h.maxComplexity)
+	ctx = withLoaders(ctx)
+	return h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+func (h *Handler) logAndReply(msg string, w http.ResponseWriter, err error) {
+	h.logger.Error(msg,
+		"error", err,
+	)
+	if err = common.ReplyWithError(w, err); err != nil {
+		h.logger.Error("failed to reply with error",
+			"error", err,
+		)
+	}
+}
@@ -0,0 +1,181 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+// countingStorage counts the queries made to the wrapped storage.
+type countingStorage struct {
+	storage.TargetStorage
+	queries int64
+}
+
+func (s *countingStorage) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	atomic.AddInt64(&s.queries, 1)
+	return s.TargetStorage.Query(ctx, sql, args...)
+}
+
+// newTestHandler creates a handler serving a new SQLite database
+// populated with test data.
+func newTestHandler(t *testing.T, cfg *config.GraphQLConfig) (*Handler, *countingStorage) {
+	logger := log.NewDefaultLogger("graphql-test")
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	client, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	t.Cleanup(client.Shutdown)
+
+	batch := &storage.QueryBatch{}
+	for _, q := range []string{
+		`INSERT INTO oasis_3.blocks (height, block_hash, time, namespace, version, type, root_hash) VALUES
+			(10, 'h10', '2022-04-11 08:30:00', 'ns', 1, 'state', 'root'),
+			(11, 'h11', '2022-04-11 08:30:06', 'ns', 1, 'state', 'root')`,
+		`INSERT INTO oasis_3.transactions (block, txn_hash, txn_index, nonce, fee_amount, method, sender, code) VALUES
+			(10, 'a', 0, 1, 100, 'staking.Transfer', 'oasis1a', 0),
+			(10, 'b', 1, 1, 100, 'staking.Transfer', 'oasis1b', 1),
+			(11, 'c', 0, 2, NULL, 'staking.AddEscrow', 'oasis1a', 0)`,
+		`INSERT INTO oasis_3.events (backend, type, body, txn_block, txn_hash, txn_index) VALUES
+			('staking', 'Transfer', '{}', 10, 'a', 0),
+			('core', 'GasUsed', '{}', 10, 'a', 0),
+			('staking', 'AddEscrow', '{}', 11, 'c', 0)`,
+		`INSERT INTO oasis_3.accounts (address, general_balance, nonce, escrow_balance_active, escrow_total_shares_active) VALUES
+			('oasis1a', 5000, 2, 0, 0),
+			('oasis1b', 7000, 1, 0, 0),
+			('oasis1v', 0, 0, 2000, 100)`,
+		`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ('oasis1v', 'oasis1a', 50)`,
+		`INSERT INTO oasis_3.entities (id, address, meta) VALUES ('ent', 'oasis1v', '{"name":"Validator"}')`,
+		`INSERT INTO oasis_3.nodes (id, entity_id, expiration, tls_pubkey, p2p_pubkey, consensus_pubkey, roles, voting_power) VALUES
+			('node', 'ent', 20, 'tls', 'p2p', 'cons', 'validator', 10)`,
+		`INSERT INTO oasis_3.proposals (id, submitter, deposit, created_at, closes_at) VALUES (1, 'oasis1a', 100, 1, 2)`,
+		`INSERT INTO oasis_3.votes (proposal, voter, vote) VALUES (1, 'oasis1a', 'yes'), (1, 'oasis1b', 'no')`,
+		`INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time) VALUES (11, 'consensus_main_damask', '2022-04-11 08:31:00')`,
+	} {
+		batch.Queue(q)
+	}
+	require.Nil(t, client.SendBatch(context.Background(), batch))
+
+	db := &countingStorage{TargetStorage: client}
+	return newHandler(cfg, db, chains, logger), db
+}
+
+// exec executes the provided query, and returns its data and errors.
+func exec(t *testing.T, h *Handler, query string) (string, []string) {
+	resp := h.Exec(context.Background(), &Request{Query: query})
+	var errs []string
+	for _, err := range resp.Errors {
+		errs = append(errs, err.Message)
+	}
+	data, err := json.Marshal(resp.Data)
+	require.Nil(t, err)
+	return string(data), errs
+}
+
+// TestRelationships tests that relationships are resolved across objects.
+func TestRelationships(t *testing.T) {
+	h, _ := newTestHandler(t, &config.GraphQLConfig{})
+
+	data, errs := exec(t, h, `{
+		status { latestChainId latestBlock }
+		blocks(first: 1) {
+			height
+			transactions { hash fee success events { type transaction { hash } } }
+		}
+		account(address: "oasis1a") {
+			available
+			transactions(first: 1) { hash block { hash } }
+			delegations {
+				amount
+				delegatee { address entity { id validator { name active node { votingPower } } } }
+			}
+		}
+		proposal(id: 1) { state votes { vote voter { available } } }
+		missing: block(height: 1) { hash }
+	}`)
+	require.Empty(t, errs)
+	require.JSONEq(t, `{
+		"status": {"latestChainId": "oasis-3", "latestBlock": 11},
+		"blocks": [{
+			"height": 11,
+			"transactions": [{"hash": "c", "fee": null, "success": true, "events": [{"type": "AddEscrow", "transaction": {"hash": "c"}}]}]
+		}],
+		"account": {
+			"available": "5000",
+			"transactions": [{"hash": "c", "block": {"hash": "h11"}}],
+			"delegations": [{
+				"amount": "1000",
+				"delegatee": {"address": "oasis1v", "entity": {"id": "ent", "validator": {"name": "Validator", "active": true, "node": {"votingPower": "10"}}}}
+			}]
+		},
+		"proposal": {"state": "active", "votes": [{"vote": "yes", "voter": {"available": "5000"}}, {"vote": "no", "voter": {"available": "7000"}}]},
+		"missing": null
+	}`, data)
+}
+
+// TestBatching tests that the relationships of items of a list are
+// fetched with a single query per relationship.
+func TestBatching(t *testing.T) {
+	h, db := newTestHandler(t, &config.GraphQLConfig{})
+
+	data, errs := exec(t, h, `{
+		transactions {
+			hash
+			block { height }
+			sender { address }
+			events { type }
+		}
+	}`)
+	require.Empty(t, errs)
+	require.JSONEq(t, `{"transactions": [
+		{"hash": "c", "block": {"height": 11}, "sender": {"address": "oasis1a"}, "events": [{"type": "AddEscrow"}]},
+		{"hash": "a", "block": {"height": 10}, "sender": {"address": "oasis1a"}, "events": [{"type": "Transfer"}, {"type": "GasUsed"}]},
+		{"hash": "b", "block": {"height": 10}, "sender": {"address": "oasis1b"}, "events": []}
+	]}`, data)
+	require.Equal(t, int64(4), atomic.LoadInt64(&db.queries))
+
+	atomic.StoreInt64(&db.queries, 0)
+	_, errs = exec(t, h, `{ blocks { transactions(first: 1) { sender { nonce } } } }`)
+	require.Empty(t, errs)
+	require.Equal(t, int64(3), atomic.LoadInt64(&db.queries))
+}
+
+// TestLimits tests that queries exceeding the configured limits are rejected.
+func TestLimits(t *testing.T) {
+	h, db := newTestHandler(t, &config.GraphQLConfig{MaxDepth: 3, MaxComplexity: 50})
+
+	_, errs := exec(t, h, `{ blocks { height } }`)
+	require.Equal(t, []string{ErrTooComplex.Error()}, errs)
+
+	_, errs = exec(t, h, `{ blocks(first: 5) { transactions(first: 5) { hash } } }`)
+	require.Empty(t, errs)
+	_, errs = exec(t, h, `{ blocks(first: 5) { transactions(first: 30) { hash } } }`)
+	require.NotEmpty(t, errs)
+	for _, err := range errs {
+		require.Equal(t, ErrTooComplex.Error(), err)
+	}
+
+	atomic.StoreInt64(&db.queries, 0)
+	_, errs = exec(t, h, `{ blocks(first: 1) { transactions(first: 1) { block { transactions(first: 1) { hash } } } } }`)
+	require.Len(t, errs, 1)
+	require.True(t, strings.Contains(errs[0], "exceeds max depth"), errs[0])
+	require.Equal(t, int64(0), atomic.LoadInt64(&db.queries))
+
+	_, errs = exec(t, h, `{ blocks(first: -1) { height } }`)
+	require.Equal(t, []string{ErrBadPage.Error()}, errs)
+}
@@ -0,0 +1,155 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// loadWait is how long a load waits for sibling loads to join its batch.
+	loadWait = 2 * time.Millisecond
+
+	// maxBatchKeys is the maximum number of keys fetched by a single query.
+	maxBatchKeys = 1000
+)
+
+// fetchFunc fetches the values of the provided keys, keyed by key.
+// Keys without a value are omitted.
+type fetchFunc func(ctx context.Context, keys []string) (map[string]interface{}, error)
+
+// result is the result of loading a key.
+type result struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// loader batches loads of values by key within a request, so that
+// resolving a relationship for each item of a list fetches all related
+// values with a single query rather than one query per item.
+//
+// Keys are batched when loaded concurrently, or when enqueued ahead of
+// loading by the resolver of a list. Values are cached for the lifetime
+// of the loader, which is a single request.
+type loader struct {
+	fetch fetchFunc
+
+	mu        sync.Mutex
+	results   map[string]*result
+	pending   []string
+	scheduled bool
+}
+
+// newLoader creates a new loader fetching values with the provided function.
+func newLoader(fetch fetchFunc) *loader {
+	return &loader{
+		fetch:   fetch,
+		results: make(map[string]*result),
+	}
+}
+
+// Enqueue adds the provided keys to the next batch without loading them,
+// so that loading any of them fetches all of them.
+func (l *loader) Enqueue(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		l.result(key)
+	}
+}
+
+// Load returns the value of the provided key, or nil if it has none.
+func (l *loader) Load(ctx context.Context, key string) (interface{}, error) {
+	l.mu.Lock()
+	r := l.result(key)
+	if len(l.pending) > 0 && !l.scheduled {
+		l.scheduled = true
+		go l.dispatch(ctx)
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// result returns the result of the provided key, adding the key to the
+// next batch if it has not been loaded. It must be called with the lock held.
+func (l *loader) result(key string) *result {
+	r, ok := l.results[key]
+	if !ok {
+		r = &result{done: make(chan struct{})}
+		l.results[key] = r
+		l.pending = append(l.pending, key)
+	}
+	return r
+}
+
+// dispatch fetches the pending batch once concurrent loads had a chance
+// to join it.
+func (l *loader) dispatch(ctx context.Context) {
+	time.Sleep(loadWait)
+
+	l.mu.Lock()
+	keys := l.pending
+	l.pending = nil
+	l.scheduled = false
+	l.mu.Unlock()
+
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > maxBatchKeys {
+			n = maxBatchKeys
+		}
+		batch := keys[:n]
+		keys = keys[n:]
+
+		values, err := l.fetch(ctx, batch)
+
+		l.mu.Lock()
+		for _, key := range batch {
+			r := l.results[key]
+			r.value, r.err = values[key], err
+			close(r.done)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// loaders are the loaders of a request, keyed by name.
+type loaders struct {
+	mu      sync.Mutex
+	loaders map[string]*loader
+}
+
+// get returns the loader with the provided name, creating it with
+// the provided fetch function if it does not exist.
+func (ls *loaders) get(name string, fetch fetchFunc) *loader {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if l, ok := ls.loaders[name]; ok {
+		return l
+	}
+	l := newLoader(fetch)
+	ls.loaders[name] = l
+	return l
+}
+
+type loadersContextKey struct{}
+
+// withLoaders returns a context with new loaders for a request.
+func withLoaders(ctx context.Context) context.Context {
+	return context.WithValue(ctx, loadersContextKey{}, &loaders{
+		loaders: make(map[string]*loader),
+	})
+}
+
+// loaderFromContext returns the loader of the request with the provided
+// name, creating it with the provided fetch function if it does not exist.
+func loaderFromContext(ctx context.Context, name string, fetch fetchFunc) *loader {
+	return ctx.Value(loadersContextKey{}).(*loaders).get(name, fetch)
+}
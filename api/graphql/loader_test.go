@@ -0,0 +1,59 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoader tests that concurrent and enqueued loads are batched,
+// and that loaded values are cached.
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var batches [][]string
+	l := newLoader(func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		batch := append([]string{}, keys...)
+		sort.Strings(batch)
+		batches = append(batches, batch)
+
+		values := make(map[string]interface{})
+		for _, key := range keys {
+			if key != "missing" {
+				values[key] = key + "!"
+			}
+		}
+		return values, nil
+	})
+
+	var wg sync.WaitGroup
+	for _, key := range []string{"a", "b", "missing"} {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			v, err := l.Load(ctx, key)
+			require.Nil(t, err)
+			if key == "missing" {
+				require.Nil(t, v)
+			} else {
+				require.Equal(t, key+"!", v)
+			}
+		}(key)
+	}
+	wg.Wait()
+	require.Equal(t, [][]string{{"a", "b", "missing"}}, batches)
+
+	l.Enqueue("a", "c", "d")
+	v, err := l.Load(ctx, "d")
+	require.Nil(t, err)
+	require.Equal(t, "d!", v)
+	v, err = l.Load(ctx, "c")
+	require.Nil(t, err)
+	require.Equal(t, "c!", v)
+	require.Equal(t, [][]string{{"a", "b", "missing"}, {"c", "d"}}, batches)
+}
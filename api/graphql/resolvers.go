@@ -0,0 +1,498 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/graph-gophers/graphql-go"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/api/common"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// ErrBadPage is returned when a list is requested with a malformed page.
+var ErrBadPage = fmt.Errorf("page must have between 0 and %d items and a non-negative offset", common.MaximumLimit)
+
+// BigInt is an arbitrary-precision integer serialized as a decimal string.
+type BigInt string
+
+// ImplementsGraphQLType implements graphql.Unmarshaler.
+func (BigInt) ImplementsGraphQLType(name string) bool {
+	return name == "BigInt"
+}
+
+// UnmarshalGraphQL implements graphql.Unmarshaler.
+func (b *BigInt) UnmarshalGraphQL(input interface{}) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("malformed BigInt %v", input)
+	}
+	if _, ok := new(big.Int).SetString(s, 10); !ok {
+		return fmt.Errorf("malformed BigInt '%s'", s)
+	}
+	*b = BigInt(s)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (b BigInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(b))
+}
+
+// pageArgs are the arguments of a paginated list.
+type pageArgs struct {
+	First  int32
+	Offset int32
+}
+
+// charge validates the page and charges its cost to the request's
+// complexity budget.
+func (p pageArgs) charge(ctx context.Context) error {
+	if p.First < 0 || uint64(p.First) > common.MaximumLimit || p.Offset < 0 {
+		return ErrBadPage
+	}
+	return charge(ctx, int64(p.First))
+}
+
+// resolver resolves queries against target storage.
+type resolver struct {
+	db     storage.TargetStorage
+	chains *analyzer.ChainRegistry
+	logger *log.Logger
+}
+
+type schemaContextKey struct{}
+
+// withSchema returns a context in which queries are resolved against
+// the provided chain schema.
+func withSchema(ctx context.Context, schema string) context.Context {
+	return context.WithValue(ctx, schemaContextKey{}, schema)
+}
+
+// schema returns the chain schema against which the request is resolved.
+func (r *resolver) schema(ctx context.Context) string {
+	return ctx.Value(schemaContextKey{}).(string)
+}
+
+// query runs the provided query, scanning each row with the provided
+// function. Storage errors are logged, and not exposed to clients.
+func (r *resolver) query(ctx context.Context, scan func(storage.QueryResults) error, sql string, args ...interface{}) error {
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		r.logger.Info("query failed",
+			"err", err.Error(),
+		)
+		return common.ErrStorageError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			r.logger.Info("row scan failed",
+				"err", err.Error(),
+			)
+			return common.ErrStorageError
+		}
+	}
+	if err := rows.Err(); err != nil {
+		r.logger.Info("query failed",
+			"err", err.Error(),
+		)
+		return common.ErrStorageError
+	}
+	return nil
+}
+
+// placeholders returns a parenthesized list of n query parameter
+// placeholders, starting with the provided parameter number.
+func placeholders(from, n int) string {
+	ps := make([]string, n)
+	for i := range ps {
+		ps[i] = fmt.Sprintf("$%d", from+i)
+	}
+	return "(" + strings.Join(ps, ", ") + ")"
+}
+
+// stringArgs returns the provided keys as query arguments.
+func stringArgs(keys []string) []interface{} {
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		args[i] = k
+	}
+	return args
+}
+
+// int64Args returns the provided integer keys as query arguments.
+func int64Args(keys []string) ([]interface{}, error) {
+	args := make([]interface{}, len(keys))
+	for i, k := range keys {
+		v, err := strconv.ParseInt(k, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+	return args, nil
+}
+
+// pageFilter returns a condition on the row number column `rn` selecting
+// the provided page, with parameters starting at the provided number.
+func pageFilter(from int, page pageArgs) (string, []interface{}) {
+	return fmt.Sprintf("rn > $%d AND rn <= $%d", from, from+1),
+		[]interface{}{int64(page.Offset), int64(page.Offset) + int64(page.First)}
+}
+
+// Query resolves the root query type.
+type Query struct {
+	r *resolver
+}
+
+// Status resolves the indexing status of the latest chain.
+func (q *Query) Status(ctx context.Context) (*Status, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	latest := q.r.chains.Latest()
+	s := Status{
+		LatestChainID: latest.ID.String(),
+	}
+	var height int64
+	var found bool
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		found = true
+		return rows.Scan(&height, &s.LatestUpdate.Time)
+	}, fmt.Sprintf(`
+		SELECT height, processed_time
+			FROM %s.processed_blocks
+			ORDER BY processed_time DESC
+			LIMIT 1`,
+		latest.Schema),
+	); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, common.ErrNotFound
+	}
+	s.LatestBlock = int32(height)
+	return &s, nil
+}
+
+// Block resolves the block at the provided height.
+func (q *Query) Block(ctx context.Context, args struct{ Height int32 }) (*Block, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.block(ctx, int64(args.Height))
+}
+
+// Blocks resolves a list of blocks, latest first.
+func (q *Query) Blocks(ctx context.Context, args struct {
+	pageArgs
+	From *int32
+	To   *int32
+}) ([]*Block, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var filters []string
+	var params []interface{}
+	if args.From != nil {
+		params = append(params, int64(*args.From))
+		filters = append(filters, fmt.Sprintf("height >= $%d", len(params)))
+	}
+	if args.To != nil {
+		params = append(params, int64(*args.To))
+		filters = append(filters, fmt.Sprintf("height <= $%d", len(params)))
+	}
+	params = append(params, int64(args.First), int64(args.Offset))
+
+	var bs []*Block
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		b, err := q.r.scanBlock(rows)
+		if err != nil {
+			return err
+		}
+		bs = append(bs, b)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT height, block_hash, time
+			FROM %s.blocks
+			%s
+			ORDER BY height DESC
+			LIMIT $%d OFFSET $%d`,
+		q.r.schema(ctx), where(filters), len(params)-1, len(params)),
+		params...,
+	); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+// Transaction resolves the transaction with the provided hash, optionally
+// at the provided height. If the hash was included several times, the
+// latest inclusion is resolved.
+func (q *Query) Transaction(ctx context.Context, args struct {
+	Hash   string
+	Height *int32
+}) (*Transaction, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	filters := []string{"txn_hash = $1"}
+	params := []interface{}{args.Hash}
+	if args.Height != nil {
+		filters = append(filters, "block = $2")
+		params = append(params, int64(*args.Height))
+	}
+	ts, err := q.r.transactions(ctx, filters, params, pageArgs{First: 1})
+	if err != nil || len(ts) == 0 {
+		return nil, err
+	}
+	return ts[0], nil
+}
+
+// Transactions resolves a list of transactions, latest first.
+func (q *Query) Transactions(ctx context.Context, args struct {
+	pageArgs
+	Block  *int32
+	Sender *string
+	Method *string
+}) ([]*Transaction, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var filters []string
+	var params []interface{}
+	if args.Block != nil {
+		params = append(params, int64(*args.Block))
+		filters = append(filters, fmt.Sprintf("block = $%d", len(params)))
+	}
+	if args.Sender != nil {
+		params = append(params, *args.Sender)
+		filters = append(filters, fmt.Sprintf("sender = $%d", len(params)))
+	}
+	if args.Method != nil {
+		params = append(params, *args.Method)
+		filters = append(filters, fmt.Sprintf("method = $%d", len(params)))
+	}
+	return q.r.transactions(ctx, filters, params, args.pageArgs)
+}
+
+// Events resolves a list of events, latest first.
+func (q *Query) Events(ctx context.Context, args struct {
+	pageArgs
+	Block *int32
+	Type  *string
+}) ([]*Event, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var filters []string
+	var params []interface{}
+	if args.Block != nil {
+		params = append(params, int64(*args.Block))
+		filters = append(filters, fmt.Sprintf("txn_block = $%d", len(params)))
+	}
+	if args.Type != nil {
+		params = append(params, *args.Type)
+		filters = append(filters, fmt.Sprintf("type = $%d", len(params)))
+	}
+	params = append(params, int64(args.First), int64(args.Offset))
+
+	var es []*Event
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		e, err := q.r.scanEvent(rows)
+		if err != nil {
+			return err
+		}
+		es = append(es, e)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT txn_block, txn_hash, backend, type, body::TEXT
+			FROM %s.events
+			%s
+			ORDER BY txn_block DESC, txn_index
+			LIMIT $%d OFFSET $%d`,
+		q.r.schema(ctx), where(filters), len(params)-1, len(params)),
+		params...,
+	); err != nil {
+		return nil, err
+	}
+	q.r.enqueueEventTransactions(ctx, es)
+	return es, nil
+}
+
+// Entity resolves the entity with the provided ID.
+func (q *Query) Entity(ctx context.Context, args struct{ ID graphql.ID }) (*Entity, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.entity(ctx, string(args.ID))
+}
+
+// Entities resolves a list of entities.
+func (q *Query) Entities(ctx context.Context, args pageArgs) ([]*Entity, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var es []*Entity
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		e, err := q.r.scanEntity(rows)
+		if err != nil {
+			return err
+		}
+		es = append(es, e)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT id, COALESCE(address, '')
+			FROM %s.entities
+			ORDER BY id
+			LIMIT $1 OFFSET $2`,
+		q.r.schema(ctx)),
+		int64(args.First), int64(args.Offset),
+	); err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+// Node resolves the node with the provided ID.
+func (q *Query) Node(ctx context.Context, args struct{ ID graphql.ID }) (*Node, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.node(ctx, string(args.ID))
+}
+
+// Account resolves the account with the provided address.
+func (q *Query) Account(ctx context.Context, args struct{ Address string }) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.account(ctx, args.Address)
+}
+
+// Accounts resolves a list of accounts.
+func (q *Query) Accounts(ctx context.Context, args pageArgs) ([]*Account, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var as []*Account
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		a, err := q.r.scanAccount(rows)
+		if err != nil {
+			return err
+		}
+		as = append(as, a)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT %s
+			FROM %s.accounts
+			ORDER BY address
+			LIMIT $1 OFFSET $2`,
+		accountColumns, q.r.schema(ctx)),
+		int64(args.First), int64(args.Offset),
+	); err != nil {
+		return nil, err
+	}
+	return as, nil
+}
+
+// Proposal resolves the proposal with the provided ID.
+func (q *Query) Proposal(ctx context.Context, args struct{ ID int32 }) (*Proposal, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.proposal(ctx, int64(args.ID))
+}
+
+// Proposals resolves a list of proposals.
+func (q *Query) Proposals(ctx context.Context, args struct {
+	pageArgs
+	Submitter *string
+	State     *string
+}) ([]*Proposal, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var filters []string
+	var params []interface{}
+	if args.Submitter != nil {
+		params = append(params, *args.Submitter)
+		filters = append(filters, fmt.Sprintf("submitter = $%d", len(params)))
+	}
+	if args.State != nil {
+		params = append(params, *args.State)
+		filters = append(filters, fmt.Sprintf("state = $%d", len(params)))
+	}
+	params = append(params, int64(args.First), int64(args.Offset))
+
+	var ps []*Proposal
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		p, err := q.r.scanProposal(rows)
+		if err != nil {
+			return err
+		}
+		ps = append(ps, p)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT %s
+			FROM %s.proposals
+			%s
+			ORDER BY id
+			LIMIT $%d OFFSET $%d`,
+		proposalColumns, q.r.schema(ctx), where(filters), len(params)-1, len(params)),
+		params...,
+	); err != nil {
+		return nil, err
+	}
+	return ps, nil
+}
+
+// Validator resolves the validator of the entity with the provided ID.
+func (q *Query) Validator(ctx context.Context, args struct{ EntityID graphql.ID }) (*Validator, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return q.r.validator(ctx, string(args.EntityID))
+}
+
+// Validators resolves a list of validators, by voting power.
+func (q *Query) Validators(ctx context.Context, args pageArgs) ([]*Validator, error) {
+	if err := args.charge(ctx); err != nil {
+		return nil, err
+	}
+	var vs []*Validator
+	if err := q.r.query(ctx, func(rows storage.QueryResults) error {
+		v, err := q.r.scanValidator(rows)
+		if err != nil {
+			return err
+		}
+		vs = append(vs, v)
+		return nil
+	}, fmt.Sprintf(`
+		%s
+			ORDER BY n.voting_power DESC, e.id
+			LIMIT $1 OFFSET $2`,
+		validatorQuery(q.r.schema(ctx))),
+		int64(args.First), int64(args.Offset),
+	); err != nil {
+		return nil, err
+	}
+	return vs, nil
+}
+
+// where returns a WHERE clause of the provided conditions,
+// or nothing if there are none.
+func where(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, " AND ")
+}
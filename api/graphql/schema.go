@@ -0,0 +1,154 @@
+package graphql
+
+// schema is the GraphQL schema of indexed consensus data.
+const schema = `
+schema {
+	query: Query
+}
+
+# Arbitrary-precision integer, e.g. an amount in base units, serialized as a decimal string.
+scalar BigInt
+
+# Timestamp in RFC 3339 format.
+scalar Time
+
+type Query {
+	# Indexing status of the latest chain.
+	status: Status!
+
+	block(height: Int!): Block
+	blocks(first: Int = 100, offset: Int = 0, from: Int, to: Int): [Block!]!
+
+	transaction(hash: String!, height: Int): Transaction
+	transactions(first: Int = 100, offset: Int = 0, block: Int, sender: String, method: String): [Transaction!]!
+
+	events(first: Int = 100, offset: Int = 0, block: Int, type: String): [Event!]!
+
+	entity(id: ID!): Entity
+	entities(first: Int = 100, offset: Int = 0): [Entity!]!
+
+	node(id: ID!): Node
+
+	account(address: String!): Account
+	accounts(first: Int = 100, offset: Int = 0): [Account!]!
+
+	proposal(id: Int!): Proposal
+	proposals(first: Int = 100, offset: Int = 0, submitter: String, state: String): [Proposal!]!
+
+	validator(entityId: ID!): Validator
+	validators(first: Int = 100, offset: Int = 0): [Validator!]!
+}
+
+type Status {
+	latestChainId: String!
+	latestBlock: Int!
+	latestUpdate: Time!
+}
+
+type Block {
+	height: Int!
+	hash: String!
+	timestamp: Time!
+	transactions(first: Int = 100, offset: Int = 0): [Transaction!]!
+}
+
+type Transaction {
+	height: Int!
+	hash: String!
+	index: Int
+	nonce: BigInt!
+	fee: BigInt
+	method: String!
+	senderAddress: String!
+	success: Boolean!
+	block: Block
+	sender: Account
+	events(first: Int = 100, offset: Int = 0): [Event!]!
+}
+
+type Event {
+	height: Int!
+	txHash: String!
+	backend: String!
+	type: String!
+	# Event body as JSON.
+	body: String
+	transaction: Transaction
+}
+
+type Entity {
+	id: ID!
+	address: String!
+	account: Account
+	nodes(first: Int = 100, offset: Int = 0): [Node!]!
+	validator: Validator
+}
+
+type Node {
+	id: ID!
+	entityId: ID!
+	expiration: Int!
+	tlsPubkey: String!
+	p2pPubkey: String!
+	consensusPubkey: String!
+	roles: String!
+	votingPower: BigInt!
+	entity: Entity
+}
+
+type Account {
+	address: String!
+	nonce: BigInt!
+	available: BigInt!
+	escrow: BigInt!
+	debonding: BigInt!
+	entity: Entity
+	delegations(first: Int = 100, offset: Int = 0): [Delegation!]!
+	transactions(first: Int = 100, offset: Int = 0): [Transaction!]!
+}
+
+type Delegation {
+	delegatorAddress: String!
+	delegateeAddress: String!
+	shares: BigInt!
+	# Amount of the delegator's share of the delegatee's active escrow.
+	amount: BigInt!
+	delegator: Account
+	delegatee: Account
+}
+
+type Proposal {
+	id: Int!
+	submitterAddress: String!
+	state: String!
+	deposit: BigInt!
+	handler: String
+	createdAt: Int!
+	closesAt: Int!
+	invalidVotes: BigInt!
+	submitter: Account
+	votes(first: Int = 100, offset: Int = 0): [Vote!]!
+}
+
+type Vote {
+	proposalId: Int!
+	voterAddress: String!
+	vote: String!
+	proposal: Proposal
+	voter: Account
+}
+
+type Validator {
+	entityId: ID!
+	entityAddress: String!
+	nodeId: ID!
+	name: String
+	escrow: BigInt!
+	# True iff the entity is part of the validator set.
+	active: Boolean!
+	# True iff the entity has a node registered as a validator.
+	status: Boolean!
+	entity: Entity
+	node: Node
+}
+`
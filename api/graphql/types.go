@@ -0,0 +1,1094 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	oasisErrors "github.com/oasisprotocol/oasis-core/go/common/errors"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	accountColumns  = "address, COALESCE(nonce, 0)::TEXT, COALESCE(general_balance, 0)::TEXT, COALESCE(escrow_balance_active, 0)::TEXT, COALESCE(escrow_balance_debonding, 0)::TEXT"
+	proposalColumns = "id, submitter, state, deposit::TEXT, handler, created_at, closes_at, invalid_votes::TEXT"
+	nodeColumns     = "id, entity_id, expiration, tls_pubkey, p2p_pubkey, consensus_pubkey, COALESCE(roles, ''), COALESCE(voting_power, 0)::TEXT"
+)
+
+// validatorQuery returns the query of validators of the provided chain
+// schema, matching the v1 API: each entity with a validator node is
+// listed with the node of the most voting power.
+func validatorQuery(schema string) string {
+	return fmt.Sprintf(`
+		SELECT
+			e.id,
+			COALESCE(e.address, ''),
+			n.id,
+			COALESCE(a.escrow_balance_active, 0)::TEXT,
+			CASE WHEN EXISTS(SELECT NULL FROM %[1]s.nodes WHERE e.id = nodes.entity_id AND voting_power > 0) THEN true ELSE false END,
+			CASE WHEN EXISTS(SELECT NULL FROM %[1]s.nodes WHERE e.id = nodes.entity_id AND roles LIKE '%%validator%%') THEN true ELSE false END,
+			e.meta::TEXT
+		FROM %[1]s.entities AS e
+		JOIN %[1]s.accounts AS a ON e.address = a.address
+		JOIN %[1]s.nodes AS n ON e.id = n.entity_id
+			AND n.roles LIKE '%%validator%%'
+			AND n.voting_power = (
+				SELECT MAX(voting_power)
+				FROM %[1]s.nodes
+				WHERE e.id = nodes.entity_id
+					AND roles LIKE '%%validator%%'
+			)`,
+		schema)
+}
+
+// txKey returns the loader key of the transaction with the provided
+// height and hash.
+func txKey(height int64, hash string) string {
+	return fmt.Sprintf("%d/%s", height, hash)
+}
+
+// txKeyArgs splits the provided transaction keys into heights and hashes.
+func txKeyArgs(keys []string) (heights []string, hashes []string) {
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 2)
+		heights = append(heights, parts[0])
+		hashes = append(hashes, parts[1])
+	}
+	return
+}
+
+// fetchByKey returns a fetch function running the provided query, in
+// which %[1]s is the chain schema and %[2]s the placeholders of the
+// keys. If paginated, %[3]s is the filter of the page, applied to the
+// row number column `rn` partitioned by key. Scanned values are
+// collected by key, in lists if paginated.
+func (r *resolver) fetchByKey(
+	query string,
+	intKeys bool,
+	page *pageArgs,
+	scan func(storage.QueryResults) (string, interface{}, error),
+) fetchFunc {
+	return func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		args := stringArgs(keys)
+		if intKeys {
+			var err error
+			if args, err = int64Args(keys); err != nil {
+				return nil, err
+			}
+		}
+		fmtArgs := []interface{}{r.schema(ctx), placeholders(1, len(keys))}
+		if page != nil {
+			filter, pageArgs := pageFilter(len(keys)+1, *page)
+			fmtArgs = append(fmtArgs, filter)
+			args = append(args, pageArgs...)
+		}
+
+		values := make(map[string]interface{})
+		if err := r.query(ctx, func(rows storage.QueryResults) error {
+			key, value, err := scan(rows)
+			if err != nil {
+				return err
+			}
+			if page == nil {
+				values[key] = value
+				return nil
+			}
+			list, _ := values[key].([]interface{})
+			values[key] = append(list, value)
+			return nil
+		}, fmt.Sprintf(query, fmtArgs...), args...); err != nil {
+			return nil, err
+		}
+		return values, nil
+	}
+}
+
+// pageName returns the name of the loader of the provided page of
+// a relationship.
+func pageName(name string, page pageArgs) string {
+	return fmt.Sprintf("%s:%d:%d", name, page.First, page.Offset)
+}
+
+// Status is the indexing status of the latest chain.
+type Status struct {
+	LatestChainID string
+	LatestBlock   int32
+	LatestUpdate  graphql.Time
+}
+
+// Block is a consensus block.
+type Block struct {
+	r *resolver
+
+	height    int64
+	hash      string
+	timestamp time.Time
+}
+
+func (r *resolver) scanBlock(rows storage.QueryResults) (*Block, error) {
+	b := Block{r: r}
+	if err := rows.Scan(&b.height, &b.hash, &b.timestamp); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (r *resolver) blockLoader(ctx context.Context) *loader {
+	return loaderFromContext(ctx, "blocks", r.fetchByKey(`
+		SELECT height, block_hash, time
+			FROM %[1]s.blocks
+			WHERE height IN %[2]s`,
+		true, nil, func(rows storage.QueryResults) (string, interface{}, error) {
+			b, err := r.scanBlock(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprint(b.height), b, nil
+		}))
+}
+
+// block returns the block at the provided height, or nil if there is none.
+func (r *resolver) block(ctx context.Context, height int64) (*Block, error) {
+	v, err := r.blockLoader(ctx).Load(ctx, fmt.Sprint(height))
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Block), nil
+}
+
+func (b *Block) Height() int32 {
+	return int32(b.height)
+}
+
+func (b *Block) Hash() string {
+	return b.hash
+}
+
+func (b *Block) Timestamp() graphql.Time {
+	return graphql.Time{Time: b.timestamp}
+}
+
+func (b *Block) Transactions(ctx context.Context, page pageArgs) ([]*Transaction, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	return b.r.transactionPage(ctx, "block_transactions", "block", fmt.Sprint(b.height), true, page)
+}
+
+// Transaction is a consensus transaction.
+type Transaction struct {
+	r *resolver
+
+	height int64
+	hash   string
+	index  *int32
+	nonce  string
+	fee    *string
+	method string
+	sender string
+	code   uint64
+}
+
+const transactionColumns = "block, txn_hash, txn_index, nonce::TEXT, fee_amount::TEXT, method, sender, code"
+
+func (r *resolver) scanTransaction(rows storage.QueryResults) (*Transaction, error) {
+	t := Transaction{r: r}
+	if err := rows.Scan(&t.height, &t.hash, &t.index, &t.nonce, &t.fee, &t.method, &t.sender, &t.code); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// transactions returns a page of transactions matching the provided
+// filters, latest first.
+func (r *resolver) transactions(ctx context.Context, filters []string, params []interface{}, page pageArgs) ([]*Transaction, error) {
+	params = append(params, int64(page.First), int64(page.Offset))
+
+	var ts []*Transaction
+	if err := r.query(ctx, func(rows storage.QueryResults) error {
+		t, err := r.scanTransaction(rows)
+		if err != nil {
+			return err
+		}
+		ts = append(ts, t)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT %s
+			FROM %s.transactions
+			%s
+			ORDER BY block DESC, txn_index
+			LIMIT $%d OFFSET $%d`,
+		transactionColumns, r.schema(ctx), where(filters), len(params)-1, len(params)),
+		params...,
+	); err != nil {
+		return nil, err
+	}
+	r.enqueueTransactionRelationships(ctx, ts)
+	return ts, nil
+}
+
+// enqueueTransactionRelationships enqueues the relationships of the
+// provided transactions, so that they are fetched in a single batch.
+func (r *resolver) enqueueTransactionRelationships(ctx context.Context, ts []*Transaction) {
+	for _, t := range ts {
+		r.blockLoader(ctx).Enqueue(fmt.Sprint(t.height))
+		r.accountLoader(ctx).Enqueue(t.sender)
+	}
+}
+
+// transactionPage returns a page of the transactions related by the
+// provided column to the provided key.
+func (r *resolver) transactionPage(ctx context.Context, name string, column string, key string, intKey bool, page pageArgs) ([]*Transaction, error) {
+	l := loaderFromContext(ctx, pageName(name, page), r.fetchByKey(fmt.Sprintf(`
+		SELECT %s
+			FROM (
+				SELECT *, ROW_NUMBER() OVER (PARTITION BY %s ORDER BY block DESC, txn_index) AS rn
+					FROM %%[1]s.transactions
+					WHERE %s IN %%[2]s
+			) AS page
+			WHERE %%[3]s
+			ORDER BY rn`,
+		transactionColumns, column, column),
+		intKey, &page, func(rows storage.QueryResults) (string, interface{}, error) {
+			t, err := r.scanTransaction(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			if column == "block" {
+				return fmt.Sprint(t.height), t, nil
+			}
+			return t.sender, t, nil
+		}))
+	v, err := l.Load(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := v.([]interface{})
+	ts := make([]*Transaction, len(list))
+	for i, t := range list {
+		ts[i] = t.(*Transaction)
+	}
+	r.enqueueTransactionRelationships(ctx, ts)
+	return ts, nil
+}
+
+func (r *resolver) transactionLoader(ctx context.Context) *loader {
+	return loaderFromContext(ctx, "transactions", func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		heights, hashes := txKeyArgs(keys)
+		args, err := int64Args(heights)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, stringArgs(hashes)...)
+
+		values := make(map[string]interface{})
+		if err := r.query(ctx, func(rows storage.QueryResults) error {
+			t, err := r.scanTransaction(rows)
+			if err != nil {
+				return err
+			}
+			values[txKey(t.height, t.hash)] = t
+			return nil
+		}, fmt.Sprintf(`
+			SELECT %s
+				FROM %s.transactions
+				WHERE block IN %s AND txn_hash IN %s`,
+			transactionColumns, r.schema(ctx), placeholders(1, len(keys)), placeholders(len(keys)+1, len(keys))),
+			args...,
+		); err != nil {
+			return nil, err
+		}
+		return values, nil
+	})
+}
+
+func (t *Transaction) Height() int32 {
+	return int32(t.height)
+}
+
+func (t *Transaction) Hash() string {
+	return t.hash
+}
+
+func (t *Transaction) Index() *int32 {
+	return t.index
+}
+
+func (t *Transaction) Nonce() BigInt {
+	return BigInt(t.nonce)
+}
+
+func (t *Transaction) Fee() *BigInt {
+	if t.fee == nil {
+		return nil
+	}
+	fee := BigInt(*t.fee)
+	return &fee
+}
+
+func (t *Transaction) Method() string {
+	return t.method
+}
+
+func (t *Transaction) SenderAddress() string {
+	return t.sender
+}
+
+func (t *Transaction) Success() bool {
+	return t.code == oasisErrors.CodeNoError
+}
+
+func (t *Transaction) Block(ctx context.Context) (*Block, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return t.r.block(ctx, t.height)
+}
+
+func (t *Transaction) Sender(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return t.r.account(ctx, t.sender)
+}
+
+func (t *Transaction) Events(ctx context.Context, page pageArgs) ([]*Event, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	l := loaderFromContext(ctx, pageName("transaction_events", page), func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		heights, hashes := txKeyArgs(keys)
+		args, err := int64Args(heights)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, stringArgs(hashes)...)
+		filter, pageArgs := pageFilter(len(args)+1, page)
+		args = append(args, pageArgs...)
+
+		values := make(map[string]interface{})
+		if err := t.r.query(ctx, func(rows storage.QueryResults) error {
+			e, err := t.r.scanEvent(rows)
+			if err != nil {
+				return err
+			}
+			key := txKey(e.height, e.txHash)
+			list, _ := values[key].([]interface{})
+			values[key] = append(list, e)
+			return nil
+		}, fmt.Sprintf(`
+			SELECT txn_block, txn_hash, backend, type, body
+				FROM (
+					SELECT txn_block, txn_hash, backend, type, body::TEXT AS body,
+							ROW_NUMBER() OVER (PARTITION BY txn_block, txn_hash ORDER BY txn_index) AS rn
+						FROM %s.events
+						WHERE txn_block IN %s AND txn_hash IN %s
+				) AS page
+				WHERE %s
+				ORDER BY rn`,
+			t.r.schema(ctx), placeholders(1, len(keys)), placeholders(len(keys)+1, len(keys)), filter),
+			args...,
+		); err != nil {
+			return nil, err
+		}
+		// Transactions matching the height of one key and the hash of
+		// another are fetched too, and are not part of the batch.
+		batch := make(map[string]interface{}, len(keys))
+		for _, key := range keys {
+			if v, ok := values[key]; ok {
+				batch[key] = v
+			}
+		}
+		return batch, nil
+	})
+	v, err := l.Load(ctx, txKey(t.height, t.hash))
+	if err != nil {
+		return nil, err
+	}
+	list, _ := v.([]interface{})
+	es := make([]*Event, len(list))
+	for i, e := range list {
+		es[i] = e.(*Event)
+	}
+	t.r.enqueueEventTransactions(ctx, es)
+	return es, nil
+}
+
+// Event is a consensus event.
+type Event struct {
+	r *resolver
+
+	height  int64
+	txHash  string
+	backend string
+	typ     string
+	body    *string
+}
+
+func (r *resolver) scanEvent(rows storage.QueryResults) (*Event, error) {
+	e := Event{r: r}
+	if err := rows.Scan(&e.height, &e.txHash, &e.backend, &e.typ, &e.body); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// enqueueEventTransactions enqueues the transactions of the provided
+// events, so that they are fetched in a single batch.
+func (r *resolver) enqueueEventTransactions(ctx context.Context, es []*Event) {
+	for _, e := range es {
+		r.transactionLoader(ctx).Enqueue(txKey(e.height, e.txHash))
+	}
+}
+
+func (e *Event) Height() int32 {
+	return int32(e.height)
+}
+
+func (e *Event) TxHash() string {
+	return e.txHash
+}
+
+func (e *Event) Backend() string {
+	return e.backend
+}
+
+func (e *Event) Type() string {
+	return e.typ
+}
+
+func (e *Event) Body() *string {
+	return e.body
+}
+
+func (e *Event) Transaction(ctx context.Context) (*Transaction, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	v, err := e.r.transactionLoader(ctx).Load(ctx, txKey(e.height, e.txHash))
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Transaction), nil
+}
+
+// Entity is a registered entity.
+type Entity struct {
+	r *resolver
+
+	id      string
+	address string
+}
+
+func (r *resolver) scanEntity(rows storage.QueryResults) (*Entity, error) {
+	e := Entity{r: r}
+	if err := rows.Scan(&e.id, &e.address); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+func (r *resolver) entityLoader(ctx context.Context, column string) *loader {
+	return loaderFromContext(ctx, "entities_by_"+column, r.fetchByKey(fmt.Sprintf(`
+		SELECT id, COALESCE(address, '')
+			FROM %%[1]s.entities
+			WHERE %s IN %%[2]s`,
+		column),
+		false, nil, func(rows storage.QueryResults) (string, interface{}, error) {
+			e, err := r.scanEntity(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			if column == "address" {
+				return e.address, e, nil
+			}
+			return e.id, e, nil
+		}))
+}
+
+// entity returns the entity with the provided ID, or nil if there is none.
+func (r *resolver) entity(ctx context.Context, id string) (*Entity, error) {
+	v, err := r.entityLoader(ctx, "id").Load(ctx, id)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Entity), nil
+}
+
+func (e *Entity) ID() graphql.ID {
+	return graphql.ID(e.id)
+}
+
+func (e *Entity) Address() string {
+	return e.address
+}
+
+func (e *Entity) Account(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return e.r.account(ctx, e.address)
+}
+
+func (e *Entity) Nodes(ctx context.Context, page pageArgs) ([]*Node, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	l := loaderFromContext(ctx, pageName("entity_nodes", page), e.r.fetchByKey(fmt.Sprintf(`
+		SELECT %s
+			FROM (
+				SELECT *, ROW_NUMBER() OVER (PARTITION BY entity_id ORDER BY id) AS rn
+					FROM %%[1]s.nodes
+					WHERE entity_id IN %%[2]s
+			) AS page
+			WHERE %%[3]s
+			ORDER BY rn`,
+		nodeColumns),
+		false, &page, func(rows storage.QueryResults) (string, interface{}, error) {
+			n, err := e.r.scanNode(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			return n.entityID, n, nil
+		}))
+	v, err := l.Load(ctx, e.id)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := v.([]interface{})
+	ns := make([]*Node, len(list))
+	for i, n := range list {
+		ns[i] = n.(*Node)
+	}
+	return ns, nil
+}
+
+func (e *Entity) Validator(ctx context.Context) (*Validator, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return e.r.validator(ctx, e.id)
+}
+
+// Node is a registered node.
+type Node struct {
+	r *resolver
+
+	id              string
+	entityID        string
+	expiration      int64
+	tlsPubkey       string
+	p2pPubkey       string
+	consensusPubkey string
+	roles           string
+	votingPower     string
+}
+
+func (r *resolver) scanNode(rows storage.QueryResults) (*Node, error) {
+	n := Node{r: r}
+	if err := rows.Scan(&n.id, &n.entityID, &n.expiration, &n.tlsPubkey, &n.p2pPubkey, &n.consensusPubkey, &n.roles, &n.votingPower); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// node returns the node with the provided ID, or nil if there is none.
+func (r *resolver) node(ctx context.Context, id string) (*Node, error) {
+	l := loaderFromContext(ctx, "nodes", r.fetchByKey(fmt.Sprintf(`
+		SELECT %s
+			FROM %%[1]s.nodes
+			WHERE id IN %%[2]s`,
+		nodeColumns),
+		false, nil, func(rows storage.QueryResults) (string, interface{}, error) {
+			n, err := r.scanNode(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			return n.id, n, nil
+		}))
+	v, err := l.Load(ctx, id)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Node), nil
+}
+
+func (n *Node) ID() graphql.ID {
+	return graphql.ID(n.id)
+}
+
+func (n *Node) EntityID() graphql.ID {
+	return graphql.ID(n.entityID)
+}
+
+func (n *Node) Expiration() int32 {
+	return int32(n.expiration)
+}
+
+func (n *Node) TLSPubkey() string {
+	return n.tlsPubkey
+}
+
+func (n *Node) P2PPubkey() string {
+	return n.p2pPubkey
+}
+
+func (n *Node) ConsensusPubkey() string {
+	return n.consensusPubkey
+}
+
+func (n *Node) Roles() string {
+	return n.roles
+}
+
+func (n *Node) VotingPower() BigInt {
+	return BigInt(n.votingPower)
+}
+
+func (n *Node) Entity(ctx context.Context) (*Entity, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return n.r.entity(ctx, n.entityID)
+}
+
+// Account is a staking account.
+type Account struct {
+	r *resolver
+
+	address   string
+	nonce     string
+	available string
+	escrow    string
+	debonding string
+}
+
+func (r *resolver) scanAccount(rows storage.QueryResults) (*Account, error) {
+	a := Account{r: r}
+	if err := rows.Scan(&a.address, &a.nonce, &a.available, &a.escrow, &a.debonding); err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *resolver) accountLoader(ctx context.Context) *loader {
+	return loaderFromContext(ctx, "accounts", r.fetchByKey(fmt.Sprintf(`
+		SELECT %s
+			FROM %%[1]s.accounts
+			WHERE address IN %%[2]s`,
+		accountColumns),
+		false, nil, func(rows storage.QueryResults) (string, interface{}, error) {
+			a, err := r.scanAccount(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			return a.address, a, nil
+		}))
+}
+
+// account returns the account with the provided address, or nil if
+// there is none.
+func (r *resolver) account(ctx context.Context, address string) (*Account, error) {
+	v, err := r.accountLoader(ctx).Load(ctx, address)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Account), nil
+}
+
+func (a *Account) Address() string {
+	return a.address
+}
+
+func (a *Account) Nonce() BigInt {
+	return BigInt(a.nonce)
+}
+
+func (a *Account) Available() BigInt {
+	return BigInt(a.available)
+}
+
+func (a *Account) Escrow() BigInt {
+	return BigInt(a.escrow)
+}
+
+func (a *Account) Debonding() BigInt {
+	return BigInt(a.debonding)
+}
+
+func (a *Account) Entity(ctx context.Context) (*Entity, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	v, err := a.r.entityLoader(ctx, "address").Load(ctx, a.address)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Entity), nil
+}
+
+func (a *Account) Delegations(ctx context.Context, page pageArgs) ([]*Delegation, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	l := loaderFromContext(ctx, pageName("account_delegations", page), a.r.fetchByKey(`
+		SELECT delegator, delegatee, shares, escrow_balance_active, escrow_total_shares_active
+			FROM (
+				SELECT d.delegator, d.delegatee, d.shares::TEXT AS shares,
+						COALESCE(a.escrow_balance_active, 0)::TEXT AS escrow_balance_active,
+						COALESCE(a.escrow_total_shares_active, 0)::TEXT AS escrow_total_shares_active,
+						ROW_NUMBER() OVER (PARTITION BY d.delegator ORDER BY d.delegatee) AS rn
+					FROM %[1]s.delegations AS d
+					JOIN %[1]s.accounts AS a ON d.delegatee = a.address
+					WHERE d.delegator IN %[2]s
+			) AS page
+			WHERE %[3]s
+			ORDER BY rn`,
+		false, &page, func(rows storage.QueryResults) (string, interface{}, error) {
+			d := Delegation{r: a.r}
+			var balance, totalShares string
+			if err := rows.Scan(&d.delegator, &d.delegatee, &d.shares, &balance, &totalShares); err != nil {
+				return "", nil, err
+			}
+			amount, err := delegationAmount(d.shares, balance, totalShares)
+			if err != nil {
+				return "", nil, err
+			}
+			d.amount = amount
+			return d.delegator, &d, nil
+		}))
+	v, err := l.Load(ctx, a.address)
+	if err != nil {
+		return nil, err
+	}
+	list, _ := v.([]interface{})
+	ds := make([]*Delegation, len(list))
+	for i, d := range list {
+		ds[i] = d.(*Delegation)
+		a.r.accountLoader(ctx).Enqueue(ds[i].delegatee)
+	}
+	return ds, nil
+}
+
+func (a *Account) Transactions(ctx context.Context, page pageArgs) ([]*Transaction, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	return a.r.transactionPage(ctx, "account_transactions", "sender", a.address, false, page)
+}
+
+// Delegation is an active delegation.
+type Delegation struct {
+	r *resolver
+
+	delegator string
+	delegatee string
+	shares    string
+	amount    string
+}
+
+// delegationAmount returns the amount of the provided shares of
+// the provided active escrow balance and total shares.
+func delegationAmount(shares, balance, totalShares string) (string, error) {
+	var s, b, t big.Int
+	for _, v := range []struct {
+		i *big.Int
+		s string
+	}{{&s, shares}, {&b, balance}, {&t, totalShares}} {
+		if _, ok := v.i.SetString(v.s, 10); !ok {
+			return "", fmt.Errorf("malformed amount '%s'", v.s)
+		}
+	}
+	if t.Sign() == 0 {
+		return "0", nil
+	}
+	return new(big.Int).Quo(new(big.Int).Mul(&s, &b), &t).String(), nil
+}
+
+func (d *Delegation) DelegatorAddress() string {
+	return d.delegator
+}
+
+func (d *Delegation) DelegateeAddress() string {
+	return d.delegatee
+}
+
+func (d *Delegation) Shares() BigInt {
+	return BigInt(d.shares)
+}
+
+func (d *Delegation) Amount() BigInt {
+	return BigInt(d.amount)
+}
+
+func (d *Delegation) Delegator(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return d.r.account(ctx, d.delegator)
+}
+
+func (d *Delegation) Delegatee(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return d.r.account(ctx, d.delegatee)
+}
+
+// Proposal is a governance proposal.
+type Proposal struct {
+	r *resolver
+
+	id           int64
+	submitter    string
+	state        string
+	deposit      string
+	handler      *string
+	createdAt    int64
+	closesAt     int64
+	invalidVotes string
+}
+
+func (r *resolver) scanProposal(rows storage.QueryResults) (*Proposal, error) {
+	p := Proposal{r: r}
+	if err := rows.Scan(&p.id, &p.submitter, &p.state, &p.deposit, &p.handler, &p.createdAt, &p.closesAt, &p.invalidVotes); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// proposal returns the proposal with the provided ID, or nil if there
+// is none.
+func (r *resolver) proposal(ctx context.Context, id int64) (*Proposal, error) {
+	l := loaderFromContext(ctx, "proposals", r.fetchByKey(fmt.Sprintf(`
+		SELECT %s
+			FROM %%[1]s.proposals
+			WHERE id IN %%[2]s`,
+		proposalColumns),
+		true, nil, func(rows storage.QueryResults) (string, interface{}, error) {
+			p, err := r.scanProposal(rows)
+			if err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprint(p.id), p, nil
+		}))
+	v, err := l.Load(ctx, fmt.Sprint(id))
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Proposal), nil
+}
+
+func (p *Proposal) ID() int32 {
+	return int32(p.id)
+}
+
+func (p *Proposal) SubmitterAddress() string {
+	return p.submitter
+}
+
+func (p *Proposal) State() string {
+	return p.state
+}
+
+func (p *Proposal) Deposit() BigInt {
+	return BigInt(p.deposit)
+}
+
+func (p *Proposal) Handler() *string {
+	return p.handler
+}
+
+func (p *Proposal) CreatedAt() int32 {
+	return int32(p.createdAt)
+}
+
+func (p *Proposal) ClosesAt() int32 {
+	return int32(p.closesAt)
+}
+
+func (p *Proposal) InvalidVotes() BigInt {
+	return BigInt(p.invalidVotes)
+}
+
+func (p *Proposal) Submitter(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return p.r.account(ctx, p.submitter)
+}
+
+func (p *Proposal) Votes(ctx context.Context, page pageArgs) ([]*Vote, error) {
+	if err := page.charge(ctx); err != nil {
+		return nil, err
+	}
+	l := loaderFromContext(ctx, pageName("proposal_votes", page), p.r.fetchByKey(`
+		SELECT proposal, voter, vote
+			FROM (
+				SELECT proposal, voter, COALESCE(vote, '') AS vote,
+						ROW_NUMBER() OVER (PARTITION BY proposal ORDER BY voter) AS rn
+					FROM %[1]s.votes
+					WHERE proposal IN %[2]s
+			) AS page
+			WHERE %[3]s
+			ORDER BY rn`,
+		true, &page, func(rows storage.QueryResults) (string, interface{}, error) {
+			v := Vote{r: p.r}
+			if err := rows.Scan(&v.proposal, &v.voter, &v.vote); err != nil {
+				return "", nil, err
+			}
+			return fmt.Sprint(v.proposal), &v, nil
+		}))
+	v, err := l.Load(ctx, fmt.Sprint(p.id))
+	if err != nil {
+		return nil, err
+	}
+	list, _ := v.([]interface{})
+	vs := make([]*Vote, len(list))
+	for i, v := range list {
+		vs[i] = v.(*Vote)
+		p.r.accountLoader(ctx).Enqueue(vs[i].voter)
+	}
+	return vs, nil
+}
+
+// Vote is a vote on a governance proposal.
+type Vote struct {
+	r *resolver
+
+	proposal int64
+	voter    string
+	vote     string
+}
+
+func (v *Vote) ProposalID() int32 {
+	return int32(v.proposal)
+}
+
+func (v *Vote) VoterAddress() string {
+	return v.voter
+}
+
+func (v *Vote) Vote() string {
+	return v.vote
+}
+
+func (v *Vote) Proposal(ctx context.Context) (*Proposal, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return v.r.proposal(ctx, v.proposal)
+}
+
+func (v *Vote) Voter(ctx context.Context) (*Account, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return v.r.account(ctx, v.voter)
+}
+
+// Validator is an entity with a validator node.
+type Validator struct {
+	r *resolver
+
+	entityID      string
+	entityAddress string
+	nodeID        string
+	escrow        string
+	active        bool
+	status        bool
+	name          *string
+}
+
+func (r *resolver) scanValidator(rows storage.QueryResults) (*Validator, error) {
+	v := Validator{r: r}
+	var meta *string
+	if err := rows.Scan(&v.entityID, &v.entityAddress, &v.nodeID, &v.escrow, &v.active, &v.status, &meta); err != nil {
+		return nil, err
+	}
+	if meta != nil {
+		var media struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(*meta), &media); err == nil && media.Name != "" {
+			v.name = &media.Name
+		}
+	}
+	return &v, nil
+}
+
+// validator returns the validator of the entity with the provided ID,
+// or nil if there is none.
+func (r *resolver) validator(ctx context.Context, entityID string) (*Validator, error) {
+	l := loaderFromContext(ctx, "validators", func(ctx context.Context, keys []string) (map[string]interface{}, error) {
+		values := make(map[string]interface{})
+		if err := r.query(ctx, func(rows storage.QueryResults) error {
+			v, err := r.scanValidator(rows)
+			if err != nil {
+				return err
+			}
+			values[v.entityID] = v
+			return nil
+		}, fmt.Sprintf(`
+			%s
+			WHERE e.id IN %s`,
+			validatorQuery(r.schema(ctx)), placeholders(1, len(keys))),
+			stringArgs(keys)...,
+		); err != nil {
+			return nil, err
+		}
+		return values, nil
+	})
+	v, err := l.Load(ctx, entityID)
+	if v == nil || err != nil {
+		return nil, err
+	}
+	return v.(*Validator), nil
+}
+
+func (v *Validator) EntityID() graphql.ID {
+	return graphql.ID(v.entityID)
+}
+
+func (v *Validator) EntityAddress() string {
+	return v.entityAddress
+}
+
+func (v *Validator) NodeID() graphql.ID {
+	return graphql.ID(v.nodeID)
+}
+
+func (v *Validator) Name() *string {
+	return v.name
+}
+
+func (v *Validator) Escrow() BigInt {
+	return BigInt(v.escrow)
+}
+
+func (v *Validator) Active() bool {
+	return v.active
+}
+
+func (v *Validator) Status() bool {
+	return v.status
+}
+
+func (v *Validator) Entity(ctx context.Context) (*Entity, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return v.r.entity(ctx, v.entityID)
+}
+
+func (v *Validator) Node(ctx context.Context) (*Node, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+	return v.r.node(ctx, v.nodeID)
+}
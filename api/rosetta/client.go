@@ -0,0 +1,475 @@
+package rosetta
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	oasisErrors "github.com/oasisprotocol/oasis-core/go/common/errors"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	// defaultSearchLimit is the default number of transactions returned
+	// by a search.
+	defaultSearchLimit = 100
+
+	// maxSearchLimit is the maximum number of transactions returned
+	// by a search.
+	maxSearchLimit = 1000
+)
+
+// storageClient serves Rosetta requests from target storage.
+type storageClient struct {
+	db     storage.TargetStorage
+	logger *log.Logger
+}
+
+// query runs the provided query, scanning each row with the provided
+// function. Storage errors are logged, and not exposed to clients.
+func (c *storageClient) query(ctx context.Context, scan func(storage.QueryResults) error, sql string, args ...interface{}) *Error {
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		c.logger.Info("query failed",
+			"err", err.Error(),
+		)
+		return ErrStorageError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			c.logger.Info("row scan failed",
+				"err", err.Error(),
+			)
+			return ErrStorageError
+		}
+	}
+	if err := rows.Err(); err != nil {
+		c.logger.Info("query failed",
+			"err", err.Error(),
+		)
+		return ErrStorageError
+	}
+	return nil
+}
+
+// block is an indexed block.
+type block struct {
+	height int64
+	hash   string
+	time   time.Time
+}
+
+// identifier returns the identifier of the block.
+func (b *block) identifier() BlockIdentifier {
+	return BlockIdentifier{Index: b.height, Hash: b.hash}
+}
+
+// findBlock returns the block matching the provided condition, or nil
+// if there is none.
+func (c *storageClient) findBlock(ctx context.Context, chain *analyzer.Chain, cond string, args ...interface{}) (*block, *Error) {
+	var b *block
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		b = &block{}
+		return rows.Scan(&b.height, &b.hash, &b.time)
+	}, fmt.Sprintf(`
+		SELECT height, block_hash, time
+			FROM %s.blocks
+			%s
+			LIMIT 1`,
+		chain.Schema, cond), args...); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// resolveBlock returns the indexed block identified by the provided
+// partial identifier, or the latest indexed block if it is empty.
+func (c *storageClient) resolveBlock(ctx context.Context, chain *analyzer.Chain, id PartialBlockIdentifier) (*block, *Error) {
+	var conds []string
+	var args []interface{}
+	if id.Index != nil {
+		ctx = storage.WithMinHeight(ctx, chain.Schema, *id.Index)
+		args = append(args, *id.Index)
+		conds = append(conds, fmt.Sprintf("height = $%d", len(args)))
+	}
+	if id.Hash != nil {
+		args = append(args, *id.Hash)
+		conds = append(conds, fmt.Sprintf("block_hash = $%d", len(args)))
+	}
+	cond := "ORDER BY height DESC"
+	if len(conds) > 0 {
+		cond = "WHERE " + strings.Join(conds, " AND ")
+	}
+
+	b, err := c.findBlock(ctx, chain, cond, args...)
+	if err != nil {
+		return nil, err
+	}
+	if b != nil {
+		return b, nil
+	}
+	if id.Index != nil {
+		return nil, c.checkRetention(ctx, chain, "blocks", *id.Index)
+	}
+	return nil, ErrBlockNotFound
+}
+
+// checkRetention returns ErrBlockPruned if the provided height is below
+// the retention horizon of the provided table, and ErrBlockNotFound
+// otherwise.
+func (c *storageClient) checkRetention(ctx context.Context, chain *analyzer.Chain, table string, height int64) *Error {
+	var horizon *int64
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		return rows.Scan(&horizon)
+	}, fmt.Sprintf(`
+		SELECT height
+			FROM %s.retention_horizons
+			WHERE table_name = $1`,
+		chain.Schema), table); err != nil {
+		return err
+	}
+	if horizon != nil && height < *horizon {
+		return ErrBlockPruned
+	}
+	return ErrBlockNotFound
+}
+
+// txKey identifies an indexed transaction. The same transaction hash may
+// be included in several blocks.
+type txKey struct {
+	block int64
+	hash  string
+	index int64
+}
+
+// transactions returns the transactions matching the provided condition,
+// with their operations.
+func (c *storageClient) transactions(ctx context.Context, chain *analyzer.Chain, cond string, args ...interface{}) ([]BlockTransaction, *Error) {
+	txs := []BlockTransaction{}
+	byKey := make(map[txKey]int)
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		var tx BlockTransaction
+		var index int64
+		var code uint64
+		var fee *string
+		if err := rows.Scan(
+			&tx.BlockIdentifier.Index,
+			&tx.BlockIdentifier.Hash,
+			&tx.Transaction.TransactionIdentifier.Hash,
+			&index,
+			&tx.Transaction.Metadata.Method,
+			&tx.Transaction.Metadata.Sender,
+			&tx.Transaction.Metadata.Nonce,
+			&fee,
+			&code,
+		); err != nil {
+			return err
+		}
+		tx.Transaction.Metadata.Fee = "0"
+		if fee != nil {
+			tx.Transaction.Metadata.Fee = *fee
+		}
+		tx.Transaction.Metadata.Success = code == oasisErrors.CodeNoError
+		tx.Transaction.Operations = []Operation{}
+		byKey[txKey{tx.BlockIdentifier.Index, tx.Transaction.TransactionIdentifier.Hash, index}] = len(txs)
+		txs = append(txs, tx)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT t.block, b.block_hash, t.txn_hash, t.txn_index, t.method, t.sender, t.nonce, t.fee_amount::TEXT, t.code
+			FROM %[1]s.transactions AS t
+			JOIN %[1]s.blocks AS b ON b.height = t.block
+			%[2]s`,
+		chain.Schema, cond), args...); err != nil {
+		return nil, err
+	}
+	if len(txs) == 0 {
+		return txs, nil
+	}
+
+	// Load the events of the transactions, and map them to operations.
+	// Events are filtered by block as well as hash, so that they are
+	// looked up by index in the partitions of the blocks.
+	keys := make([]string, 0, len(txs))
+	params := make([]interface{}, 0, 2*len(txs))
+	for _, tx := range txs {
+		params = append(params, tx.BlockIdentifier.Index, tx.Transaction.TransactionIdentifier.Hash)
+		keys = append(keys, fmt.Sprintf("($%d, $%d)", len(params)-1, len(params)))
+	}
+	events := make(map[txKey][]event)
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		var key txKey
+		var e event
+		if err := rows.Scan(&key.block, &key.hash, &key.index, &e.typ, &e.body); err != nil {
+			return err
+		}
+		events[key] = append(events[key], e)
+		return nil
+	}, fmt.Sprintf(`
+		SELECT txn_block, txn_hash, txn_index, type, body::TEXT
+			FROM %s.events
+			WHERE backend = 'staking' AND (txn_block, txn_hash) IN (%s)
+			ORDER BY txn_block, txn_index`,
+		chain.Schema, strings.Join(keys, ", ")), params...); err != nil {
+		return nil, err
+	}
+	for key, es := range events {
+		i, ok := byKey[key]
+		if !ok {
+			continue
+		}
+		ops, err := operations(es)
+		if err != nil {
+			c.logger.Info("malformed event",
+				"err", err.Error(),
+			)
+			return nil, ErrStorageError
+		}
+		txs[i].Transaction.Operations = ops
+	}
+	return txs, nil
+}
+
+// NetworkStatus returns the status of the provided chain.
+func (c *storageClient) NetworkStatus(ctx context.Context, chain *analyzer.Chain) (*NetworkStatusResponse, *Error) {
+	current, err := c.findBlock(ctx, chain, "ORDER BY height DESC")
+	if err != nil {
+		return nil, err
+	}
+	oldest, err := c.findBlock(ctx, chain, "ORDER BY height ASC")
+	if err != nil {
+		return nil, err
+	}
+	if current == nil || oldest == nil {
+		return nil, ErrBlockNotFound
+	}
+	genesis, err := c.findBlock(ctx, chain, "WHERE height = $1", chain.Range.From)
+	if err != nil {
+		return nil, err
+	}
+	if genesis == nil {
+		genesis = oldest
+	}
+	return &NetworkStatusResponse{
+		CurrentBlockIdentifier: current.identifier(),
+		CurrentBlockTimestamp:  current.time.UnixMilli(),
+		GenesisBlockIdentifier: genesis.identifier(),
+		OldestBlockIdentifier:  oldest.identifier(),
+		Peers:                  []Peer{},
+	}, nil
+}
+
+// Block returns the identified block, with its transactions.
+func (c *storageClient) Block(ctx context.Context, chain *analyzer.Chain, id PartialBlockIdentifier) (*Block, *Error) {
+	b, err := c.resolveBlock(ctx, chain, id)
+	if err != nil {
+		return nil, err
+	}
+	ctx = storage.WithMinHeight(ctx, chain.Schema, b.height)
+
+	parent, err := c.findBlock(ctx, chain, "WHERE height = $1", b.height-1)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		// The parent of the oldest block is itself.
+		parent = b
+	}
+	txs, err := c.transactions(ctx, chain, "WHERE t.block = $1 ORDER BY t.txn_index", b.height)
+	if err != nil {
+		return nil, err
+	}
+
+	block := Block{
+		BlockIdentifier:       b.identifier(),
+		ParentBlockIdentifier: parent.identifier(),
+		Timestamp:             b.time.UnixMilli(),
+		Transactions:          make([]Transaction, 0, len(txs)),
+	}
+	for _, tx := range txs {
+		block.Transactions = append(block.Transactions, tx.Transaction)
+	}
+	return &block, nil
+}
+
+// BlockTransaction returns the identified transaction of the identified block.
+func (c *storageClient) BlockTransaction(ctx context.Context, chain *analyzer.Chain, bid BlockIdentifier, tid TransactionIdentifier) (*Transaction, *Error) {
+	b, err := c.resolveBlock(ctx, chain, PartialBlockIdentifier{Index: &bid.Index, Hash: &bid.Hash})
+	if err != nil {
+		return nil, err
+	}
+	ctx = storage.WithMinHeight(ctx, chain.Schema, b.height)
+
+	txs, err := c.transactions(ctx, chain, "WHERE t.block = $1 AND t.txn_hash = $2", b.height, tid.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(txs) == 0 {
+		return nil, ErrTransactionNotFound
+	}
+	return &txs[0].Transaction, nil
+}
+
+// AccountBalance returns the balance of the identified account at the
+// identified block, from the recorded balance history.
+func (c *storageClient) AccountBalance(ctx context.Context, chain *analyzer.Chain, account AccountIdentifier, at PartialBlockIdentifier) (*AccountBalanceResponse, *Error) {
+	column := "general_balance"
+	if account.SubAccount != nil {
+		switch account.SubAccount.Address {
+		case SubAccountEscrow:
+			column = "escrow_balance_active"
+		case SubAccountDebonding:
+			column = "escrow_balance_debonding"
+		default:
+			return nil, ErrInvalidSubAccount
+		}
+	}
+
+	b, err := c.resolveBlock(ctx, chain, at)
+	if err != nil {
+		return nil, err
+	}
+	ctx = storage.WithMinHeight(ctx, chain.Schema, b.height)
+
+	// Balances are recorded from the oldest recorded height onwards.
+	var oldest *int64
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		return rows.Scan(&oldest)
+	}, fmt.Sprintf(`
+		SELECT MIN(height)
+			FROM %s.account_balance_history`,
+		chain.Schema)); err != nil {
+		return nil, err
+	}
+	if oldest == nil || b.height < *oldest {
+		return nil, ErrBalanceUnavailable
+	}
+
+	balance := "0"
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		return rows.Scan(&balance)
+	}, fmt.Sprintf(`
+		SELECT %s::TEXT
+			FROM %s.account_balance_history
+			WHERE address = $1 AND height <= $2
+			ORDER BY height DESC
+			LIMIT 1`,
+		column, chain.Schema), account.Address, b.height); err != nil {
+		return nil, err
+	}
+	return &AccountBalanceResponse{
+		BlockIdentifier: b.identifier(),
+		Balances:        []Amount{{Value: balance, Currency: Native}},
+	}, nil
+}
+
+// SearchTransactions returns the transactions matching the provided
+// search, latest first.
+func (c *storageClient) SearchTransactions(ctx context.Context, chain *analyzer.Chain, req *SearchTransactionsRequest) (*SearchTransactionsResponse, *Error) {
+	operator := " AND "
+	if req.Operator != nil {
+		switch *req.Operator {
+		case "and":
+		case "or":
+			operator = " OR "
+		default:
+			return nil, ErrInvalidRequest
+		}
+	}
+	limit, offset := int64(defaultSearchLimit), int64(0)
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+	if req.Offset != nil {
+		offset = *req.Offset
+	}
+	if limit < 0 || limit > maxSearchLimit || offset < 0 {
+		return nil, ErrInvalidRequest
+	}
+
+	var conds []string
+	var args []interface{}
+	param := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	if req.TransactionIdentifier != nil {
+		conds = append(conds, "t.txn_hash = "+param(req.TransactionIdentifier.Hash))
+	}
+	address := req.Address
+	if req.AccountIdentifier != nil {
+		address = &req.AccountIdentifier.Address
+	}
+	if address != nil {
+		p := param(*address)
+		conds = append(conds, fmt.Sprintf(`(t.sender = %[2]s OR EXISTS (
+			SELECT 1 FROM %[1]s.events AS e
+				WHERE e.txn_block = t.block AND e.txn_hash = t.txn_hash AND e.related_accounts @> ARRAY[%[2]s::TEXT]))`,
+			chain.Schema, p))
+	}
+	if req.Type != nil {
+		conds = append(conds, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM %s.events AS e
+				WHERE e.txn_block = t.block AND e.txn_hash = t.txn_hash AND e.type = %s)`,
+			chain.Schema, param(*req.Type)))
+	}
+	if req.Success != nil {
+		if *req.Success {
+			conds = append(conds, "t.code = 0")
+		} else {
+			conds = append(conds, "t.code <> 0")
+		}
+	}
+	if req.Status != nil && *req.Status != StatusOK {
+		// All operations are successful.
+		return nil, ErrInvalidRequest
+	}
+
+	where := ""
+	if len(conds) > 0 {
+		where = "(" + strings.Join(conds, operator) + ")"
+	}
+	if req.MaxBlock != nil {
+		ctx = storage.WithMinHeight(ctx, chain.Schema, *req.MaxBlock)
+		if where != "" {
+			where += " AND "
+		}
+		where += "t.block <= " + param(*req.MaxBlock)
+	}
+	if where != "" {
+		where = "WHERE " + where
+	}
+
+	var total int64
+	if err := c.query(ctx, func(rows storage.QueryResults) error {
+		return rows.Scan(&total)
+	}, fmt.Sprintf(`
+		SELECT COUNT(*)
+			FROM %s.transactions AS t
+			%s`,
+		chain.Schema, where), args...); err != nil {
+		return nil, err
+	}
+
+	txs, err := c.transactions(ctx, chain, fmt.Sprintf(`%s
+			ORDER BY t.block DESC, t.txn_index
+			LIMIT %s OFFSET %s`,
+		where, param(limit), param(offset)), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := SearchTransactionsResponse{
+		Transactions: txs,
+		TotalCount:   total,
+	}
+	if next := offset + int64(len(txs)); next < total {
+		resp.NextOffset = &next
+	}
+	return &resp, nil
+}
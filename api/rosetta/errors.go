@@ -0,0 +1,46 @@
+package rosetta
+
+// Error is a Rosetta error.
+type Error struct {
+	Code      int32  `json:"code"`
+	Message   string `json:"message"`
+	Retriable bool   `json:"retriable"`
+}
+
+// Error implements error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+var (
+	// ErrInvalidRequest is returned for malformed requests.
+	ErrInvalidRequest = &Error{Code: 1, Message: "invalid request"}
+	// ErrInvalidNetwork is returned for requests about unknown networks.
+	ErrInvalidNetwork = &Error{Code: 2, Message: "invalid network identifier"}
+	// ErrBlockNotFound is returned when the requested block is not indexed.
+	ErrBlockNotFound = &Error{Code: 3, Message: "block not found"}
+	// ErrBlockPruned is returned when the requested block has been pruned.
+	ErrBlockPruned = &Error{Code: 4, Message: "block has been pruned"}
+	// ErrTransactionNotFound is returned when the requested transaction
+	// is not indexed.
+	ErrTransactionNotFound = &Error{Code: 5, Message: "transaction not found"}
+	// ErrBalanceUnavailable is returned when balances are requested at a
+	// height below the oldest recorded balances.
+	ErrBalanceUnavailable = &Error{Code: 6, Message: "balance unavailable at the requested block"}
+	// ErrInvalidSubAccount is returned for requests about unknown sub-accounts.
+	ErrInvalidSubAccount = &Error{Code: 7, Message: "invalid sub-account"}
+	// ErrStorageError is returned when the underlying storage fails.
+	ErrStorageError = &Error{Code: 8, Message: "internal storage error", Retriable: true}
+)
+
+// Errors are all errors returned by the API.
+var Errors = []*Error{
+	ErrInvalidRequest,
+	ErrInvalidNetwork,
+	ErrBlockNotFound,
+	ErrBlockPruned,
+	ErrTransactionNotFound,
+	ErrBalanceUnavailable,
+	ErrInvalidSubAccount,
+	ErrStorageError,
+}
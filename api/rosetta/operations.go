@@ -0,0 +1,139 @@
+package rosetta
+
+import (
+	"encoding/json"
+
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+)
+
+const (
+	// SubAccountEscrow is the sub-account of an account's active escrow.
+	SubAccountEscrow = "escrow"
+	// SubAccountDebonding is the sub-account of an account's debonding escrow.
+	SubAccountDebonding = "debonding_escrow"
+
+	// StatusOK is the status of operations. As operations are mapped from
+	// the events of their effects, all operations are successful.
+	StatusOK = "OK"
+)
+
+// Native is the native currency, in base units.
+var Native = Currency{
+	Symbol:   "ROSE",
+	Decimals: 9,
+}
+
+var (
+	typeTransfer       = eventType(analyzer.EventStakingTransfer)
+	typeBurn           = eventType(analyzer.EventStakingBurn)
+	typeAddEscrow      = eventType(analyzer.EventStakingAddEscrow)
+	typeTakeEscrow     = eventType(analyzer.EventStakingTakeEscrow)
+	typeDebondingStart = eventType(analyzer.EventStakingDebondingStart)
+	typeReclaimEscrow  = eventType(analyzer.EventStakingReclaimEscrow)
+)
+
+// OperationTypes are the types of operations, which are the types of
+// the staking events they are mapped from.
+var OperationTypes = []string{
+	typeTransfer,
+	typeBurn,
+	typeAddEscrow,
+	typeTakeEscrow,
+	typeDebondingStart,
+	typeReclaimEscrow,
+}
+
+// eventType returns the type of the provided event, as indexed.
+func eventType(e analyzer.Event) string {
+	return e.String()
+}
+
+// event is an indexed event of a transaction.
+type event struct {
+	typ  string
+	body string
+}
+
+// operationBuilder builds the operations of a transaction.
+type operationBuilder struct {
+	ops []Operation
+}
+
+// add adds an operation changing the balance of the provided account by
+// the provided amount, negated if debited. The operation is related to
+// the previous one if related is true.
+func (b *operationBuilder) add(typ string, address staking.Address, subAccount string, amount quantity.Quantity, debit bool, related bool) {
+	value := amount.String()
+	if debit && !amount.IsZero() {
+		value = "-" + value
+	}
+	op := Operation{
+		OperationIdentifier: OperationIdentifier{Index: int64(len(b.ops))},
+		Type:                typ,
+		Status:              StatusOK,
+		Account:             AccountIdentifier{Address: address.String()},
+		Amount:              Amount{Value: value, Currency: Native},
+	}
+	if subAccount != "" {
+		op.Account.SubAccount = &SubAccountIdentifier{Address: subAccount}
+	}
+	if related {
+		op.RelatedOperations = []OperationIdentifier{{Index: int64(len(b.ops) - 1)}}
+	}
+	b.ops = append(b.ops, op)
+}
+
+// operations maps the staking events of a transaction to operations.
+// Escrowed balances are held by the escrow account, in its escrow
+// sub-accounts. Events of other backends are omitted.
+func operations(events []event) ([]Operation, error) {
+	b := operationBuilder{ops: []Operation{}}
+	for _, e := range events {
+		switch e.typ {
+		case typeTransfer:
+			var t staking.TransferEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.From, "", t.Amount, true, false)
+			b.add(e.typ, t.To, "", t.Amount, false, true)
+		case typeBurn:
+			var t staking.BurnEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.Owner, "", t.Amount, true, false)
+		case typeAddEscrow:
+			var t staking.AddEscrowEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.Owner, "", t.Amount, true, false)
+			b.add(e.typ, t.Escrow, SubAccountEscrow, t.Amount, false, true)
+		case typeTakeEscrow:
+			var t staking.TakeEscrowEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.Owner, SubAccountEscrow, t.Amount, true, false)
+		case typeDebondingStart:
+			var t staking.DebondingStartEscrowEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.Escrow, SubAccountEscrow, t.Amount, true, false)
+			b.add(e.typ, t.Escrow, SubAccountDebonding, t.Amount, false, true)
+		case typeReclaimEscrow:
+			var t staking.ReclaimEscrowEvent
+			if err := json.Unmarshal([]byte(e.body), &t); err != nil {
+				return nil, err
+			}
+			b.add(e.typ, t.Escrow, SubAccountDebonding, t.Amount, true, false)
+			b.add(e.typ, t.Owner, "", t.Amount, false, true)
+		}
+	}
+	return b.ops, nil
+}
@@ -0,0 +1,258 @@
+// Package rosetta implements the Rosetta Data API of the Oasis Indexer,
+// which serves indexed blocks, transactions and balances in the format
+// expected by exchanges and other Rosetta clients.
+//
+// Operations are mapped from the staking events of transactions, so
+// balance changes without a transaction, e.g. rewards and slashing, are
+// not included in blocks. Balances are served from the recorded balance
+// history, which accounts for all changes.
+package rosetta
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oasisprotocol/oasis-core/go/common/version"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	moduleName = "api_rosetta"
+
+	// maxRequestBytes is the maximum size of a request body.
+	maxRequestBytes = 1 << 20
+
+	// Blockchain is the blockchain of all networks served.
+	Blockchain = "Oasis"
+
+	// RosettaVersion is the version of the Rosetta specification implemented.
+	RosettaVersion = "1.4.10"
+)
+
+// Handler is the Oasis Indexer Rosetta API handler.
+type Handler struct {
+	client  *storageClient
+	chains  *analyzer.ChainRegistry
+	logger  *log.Logger
+	metrics metrics.RequestMetrics
+}
+
+// NewHandler creates a new Rosetta API handler.
+func NewHandler(db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
+	h := newHandler(db, chains, l)
+	h.metrics = metrics.NewDefaultRequestMetrics(moduleName)
+	return h
+}
+
+// newHandler creates a new Rosetta API handler without metrics.
+func newHandler(db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
+	logger := l.WithModule(moduleName)
+	return &Handler{
+		client: &storageClient{db, logger},
+		chains: chains,
+		logger: logger,
+	}
+}
+
+// RegisterMiddlewares implements the APIHandler interface.
+func (h *Handler) RegisterMiddlewares(r chi.Router) {}
+
+// RegisterRoutes implements the APIHandler interface.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	r.Route("/rosetta", func(r chi.Router) {
+		r.Post("/network/list", h.NetworkList)
+		r.Post("/network/options", h.NetworkOptions)
+		r.Post("/network/status", h.NetworkStatus)
+		r.Post("/block", h.Block)
+		r.Post("/block/transaction", h.BlockTransaction)
+		r.Post("/account/balance", h.AccountBalance)
+		r.Post("/search/transactions", h.SearchTransactions)
+	})
+}
+
+// Name implements the APIHandler interface.
+func (h *Handler) Name() string {
+	return "rosetta"
+}
+
+// NetworkList serves /network/list.
+func (h *Handler) NetworkList(w http.ResponseWriter, r *http.Request) {
+	var req struct{}
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		resp := NetworkListResponse{NetworkIdentifiers: []NetworkIdentifier{}}
+		for _, chain := range h.chains.All() {
+			resp.NetworkIdentifiers = append(resp.NetworkIdentifiers, networkIdentifier(chain))
+		}
+		return &resp, nil
+	})
+}
+
+// NetworkOptions serves /network/options.
+func (h *Handler) NetworkOptions(w http.ResponseWriter, r *http.Request) {
+	var req NetworkRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		if _, err := h.chain(req.NetworkIdentifier); err != nil {
+			return nil, err
+		}
+		return &NetworkOptionsResponse{
+			Version: Version{
+				RosettaVersion: RosettaVersion,
+				NodeVersion:    version.ConsensusProtocol.String(),
+			},
+			Allow: Allow{
+				OperationStatuses:       []OperationStatus{{Status: StatusOK, Successful: true}},
+				OperationTypes:          OperationTypes,
+				Errors:                  Errors,
+				HistoricalBalanceLookup: true,
+				CallMethods:             []string{},
+				BalanceExemptions:       []interface{}{},
+			},
+		}, nil
+	})
+}
+
+// NetworkStatus serves /network/status.
+func (h *Handler) NetworkStatus(w http.ResponseWriter, r *http.Request) {
+	var req NetworkRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		chain, err := h.chain(req.NetworkIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return h.client.NetworkStatus(ctx, chain)
+	})
+}
+
+// Block serves /block.
+func (h *Handler) Block(w http.ResponseWriter, r *http.Request) {
+	var req BlockRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		chain, err := h.chain(req.NetworkIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		block, err := h.client.Block(ctx, chain, req.BlockIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return &BlockResponse{Block: *block}, nil
+	})
+}
+
+// BlockTransaction serves /block/transaction.
+func (h *Handler) BlockTransaction(w http.ResponseWriter, r *http.Request) {
+	var req BlockTransactionRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		chain, err := h.chain(req.NetworkIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := h.client.BlockTransaction(ctx, chain, req.BlockIdentifier, req.TransactionIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return &BlockTransactionResponse{Transaction: *tx}, nil
+	})
+}
+
+// AccountBalance serves /account/balance.
+func (h *Handler) AccountBalance(w http.ResponseWriter, r *http.Request) {
+	var req AccountBalanceRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		chain, err := h.chain(req.NetworkIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		if req.AccountIdentifier.Address == "" {
+			return nil, ErrInvalidRequest
+		}
+		var at PartialBlockIdentifier
+		if req.BlockIdentifier != nil {
+			at = *req.BlockIdentifier
+		}
+		return h.client.AccountBalance(ctx, chain, req.AccountIdentifier, at)
+	})
+}
+
+// SearchTransactions serves /search/transactions.
+func (h *Handler) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	var req SearchTransactionsRequest
+	h.serve(w, r, &req, func(ctx context.Context) (interface{}, *Error) {
+		chain, err := h.chain(req.NetworkIdentifier)
+		if err != nil {
+			return nil, err
+		}
+		return h.client.SearchTransactions(ctx, chain, &req)
+	})
+}
+
+// networkIdentifier returns the identifier of the provided chain.
+func networkIdentifier(chain *analyzer.Chain) NetworkIdentifier {
+	network := chain.ChainContext
+	if network == "" {
+		network = chain.ID.String()
+	}
+	return NetworkIdentifier{
+		Blockchain: Blockchain,
+		Network:    network,
+	}
+}
+
+// chain returns the chain identified by the provided network identifier.
+func (h *Handler) chain(id NetworkIdentifier) (*analyzer.Chain, *Error) {
+	for _, chain := range h.chains.All() {
+		if networkIdentifier(chain) == id {
+			return chain, nil
+		}
+	}
+	return nil, ErrInvalidNetwork
+}
+
+// serve decodes the JSON request body into req, and replies with the
+// result of f. Rosetta errors are replied with a 500 status code, as
+// required by the specification.
+func (h *Handler) serve(w http.ResponseWriter, r *http.Request, req interface{}, f func(context.Context) (interface{}, *Error)) {
+	timer := h.metrics.RequestTimer(r.URL.Path)
+	defer timer.ObserveDuration()
+
+	var resp interface{}
+	var rerr *Error
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(req); err != nil {
+		rerr = ErrInvalidRequest
+	} else {
+		resp, rerr = f(r.Context())
+	}
+
+	status := http.StatusOK
+	if rerr != nil {
+		resp = rerr
+		status = http.StatusInternalServerError
+		h.metrics.RequestCounter(r.URL.Path, "failure", "rosetta_error").Inc()
+	}
+	body, err := json.Marshal(resp)
+	if err != nil {
+		h.logger.Error("failed to marshal response",
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(body); err != nil {
+		h.logger.Error("failed to write response",
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else if rerr == nil {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
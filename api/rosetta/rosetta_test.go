@@ -0,0 +1,278 @@
+package rosetta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+// testMetrics are shared by test handlers, as metrics are registered globally.
+var testMetrics = metrics.NewDefaultRequestMetrics("api_rosetta_test")
+
+var (
+	alice = staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001")).String()
+	bob   = staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002")).String()
+	val   = staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000003")).String()
+)
+
+// newTestServer creates a server of the Rosetta API of a new SQLite
+// database populated with test data, and the network identifier of
+// its chain.
+func newTestServer(t *testing.T) (*httptest.Server, NetworkIdentifier) {
+	logger := log.NewDefaultLogger("rosetta-test")
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	client, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	t.Cleanup(client.Shutdown)
+
+	batch := &storage.QueryBatch{}
+	for _, q := range []string{
+		`INSERT INTO oasis_3.blocks (height, block_hash, time, namespace, version, type, root_hash) VALUES
+			(10, 'h10', '2022-04-11 08:30:00', 'ns', 1, 'state', 'root'),
+			(11, 'h11', '2022-04-11 08:30:06', 'ns', 1, 'state', 'root'),
+			(12, 'h12', '2022-04-11 08:30:12', 'ns', 1, 'state', 'root')`,
+		fmt.Sprintf(`INSERT INTO oasis_3.transactions (block, txn_hash, txn_index, nonce, fee_amount, method, sender, code) VALUES
+			(11, 'a', 0, 1, 100, 'staking.Transfer', '%[1]s', 0),
+			(11, 'b', 1, 2, 100, 'staking.Transfer', '%[1]s', 1),
+			(12, 'c', 0, 1, NULL, 'staking.AddEscrow', '%[2]s', 0),
+			(10, 'c', 0, 0, 100, 'staking.Transfer', '%[1]s', 0)`, alice, bob),
+		// The hash of c is included in two blocks, and an event of b
+		// mentions bob without bob being a party to it.
+		fmt.Sprintf(`INSERT INTO oasis_3.events (backend, type, body, txn_block, txn_hash, txn_index, related_accounts) VALUES
+			('staking', 'Transfer', '{"from":"%[1]s","to":"%[2]s","amount":"1000"}', 11, 'a', 0, '["%[1]s","%[2]s"]'),
+			('core', 'GasUsed', '{"amount":10}', 11, 'a', 0, '[]'),
+			('core', 'Message', '{"note":"%[2]s"}', 11, 'b', 1, '[]'),
+			('staking', 'AddEscrow', '{"owner":"%[2]s","escrow":"%[3]s","amount":"500","new_shares":"500"}', 12, 'c', 0, '["%[2]s","%[3]s"]'),
+			('staking', 'Transfer', '{"from":"%[1]s","to":"%[3]s","amount":"7"}', 10, 'c', 0, '["%[1]s","%[3]s"]')`, alice, bob, val),
+		fmt.Sprintf(`INSERT INTO oasis_3.account_balance_history (address, height, general_balance, escrow_balance_active, escrow_balance_debonding) VALUES
+			('%[1]s', 10, 5000, 0, 0),
+			('%[2]s', 10, 0, 0, 0),
+			('%[1]s', 11, 4000, 0, 0),
+			('%[2]s', 11, 1000, 0, 0),
+			('%[2]s', 12, 500, 0, 0),
+			('%[3]s', 12, 0, 500, 0)`, alice, bob, val),
+		`INSERT INTO oasis_3.retention_horizons (table_name, height, pruned_time) VALUES ('blocks', 5, '2022-04-11 08:31:00')`,
+	} {
+		batch.Queue(q)
+	}
+	require.Nil(t, client.SendBatch(context.Background(), batch))
+
+	h := newHandler(client, chains, logger)
+	h.metrics = testMetrics
+	r := chi.NewRouter()
+	h.RegisterRoutes(r)
+	s := httptest.NewServer(r)
+	t.Cleanup(s.Close)
+	return s, networkIdentifier(chain)
+}
+
+// post posts the provided request to the provided endpoint, and decodes
+// the response into resp. It returns the Rosetta error replied, if any.
+func post(t *testing.T, s *httptest.Server, path string, req interface{}, resp interface{}) *Error {
+	body, err := json.Marshal(req)
+	require.Nil(t, err)
+	r, err := http.Post(s.URL+"/rosetta"+path, "application/json", bytes.NewReader(body))
+	require.Nil(t, err)
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusOK {
+		require.Equal(t, http.StatusInternalServerError, r.StatusCode)
+		var e Error
+		require.Nil(t, json.NewDecoder(r.Body).Decode(&e))
+		return &e
+	}
+	require.Nil(t, json.NewDecoder(r.Body).Decode(resp))
+	return nil
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+func TestNetwork(t *testing.T) {
+	s, network := newTestServer(t)
+
+	var list NetworkListResponse
+	require.Nil(t, post(t, s, "/network/list", struct{}{}, &list))
+	require.Contains(t, list.NetworkIdentifiers, network)
+
+	var options NetworkOptionsResponse
+	require.Nil(t, post(t, s, "/network/options", NetworkRequest{network}, &options))
+	require.Equal(t, RosettaVersion, options.Version.RosettaVersion)
+	require.Equal(t, OperationTypes, options.Allow.OperationTypes)
+	require.True(t, options.Allow.HistoricalBalanceLookup)
+
+	var status NetworkStatusResponse
+	require.Nil(t, post(t, s, "/network/status", NetworkRequest{network}, &status))
+	require.Equal(t, BlockIdentifier{Index: 12, Hash: "h12"}, status.CurrentBlockIdentifier)
+	require.Equal(t, BlockIdentifier{Index: 10, Hash: "h10"}, status.OldestBlockIdentifier)
+	require.Equal(t, BlockIdentifier{Index: 10, Hash: "h10"}, status.GenesisBlockIdentifier)
+
+	unknown := NetworkIdentifier{Blockchain: Blockchain, Network: "unknown"}
+	require.Equal(t, ErrInvalidNetwork.Code, post(t, s, "/network/status", NetworkRequest{unknown}, &status).Code)
+}
+
+func TestBlock(t *testing.T) {
+	s, network := newTestServer(t)
+
+	var resp BlockResponse
+	require.Nil(t, post(t, s, "/block", BlockRequest{
+		NetworkIdentifier: network,
+		BlockIdentifier:   PartialBlockIdentifier{Index: int64Ptr(11)},
+	}, &resp))
+	require.Equal(t, BlockIdentifier{Index: 11, Hash: "h11"}, resp.Block.BlockIdentifier)
+	require.Equal(t, BlockIdentifier{Index: 10, Hash: "h10"}, resp.Block.ParentBlockIdentifier)
+	require.Len(t, resp.Block.Transactions, 2)
+
+	transfer := resp.Block.Transactions[0]
+	require.Equal(t, "a", transfer.TransactionIdentifier.Hash)
+	require.True(t, transfer.Metadata.Success)
+	require.Equal(t, "100", transfer.Metadata.Fee)
+	require.Len(t, transfer.Operations, 2)
+	require.Equal(t, alice, transfer.Operations[0].Account.Address)
+	require.Equal(t, "-1000", transfer.Operations[0].Amount.Value)
+	require.Equal(t, bob, transfer.Operations[1].Account.Address)
+	require.Equal(t, "1000", transfer.Operations[1].Amount.Value)
+	require.Equal(t, []OperationIdentifier{{Index: 0}}, transfer.Operations[1].RelatedOperations)
+
+	failed := resp.Block.Transactions[1]
+	require.False(t, failed.Metadata.Success)
+	require.Empty(t, failed.Operations)
+
+	// Operations are those of the transaction in the requested block,
+	// although its hash is included in another block.
+	require.Nil(t, post(t, s, "/block", BlockRequest{
+		NetworkIdentifier: network,
+		BlockIdentifier:   PartialBlockIdentifier{Index: int64Ptr(10)},
+	}, &resp))
+	require.Len(t, resp.Block.Transactions, 1)
+	require.Len(t, resp.Block.Transactions[0].Operations, 2)
+	require.Equal(t, "-7", resp.Block.Transactions[0].Operations[0].Amount.Value)
+
+	// The latest block is served if no block is identified.
+	require.Nil(t, post(t, s, "/block", BlockRequest{NetworkIdentifier: network}, &resp))
+	require.Equal(t, int64(12), resp.Block.BlockIdentifier.Index)
+
+	require.Equal(t, ErrBlockPruned.Code, post(t, s, "/block", BlockRequest{
+		NetworkIdentifier: network,
+		BlockIdentifier:   PartialBlockIdentifier{Index: int64Ptr(4)},
+	}, &resp).Code)
+	require.Equal(t, ErrBlockNotFound.Code, post(t, s, "/block", BlockRequest{
+		NetworkIdentifier: network,
+		BlockIdentifier:   PartialBlockIdentifier{Index: int64Ptr(13)},
+	}, &resp).Code)
+}
+
+func TestBlockTransaction(t *testing.T) {
+	s, network := newTestServer(t)
+
+	var resp BlockTransactionResponse
+	require.Nil(t, post(t, s, "/block/transaction", BlockTransactionRequest{
+		NetworkIdentifier:     network,
+		BlockIdentifier:       BlockIdentifier{Index: 12, Hash: "h12"},
+		TransactionIdentifier: TransactionIdentifier{Hash: "c"},
+	}, &resp))
+	require.Len(t, resp.Transaction.Operations, 2)
+	require.Equal(t, bob, resp.Transaction.Operations[0].Account.Address)
+	require.Equal(t, "-500", resp.Transaction.Operations[0].Amount.Value)
+	require.Equal(t, val, resp.Transaction.Operations[1].Account.Address)
+	require.Equal(t, &SubAccountIdentifier{Address: SubAccountEscrow}, resp.Transaction.Operations[1].Account.SubAccount)
+
+	require.Equal(t, ErrTransactionNotFound.Code, post(t, s, "/block/transaction", BlockTransactionRequest{
+		NetworkIdentifier:     network,
+		BlockIdentifier:       BlockIdentifier{Index: 12, Hash: "h12"},
+		TransactionIdentifier: TransactionIdentifier{Hash: "a"},
+	}, &resp).Code)
+}
+
+func TestAccountBalance(t *testing.T) {
+	s, network := newTestServer(t)
+
+	for _, tt := range []struct {
+		account AccountIdentifier
+		height  *int64
+		index   int64
+		balance string
+	}{
+		{AccountIdentifier{Address: alice}, int64Ptr(10), 10, "5000"},
+		{AccountIdentifier{Address: alice}, int64Ptr(11), 11, "4000"},
+		{AccountIdentifier{Address: alice}, nil, 12, "4000"},
+		{AccountIdentifier{Address: bob}, int64Ptr(12), 12, "500"},
+		{AccountIdentifier{Address: val, SubAccount: &SubAccountIdentifier{SubAccountEscrow}}, int64Ptr(12), 12, "500"},
+		{AccountIdentifier{Address: val, SubAccount: &SubAccountIdentifier{SubAccountEscrow}}, int64Ptr(11), 11, "0"},
+	} {
+		req := AccountBalanceRequest{NetworkIdentifier: network, AccountIdentifier: tt.account}
+		if tt.height != nil {
+			req.BlockIdentifier = &PartialBlockIdentifier{Index: tt.height}
+		}
+		var resp AccountBalanceResponse
+		require.Nil(t, post(t, s, "/account/balance", req, &resp))
+		require.Equal(t, tt.index, resp.BlockIdentifier.Index)
+		require.Equal(t, []Amount{{Value: tt.balance, Currency: Native}}, resp.Balances)
+	}
+
+	var resp AccountBalanceResponse
+	require.Equal(t, ErrInvalidSubAccount.Code, post(t, s, "/account/balance", AccountBalanceRequest{
+		NetworkIdentifier: network,
+		AccountIdentifier: AccountIdentifier{Address: val, SubAccount: &SubAccountIdentifier{"unknown"}},
+	}, &resp).Code)
+}
+
+func TestSearchTransactions(t *testing.T) {
+	s, network := newTestServer(t)
+
+	search := func(req SearchTransactionsRequest) []string {
+		req.NetworkIdentifier = network
+		var resp SearchTransactionsResponse
+		require.Nil(t, post(t, s, "/search/transactions", req, &resp))
+		require.Equal(t, int64(len(resp.Transactions)), resp.TotalCount)
+		var hashes []string
+		for _, tx := range resp.Transactions {
+			hashes = append(hashes, tx.Transaction.TransactionIdentifier.Hash)
+		}
+		return hashes
+	}
+	or := "or"
+	success := false
+	typ := "AddEscrow"
+
+	require.Equal(t, []string{"c", "a", "b", "c"}, search(SearchTransactionsRequest{}))
+	require.Equal(t, []string{"a", "b", "c"}, search(SearchTransactionsRequest{MaxBlock: int64Ptr(11)}))
+	// Bob is the recipient of a, and the sender of c, but not a party to
+	// the event of b mentioning him.
+	require.Equal(t, []string{"c", "a"}, search(SearchTransactionsRequest{Address: &bob}))
+	require.Equal(t, []string{"c", "c"}, search(SearchTransactionsRequest{AccountIdentifier: &AccountIdentifier{Address: val}}))
+	require.Equal(t, []string{"c", "b"}, search(SearchTransactionsRequest{Operator: &or, Type: &typ, Success: &success}))
+	require.Empty(t, search(SearchTransactionsRequest{Type: &typ, Success: &success}))
+
+	var resp SearchTransactionsResponse
+	require.Nil(t, post(t, s, "/search/transactions", SearchTransactionsRequest{
+		NetworkIdentifier: network,
+		Limit:             int64Ptr(1),
+		Offset:            int64Ptr(1),
+	}, &resp))
+	require.Equal(t, int64(4), resp.TotalCount)
+	require.Equal(t, int64Ptr(2), resp.NextOffset)
+	require.Equal(t, "a", resp.Transactions[0].Transaction.TransactionIdentifier.Hash)
+}
@@ -0,0 +1,206 @@
+package rosetta
+
+// NetworkIdentifier identifies a network, i.e. a chain.
+type NetworkIdentifier struct {
+	Blockchain string `json:"blockchain"`
+	Network    string `json:"network"`
+}
+
+// BlockIdentifier identifies a block.
+type BlockIdentifier struct {
+	Index int64  `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+// PartialBlockIdentifier identifies a block by index, hash or both.
+// If neither is provided, it identifies the latest block.
+type PartialBlockIdentifier struct {
+	Index *int64  `json:"index,omitempty"`
+	Hash  *string `json:"hash,omitempty"`
+}
+
+// TransactionIdentifier identifies a transaction.
+type TransactionIdentifier struct {
+	Hash string `json:"hash"`
+}
+
+// AccountIdentifier identifies an account, or one of its sub-accounts.
+type AccountIdentifier struct {
+	Address    string                `json:"address"`
+	SubAccount *SubAccountIdentifier `json:"sub_account,omitempty"`
+}
+
+// SubAccountIdentifier identifies a sub-account, i.e. an escrow balance.
+type SubAccountIdentifier struct {
+	Address string `json:"address"`
+}
+
+// Currency is a currency.
+type Currency struct {
+	Symbol   string `json:"symbol"`
+	Decimals int32  `json:"decimals"`
+}
+
+// Amount is an amount of a currency in base units.
+type Amount struct {
+	Value    string   `json:"value"`
+	Currency Currency `json:"currency"`
+}
+
+// OperationIdentifier identifies an operation within a transaction.
+type OperationIdentifier struct {
+	Index int64 `json:"index"`
+}
+
+// Operation is a change of the balance of an account.
+type Operation struct {
+	OperationIdentifier OperationIdentifier   `json:"operation_identifier"`
+	RelatedOperations   []OperationIdentifier `json:"related_operations,omitempty"`
+	Type                string                `json:"type"`
+	Status              string                `json:"status"`
+	Account             AccountIdentifier     `json:"account"`
+	Amount              Amount                `json:"amount"`
+}
+
+// OperationStatus is a status of operations.
+type OperationStatus struct {
+	Status     string `json:"status"`
+	Successful bool   `json:"successful"`
+}
+
+// Transaction is a transaction and the operations it performed.
+type Transaction struct {
+	TransactionIdentifier TransactionIdentifier `json:"transaction_identifier"`
+	Operations            []Operation           `json:"operations"`
+	Metadata              TransactionMetadata   `json:"metadata"`
+}
+
+// TransactionMetadata is the Oasis-specific data of a transaction.
+type TransactionMetadata struct {
+	Method  string `json:"method"`
+	Sender  string `json:"sender"`
+	Nonce   uint64 `json:"nonce"`
+	Fee     string `json:"fee"`
+	Success bool   `json:"success"`
+}
+
+// Block is a block and its transactions.
+type Block struct {
+	BlockIdentifier       BlockIdentifier `json:"block_identifier"`
+	ParentBlockIdentifier BlockIdentifier `json:"parent_block_identifier"`
+	Timestamp             int64           `json:"timestamp"`
+	Transactions          []Transaction   `json:"transactions"`
+}
+
+// BlockTransaction is a transaction and the block including it.
+type BlockTransaction struct {
+	BlockIdentifier BlockIdentifier `json:"block_identifier"`
+	Transaction     Transaction     `json:"transaction"`
+}
+
+// Version is the version of the API and of the network.
+type Version struct {
+	RosettaVersion string `json:"rosetta_version"`
+	NodeVersion    string `json:"node_version"`
+}
+
+// Allow describes what the API supports.
+type Allow struct {
+	OperationStatuses       []OperationStatus `json:"operation_statuses"`
+	OperationTypes          []string          `json:"operation_types"`
+	Errors                  []*Error          `json:"errors"`
+	HistoricalBalanceLookup bool              `json:"historical_balance_lookup"`
+	CallMethods             []string          `json:"call_methods"`
+	BalanceExemptions       []interface{}     `json:"balance_exemptions"`
+	MempoolCoins            bool              `json:"mempool_coins"`
+}
+
+// Peer is a peer of the network.
+type Peer struct {
+	PeerID string `json:"peer_id"`
+}
+
+// NetworkRequest is a request about a network.
+type NetworkRequest struct {
+	NetworkIdentifier NetworkIdentifier `json:"network_identifier"`
+}
+
+// NetworkListResponse is the response to /network/list.
+type NetworkListResponse struct {
+	NetworkIdentifiers []NetworkIdentifier `json:"network_identifiers"`
+}
+
+// NetworkOptionsResponse is the response to /network/options.
+type NetworkOptionsResponse struct {
+	Version Version `json:"version"`
+	Allow   Allow   `json:"allow"`
+}
+
+// NetworkStatusResponse is the response to /network/status.
+type NetworkStatusResponse struct {
+	CurrentBlockIdentifier BlockIdentifier `json:"current_block_identifier"`
+	CurrentBlockTimestamp  int64           `json:"current_block_timestamp"`
+	GenesisBlockIdentifier BlockIdentifier `json:"genesis_block_identifier"`
+	OldestBlockIdentifier  BlockIdentifier `json:"oldest_block_identifier"`
+	Peers                  []Peer          `json:"peers"`
+}
+
+// BlockRequest is a request to /block.
+type BlockRequest struct {
+	NetworkIdentifier NetworkIdentifier      `json:"network_identifier"`
+	BlockIdentifier   PartialBlockIdentifier `json:"block_identifier"`
+}
+
+// BlockResponse is the response to /block.
+type BlockResponse struct {
+	Block Block `json:"block"`
+}
+
+// BlockTransactionRequest is a request to /block/transaction.
+type BlockTransactionRequest struct {
+	NetworkIdentifier     NetworkIdentifier     `json:"network_identifier"`
+	BlockIdentifier       BlockIdentifier       `json:"block_identifier"`
+	TransactionIdentifier TransactionIdentifier `json:"transaction_identifier"`
+}
+
+// BlockTransactionResponse is the response to /block/transaction.
+type BlockTransactionResponse struct {
+	Transaction Transaction `json:"transaction"`
+}
+
+// AccountBalanceRequest is a request to /account/balance.
+type AccountBalanceRequest struct {
+	NetworkIdentifier NetworkIdentifier       `json:"network_identifier"`
+	AccountIdentifier AccountIdentifier       `json:"account_identifier"`
+	BlockIdentifier   *PartialBlockIdentifier `json:"block_identifier,omitempty"`
+}
+
+// AccountBalanceResponse is the response to /account/balance.
+type AccountBalanceResponse struct {
+	BlockIdentifier BlockIdentifier `json:"block_identifier"`
+	Balances        []Amount        `json:"balances"`
+}
+
+// SearchTransactionsRequest is a request to /search/transactions.
+// Conditions are combined by the operator, which is either "and"
+// (the default) or "or".
+type SearchTransactionsRequest struct {
+	NetworkIdentifier     NetworkIdentifier      `json:"network_identifier"`
+	Operator              *string                `json:"operator,omitempty"`
+	MaxBlock              *int64                 `json:"max_block,omitempty"`
+	Offset                *int64                 `json:"offset,omitempty"`
+	Limit                 *int64                 `json:"limit,omitempty"`
+	TransactionIdentifier *TransactionIdentifier `json:"transaction_identifier,omitempty"`
+	AccountIdentifier     *AccountIdentifier     `json:"account_identifier,omitempty"`
+	Address               *string                `json:"address,omitempty"`
+	Type                  *string                `json:"type,omitempty"`
+	Status                *string                `json:"status,omitempty"`
+	Success               *bool                  `json:"success,omitempty"`
+}
+
+// SearchTransactionsResponse is the response to /search/transactions.
+type SearchTransactionsResponse struct {
+	Transactions []BlockTransaction `json:"transactions"`
+	TotalCount   int64              `json:"total_count"`
+	NextOffset   *int64             `json:"next_offset,omitempty"`
+}
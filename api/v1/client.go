@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -10,12 +11,14 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/iancoleman/strcase"
 	oasisErrors "github.com/oasisprotocol/oasis-core/go/common/errors"
 
 	beacon "github.com/oasisprotocol/oasis-core/go/beacon/api"
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
 	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
 	"github.com/oasislabs/oasis-indexer/analyzer/util"
 	"github.com/oasislabs/oasis-indexer/api/common"
 	"github.com/oasislabs/oasis-indexer/log"
@@ -36,18 +39,32 @@ func NewQueryBuilder(sql string, db storage.TargetStorage) *QueryBuilder {
 	return &QueryBuilder{inner, db}
 }
 
-// AddPagination adds pagination to the query builder.
-func (q *QueryBuilder) AddPagination(_ctx context.Context, p common.Pagination) error {
+// AddPagination adds pagination to the query builder, in descending order.
+func (q *QueryBuilder) AddPagination(ctx context.Context, p common.Pagination) error {
+	p.Order += " DESC"
+	return q.AddOrderedPagination(ctx, p)
+}
+
+// AddOrderedPagination adds pagination to the query builder, with an order
+// which spells out the direction of each column.
+func (q *QueryBuilder) AddOrderedPagination(_ctx context.Context, p common.Pagination) error {
 	_, err := q.inner.WriteString(
-		fmt.Sprintf("\n\tORDER BY %s DESC\n\tLIMIT %d\n\tOFFSET %d", p.Order, p.Limit, p.Offset),
+		fmt.Sprintf("\n\tORDER BY %s\n\tLIMIT %d\n\tOFFSET %d", p.Order, p.Limit, p.Offset),
 	)
 	return err
 }
 
 // AddTimestamp adds time travel to the query builder, at the time of the provided height.
+// It returns ErrPruned if the progress of the height has been compacted by pruning,
+// and ErrNotFound if the height has not been processed.
 func (q *QueryBuilder) AddTimestamp(ctx context.Context, height int64) error {
+	chainID, ok := ctx.Value(ChainIDContextKey).(string)
+	if !ok {
+		return common.ErrBadChainID
+	}
+
 	var processedTime time.Time
-	if err := q.db.QueryRow(
+	switch err := q.db.QueryRow(
 		ctx,
 		fmt.Sprintf(`
 			SELECT processed_time
@@ -55,9 +72,32 @@ func (q *QueryBuilder) AddTimestamp(ctx context.Context, height int64) error {
 				WHERE height = $1
 				ORDER BY processed_time DESC
 				LIMIT 1`,
-			strcase.ToSnake(LatestChainID)),
+			chainID),
 		height,
-	).Scan(&processedTime); err != nil {
+	).Scan(&processedTime); err {
+	case nil:
+	case storage.ErrNoRows:
+		// Pruning compacts the progress of processed heights to ranges,
+		// which have no time to travel to.
+		var compacted bool
+		if err := q.db.QueryRow(
+			ctx,
+			fmt.Sprintf(`
+				SELECT EXISTS (
+					SELECT 1
+						FROM %s.processed_block_ranges
+						WHERE $1 BETWEEN from_height AND to_height
+				)`,
+				chainID),
+			height,
+		).Scan(&compacted); err != nil {
+			return err
+		}
+		if compacted {
+			return common.ErrPruned
+		}
+		return common.ErrNotFound
+	default:
 		return err
 	}
 
@@ -65,6 +105,17 @@ func (q *QueryBuilder) AddTimestamp(ctx context.Context, height int64) error {
 	return err
 }
 
+// timestampError returns the error with which to respond to a request
+// for which adding time travel failed with the provided error.
+func timestampError(err error) error {
+	switch err {
+	case common.ErrPruned, common.ErrNotFound:
+		return err
+	default:
+		return common.ErrBadRequest
+	}
+}
+
 // AddFilters adds the provided filters to the query builder.
 func (q *QueryBuilder) AddFilters(_ctx context.Context, filters []string) error {
 	if len(filters) > 0 {
@@ -83,18 +134,75 @@ func (q *QueryBuilder) String() string {
 // with knowledge of network semantics.
 type storageClient struct {
 	db     storage.TargetStorage
+	chains *analyzer.ChainRegistry
 	logger *log.Logger
 }
 
 // newStorageClient creates a new storage client.
-func newStorageClient(db storage.TargetStorage, l *log.Logger) *storageClient {
-	return &storageClient{db, l}
+func newStorageClient(db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *storageClient {
+	return &storageClient{db, chains, l}
+}
+
+// checkRetention returns ErrPruned iff the provided height is below
+// the retention horizon of the provided table.
+func (c *storageClient) checkRetention(ctx context.Context, chainID string, table string, height int64) error {
+	var horizon int64
+	switch err := c.db.QueryRow(
+		ctx,
+		fmt.Sprintf(`
+			SELECT height
+				FROM %s.retention_horizons
+				WHERE table_name = $1`,
+			chainID),
+		table,
+	).Scan(&horizon); err {
+	case nil:
+	case storage.ErrNoRows:
+		// The table has never been pruned.
+		return nil
+	default:
+		c.logger.Info("row scan failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return common.ErrStorageError
+	}
+	if height < horizon {
+		return common.ErrPruned
+	}
+	return nil
+}
+
+// checkRetentionParams returns ErrPruned iff the height of any of the
+// provided query parameters is below the retention horizon of the
+// provided table.
+func (c *storageClient) checkRetentionParams(ctx context.Context, r *http.Request, chainID string, table string, params ...string) error {
+	for _, param := range params {
+		v := r.URL.Query().Get(param)
+		if v == "" {
+			continue
+		}
+		height, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.logger.Info("height parsing failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"param", param,
+				"err", err.Error(),
+			)
+			return common.ErrBadRequest
+		}
+		if err := c.checkRetention(ctx, chainID, table, height); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Status returns status information for the Oasis Indexer.
 func (c *storageClient) Status(ctx context.Context) (*Status, error) {
+	latest := c.chains.Latest()
 	s := Status{
-		LatestChainID: LatestChainID,
+		LatestChainID: latest.ID.String(),
 	}
 	if err := c.db.QueryRow(
 		ctx,
@@ -103,7 +211,7 @@ func (c *storageClient) Status(ctx context.Context) (*Status, error) {
 				FROM %s.processed_blocks
 				ORDER BY processed_time DESC
 				LIMIT 1`,
-			strcase.ToSnake(LatestChainID)),
+			latest.Schema),
 	).Scan(&s.LatestBlock, &s.LatestUpdate); err != nil {
 		c.logger.Info("row scan failed",
 			"request_id", ctx.Value(RequestIDContextKey),
@@ -121,6 +229,10 @@ func (c *storageClient) Blocks(ctx context.Context, r *http.Request) (*BlockList
 		return nil, common.ErrBadChainID
 	}
 
+	if err := c.checkRetentionParams(ctx, r, chainID, "blocks", "to"); err != nil {
+		return nil, err
+	}
+
 	qb := NewQueryBuilder(fmt.Sprintf(`
 			SELECT height, block_hash, time
 				FROM %s.blocks`,
@@ -200,6 +312,14 @@ func (c *storageClient) Block(ctx context.Context, r *http.Request) (*Block, err
 		return nil, common.ErrBadChainID
 	}
 
+	// Blocks must not be read from replicas which have not yet indexed them.
+	if height, err := strconv.ParseInt(chi.URLParam(r, "height"), 10, 64); err == nil {
+		if err := c.checkRetention(ctx, chainID, "blocks", height); err != nil {
+			return nil, err
+		}
+		ctx = storage.WithMinHeight(ctx, chainID, height)
+	}
+
 	var b Block
 	if err := c.db.QueryRow(
 		ctx,
@@ -228,6 +348,10 @@ func (c *storageClient) Transactions(ctx context.Context, r *http.Request) (*Tra
 		return nil, common.ErrBadChainID
 	}
 
+	if err := c.checkRetentionParams(ctx, r, chainID, "transactions", "block", "to"); err != nil {
+		return nil, err
+	}
+
 	qb := NewQueryBuilder(fmt.Sprintf(`
 			SELECT block, txn_hash, sender, nonce, fee_amount, method, body, code
 				FROM %s.transactions`,
@@ -238,6 +362,8 @@ func (c *storageClient) Transactions(ctx context.Context, r *http.Request) (*Tra
 	var filters []string
 	for param, condition := range map[string]string{
 		"block":  "block = %s",
+		"from":   "block >= %s",
+		"to":     "block <= %s",
 		"method": "method = '%s'",
 		"sender": "sender = '%s'",
 		"minFee": "fee_amount >= %s",
@@ -320,16 +446,33 @@ func (c *storageClient) Transaction(ctx context.Context, r *http.Request) (*Tran
 		return nil, common.ErrBadChainID
 	}
 
-	var t Transaction
-	var code uint64
-	if err := c.db.QueryRow(
-		ctx,
-		fmt.Sprintf(`
+	query := fmt.Sprintf(`
 			SELECT block, txn_hash, sender, nonce, fee_amount, method, body, code
 				FROM %s.transactions
-				WHERE txn_hash = $1::text`, chainID),
-		chi.URLParam(r, "txn_hash"),
-	).Scan(
+				WHERE txn_hash = $1::text`, chainID)
+	args := []interface{}{chi.URLParam(r, "txn_hash")}
+
+	// Lookups at a known height only scan the partition of that height.
+	if v := r.URL.Query().Get("height"); v != "" {
+		height, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.logger.Info("height parsing failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"err", err.Error(),
+			)
+			return nil, common.ErrBadRequest
+		}
+		if err := c.checkRetention(ctx, chainID, "transactions", height); err != nil {
+			return nil, err
+		}
+		query += " AND block = $2::bigint"
+		args = append(args, height)
+		ctx = storage.WithMinHeight(ctx, chainID, height)
+	}
+
+	var t Transaction
+	var code uint64
+	if err := c.db.QueryRow(ctx, query, args...).Scan(
 		&t.Height,
 		&t.Hash,
 		&t.Sender,
@@ -352,6 +495,126 @@ func (c *storageClient) Transaction(ctx context.Context, r *http.Request) (*Tran
 	return &t, nil
 }
 
+// Events returns a list of consensus events.
+func (c *storageClient) Events(ctx context.Context, r *http.Request) (*EventList, error) {
+	chainID, ok := ctx.Value(ChainIDContextKey).(string)
+	if !ok {
+		return nil, common.ErrBadChainID
+	}
+
+	if err := c.checkRetentionParams(ctx, r, chainID, "events", "block", "to"); err != nil {
+		return nil, err
+	}
+
+	qb := NewQueryBuilder(fmt.Sprintf(`
+			SELECT txn_block, txn_hash, txn_index, backend, type, body::TEXT
+				FROM %s.events`,
+		chainID), c.db)
+
+	params := r.URL.Query()
+
+	// Filter values are bound as query arguments.
+	var filters []string
+	var args []interface{}
+	for _, f := range []struct {
+		param     string
+		condition string
+		height    bool
+	}{
+		{"block", "txn_block = $%d::bigint", true},
+		{"from", "txn_block >= $%d::bigint", true},
+		{"to", "txn_block <= $%d::bigint", true},
+		{"tx_hash", "txn_hash = $%d::text", false},
+		{"backend", "backend = $%d::text", false},
+		{"type", "type = $%d::text", false},
+		{"rel", "related_accounts @> ARRAY[$%d::text]", false},
+	} {
+		v := params.Get(f.param)
+		if v == "" {
+			continue
+		}
+		if f.height {
+			height, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				c.logger.Info("height parsing failed",
+					"request_id", ctx.Value(RequestIDContextKey),
+					"err", err.Error(),
+				)
+				return nil, common.ErrBadRequest
+			}
+			args = append(args, height)
+		} else {
+			args = append(args, v)
+		}
+		filters = append(filters, fmt.Sprintf(f.condition, len(args)))
+	}
+	if err := qb.AddFilters(ctx, filters); err != nil {
+		c.logger.Info("filtering failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+
+	pagination, err := common.NewPagination(r)
+	if err != nil {
+		c.logger.Info("pagination failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+	// Latest events first, in reverse order of emission.
+	pagination.Order = "txn_block DESC, txn_index"
+	if err = qb.AddPagination(ctx, pagination); err != nil {
+		c.logger.Info("pagination add failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+
+	rows, err := c.db.Query(ctx, qb.String(), args...)
+	if err != nil {
+		c.logger.Info("query failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrStorageError
+	}
+	defer rows.Close()
+
+	es := EventList{
+		Events: []Event{},
+	}
+	for rows.Next() {
+		var e Event
+		var body *string
+		if err := rows.Scan(
+			&e.Height,
+			&e.TxHash,
+			&e.TxIndex,
+			&e.Backend,
+			&e.Type,
+			&body,
+		); err != nil {
+			c.logger.Info("row scan failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"err", err.Error(),
+			)
+			return nil, common.ErrStorageError
+		}
+		e.Body = json.RawMessage("null")
+		if body != nil {
+			e.Body = json.RawMessage(*body)
+		}
+
+		es.Events = append(es.Events, e)
+	}
+
+	return &es, nil
+}
+
 // Entities returns a list of registered entities.
 func (c *storageClient) Entities(ctx context.Context, r *http.Request) (*EntityList, error) {
 	chainID, ok := ctx.Value(ChainIDContextKey).(string)
@@ -369,7 +632,7 @@ func (c *storageClient) Entities(ctx context.Context, r *http.Request) (*EntityL
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -435,7 +698,7 @@ func (c *storageClient) Entity(ctx context.Context, r *http.Request) (*Entity, e
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -474,7 +737,7 @@ func (c *storageClient) Entity(ctx context.Context, r *http.Request) (*Entity, e
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -541,7 +804,7 @@ func (c *storageClient) EntityNodes(ctx context.Context, r *http.Request) (*Node
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -632,7 +895,7 @@ func (c *storageClient) EntityNode(ctx context.Context, r *http.Request) (*Node,
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -698,7 +961,7 @@ func (c *storageClient) Accounts(ctx context.Context, r *http.Request) (*Account
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -797,7 +1060,7 @@ func (c *storageClient) Account(ctx context.Context, r *http.Request) (*Account,
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -841,7 +1104,7 @@ func (c *storageClient) Account(ctx context.Context, r *http.Request) (*Account,
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -1459,7 +1722,7 @@ func (c *storageClient) Validators(ctx context.Context, r *http.Request) (*Valid
 					"request_id", ctx.Value(RequestIDContextKey),
 					"err", err.Error(),
 				)
-				return nil, common.ErrBadRequest
+				return nil, timestampError(err)
 			}
 		}
 	}
@@ -1532,3 +1795,469 @@ func (c *storageClient) Validators(ctx context.Context, r *http.Request) (*Valid
 
 	return &vs, nil
 }
+
+// searchQuery is a search query, classified by the kinds of identifiers
+// it may be.
+type searchQuery struct {
+	// height is set if the query is a block height or proposal ID.
+	height *int64
+	// hash is set if the query is a hex-encoded block or transaction hash.
+	hash string
+	// publicKey is set if the query is an entity or node public key,
+	// either base64 or hex-encoded.
+	publicKey string
+	// address is set if the query is a staking address, or a public key
+	// in which case it is the staking address of the key.
+	address string
+}
+
+// parseSearchQuery classifies the provided search query.
+func parseSearchQuery(q string) searchQuery {
+	var sq searchQuery
+	if v, err := strconv.ParseInt(q, 10, 64); err == nil && v >= 0 {
+		sq.height = &v
+	}
+
+	var pk signature.PublicKey
+	switch {
+	case pk.UnmarshalText([]byte(q)) == nil:
+		sq.publicKey = pk.String()
+	case pk.UnmarshalHex(q) == nil:
+		sq.hash = strings.ToLower(q)
+		sq.publicKey = pk.String()
+	}
+	if sq.publicKey != "" {
+		sq.address = staking.NewAddress(pk).String()
+	}
+
+	var address staking.Address
+	if strings.HasPrefix(q, "oasis1") && address.UnmarshalText([]byte(q)) == nil {
+		sq.address = address.String()
+	}
+	return sq
+}
+
+// search appends the results of the provided query to the provided
+// search results.
+func (c *storageClient) search(ctx context.Context, results *SearchResults, scan func(storage.QueryResults) (SearchResult, error), sql string, args ...interface{}) error {
+	rows, err := c.db.Query(ctx, sql, args...)
+	if err != nil {
+		c.logger.Info("query failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return common.ErrStorageError
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result, err := scan(rows)
+		if err != nil {
+			c.logger.Info("row scan failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"err", err.Error(),
+			)
+			return common.ErrStorageError
+		}
+		results.Results = append(results.Results, result)
+	}
+	return nil
+}
+
+// Search returns the blocks, transactions, accounts, entities, nodes
+// and proposals matching a search query.
+func (c *storageClient) Search(ctx context.Context, r *http.Request) (*SearchResults, error) {
+	chainID, ok := ctx.Value(ChainIDContextKey).(string)
+	if !ok {
+		return nil, common.ErrBadChainID
+	}
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		return nil, common.ErrBadRequest
+	}
+	sq := parseSearchQuery(q)
+
+	results := SearchResults{
+		Query:   q,
+		Results: []SearchResult{},
+	}
+	if sq.height != nil {
+		if err := c.search(ctx, &results, func(rows storage.QueryResults) (SearchResult, error) {
+			result := SearchResult{Type: "block"}
+			var height int64
+			if err := rows.Scan(&height); err != nil {
+				return result, err
+			}
+			result.ID = strconv.FormatInt(height, 10)
+			result.Height = &height
+			return result, nil
+		}, fmt.Sprintf(`
+			SELECT height
+				FROM %s.blocks
+				WHERE height = $1`,
+			chainID), *sq.height); err != nil {
+			return nil, err
+		}
+	}
+	if sq.hash != "" {
+		if err := c.search(ctx, &results, func(rows storage.QueryResults) (SearchResult, error) {
+			var result SearchResult
+			var height int64
+			if err := rows.Scan(&result.Type, &result.ID, &height); err != nil {
+				return result, err
+			}
+			result.Height = &height
+			return result, nil
+		}, fmt.Sprintf(`
+			SELECT 'block', CAST(height AS TEXT), height
+				FROM %[1]s.blocks
+				WHERE block_hash = $1
+			UNION ALL
+			SELECT 'transaction', txn_hash, block
+				FROM %[1]s.transactions
+				WHERE txn_hash = $1`,
+			chainID), sq.hash); err != nil {
+			return nil, err
+		}
+	}
+	if sq.address != "" {
+		if err := c.search(ctx, &results, func(rows storage.QueryResults) (SearchResult, error) {
+			result := SearchResult{Type: "account"}
+			if err := rows.Scan(&result.ID); err != nil {
+				return result, err
+			}
+			result.Address = result.ID
+			return result, nil
+		}, fmt.Sprintf(`
+			SELECT address
+				FROM %s.accounts
+				WHERE address = $1`,
+			chainID), sq.address); err != nil {
+			return nil, err
+		}
+	}
+	if sq.publicKey != "" {
+		if err := c.search(ctx, &results, func(rows storage.QueryResults) (SearchResult, error) {
+			var result SearchResult
+			if err := rows.Scan(&result.Type, &result.ID); err != nil {
+				return result, err
+			}
+			result.Address = sq.address
+			return result, nil
+		}, fmt.Sprintf(`
+			SELECT 'entity', id
+				FROM %[1]s.entities
+				WHERE id = $1
+			UNION ALL
+			SELECT 'node', id
+				FROM %[1]s.nodes
+				WHERE id = $1`,
+			chainID), sq.publicKey); err != nil {
+			return nil, err
+		}
+	}
+	if sq.height != nil {
+		if err := c.search(ctx, &results, func(rows storage.QueryResults) (SearchResult, error) {
+			result := SearchResult{Type: "proposal"}
+			var id uint64
+			if err := rows.Scan(&id); err != nil {
+				return result, err
+			}
+			result.ID = strconv.FormatUint(id, 10)
+			return result, nil
+		}, fmt.Sprintf(`
+			SELECT id
+				FROM %s.proposals
+				WHERE id = $1`,
+			chainID), *sq.height); err != nil {
+			return nil, err
+		}
+	}
+
+	return &results, nil
+}
+
+const (
+	// ActivityKindTransaction is the kind of activity entries for
+	// transactions signed by the account.
+	ActivityKindTransaction = "transaction"
+	// ActivityKindEvent is the kind of activity entries for events to
+	// which the account is a party.
+	ActivityKindEvent = "event"
+
+	// DirectionIn is the direction of funds credited to the account's
+	// general balance.
+	DirectionIn = "in"
+	// DirectionOut is the direction of funds debited from the account's
+	// general balance.
+	DirectionOut = "out"
+	// DirectionSelf is the direction of funds moved by the account to
+	// itself.
+	DirectionSelf = "self"
+	// DirectionNone is the direction of activity not moving funds in or
+	// out of the account's general balance.
+	DirectionNone = "none"
+)
+
+var (
+	typeTransfer        = eventType(analyzer.EventStakingTransfer)
+	typeBurn            = eventType(analyzer.EventStakingBurn)
+	typeAddEscrow       = eventType(analyzer.EventStakingAddEscrow)
+	typeTakeEscrow      = eventType(analyzer.EventStakingTakeEscrow)
+	typeDebondingStart  = eventType(analyzer.EventStakingDebondingStart)
+	typeReclaimEscrow   = eventType(analyzer.EventStakingReclaimEscrow)
+	typeAllowanceChange = eventType(analyzer.EventStakingAllowanceChange)
+)
+
+// eventType returns the type of the provided event, as indexed.
+func eventType(e analyzer.Event) string {
+	return e.String()
+}
+
+// eventFlow returns the direction, amount and counterparty of the funds
+// moved by the provided event, relative to the provided account. Escrowed
+// funds are not part of the general balance, so delegations debit the
+// delegator, and reclaims credit it. Events which do not move funds have
+// no direction and a zero amount.
+func eventFlow(address string, typ string, body []byte) (direction string, amount string, counterparty string, err error) {
+	// flow returns the flow of funds moved from one account to another.
+	flow := func(from staking.Address, to staking.Address, amount quantity.Quantity) (string, string, string) {
+		switch {
+		case from.String() == address && to.String() == address:
+			return DirectionSelf, amount.String(), ""
+		case from.String() == address:
+			return DirectionOut, amount.String(), to.String()
+		default:
+			return DirectionIn, amount.String(), from.String()
+		}
+	}
+	// other returns the other party of an event between two accounts.
+	other := func(a staking.Address, b staking.Address) string {
+		if a.String() == address {
+			return b.String()
+		}
+		return a.String()
+	}
+
+	switch typ {
+	case typeTransfer:
+		var e staking.TransferEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount, counterparty = flow(e.From, e.To, e.Amount)
+	case typeBurn:
+		var e staking.BurnEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount = DirectionOut, e.Amount.String()
+	case typeAddEscrow:
+		var e staking.AddEscrowEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount, counterparty = DirectionNone, e.Amount.String(), other(e.Owner, e.Escrow)
+		if e.Owner.String() == address {
+			direction = DirectionOut
+		}
+	case typeTakeEscrow:
+		// Slashing takes from the escrow, not the general balance.
+		var e staking.TakeEscrowEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount = DirectionNone, e.Amount.String()
+	case typeDebondingStart:
+		var e staking.DebondingStartEscrowEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount, counterparty = DirectionNone, e.Amount.String(), other(e.Owner, e.Escrow)
+	case typeReclaimEscrow:
+		var e staking.ReclaimEscrowEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount, counterparty = DirectionNone, e.Amount.String(), other(e.Owner, e.Escrow)
+		if e.Owner.String() == address {
+			direction = DirectionIn
+		}
+	case typeAllowanceChange:
+		var e staking.AllowanceChangeEvent
+		if err = json.Unmarshal(body, &e); err != nil {
+			return
+		}
+		direction, amount, counterparty = DirectionNone, e.AmountChange.String(), other(e.Owner, e.Beneficiary)
+	default:
+		direction, amount = DirectionNone, "0"
+	}
+	return
+}
+
+// AccountActivity returns the transactions signed by an account, merged
+// with the events to which the account is a party.
+func (c *storageClient) AccountActivity(ctx context.Context, r *http.Request) (*AccountActivity, error) {
+	chainID, ok := ctx.Value(ChainIDContextKey).(string)
+	if !ok {
+		return nil, common.ErrBadChainID
+	}
+
+	address := chi.URLParam(r, "address")
+	var a staking.Address
+	if err := a.UnmarshalText([]byte(address)); err != nil {
+		c.logger.Info("address parsing failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+
+	if err := c.checkRetentionParams(ctx, r, chainID, "events", "to"); err != nil {
+		return nil, err
+	}
+
+	// Transactions are ordered before the events they emitted. The fee
+	// transfer of a signed transaction, i.e. its transfer from the signer
+	// to the fee accumulator, is left out, as the transaction already
+	// reports its fee.
+	qb := NewQueryBuilder(fmt.Sprintf(`
+			SELECT height, txn_hash, txn_index, kind, type, amount, body, code
+				FROM (
+					SELECT block AS height, txn_hash, txn_index, 1 AS kind_order,
+							'%[1]s' AS kind, method AS type, fee_amount::TEXT AS amount, NULL AS body, code
+						FROM %[2]s.transactions
+						WHERE sender = $1::text
+					UNION ALL
+					SELECT e.txn_block, e.txn_hash, e.txn_index, 0,
+							'%[3]s', e.type, NULL, e.body::TEXT, NULL
+						FROM %[2]s.events AS e
+						WHERE e.related_accounts @> ARRAY[$1::text]
+							AND NOT (
+								e.type = 'Transfer' AND e.body->>'from' = $1::text AND e.body->>'to' = $2::text
+								AND EXISTS (
+									SELECT 1 FROM %[2]s.transactions AS t
+										WHERE t.block = e.txn_block AND t.txn_hash = e.txn_hash AND t.txn_index = e.txn_index
+											AND t.sender = $1::text
+								)
+							)
+				) AS activity`,
+		ActivityKindTransaction, chainID, ActivityKindEvent), c.db)
+
+	params := r.URL.Query()
+
+	args := []interface{}{address, staking.FeeAccumulatorAddress.String()}
+	var filters []string
+	for _, f := range []struct {
+		param     string
+		condition string
+	}{
+		{"from", "height >= $%d::bigint"},
+		{"to", "height <= $%d::bigint"},
+	} {
+		v := params.Get(f.param)
+		if v == "" {
+			continue
+		}
+		height, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			c.logger.Info("height parsing failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"err", err.Error(),
+			)
+			return nil, common.ErrBadRequest
+		}
+		args = append(args, height)
+		filters = append(filters, fmt.Sprintf(f.condition, len(args)))
+	}
+	if err := qb.AddFilters(ctx, filters); err != nil {
+		c.logger.Info("filtering failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+
+	pagination, err := common.NewPagination(r)
+	if err != nil {
+		c.logger.Info("pagination failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+	// Latest activity first.
+	pagination.Order = "height DESC, txn_index DESC, kind_order DESC"
+	if err = qb.AddOrderedPagination(ctx, pagination); err != nil {
+		c.logger.Info("pagination add failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrBadRequest
+	}
+
+	rows, err := c.db.Query(ctx, qb.String(), args...)
+	if err != nil {
+		c.logger.Info("query failed",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"err", err.Error(),
+		)
+		return nil, common.ErrStorageError
+	}
+	defer rows.Close()
+
+	activity := AccountActivity{
+		Address:  address,
+		Activity: []ActivityEntry{},
+	}
+	for rows.Next() {
+		var e ActivityEntry
+		var amount *string
+		var body *string
+		var code *uint64
+		if err := rows.Scan(
+			&e.Height,
+			&e.TxHash,
+			&e.TxIndex,
+			&e.Kind,
+			&e.Type,
+			&amount,
+			&body,
+			&code,
+		); err != nil {
+			c.logger.Info("row scan failed",
+				"request_id", ctx.Value(RequestIDContextKey),
+				"err", err.Error(),
+			)
+			return nil, common.ErrStorageError
+		}
+
+		switch e.Kind {
+		case ActivityKindTransaction:
+			// Signers pay the fee of their transactions.
+			e.Direction, e.Amount = DirectionOut, "0"
+			if amount != nil {
+				e.Amount = *amount
+			}
+			success := code != nil && *code == oasisErrors.CodeNoError
+			e.Success = &success
+		case ActivityKindEvent:
+			var b []byte
+			if body != nil {
+				b = []byte(*body)
+			}
+			if e.Direction, e.Amount, e.Counterparty, err = eventFlow(address, e.Type, b); err != nil {
+				c.logger.Info("event body parsing failed",
+					"request_id", ctx.Value(RequestIDContextKey),
+					"err", err.Error(),
+				)
+				return nil, common.ErrStorageError
+			}
+		}
+
+		activity.Activity = append(activity.Activity, e)
+	}
+
+	return &activity, nil
+}
@@ -4,50 +4,34 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
 	"github.com/stretchr/testify/require"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
 	"github.com/oasislabs/oasis-indexer/api/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
 )
 
 const (
 	queryBase = "SELECT * FROM table"
 )
 
-// MockStorage is a mock object that implements the
-// storage.TargetStorage interface.
-type MockStorage struct {
-	name string
-}
-
-func NewMockStorage() *MockStorage {
-	return &MockStorage{"mock"}
-}
-
-func (m *MockStorage) SendBatch(ctx context.Context, batch *storage.QueryBatch) error {
-	return nil
-}
-
-func (m *MockStorage) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
-	return nil, nil
-}
-
-func (m *MockStorage) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
-	return nil
-}
-
-func (m *MockStorage) Shutdown() {}
-
-func (m *MockStorage) Name() string {
-	return m.name
-}
-
 // TestQueryBuilderBasic simply creates a new QueryBuilder
 // and sees if it returns the initial base query when built.
 func TestQueryBuilderBasic(t *testing.T) {
-	qb := NewQueryBuilder(queryBase, NewMockStorage())
+	qb := NewQueryBuilder(queryBase, storagetest.NewStorage())
 	require.Equal(t, queryBase, qb.String())
 }
 
@@ -56,7 +40,7 @@ func TestQueryBuilderBasic(t *testing.T) {
 func TestQueryBuilderPagination(t *testing.T) {
 	ctx := context.Background()
 
-	qb := NewQueryBuilder(queryBase, NewMockStorage())
+	qb := NewQueryBuilder(queryBase, storagetest.NewStorage())
 
 	r, err := http.NewRequestWithContext(ctx, "GET", "https://fake-api.com/get-resource", nil)
 	require.Nil(t, err)
@@ -67,6 +51,12 @@ func TestQueryBuilderPagination(t *testing.T) {
 	err = qb.AddPagination(ctx, p)
 	require.Nil(t, err)
 	require.Equal(t, fmt.Sprintf("%s\n\tORDER BY 1 DESC\n\tLIMIT 100\n\tOFFSET 0", queryBase), qb.String())
+
+	qb = NewQueryBuilder(queryBase, storagetest.NewStorage())
+	p.Order = "height DESC, txn_index ASC"
+	err = qb.AddOrderedPagination(ctx, p)
+	require.Nil(t, err)
+	require.Equal(t, fmt.Sprintf("%s\n\tORDER BY height DESC, txn_index ASC\n\tLIMIT 100\n\tOFFSET 0", queryBase), qb.String())
 }
 
 // TestQueryBuilderFilters tests adding filters
@@ -74,7 +64,7 @@ func TestQueryBuilderPagination(t *testing.T) {
 func TestQueryBuilderFilters(t *testing.T) {
 	ctx := context.Background()
 
-	qb := NewQueryBuilder(queryBase, NewMockStorage())
+	qb := NewQueryBuilder(queryBase, storagetest.NewStorage())
 
 	filters := []string{
 		"n > 10",
@@ -85,3 +75,499 @@ func TestQueryBuilderFilters(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, fmt.Sprintf("%s\n\tWHERE %s AND %s AND %s", queryBase, filters[0], filters[1], filters[2]), qb.String())
 }
+
+// TestQueryBuilderTimestamp tests that time travel to heights whose
+// progress is not recorded fails cleanly.
+func TestQueryBuilderTimestamp(t *testing.T) {
+	ctx := context.WithValue(context.Background(), ChainIDContextKey, "oasis_3")
+	processed := time.Date(2022, 4, 11, 8, 30, 0, 0, time.UTC)
+
+	db := storagetest.NewStorage()
+	db.AddRows("processed_blocks", []interface{}{processed})
+	qb := NewQueryBuilder("SELECT id FROM oasis_3.entities", db)
+	require.Nil(t, qb.AddTimestamp(ctx, 100))
+	require.Contains(t, qb.String(), "AS OF SYSTEM TIME "+processed.String())
+
+	db = storagetest.NewStorage()
+	db.AddRows("processed_blocks")
+	db.AddRows("processed_block_ranges", []interface{}{true})
+	qb = NewQueryBuilder("SELECT id FROM oasis_3.entities", db)
+	require.Equal(t, common.ErrPruned, qb.AddTimestamp(ctx, 100))
+
+	db = storagetest.NewStorage()
+	db.AddRows("processed_blocks")
+	db.AddRows("processed_block_ranges", []interface{}{false})
+	qb = NewQueryBuilder("SELECT id FROM oasis_3.entities", db)
+	require.Equal(t, common.ErrNotFound, qb.AddTimestamp(ctx, 100))
+}
+
+// TestCheckRetention tests that heights below a table's retention
+// horizon are reported as pruned.
+func TestCheckRetention(t *testing.T) {
+	ctx := context.Background()
+
+	db := storagetest.NewStorage()
+	db.AddRows("retention_horizons")
+	c := newStorageClient(db, nil, log.NewDefaultLogger("api-test"))
+	require.Nil(t, c.checkRetention(ctx, "oasis_3", "blocks", 1))
+
+	db = storagetest.NewStorage()
+	db.AddRows("retention_horizons", []interface{}{int64(100)})
+	c = newStorageClient(db, nil, log.NewDefaultLogger("api-test"))
+	require.Nil(t, c.checkRetention(ctx, "oasis_3", "blocks", 100))
+	require.Equal(t, common.ErrPruned, c.checkRetention(ctx, "oasis_3", "blocks", 99))
+
+	r, err := http.NewRequestWithContext(ctx, "GET", "https://fake-api.com/get-resource?to=99", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.ErrPruned, c.checkRetentionParams(ctx, r, "oasis_3", "blocks", "from", "to"))
+
+	r, err = http.NewRequestWithContext(ctx, "GET", "https://fake-api.com/get-resource?to=abc", nil)
+	require.Nil(t, err)
+	require.Equal(t, common.ErrBadRequest, c.checkRetentionParams(ctx, r, "oasis_3", "blocks", "to"))
+}
+
+// TestParseSearchQuery tests that search queries are classified by
+// the kinds of identifiers they may be.
+func TestParseSearchQuery(t *testing.T) {
+	hexKey := "00000000000000000000000000000000000000000000000000000000000000ab"
+	pk := signature.NewPublicKey(hexKey)
+	address := staking.NewAddress(pk).String()
+
+	sq := parseSearchQuery("42")
+	require.Equal(t, int64(42), *sq.height)
+	require.Empty(t, sq.hash)
+	require.Empty(t, sq.address)
+
+	sq = parseSearchQuery(hexKey)
+	require.Nil(t, sq.height)
+	require.Equal(t, hexKey, sq.hash)
+	require.Equal(t, pk.String(), sq.publicKey)
+	require.Equal(t, address, sq.address)
+
+	sq = parseSearchQuery(pk.String())
+	require.Empty(t, sq.hash)
+	require.Equal(t, pk.String(), sq.publicKey)
+	require.Equal(t, address, sq.address)
+
+	sq = parseSearchQuery(address)
+	require.Empty(t, sq.publicKey)
+	require.Equal(t, address, sq.address)
+
+	require.Equal(t, searchQuery{}, parseSearchQuery("oasis1invalid"))
+	require.Equal(t, searchQuery{}, parseSearchQuery("-1"))
+}
+
+// TestSQLiteQueries tests that API queries are supported by
+// the SQLite backend.
+func TestSQLiteQueries(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("api-test")
+
+	chains, err := analyzer.NewChainRegistry([]*config.ChainConfig{{ChainID: "oasis-3", From: 1}})
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	db, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer db.Shutdown()
+
+	blockTime := time.Date(2022, 4, 11, 8, 30, 0, 0, time.UTC)
+	batch := &storage.QueryBatch{}
+	for height := int64(1); height <= 2; height++ {
+		batch.Copy("oasis_3.blocks", []string{"height", "block_hash", "time", "namespace", "version", "type", "root_hash"},
+			height, fmt.Sprintf("hash%d", height), blockTime, "ns", height, "type", "root")
+		batch.Queue(`
+			INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+				VALUES ($1, $2, $3)
+		`, height, "consensus", blockTime.Add(time.Duration(height)*time.Second))
+	}
+	batch.Copy("oasis_3.transactions", []string{"block", "txn_hash", "txn_index", "nonce", "fee_amount", "method", "sender", "body", "code"},
+		int64(2), "txn", 0, "7", "1000", "staking.Transfer", "oasis1", []byte{0xa1}, 0)
+	txHash := "0d0531d6b8a468c07440182b1cdda517f5a076d69fb2199126a83082ecfc0f41"
+	batch.Copy("oasis_3.transactions", []string{"block", "txn_hash", "txn_index", "nonce", "fee_amount", "method", "sender", "body", "code"},
+		int64(1), txHash, 0, "6", "1000", "staking.Transfer", "oasis2", []byte{0xa1}, 0)
+	entity := signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001")
+	entityAddress := staking.NewAddress(entity).String()
+	delegator := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002")).String()
+	node := "0000000000000000000000000000000000000000000000000000000000000003"
+	batch.Queue(`INSERT INTO oasis_3.accounts (address, nonce, general_balance, escrow_balance_active, escrow_total_shares_active, escrow_balance_debonding, escrow_total_shares_debonding)
+		VALUES ($1, 3, 100, 60, 30, 10, 10), ($2, 0, 5, 0, 0, 0, 0)`, entityAddress, delegator)
+	batch.Queue(`INSERT INTO oasis_3.allowances (owner, beneficiary, allowance) VALUES ($1, $2, 7)`, entityAddress, delegator)
+	batch.Queue(`INSERT INTO oasis_3.delegations (delegatee, delegator, shares) VALUES ($1, $2, 15)`, entityAddress, delegator)
+	batch.Queue(`INSERT INTO oasis_3.debonding_delegations (delegatee, delegator, shares, debond_end) VALUES ($1, $2, 5, 4)`, entityAddress, delegator)
+	batch.Queue(`INSERT INTO oasis_3.entities (id, address, meta) VALUES ($1, $2, '{"name":"validator","url":"https://example.com"}')`, entity.String(), entityAddress)
+	batch.Queue(`INSERT INTO oasis_3.nodes (id, entity_id, expiration, tls_pubkey, tls_next_pubkey, p2p_pubkey, consensus_pubkey, roles, voting_power)
+		VALUES ($1, $2, 10, 'tls', 'tls', 'p2p', 'consensus', 'validator', 1)`, node, entity.String())
+	batch.Queue(`INSERT INTO oasis_3.commissions (address, schedule)
+		VALUES ($1, '{"rates":[{"rate":"5000"}],"bounds":[{"rate_min":"0","rate_max":"20000"}]}')`, entityAddress)
+	batch.Queue(`INSERT INTO oasis_3.epochs (id, start_height, end_height) VALUES (1, 1, 1), (2, 2, NULL)`)
+	batch.Queue(`INSERT INTO oasis_3.proposals (id, submitter, deposit, created_at, closes_at) VALUES (2, 'oasis1', 100, 1, 2)`)
+	batch.Queue(`INSERT INTO oasis_3.votes (proposal, voter, vote) VALUES (2, $1, 'yes')`, delegator)
+	require.Nil(t, db.SendBatch(ctx, batch))
+
+	c := newStorageClient(db, chains, logger)
+	ctx = context.WithValue(ctx, ChainIDContextKey, chain.Schema)
+	newRequest := func(target string, params map[string]string) *http.Request {
+		rctx := chi.NewRouteContext()
+		for k, v := range params {
+			rctx.URLParams.Add(k, v)
+		}
+		return httptest.NewRequest(http.MethodGet, target, nil).WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+	}
+
+	status, err := c.Status(ctx)
+	require.Nil(t, err)
+	require.Equal(t, int64(2), status.LatestBlock)
+
+	blocks, err := c.Blocks(ctx, newRequest("/v1/consensus/blocks?from=2", nil))
+	require.Nil(t, err)
+	require.Len(t, blocks.Blocks, 1)
+	require.Equal(t, "hash2", blocks.Blocks[0].Hash)
+	require.Equal(t, blockTime, blocks.Blocks[0].Timestamp)
+
+	block, err := c.Block(ctx, newRequest("/v1/consensus/blocks/1", map[string]string{"height": "1"}))
+	require.Nil(t, err)
+	require.Equal(t, "hash1", block.Hash)
+
+	txs, err := c.Transactions(ctx, newRequest("/v1/consensus/transactions?sender=oasis1&minFee=100", nil))
+	require.Nil(t, err)
+	require.Len(t, txs.Transactions, 1)
+	require.Equal(t, uint64(7), txs.Transactions[0].Nonce)
+	require.Equal(t, uint64(1000), txs.Transactions[0].Fee)
+	require.True(t, txs.Transactions[0].Success)
+
+	tx, err := c.Transaction(ctx, newRequest("/v1/consensus/transactions/txn?height=2", map[string]string{"txn_hash": "txn"}))
+	require.Nil(t, err)
+	require.Equal(t, []byte{0xa1}, tx.Body)
+
+	results, err := c.Search(ctx, newRequest("/v1/search?q=2", nil))
+	require.Nil(t, err)
+	require.Len(t, results.Results, 2)
+	require.Equal(t, "block", results.Results[0].Type)
+	require.Equal(t, int64(2), *results.Results[0].Height)
+	require.Equal(t, SearchResult{Type: "proposal", ID: "2"}, results.Results[1])
+
+	results, err = c.Search(ctx, newRequest("/v1/search?q="+strings.ToUpper(txHash), nil))
+	require.Nil(t, err)
+	require.Len(t, results.Results, 1)
+	require.Equal(t, "transaction", results.Results[0].Type)
+	require.Equal(t, txHash, results.Results[0].ID)
+	require.Equal(t, int64(1), *results.Results[0].Height)
+
+	results, err = c.Search(ctx, newRequest("/v1/search?q=hash1", nil))
+	require.Nil(t, err)
+	require.Empty(t, results.Results)
+
+	results, err = c.Search(ctx, newRequest("/v1/search?q="+entity.String(), nil))
+	require.Nil(t, err)
+	require.Equal(t, []SearchResult{
+		{Type: "account", ID: entityAddress, Address: entityAddress},
+		{Type: "entity", ID: entity.String(), Address: entityAddress},
+	}, results.Results)
+
+	_, err = c.Search(ctx, newRequest("/v1/search?q=", nil))
+	require.Equal(t, common.ErrBadRequest, err)
+
+	entities, err := c.Entities(ctx, newRequest("/v1/consensus/entities", nil))
+	require.Nil(t, err)
+	require.Equal(t, []Entity{{ID: entity.String(), Address: entityAddress}}, entities.Entities)
+
+	e, err := c.Entity(ctx, newRequest("/v1/consensus/entities/"+entity.String(), map[string]string{"entity_id": entity.String()}))
+	require.Nil(t, err)
+	require.Equal(t, []string{node}, e.Nodes)
+
+	nodes, err := c.EntityNodes(ctx, newRequest("/v1/consensus/entities/"+entity.String()+"/nodes", map[string]string{"entity_id": entity.String()}))
+	require.Nil(t, err)
+	require.Len(t, nodes.Nodes, 1)
+	require.Equal(t, "validator", nodes.Nodes[0].Roles)
+
+	n, err := c.EntityNode(ctx, newRequest("/v1/consensus/entities/"+entity.String()+"/nodes/"+node, map[string]string{"entity_id": entity.String(), "node_id": node}))
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), n.Expiration)
+
+	accounts, err := c.Accounts(ctx, newRequest("/v1/consensus/accounts?minAvailable=50&maxEscrow=60", nil))
+	require.Nil(t, err)
+	require.Len(t, accounts.Accounts, 1)
+	require.Equal(t, entityAddress, accounts.Accounts[0].Address)
+
+	account, err := c.Account(ctx, newRequest("/v1/consensus/accounts/"+entityAddress, map[string]string{"address": entityAddress}))
+	require.Nil(t, err)
+	require.Equal(t, Account{
+		Address:    entityAddress,
+		Nonce:      3,
+		Available:  100,
+		Escrow:     60,
+		Debonding:  10,
+		Allowances: []Allowance{{Address: delegator, Amount: 7}},
+	}, *account)
+
+	delegations, err := c.Delegations(ctx, newRequest("/v1/consensus/accounts/"+delegator+"/delegations", map[string]string{"address": delegator}))
+	require.Nil(t, err)
+	require.Equal(t, []Delegation{{Amount: 30, Shares: 15, ValidatorAddress: entityAddress}}, delegations.Delegations)
+
+	debonding, err := c.DebondingDelegations(ctx, newRequest("/v1/consensus/accounts/"+delegator+"/debonding_delegations", map[string]string{"address": delegator}))
+	require.Nil(t, err)
+	require.Equal(t, []DebondingDelegation{{Amount: 5, Shares: 5, ValidatorAddress: entityAddress, DebondEnd: 4}}, debonding.DebondingDelegations)
+
+	epochs, err := c.Epochs(ctx, newRequest("/v1/consensus/epochs", nil))
+	require.Nil(t, err)
+	require.Len(t, epochs.Epochs, 2)
+
+	epoch, err := c.Epoch(ctx, newRequest("/v1/consensus/epochs/1", map[string]string{"epoch": "1"}))
+	require.Nil(t, err)
+	require.Equal(t, Epoch{ID: 1, StartHeight: 1, EndHeight: 1}, *epoch)
+
+	proposals, err := c.Proposals(ctx, newRequest("/v1/consensus/proposals?submitter=oasis1", nil))
+	require.Nil(t, err)
+	require.Len(t, proposals.Proposals, 1)
+	require.Equal(t, uint64(100), proposals.Proposals[0].Deposit)
+
+	proposal, err := c.Proposal(ctx, newRequest("/v1/consensus/proposals/2", map[string]string{"proposal_id": "2"}))
+	require.Nil(t, err)
+	require.Equal(t, "active", proposal.State)
+
+	votes, err := c.ProposalVotes(ctx, newRequest("/v1/consensus/proposals/2/votes", map[string]string{"proposal_id": "2"}))
+	require.Nil(t, err)
+	require.Equal(t, []ProposalVote{{Address: delegator, Vote: "yes"}}, votes.Votes)
+
+	validator, err := c.Validator(ctx, newRequest("/v1/consensus/validators/"+entityAddress, map[string]string{"entity_id": entityAddress}))
+	require.Nil(t, err)
+	require.Equal(t, node, validator.NodeID)
+	require.Equal(t, "validator", validator.Name)
+	require.Equal(t, uint64(60), validator.Escrow)
+	require.Equal(t, uint64(5000), validator.CurrentRate)
+	require.True(t, validator.Active)
+
+	validators, err := c.Validators(ctx, newRequest("/v1/consensus/validators", nil))
+	require.Nil(t, err)
+	require.Len(t, validators.Validators, 1)
+	require.Equal(t, *validator, validators.Validators[0])
+}
+
+// TestSQLiteEvents tests that events are looked up by the accounts they
+// relate to, including events indexed before related accounts were.
+func TestSQLiteEvents(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("api-test")
+
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	a := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001")).String()
+	b := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002")).String()
+
+	// Index a legacy event before related accounts are introduced.
+	endpoint := sqlite.Scheme + t.TempDir()
+	m := migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger)
+	require.Nil(t, m.Goto(chain, 12))
+	db, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.blocks (height, block_hash, time, namespace, version, type, root_hash) VALUES
+		(1, 'h1', '2022-04-11 08:30:00', 'ns', 1, 'state', 'root'),
+		(2, 'h2', '2022-04-11 08:30:06', 'ns', 1, 'state', 'root'),
+		(3, 'h3', '2022-04-11 08:30:12', 'ns', 1, 'state', 'root')`)
+	batch.Queue(`INSERT INTO oasis_3.transactions (block, txn_hash, txn_index, nonce, method, sender, code) VALUES
+		(1, 'tx1', 0, 1, 'staking.Transfer', $1, 0),
+		(2, 'tx2', 0, 1, 'governance.CastVote', $2, 0)`, a, b)
+	batch.Queue(fmt.Sprintf(`INSERT INTO oasis_3.events (backend, type, body, txn_block, txn_hash, txn_index) VALUES
+		('staking', 'Transfer', '{"from":"%s","to":"%s","amount":"10"}', 1, 'tx1', 0),
+		('governance', 'ProposalExecuted', '{"id":1,"state":"passed"}', 1, 'tx1', 0)`, a, b))
+	require.Nil(t, db.SendBatch(ctx, batch))
+	db.Shutdown()
+
+	require.Nil(t, m.Up(chain))
+	db, err = sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer db.Shutdown()
+
+	batch = &storage.QueryBatch{}
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"governance", "Vote", fmt.Sprintf(`{"id":1,"submitter":"%s","vote":"yes"}`, b), int64(2), "tx2", 0, []string{b})
+	require.Nil(t, db.SendBatch(ctx, batch))
+
+	c := newStorageClient(db, chains, logger)
+	ctx = context.WithValue(ctx, ChainIDContextKey, chain.Schema)
+	list := func(query string) []Event {
+		events, err := c.Events(ctx, httptest.NewRequest(http.MethodGet, "/v1/consensus/events?"+query, nil).WithContext(ctx))
+		require.Nil(t, err)
+		return events.Events
+	}
+
+	require.Len(t, list(""), 3)
+
+	events := list("rel=" + a)
+	require.Len(t, events, 1)
+	require.Equal(t, "Transfer", events[0].Type)
+	require.Equal(t, "tx1", events[0].TxHash)
+
+	events = list("rel=" + b)
+	require.Len(t, events, 2)
+	require.Equal(t, "Vote", events[0].Type)
+	require.Equal(t, int64(2), events[0].Height)
+	require.JSONEq(t, fmt.Sprintf(`{"id":1,"submitter":"%s","vote":"yes"}`, b), string(events[0].Body))
+
+	require.Len(t, list("rel="+b+"&to=1"), 1)
+	require.Len(t, list("backend=governance&tx_hash=tx1"), 1)
+
+	// Finalization events indexed as executions are retyped.
+	events = list("type=ProposalFinalized")
+	require.Len(t, events, 1)
+	require.Empty(t, list("type=ProposalExecuted"))
+
+	_, err = c.Events(ctx, httptest.NewRequest(http.MethodGet, "/v1/consensus/events?from=abc", nil).WithContext(ctx))
+	require.Equal(t, common.ErrBadRequest, err)
+}
+
+func TestEventFlow(t *testing.T) {
+	a := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001")).String()
+	b := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002")).String()
+
+	for _, tc := range []struct {
+		address      string
+		typ          string
+		body         string
+		direction    string
+		amount       string
+		counterparty string
+	}{
+		{a, "Transfer", fmt.Sprintf(`{"from":"%s","to":"%s","amount":"10"}`, a, b), DirectionOut, "10", b},
+		{b, "Transfer", fmt.Sprintf(`{"from":"%s","to":"%s","amount":"10"}`, a, b), DirectionIn, "10", a},
+		{a, "Transfer", fmt.Sprintf(`{"from":"%s","to":"%s","amount":"10"}`, a, a), DirectionSelf, "10", ""},
+		{a, "Burn", fmt.Sprintf(`{"owner":"%s","amount":"5"}`, a), DirectionOut, "5", ""},
+		{a, "AddEscrow", fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"20","new_shares":"20"}`, a, b), DirectionOut, "20", b},
+		{b, "AddEscrow", fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"20","new_shares":"20"}`, a, b), DirectionNone, "20", a},
+		{b, "TakeEscrow", fmt.Sprintf(`{"owner":"%s","amount":"3"}`, b), DirectionNone, "3", ""},
+		{a, "DebondingStart", fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"7","active_shares":"7","debonding_shares":"7","debond_end_time":10}`, a, b), DirectionNone, "7", b},
+		{a, "ReclaimEscrow", fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"7","shares":"7"}`, a, b), DirectionIn, "7", b},
+		{b, "ReclaimEscrow", fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"7","shares":"7"}`, a, b), DirectionNone, "7", a},
+		{b, "AllowanceChange", fmt.Sprintf(`{"owner":"%s","beneficiary":"%s","allowance":"9","negative":false,"amount_change":"9"}`, a, b), DirectionNone, "9", a},
+		{b, "Vote", fmt.Sprintf(`{"id":1,"submitter":"%s","vote":"yes"}`, b), DirectionNone, "0", ""},
+	} {
+		direction, amount, counterparty, err := eventFlow(tc.address, tc.typ, []byte(tc.body))
+		require.Nil(t, err, tc.typ)
+		require.Equal(t, tc.direction, direction, tc.typ)
+		require.Equal(t, tc.amount, amount, tc.typ)
+		require.Equal(t, tc.counterparty, counterparty, tc.typ)
+	}
+
+	_, _, _, err := eventFlow(a, "Transfer", []byte(`{"from":1}`))
+	require.NotNil(t, err)
+}
+
+func TestSQLiteAccountActivity(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewDefaultLogger("api-test")
+
+	chains, err := analyzer.NewChainRegistry(nil)
+	require.Nil(t, err)
+	chain := chains.Latest()
+
+	a := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000001")).String()
+	b := staking.NewAddress(signature.NewPublicKey("0000000000000000000000000000000000000000000000000000000000000002")).String()
+
+	endpoint := sqlite.Scheme + t.TempDir()
+	require.Nil(t, migrator.NewMigrator("file://../../storage/migrations/sqlite", endpoint, logger).Up(chain))
+	db, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	defer db.Shutdown()
+
+	transfer := fmt.Sprintf(`{"from":"%s","to":"%s","amount":"10"}`, b, a)
+	addEscrow := fmt.Sprintf(`{"owner":"%s","escrow":"%s","amount":"4","new_shares":"4"}`, a, b)
+	feeAccumulator := staking.FeeAccumulatorAddress.String()
+	fee := fmt.Sprintf(`{"from":"%s","to":"%s","amount":"200"}`, a, feeAccumulator)
+	// A transfer of the same amount as the fee is not a fee transfer.
+	sameAmount := fmt.Sprintf(`{"from":"%s","to":"%s","amount":"50"}`, a, b)
+	sameAmountFee := fmt.Sprintf(`{"from":"%s","to":"%s","amount":"50"}`, a, feeAccumulator)
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.blocks (height, block_hash, time, namespace, version, type, root_hash) VALUES
+		(1, 'h1', '2022-04-11 08:30:00', 'ns', 1, 'state', 'root'),
+		(2, 'h2', '2022-04-11 08:30:06', 'ns', 1, 'state', 'root')`)
+	batch.Queue(`INSERT INTO oasis_3.transactions (block, txn_hash, txn_index, nonce, fee_amount, method, sender, code) VALUES
+		(1, 'tx1', 0, 1, 100, 'staking.Transfer', $1, 0),
+		(2, 'tx2', 0, 1, 200, 'staking.AddEscrow', $2, 1),
+		(3, 'tx3', 0, 2, 50, 'staking.Transfer', $2, 0)`, b, a)
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"staking", "Transfer", transfer, int64(1), "tx1", 0, []string{b, a})
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"staking", "AddEscrow", addEscrow, int64(2), "tx2", 0, []string{a, b})
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"staking", "Transfer", fee, int64(2), "tx2", 0, []string{a, feeAccumulator})
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"staking", "Transfer", sameAmount, int64(3), "tx3", 0, []string{a, b})
+	batch.Copy("oasis_3.events", []string{"backend", "type", "body", "txn_block", "txn_hash", "txn_index", "related_accounts"},
+		"staking", "Transfer", sameAmountFee, int64(3), "tx3", 0, []string{a, feeAccumulator})
+	require.Nil(t, db.SendBatch(ctx, batch))
+
+	c := newStorageClient(db, chains, logger)
+	ctx = context.WithValue(ctx, ChainIDContextKey, chain.Schema)
+	activity := func(address string, query string) ([]ActivityEntry, error) {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("address", address)
+		r := httptest.NewRequest(http.MethodGet, "/v1/consensus/accounts/"+address+"/activity?"+query, nil).
+			WithContext(context.WithValue(ctx, chi.RouteCtxKey, rctx))
+		res, err := c.AccountActivity(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		require.Equal(t, address, res.Address)
+		return res.Activity, nil
+	}
+
+	// The signed transaction precedes the events it emitted, and its fee
+	// transfer is not reported twice, unlike transfers of the same amount
+	// to other accounts.
+	entries, err := activity(a, "")
+	require.Nil(t, err)
+	require.Len(t, entries, 5)
+	require.Equal(t, ActivityKindTransaction, entries[0].Kind)
+	require.Equal(t, "50", entries[0].Amount)
+	require.Equal(t, ActivityKindEvent, entries[1].Kind)
+	require.Equal(t, "Transfer", entries[1].Type)
+	require.Equal(t, DirectionOut, entries[1].Direction)
+	require.Equal(t, "50", entries[1].Amount)
+	require.Equal(t, b, entries[1].Counterparty)
+	entries = entries[2:]
+	require.Equal(t, ActivityKindTransaction, entries[0].Kind)
+	require.Equal(t, "staking.AddEscrow", entries[0].Type)
+	require.Equal(t, DirectionOut, entries[0].Direction)
+	require.Equal(t, "200", entries[0].Amount)
+	require.False(t, *entries[0].Success)
+	require.Equal(t, ActivityKindEvent, entries[1].Kind)
+	require.Equal(t, "AddEscrow", entries[1].Type)
+	require.Equal(t, DirectionOut, entries[1].Direction)
+	require.Equal(t, b, entries[1].Counterparty)
+	require.Equal(t, int64(1), entries[2].Height)
+	require.Equal(t, "Transfer", entries[2].Type)
+	require.Equal(t, DirectionIn, entries[2].Direction)
+	require.Equal(t, "10", entries[2].Amount)
+	require.Equal(t, b, entries[2].Counterparty)
+	require.Nil(t, entries[2].Success)
+
+	// Fee transfers are reported to the fee accumulator.
+	entries, err = activity(feeAccumulator, "")
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, DirectionIn, entries[1].Direction)
+	require.Equal(t, "200", entries[1].Amount)
+	require.Equal(t, a, entries[1].Counterparty)
+
+	entries, err = activity(b, "to=1")
+	require.Nil(t, err)
+	require.Len(t, entries, 2)
+	require.True(t, *entries[0].Success)
+	require.Equal(t, DirectionOut, entries[1].Direction)
+
+	entries, err = activity(a, "from=2&to=2&limit=1&offset=1")
+	require.Nil(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "AddEscrow", entries[0].Type)
+
+	_, err = activity("oasis1invalid", "")
+	require.Equal(t, common.ErrBadRequest, err)
+	_, err = activity(a, "from=abc")
+	require.Equal(t, common.ErrBadRequest, err)
+}
@@ -38,6 +38,36 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Search searches for objects matching a query.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	results, err := h.client.Search(ctx, r)
+	if err != nil {
+		h.logAndReply(ctx, "failed to search", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "database_error").Inc()
+		return
+	}
+
+	resp, err := json.Marshal(results)
+	if err != nil {
+		h.logAndReply(ctx, "failed to marshal search results", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		h.logger.Error("failed to write response",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
+
 // ListBlocks gets a list of consensus blocks.
 func (h *Handler) ListBlocks(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -158,6 +188,36 @@ func (h *Handler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// ListEvents gets a list of consensus events.
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	events, err := h.client.Events(ctx, r)
+	if err != nil {
+		h.logAndReply(ctx, "failed to list events", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "database_error").Inc()
+		return
+	}
+
+	resp, err := json.Marshal(events)
+	if err != nil {
+		h.logAndReply(ctx, "failed to marshal events", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		h.logger.Error("failed to write response",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
+
 // ListEntities gets a list of registered entities.
 func (h *Handler) ListEntities(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -398,6 +458,36 @@ func (h *Handler) GetDebondingDelegations(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// GetAccountActivity gets the transactions and events of an account.
+func (h *Handler) GetAccountActivity(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	activity, err := h.client.AccountActivity(ctx, r)
+	if err != nil {
+		h.logAndReply(ctx, "failed to get account activity", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "database_error").Inc()
+		return
+	}
+
+	resp, err := json.Marshal(activity)
+	if err != nil {
+		h.logAndReply(ctx, "failed to marshal account activity", w, err)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "serde_error").Inc()
+		return
+	}
+
+	w.Header().Set("content-type", "application/json")
+	if _, err := w.Write(resp); err != nil {
+		h.logger.Error("failed to write response",
+			"request_id", ctx.Value(RequestIDContextKey),
+			"error", err,
+		)
+		h.metrics.RequestCounter(r.URL.Path, "failure", "http_error").Inc()
+	} else {
+		h.metrics.RequestCounter(r.URL.Path, "success").Inc()
+	}
+}
+
 // ListEpochs gets a list of epochs.
 func (h *Handler) ListEpochs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -3,17 +3,20 @@ package v1
 import (
 	"context"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/iancoleman/strcase"
+
+	"github.com/oasislabs/oasis-indexer/api/common"
+	"github.com/oasislabs/oasis-indexer/storage"
 )
 
 type ContextKey string
 
 const (
-	// ChainIDContextKey is used to set the relevant chain ID
-	// in a request context.
+	// ChainIDContextKey is used to set the storage schema of the
+	// relevant chain in a request context.
 	ChainIDContextKey ContextKey = "chain_id"
 	// RequestIDContextKey is used to set a request id for tracing
 	// in a request context.
@@ -49,15 +52,33 @@ func (h *Handler) metricsMiddleware(next http.Handler) http.Handler {
 }
 
 // chainMiddleware is a middleware that adds chain-specific information
-// to the request context.
+// to the request context. The chain is resolved from the `height` query
+// parameter if provided, and is otherwise the latest chain.
 func (h *Handler) chainMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		chainID := strcase.ToSnake(LatestChainID)
-
-		// TODO: Set chainID based on provided height params.
+		ctx := r.Context()
+		chain := h.chains.Latest()
+		// Reads may be served from any replica by default.
+		ctx = storage.WithMinHeight(ctx, chain.Schema, 0)
+		if v := r.URL.Query().Get("height"); v != "" {
+			if height, err := strconv.ParseInt(v, 10, 64); err == nil {
+				if chain, err = h.chains.FromHeight(height); err != nil {
+					if err = common.ReplyWithError(w, common.ErrBadChainID); err != nil {
+						h.logger.Error("failed to reply with error",
+							"error", err,
+						)
+					}
+					return
+				}
+				// Reads at a height must not be served from replicas
+				// which have not yet indexed it.
+				ctx = storage.WithMinHeight(ctx, chain.Schema, height)
+			}
+			// ^malformed heights are rejected by the request handlers.
+		}
 
 		next.ServeHTTP(w, r.WithContext(
-			context.WithValue(r.Context(), ChainIDContextKey, chainID),
+			context.WithValue(ctx, ChainIDContextKey, chain.Schema),
 		))
 	})
 }
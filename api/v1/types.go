@@ -2,6 +2,7 @@
 package v1
 
 import (
+	"encoding/json"
 	"time"
 )
 
@@ -41,6 +42,21 @@ type Transaction struct {
 	Success bool   `json:"success"`
 }
 
+// EventList is the API response for ListEvents.
+type EventList struct {
+	Events []Event `json:"events"`
+}
+
+// Event is a consensus event emitted by a transaction.
+type Event struct {
+	Height  int64           `json:"height"`
+	TxHash  string          `json:"tx_hash"`
+	TxIndex *int32          `json:"tx_index,omitempty"`
+	Backend string          `json:"backend"`
+	Type    string          `json:"type"`
+	Body    json.RawMessage `json:"body"`
+}
+
 // EntityList is the API response for ListEntities.
 type EntityList struct {
 	Entities []Entity `json:"entities"`
@@ -205,3 +221,46 @@ type ValidatorCommissionBound struct {
 	EpochStart uint64 `json:"epoch_start"`
 	EpochEnd   uint64 `json:"epoch_end"`
 }
+
+// SearchResults is the API response for Search.
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Results []SearchResult `json:"results"`
+}
+
+// SearchResult is an object matching a search query.
+type SearchResult struct {
+	// Type is one of "block", "transaction", "account", "entity", "node"
+	// and "proposal".
+	Type   string `json:"type"`
+	ID     string `json:"id"`
+	Height *int64 `json:"height,omitempty"`
+	// Address is the staking address of the matched account, entity or node.
+	Address string `json:"address,omitempty"`
+}
+
+// AccountActivity is the API response for GetAccountActivity.
+type AccountActivity struct {
+	Address  string          `json:"address"`
+	Activity []ActivityEntry `json:"activity"`
+}
+
+// ActivityEntry is a transaction signed by an account, or an event
+// to which the account is a party.
+type ActivityEntry struct {
+	Height  int64  `json:"height"`
+	TxHash  string `json:"tx_hash"`
+	TxIndex *int32 `json:"tx_index,omitempty"`
+	// Kind is either "transaction" or "event".
+	Kind string `json:"kind"`
+	// Type is the method of a transaction, or the type of an event.
+	Type string `json:"type"`
+	// Direction is the direction of the funds moved, relative to the
+	// account's general balance: one of "in", "out", "self" and "none".
+	Direction string `json:"direction"`
+	// Amount is the amount moved, which is the fee of a transaction.
+	Amount       string `json:"amount"`
+	Counterparty string `json:"counterparty,omitempty"`
+	// Success is set for transactions.
+	Success *bool `json:"success,omitempty"`
+}
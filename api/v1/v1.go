@@ -3,28 +3,29 @@ package v1
 import (
 	"github.com/go-chi/chi/v5"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
 	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/metrics"
 	"github.com/oasislabs/oasis-indexer/storage"
 )
 
 const (
-	LatestChainID = "oasis-3"
-
 	moduleName = "api_v1"
 )
 
 // Handler is the Oasis Indexer V1 API handler.
 type Handler struct {
+	chains  *analyzer.ChainRegistry
 	client  *storageClient
 	logger  *log.Logger
 	metrics metrics.RequestMetrics
 }
 
 // NewHandler creates a new V1 API handler.
-func NewHandler(db storage.TargetStorage, l *log.Logger) *Handler {
+func NewHandler(db storage.TargetStorage, chains *analyzer.ChainRegistry, l *log.Logger) *Handler {
 	return &Handler{
-		client:  newStorageClient(db, l),
+		chains:  chains,
+		client:  newStorageClient(db, chains, l),
 		logger:  l.WithModule(moduleName),
 		metrics: metrics.NewDefaultRequestMetrics(moduleName),
 	}
@@ -42,6 +43,9 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 		// Status endpoints.
 		r.Get("/", h.GetStatus)
 
+		// Search Endpoints.
+		r.Get("/search", h.Search)
+
 		r.Route("/consensus", func(r chi.Router) {
 			// Block Endpoints.
 			r.Route("/blocks", func(r chi.Router) {
@@ -52,6 +56,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 				r.Get("/", h.ListTransactions)
 				r.Get("/{txn_hash}", h.GetTransaction)
 			})
+			r.Get("/events", h.ListEvents)
 
 			// Registry Endpoints.
 			r.Route("/entities", func(r chi.Router) {
@@ -67,6 +72,7 @@ func (h *Handler) RegisterRoutes(r chi.Router) {
 				r.Get("/{address}", h.GetAccount)
 				r.Get("/{address}/delegations", h.GetDelegations)
 				r.Get("/{address}/debonding_delegations", h.GetDebondingDelegations)
+				r.Get("/{address}/activity", h.GetAccountActivity)
 			})
 
 			// Scheduler Endpoints.
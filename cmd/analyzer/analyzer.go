@@ -7,15 +7,15 @@ import (
 	"sync"
 	"time"
 
-	migrate "github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database/postgres" // postgres driver for golang_migrate
-	_ "github.com/golang-migrate/migrate/v4/source/file"       // support file scheme for golang_migrate
-	_ "github.com/golang-migrate/migrate/v4/source/github"     // support github scheme for golang_migrate
 	oasisConfig "github.com/oasisprotocol/oasis-sdk/client-sdk/go/config"
 	"github.com/spf13/cobra"
 
 	"github.com/oasislabs/oasis-indexer/analyzer"
-	"github.com/oasislabs/oasis-indexer/analyzer/consensus"
+	_ "github.com/oasislabs/oasis-indexer/analyzer/consensus" // register consensus analyzers
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	_ "github.com/oasislabs/oasis-indexer/analyzer/exporter" // register exporter analyzers
+	"github.com/oasislabs/oasis-indexer/analyzer/retention"
+	_ "github.com/oasislabs/oasis-indexer/analyzer/verifier" // register verifier analyzers
 	"github.com/oasislabs/oasis-indexer/cmd/common"
 	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
@@ -75,27 +75,35 @@ func runAnalyzer(cmd *cobra.Command, args []string) {
 func Init(cfg *config.AnalysisConfig) (*Service, error) {
 	logger := common.Logger()
 
-	m, err := migrate.New(
-		cfg.Migrations,
-		cfg.Storage.Endpoint,
-	)
+	// Run migrations for each chain being analyzed.
+	m, err := common.NewMigrator(cfg, logger)
 	if err != nil {
-		logger.Error("migrator failed to start",
+		logger.Error("migrator init failed",
 			"error", err,
 		)
 		return nil, err
 	}
-
-	switch err = m.Up(); {
-	case err == migrate.ErrNoChange:
-		logger.Info("migrations are up to date")
-	case err != nil:
-		logger.Error("migrations failed",
-			"error", err,
-		)
-		return nil, err
-	default:
-		logger.Info("migrations completed")
+	migrated := make(map[analyzer.ChainID]bool)
+	for _, analyzerCfg := range cfg.Analyzers {
+		chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+		if err != nil {
+			logger.Error("chain not found",
+				"chain_id", analyzerCfg.ChainID,
+				"error", err,
+			)
+			return nil, err
+		}
+		if migrated[chain.ID] {
+			continue
+		}
+		if err := m.Up(chain); err != nil {
+			logger.Error("migrations failed",
+				"chain_id", chain.ID,
+				"error", err,
+			)
+			return nil, err
+		}
+		migrated[chain.ID] = true
 	}
 
 	service, err := NewService(cfg)
@@ -112,6 +120,9 @@ func Init(cfg *config.AnalysisConfig) (*Service, error) {
 type Service struct {
 	Analyzers map[string]analyzer.Analyzer
 
+	// Pruners prune the history of analyzed chains, if configured.
+	Pruners []*retention.Pruner
+
 	target storage.TargetStorage
 	logger *log.Logger
 }
@@ -127,57 +138,122 @@ func NewService(cfg *config.AnalysisConfig) (*Service, error) {
 		return nil, err
 	}
 
-	// Initialize analyzers.
-	consensusMainDamask := consensus.NewMain(client, logger)
-
-	analyzers := map[string]analyzer.Analyzer{
-		consensusMainDamask.Name(): consensusMainDamask,
+	// Initialize coordination.
+	var holder string
+	var leaseDuration time.Duration
+	if cfg.Coordination != nil {
+		if holder = cfg.Coordination.Holder; holder == "" {
+			holder = coordination.DefaultHolder()
+		}
+		if leaseDuration, err = cfg.Coordination.Duration(); err != nil {
+			return nil, err
+		}
+		logger.Info("coordinating analyzers with other instances",
+			"holder", holder,
+		)
 	}
 
+	// Initialize analyzers.
+	analyzers := make(map[string]analyzer.Analyzer, len(cfg.Analyzers))
 	for _, analyzerCfg := range cfg.Analyzers {
-		a, ok := analyzers[analyzerCfg.Name]
-		if !ok {
-			continue
+		a, err := analyzer.New(analyzerCfg.AnalyzerType(), analyzerCfg.Name, client, logger)
+		if err != nil {
+			return nil, err
+		}
+		chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		// Configure analyzer.
+		analyzerConfig := analyzer.Config{
+			Chain: chain,
+			BlockRange: analyzer.Range{
+				From: analyzerCfg.From,
+				To:   analyzerCfg.To,
+			},
+			Options: analyzerCfg.Options,
 		}
-		if analyzerCfg.Interval == "" {
+		if analyzerConfig.BlockRange.From == 0 {
+			analyzerConfig.BlockRange.From = chain.Range.From
+		}
+		if analyzerConfig.BlockRange.To == 0 {
+			analyzerConfig.BlockRange.To = chain.Range.To
+		}
+		if analyzerCfg.RPC != "" {
 			// Initialize source.
+			chainContext := analyzerCfg.ChainContext
+			if chainContext == "" {
+				chainContext = chain.ChainContext
+			}
 			networkCfg := oasisConfig.Network{
-				ChainContext: analyzerCfg.ChainContext,
+				ChainContext: chainContext,
 				RPC:          analyzerCfg.RPC,
 			}
 			source, err := source.NewClient(ctx, &networkCfg)
 			if err != nil {
 				return nil, err
 			}
-
-			// Configure analyzer.
-			blockRange := analyzer.Range{
-				From: analyzerCfg.From,
-				To:   analyzerCfg.To,
-			}
-			a.SetConfig(analyzer.Config{
-				ChainID:    analyzerCfg.ChainID,
-				BlockRange: blockRange,
-				Source:     source,
-			})
-		} else {
+			analyzerConfig.Source = source
+		}
+		if analyzerCfg.Interval != "" {
 			interval, err := time.ParseDuration(analyzerCfg.Interval)
 			if err != nil {
 				return nil, err
 			}
-
-			// Configure analyzer.
-			a.SetConfig(analyzer.Config{
-				ChainID:  analyzerCfg.ChainID,
-				Interval: interval,
-			})
+			analyzerConfig.Interval = interval
+		}
+		if cfg.Quarantine != nil {
+			analyzerConfig.Quarantine = &analyzer.QuarantinePolicy{
+				MaxAttempts: cfg.Quarantine.MaxAttempts,
+				Halt:        cfg.Quarantine.Halt,
+			}
+		}
+		if cfg.Invariants != nil {
+			analyzerConfig.Invariants = &analyzer.InvariantPolicy{
+				PerEpoch: cfg.Invariants.Frequency == config.InvariantsFrequencyEpoch,
+				Halt:     cfg.Invariants.Halt,
+			}
 		}
+		if cfg.Coordination != nil {
+			analyzerConfig.Lease = coordination.NewLease(client, chain.Schema, analyzerCfg.Name, holder, leaseDuration)
+		}
+		a.SetConfig(analyzerConfig)
+
+		analyzers[a.Name()] = a
 	}
 
 	logger.Info("initialized analyzers")
 
+	// Initialize pruning.
+	var pruners []*retention.Pruner
+	if cfg.Retention != nil {
+		policy, err := retentionPolicy(cfg.Retention)
+		if err != nil {
+			return nil, err
+		}
+		pruned := make(map[analyzer.ChainID]bool)
+		for _, analyzerCfg := range cfg.Analyzers {
+			chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+			if err != nil {
+				return nil, err
+			}
+			if pruned[chain.ID] {
+				continue
+			}
+			pruned[chain.ID] = true
+
+			var lease *coordination.Lease
+			if cfg.Coordination != nil {
+				lease = coordination.NewLease(client, chain.Schema, retention.LeaseName, holder, leaseDuration)
+			}
+			pruners = append(pruners, retention.NewPruner(chain, policy, lease, client, logger))
+		}
+		logger.Info("initialized pruners")
+	}
+
 	return &Service{
 		Analyzers: analyzers,
+		Pruners:   pruners,
 
 		target: client,
 		logger: logger,
@@ -188,6 +264,17 @@ func NewService(cfg *config.AnalysisConfig) (*Service, error) {
 func (a *Service) Start() {
 	a.logger.Info("starting analysis service")
 
+	// Pruners run until all analyzers have stopped.
+	ctx, cancel := context.WithCancel(context.Background())
+	var prunersWg sync.WaitGroup
+	for _, p := range a.Pruners {
+		prunersWg.Add(1)
+		go func(p *retention.Pruner) {
+			defer prunersWg.Done()
+			p.Start(ctx)
+		}(p)
+	}
+
 	var wg sync.WaitGroup
 	for _, an := range a.Analyzers {
 		wg.Add(1)
@@ -198,6 +285,28 @@ func (a *Service) Start() {
 	}
 
 	wg.Wait()
+	cancel()
+	prunersWg.Wait()
+}
+
+// retentionPolicy returns the pruning policy of the provided retention config.
+func retentionPolicy(cfg *config.RetentionConfig) (retention.Policy, error) {
+	interval, err := cfg.Duration()
+	if err != nil {
+		return retention.Policy{}, err
+	}
+	policy := retention.Policy{
+		Interval:               interval,
+		CompactProcessedBlocks: cfg.CompactProcessedBlocks,
+	}
+	for _, rule := range cfg.Rules {
+		policy.Rules = append(policy.Rules, retention.Rule{
+			Table:      rule.Table,
+			KeepBlocks: rule.KeepBlocks,
+			KeepEpochs: rule.KeepEpochs,
+		})
+	}
+	return policy, nil
 }
 
 // Shutdown gracefully shuts down the service.
@@ -2,6 +2,7 @@
 package api
 
 import (
+	"context"
 	"net/http"
 	"os"
 	"time"
@@ -13,6 +14,7 @@ import (
 	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
 )
 
 const (
@@ -74,9 +76,40 @@ func Init(cfg *config.ServerConfig) (*Service, error) {
 		)
 		return nil, err
 	}
+
+	if cfg.Migrations != "" {
+		if err := checkSchemaVersions(context.Background(), service.target, cfg.Migrations); err != nil {
+			service.Shutdown()
+			return nil, err
+		}
+	}
 	return service, nil
 }
 
+// checkSchemaVersions checks that the schema of each chain is at the latest
+// migration version. Chains without applied migrations are not indexed,
+// and are skipped.
+func checkSchemaVersions(ctx context.Context, target storage.TargetStorage, migrations string) error {
+	logger := common.Logger()
+
+	for _, chain := range common.Chains().All() {
+		switch err := migrator.CheckVersion(ctx, target, migrations, chain); err {
+		case nil:
+		case migrator.ErrNoVersion:
+			logger.Info("chain schema not migrated, skipping version check",
+				"chain_id", chain.ID,
+			)
+		default:
+			logger.Error("unexpected schema version, run `oasis-indexer migrate`",
+				"chain_id", chain.ID,
+				"error", err,
+			)
+			return err
+		}
+	}
+	return nil
+}
+
 // Service is the Oasis Indexer's API service.
 type Service struct {
 	server string
@@ -97,7 +130,7 @@ func NewService(cfg *config.ServerConfig) (*Service, error) {
 
 	return &Service{
 		server: cfg.Endpoint,
-		api:    api.NewIndexerAPI(client, logger),
+		api:    api.NewIndexerAPI(cfg, client, common.Chains(), logger),
 		target: client,
 		logger: logger,
 	}, nil
@@ -6,15 +6,22 @@ import (
 	"io"
 	"os"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
 	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
 	"github.com/oasislabs/oasis-indexer/metrics"
 	"github.com/oasislabs/oasis-indexer/storage"
 	"github.com/oasislabs/oasis-indexer/storage/cockroach"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
 	"github.com/oasislabs/oasis-indexer/storage/postgres"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
 )
 
-var rootLogger = log.NewDefaultLogger("oasis-indexer")
+var (
+	rootLogger = log.NewDefaultLogger("oasis-indexer")
+
+	chainRegistry *analyzer.ChainRegistry
+)
 
 // Init initializes the common environment.
 func Init(cfg *config.Config) error {
@@ -39,6 +46,13 @@ func Init(cfg *config.Config) error {
 	}
 	rootLogger = logger
 
+	// Initialize chain registry.
+	chains, err := analyzer.NewChainRegistry(cfg.Chains)
+	if err != nil {
+		return fmt.Errorf("chain registry: %w", err)
+	}
+	chainRegistry = chains
+
 	// Initialize Prometheus service.
 	if cfg.Metrics != nil {
 		promServer, err := metrics.NewPullService(cfg.Metrics.PullEndpoint, rootLogger)
@@ -56,6 +70,11 @@ func Logger() *log.Logger {
 	return rootLogger
 }
 
+// Chains returns the chain registry defined by the chains config.
+func Chains() *analyzer.ChainRegistry {
+	return chainRegistry
+}
+
 func getLoggingStream(cfg *config.LogConfig) (io.Writer, error) {
 	if cfg == nil || cfg.File == "" {
 		return os.Stdout, nil
@@ -78,9 +97,19 @@ func NewClient(cfg *config.StorageConfig, logger *log.Logger) (storage.TargetSto
 	var err error
 	switch backend {
 	case config.BackendCockroach:
-		client, err = cockroach.NewClient(cfg.Endpoint, logger)
+		client, err = cockroach.NewClientWithMaxConns(cfg.Endpoint, cfg.MaxConns, logger)
 	case config.BackendPostgres:
-		client, err = postgres.NewClient(cfg.Endpoint, logger)
+		client, err = postgres.NewClientWithOptions(cfg.Endpoint, postgres.Options{
+			MaxConns:        cfg.MaxConns,
+			Replicas:        cfg.Replicas,
+			ReplicaMaxConns: cfg.ReplicaMaxConns,
+		}, logger)
+	case config.BackendSQLite:
+		var schemas []string
+		for _, chain := range Chains().All() {
+			schemas = append(schemas, chain.Schema)
+		}
+		client, err = sqlite.NewClient(cfg.Endpoint, schemas, logger)
 	default:
 		panic(fmt.Sprintf("unsupported storage backend: %v", backend))
 	}
@@ -89,3 +118,13 @@ func NewClient(cfg *config.StorageConfig, logger *log.Logger) (storage.TargetSto
 	}
 	return client, nil
 }
+
+// NewMigrator creates a new migrator of the configured migrations,
+// to be applied to the configured target storage.
+func NewMigrator(cfg *config.AnalysisConfig, logger *log.Logger) (*migrator.Migrator, error) {
+	var backend config.StorageBackend
+	if err := backend.Set(cfg.Storage.Backend); err != nil {
+		return nil, err
+	}
+	return migrator.NewMigratorForBackend(cfg.Migrations, cfg.Storage.Endpoint, backend, logger), nil
+}
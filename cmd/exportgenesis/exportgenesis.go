@@ -0,0 +1,156 @@
+// Package exportgenesis implements the `export-genesis` sub-command, which
+// exports indexed state as a genesis document.
+package exportgenesis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+	"github.com/spf13/cobra"
+
+	"github.com/oasislabs/oasis-indexer/cmd/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage/generator"
+)
+
+const (
+	moduleName = "export_genesis"
+)
+
+var (
+	// Path to the configuration file.
+	configFile string
+
+	// Height at which to export state.
+	height int64
+
+	// Name of the analyzer whose indexed state to export.
+	analyzerName string
+
+	// Path to the base genesis file.
+	baseFile string
+
+	// Path to the output genesis file.
+	outputFile string
+
+	exportGenesisCmd = &cobra.Command{
+		Use:   "export-genesis",
+		Short: "Export indexed state as a genesis document",
+		Run:   runExportGenesis,
+	}
+)
+
+func runExportGenesis(cmd *cobra.Command, args []string) {
+	// Initialize config.
+	cfg, err := config.InitConfig(configFile)
+	if err != nil {
+		log.NewDefaultLogger("init").Error("config init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	// Initialize common environment.
+	if err = common.Init(cfg); err != nil {
+		log.NewDefaultLogger("init").Error("init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	logger := common.Logger().WithModule(moduleName)
+
+	if cfg.Analysis == nil {
+		logger.Error("analysis config not provided")
+		os.Exit(1)
+	}
+	if height <= 0 {
+		logger.Error("export height not provided")
+		os.Exit(1)
+	}
+
+	document, err := Export(context.Background(), cfg.Analysis, logger)
+	if err != nil {
+		logger.Error("export failed",
+			"height", height,
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	out, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		logger.Error("failed to marshal genesis document",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	if outputFile == "" {
+		fmt.Println(string(out))
+		return
+	}
+	if err := ioutil.WriteFile(outputFile, out, 0o600); err != nil {
+		logger.Error("failed to write genesis document",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+}
+
+// Export exports the state indexed by the configured analyzer
+// as a genesis document.
+func Export(ctx context.Context, cfg *config.AnalysisConfig, logger *log.Logger) (*genesis.Document, error) {
+	var analyzerCfg *config.AnalyzerConfig
+	for _, c := range cfg.Analyzers {
+		if c.Name == analyzerName {
+			analyzerCfg = c
+		}
+	}
+	if analyzerCfg == nil {
+		return nil, fmt.Errorf("analyzer '%s' not configured", analyzerName)
+	}
+
+	chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+
+	var base *genesis.Document
+	if baseFile != "" {
+		rawDoc, err := ioutil.ReadFile(baseFile)
+		if err != nil {
+			return nil, err
+		}
+		base = &genesis.Document{}
+		if err := json.Unmarshal(rawDoc, base); err != nil {
+			return nil, err
+		}
+	}
+
+	target, err := common.NewClient(cfg.Storage, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Shutdown()
+
+	document, err := generator.NewGenesisExporter(target, chain.Schema, logger).Export(ctx, height, analyzerCfg.Name, base)
+	if err != nil {
+		return nil, err
+	}
+	document.ChainID = analyzerCfg.ChainID
+	return document, nil
+}
+
+// Register registers the export-genesis sub-command.
+func Register(parentCmd *cobra.Command) {
+	exportGenesisCmd.Flags().StringVar(&configFile, "config", "./config/local.yml", "path to the config.yml file")
+	exportGenesisCmd.Flags().Int64Var(&height, "height", 0, "height at which to export state, which indexed state must be at")
+	exportGenesisCmd.Flags().StringVar(&analyzerName, "analyzer", "consensus_main_damask", "name of the analyzer whose indexed state to export")
+	exportGenesisCmd.Flags().StringVar(&baseFile, "base", "", "path to a genesis file from which to copy sections and parameters which are not indexed")
+	exportGenesisCmd.Flags().StringVar(&outputFile, "output", "", "path to output genesis file, or stdout if omitted")
+	parentCmd.AddCommand(exportGenesisCmd)
+}
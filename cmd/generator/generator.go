@@ -16,6 +16,7 @@ import (
 
 	oasisConfig "github.com/oasisprotocol/oasis-sdk/client-sdk/go/config"
 
+	"github.com/oasislabs/oasis-indexer/analyzer"
 	"github.com/oasislabs/oasis-indexer/cmd/common"
 	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
@@ -35,6 +36,10 @@ const (
 	// CfgNetworkConfigFile is the config file for connecting to an oasis-node.
 	CfgNetworkConfigFile = "generator.network_config_file"
 
+	// CfgDirectLoad enables loading genesis state directly into target
+	// storage instead of writing a migration.
+	CfgDirectLoad = "generator.direct_load"
+
 	moduleName = "generator"
 )
 
@@ -45,6 +50,7 @@ var (
 	cfgMigrationFile     string
 	cfgGenesisFile       string
 	cfgNetworkConfigFile string
+	cfgDirectLoad        bool
 
 	generateCmd = &cobra.Command{
 		Use:   "generate",
@@ -68,13 +74,28 @@ func runGenerator(cmd *cobra.Command, args []string) {
 
 	g, err := NewGenerator()
 	if err != nil {
-		logger.Error("migration failed to run",
+		logger.Error("generator failed to initialize",
 			"error", err,
 		)
 		os.Exit(1)
 	}
+
+	if cfgDirectLoad {
+		if cfg.Analysis == nil {
+			logger.Error("analysis config not provided")
+			os.Exit(1)
+		}
+		if err := g.Load(cfg.Analysis); err != nil {
+			logger.Error("genesis state failed to load",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := g.WriteMigration(); err != nil {
-		logger.Error("generator failed to initialize",
+		logger.Error("migration failed to run",
 			"error", err,
 		)
 		os.Exit(1)
@@ -99,22 +120,9 @@ func NewGenerator() (*Generator, error) {
 
 // WriteMigration writes the state migration.
 func (g *Generator) WriteMigration() error {
-	var d *genesis.Document
-	switch {
-	case cfgGenesisFile != "":
-		doc, err := g.genesisDocFromFile()
-		if err != nil {
-			return err
-		}
-		d = doc
-	case cfgNetworkConfigFile != "":
-		doc, err := g.genesisDocFromClient()
-		if err != nil {
-			return err
-		}
-		d = doc
-	default:
-		return errors.New("neither genesis file nor network config provided")
+	d, err := g.genesisDoc()
+	if err != nil {
+		return err
 	}
 
 	// Create output file.
@@ -129,24 +137,73 @@ func (g *Generator) WriteMigration() error {
 	}
 
 	// Generate migration.
-	switch d.ChainID {
-	case "oasis-3":
-		if err := g.gen.WriteGenesisDocumentMigrationOasis3(w, d); err != nil {
-			return err
-		}
-	case "test":
-		if err := g.gen.WriteGenesisDocumentMigrationOasis3(w, d); err != nil {
-			return err
-		}
-	default:
-		g.logger.Error("unsupported chain id")
-		return errors.New("unsupported chain id")
+	chain, err := g.chain(d)
+	if err != nil {
+		return err
+	}
+	if err := g.gen.WriteGenesisDocumentMigration(w, chain.Schema, d); err != nil {
+		return err
 	}
 
 	g.logger.Info("successfully wrote migration")
 	return nil
 }
 
+// Load loads genesis state directly into target storage, after
+// running migrations for its chain.
+func (g *Generator) Load(cfg *config.AnalysisConfig) error {
+	ctx := context.Background()
+
+	d, err := g.genesisDoc()
+	if err != nil {
+		return err
+	}
+	chain, err := g.chain(d)
+	if err != nil {
+		return err
+	}
+
+	m, err := common.NewMigrator(cfg, g.logger)
+	if err != nil {
+		return err
+	}
+	if err := m.Up(chain); err != nil {
+		return err
+	}
+
+	target, err := common.NewClient(cfg.Storage, g.logger)
+	if err != nil {
+		return err
+	}
+	defer target.Shutdown()
+
+	return g.gen.LoadGenesisDocument(ctx, target, chain.Schema, d)
+}
+
+// genesisDoc returns the genesis document from the configured source.
+func (g *Generator) genesisDoc() (*genesis.Document, error) {
+	switch {
+	case cfgGenesisFile != "":
+		return g.genesisDocFromFile()
+	case cfgNetworkConfigFile != "":
+		return g.genesisDocFromClient()
+	default:
+		return nil, errors.New("neither genesis file nor network config provided")
+	}
+}
+
+// chain returns the chain of the provided genesis document.
+func (g *Generator) chain(d *genesis.Document) (*analyzer.Chain, error) {
+	chain, err := common.Chains().FromID(d.ChainID)
+	if err != nil {
+		g.logger.Error("unsupported chain id",
+			"chain_id", d.ChainID,
+		)
+		return nil, err
+	}
+	return chain, nil
+}
+
 func (g *Generator) genesisDocFromFile() (*genesis.Document, error) {
 	rawDoc, err := ioutil.ReadFile(cfgGenesisFile)
 	if err != nil {
@@ -193,5 +250,6 @@ func Register(parentCmd *cobra.Command) {
 	generateCmd.Flags().StringVar(&cfgMigrationFile, CfgMigrationFile, "", "path to output migration file")
 	generateCmd.Flags().StringVar(&cfgGenesisFile, CfgGenesisFile, "", "path to input genesis file")
 	generateCmd.Flags().StringVar(&cfgNetworkConfigFile, CfgNetworkConfigFile, "", "path to a network configuration file")
+	generateCmd.Flags().BoolVar(&cfgDirectLoad, CfgDirectLoad, false, "load genesis state directly into target storage instead of writing a migration")
 	parentCmd.AddCommand(generateCmd)
 }
@@ -0,0 +1,203 @@
+// Package migrate implements the `migrate` sub-command, which manages
+// target storage migrations for each chain.
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/cmd/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+)
+
+var (
+	// Path to the configuration file.
+	configFile string
+
+	// Chain ID of the chain whose migrations to manage.
+	chainID string
+
+	// Number of migrations to revert.
+	downSteps int
+
+	// Whether to revert all migrations.
+	downAll bool
+
+	migrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage target storage migrations",
+	}
+
+	upCmd = &cobra.Command{
+		Use:   "up",
+		Short: "Apply all pending migrations",
+		Args:  cobra.NoArgs,
+		Run: run(false, func(m *migrator.Migrator, chain *analyzer.Chain, args []string) error {
+			return m.Up(chain)
+		}),
+	}
+
+	downCmd = &cobra.Command{
+		Use:   "down",
+		Short: "Revert applied migrations",
+		Args:  cobra.NoArgs,
+		Run: run(true, func(m *migrator.Migrator, chain *analyzer.Chain, args []string) error {
+			if downAll {
+				return m.Down(chain, 0)
+			}
+			if downSteps <= 0 {
+				return fmt.Errorf("malformed number of steps %d", downSteps)
+			}
+			return m.Down(chain, downSteps)
+		}),
+	}
+
+	gotoCmd = &cobra.Command{
+		Use:   "goto VERSION",
+		Short: "Apply or revert migrations until a version is reached",
+		Args:  cobra.ExactArgs(1),
+		Run: run(true, func(m *migrator.Migrator, chain *analyzer.Chain, args []string) error {
+			version, err := strconv.ParseUint(args[0], 10, 0)
+			if err != nil {
+				return err
+			}
+			return m.Goto(chain, uint(version))
+		}),
+	}
+
+	forceCmd = &cobra.Command{
+		Use:   "force VERSION",
+		Short: "Set the migration version without applying migrations, e.g. to recover a dirty schema",
+		Args:  cobra.ExactArgs(1),
+		Run: run(true, func(m *migrator.Migrator, chain *analyzer.Chain, args []string) error {
+			version, err := strconv.Atoi(args[0])
+			if err != nil {
+				return err
+			}
+			return m.Force(chain, version)
+		}),
+	}
+
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Print the migration status of each chain",
+		Args:  cobra.NoArgs,
+		Run: run(false, func(m *migrator.Migrator, chain *analyzer.Chain, args []string) error {
+			status, err := m.Status(chain)
+			if err != nil {
+				return err
+			}
+			out, err := json.Marshal(status)
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+			return nil
+		}),
+	}
+)
+
+// run returns a command function which calls the provided function for
+// the selected chain, or for each chain being analyzed if none is selected.
+// Commands which revert or overwrite migration progress require a chain to
+// be selected.
+func run(requireChain bool, f func(*migrator.Migrator, *analyzer.Chain, []string) error) func(*cobra.Command, []string) {
+	return func(cmd *cobra.Command, args []string) {
+		// Initialize config.
+		cfg, err := config.InitConfig(configFile)
+		if err != nil {
+			log.NewDefaultLogger("init").Error("config init failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+
+		// Initialize common environment.
+		if err = common.Init(cfg); err != nil {
+			log.NewDefaultLogger("init").Error("init failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		logger := common.Logger()
+
+		if cfg.Analysis == nil {
+			logger.Error("analysis config not provided")
+			os.Exit(1)
+		}
+		if requireChain && chainID == "" {
+			logger.Error("chain not provided")
+			os.Exit(1)
+		}
+
+		chains, err := Chains(cfg.Analysis)
+		if err != nil {
+			logger.Error("failed to select chains",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+
+		m, err := common.NewMigrator(cfg.Analysis, logger)
+		if err != nil {
+			logger.Error("migrator init failed",
+				"error", err,
+			)
+			os.Exit(1)
+		}
+		for _, chain := range chains {
+			if err := f(m, chain, args); err != nil {
+				logger.Error("migration command failed",
+					"command", cmd.Name(),
+					"chain_id", chain.ID,
+					"error", err,
+				)
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// Chains returns the selected chain, or each chain being analyzed
+// if none is selected.
+func Chains(cfg *config.AnalysisConfig) ([]*analyzer.Chain, error) {
+	if chainID != "" {
+		chain, err := common.Chains().FromID(chainID)
+		if err != nil {
+			return nil, err
+		}
+		return []*analyzer.Chain{chain}, nil
+	}
+
+	var chains []*analyzer.Chain
+	selected := make(map[analyzer.ChainID]bool)
+	for _, analyzerCfg := range cfg.Analyzers {
+		chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+		if err != nil {
+			return nil, err
+		}
+		if selected[chain.ID] {
+			continue
+		}
+		chains = append(chains, chain)
+		selected[chain.ID] = true
+	}
+	return chains, nil
+}
+
+// Register registers the migrate sub-command.
+func Register(parentCmd *cobra.Command) {
+	migrateCmd.PersistentFlags().StringVar(&configFile, "config", "./config/local.yml", "path to the config.yml file")
+	migrateCmd.PersistentFlags().StringVar(&chainID, "chain", "", "chain ID of the chain whose migrations to manage, or each chain being analyzed if omitted")
+	downCmd.Flags().IntVar(&downSteps, "steps", 1, "number of applied migrations to revert")
+	downCmd.Flags().BoolVar(&downAll, "all", false, "revert all applied migrations")
+	migrateCmd.AddCommand(upCmd, downCmd, gotoCmd, forceCmd, statusCmd)
+	parentCmd.AddCommand(migrateCmd)
+}
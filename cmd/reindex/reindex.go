@@ -0,0 +1,109 @@
+// Package reindex implements the `reindex` sub-command, which rolls
+// indexed data back to a height and resumes analysis from there.
+package reindex
+
+import (
+	"context"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	analysis "github.com/oasislabs/oasis-indexer/cmd/analyzer"
+	"github.com/oasislabs/oasis-indexer/cmd/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+)
+
+var (
+	// Path to the configuration file.
+	configFile string
+
+	// Height to which to roll back.
+	fromHeight int64
+
+	// Whether to resume analysis after rolling back.
+	resume bool
+
+	reindexCmd = &cobra.Command{
+		Use:   "reindex",
+		Short: "Roll back indexed data to a height and reprocess",
+		Run:   runReindex,
+	}
+)
+
+func runReindex(cmd *cobra.Command, args []string) {
+	// Initialize config.
+	cfg, err := config.InitConfig(configFile)
+	if err != nil {
+		log.NewDefaultLogger("init").Error("config init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	// Initialize common environment.
+	if err = common.Init(cfg); err != nil {
+		log.NewDefaultLogger("init").Error("init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	logger := common.Logger()
+
+	if cfg.Analysis == nil {
+		logger.Error("analysis config not provided")
+		os.Exit(1)
+	}
+	if fromHeight <= 0 {
+		logger.Error("reindex height not provided")
+		os.Exit(1)
+	}
+
+	service, err := analysis.Init(cfg.Analysis)
+	if err != nil {
+		os.Exit(1)
+	}
+	defer service.Shutdown()
+
+	if err := Rollback(context.Background(), service, fromHeight); err != nil {
+		logger.Error("rollback failed",
+			"height", fromHeight,
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	if resume {
+		service.Start()
+	}
+}
+
+// Rollback rolls back the data indexed by all analyzers of the provided
+// service to the provided height. Analyzers which do not support rolling
+// back are skipped.
+func Rollback(ctx context.Context, service *analysis.Service, height int64) error {
+	logger := common.Logger()
+
+	for name, a := range service.Analyzers {
+		r, ok := a.(analyzer.Reindexer)
+		if !ok {
+			logger.Info("analyzer does not support reindexing, skipping",
+				"analyzer", name,
+			)
+			continue
+		}
+		if err := r.Rollback(ctx, height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register registers the reindex sub-command.
+func Register(parentCmd *cobra.Command) {
+	reindexCmd.Flags().StringVar(&configFile, "config", "./config/local.yml", "path to the config.yml file")
+	reindexCmd.Flags().Int64Var(&fromHeight, "from", 0, "height to roll back to, from which analysis resumes")
+	reindexCmd.Flags().BoolVar(&resume, "resume", true, "resume analysis after rolling back")
+	parentCmd.AddCommand(reindexCmd)
+}
@@ -11,7 +11,11 @@ import (
 	"github.com/oasislabs/oasis-indexer/cmd/analyzer"
 	"github.com/oasislabs/oasis-indexer/cmd/api"
 	"github.com/oasislabs/oasis-indexer/cmd/common"
+	"github.com/oasislabs/oasis-indexer/cmd/exportgenesis"
 	"github.com/oasislabs/oasis-indexer/cmd/generator"
+	"github.com/oasislabs/oasis-indexer/cmd/migrate"
+	"github.com/oasislabs/oasis-indexer/cmd/reindex"
+	"github.com/oasislabs/oasis-indexer/cmd/verify"
 	"github.com/oasislabs/oasis-indexer/config"
 	"github.com/oasislabs/oasis-indexer/log"
 )
@@ -96,7 +100,11 @@ func init() {
 	for _, f := range []func(*cobra.Command){
 		analyzer.Register,
 		api.Register,
+		exportgenesis.Register,
 		generator.Register,
+		migrate.Register,
+		reindex.Register,
+		verify.Register,
 	} {
 		f(rootCmd)
 	}
@@ -0,0 +1,136 @@
+// Package verify implements the `verify` sub-command, which compares
+// indexed state against the state of an Oasis node.
+package verify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	oasisConfig "github.com/oasisprotocol/oasis-sdk/client-sdk/go/config"
+	"github.com/spf13/cobra"
+
+	"github.com/oasislabs/oasis-indexer/analyzer/verifier"
+	"github.com/oasislabs/oasis-indexer/cmd/common"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/metrics"
+	source "github.com/oasislabs/oasis-indexer/storage/oasis"
+)
+
+const (
+	moduleName = "verification"
+)
+
+var (
+	// Path to the configuration file.
+	configFile string
+
+	// Height at which to verify state.
+	height int64
+
+	// Name of the analyzer whose indexed state to verify.
+	analyzerName string
+
+	verifyCmd = &cobra.Command{
+		Use:   "verify",
+		Short: "Verify indexed state against node state",
+		Run:   runVerify,
+	}
+)
+
+func runVerify(cmd *cobra.Command, args []string) {
+	// Initialize config.
+	cfg, err := config.InitConfig(configFile)
+	if err != nil {
+		log.NewDefaultLogger("init").Error("config init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+
+	// Initialize common environment.
+	if err = common.Init(cfg); err != nil {
+		log.NewDefaultLogger("init").Error("init failed",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	logger := common.Logger().WithModule(moduleName)
+
+	if cfg.Analysis == nil {
+		logger.Error("analysis config not provided")
+		os.Exit(1)
+	}
+
+	report, err := Verify(context.Background(), cfg.Analysis, logger)
+	if err != nil {
+		logger.Error("verification failed to run",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	verifier.Record(metrics.NewDefaultVerificationMetrics(moduleName), report)
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		logger.Error("failed to marshal report",
+			"error", err,
+		)
+		os.Exit(1)
+	}
+	fmt.Println(string(out))
+
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
+
+// Verify verifies the state indexed by the configured analyzer.
+func Verify(ctx context.Context, cfg *config.AnalysisConfig, logger *log.Logger) (*verifier.Report, error) {
+	var analyzerCfg *config.AnalyzerConfig
+	for _, c := range cfg.Analyzers {
+		if c.Name == analyzerName {
+			analyzerCfg = c
+		}
+	}
+	if analyzerCfg == nil {
+		return nil, fmt.Errorf("analyzer '%s' not configured", analyzerName)
+	}
+	if analyzerCfg.RPC == "" {
+		return nil, fmt.Errorf("analyzer '%s' has no node rpc", analyzerName)
+	}
+
+	chain, err := common.Chains().FromID(analyzerCfg.ChainID)
+	if err != nil {
+		return nil, err
+	}
+	chainContext := analyzerCfg.ChainContext
+	if chainContext == "" {
+		chainContext = chain.ChainContext
+	}
+	src, err := source.NewClient(ctx, &oasisConfig.Network{
+		ChainContext: chainContext,
+		RPC:          analyzerCfg.RPC,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := common.NewClient(cfg.Storage, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Shutdown()
+
+	return verifier.NewVerifier(src, target, chain.Schema, analyzerCfg.Name, logger).Verify(ctx, height)
+}
+
+// Register registers the verify sub-command.
+func Register(parentCmd *cobra.Command) {
+	verifyCmd.Flags().StringVar(&configFile, "config", "./config/local.yml", "path to the config.yml file")
+	verifyCmd.Flags().Int64Var(&height, "height", 0, "height at which indexed state is expected to be, or the latest indexed height if omitted")
+	verifyCmd.Flags().StringVar(&analyzerName, "analyzer", "consensus_main_damask", "name of the analyzer whose indexed state to verify")
+	parentCmd.AddCommand(verifyCmd)
+}
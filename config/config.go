@@ -3,7 +3,9 @@ package config
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf"
 	"github.com/knadh/koanf/parsers/yaml"
@@ -15,6 +17,7 @@ import (
 
 // Config contains the CLI configuration.
 type Config struct {
+	Chains   []*ChainConfig  `koanf:"chains"`
 	Analysis *AnalysisConfig `koanf:"analysis"`
 	Server   *ServerConfig   `koanf:"server"`
 	Log      *LogConfig      `koanf:"log"`
@@ -23,10 +26,27 @@ type Config struct {
 
 // Validate performs config validation.
 func (cfg *Config) Validate() error {
+	chainIDs := make(map[string]bool, len(cfg.Chains))
+	for _, chainCfg := range cfg.Chains {
+		if err := chainCfg.Validate(); err != nil {
+			return fmt.Errorf("chains: %w", err)
+		}
+		if _, ok := chainIDs[chainCfg.ChainID]; ok {
+			return fmt.Errorf("chains: repeated chain id '%s'", chainCfg.ChainID)
+		}
+		chainIDs[chainCfg.ChainID] = true
+	}
 	if cfg.Analysis != nil {
 		if err := cfg.Analysis.Validate(); err != nil {
 			return fmt.Errorf("analysis: %w", err)
 		}
+		if len(cfg.Chains) > 0 {
+			for _, analyzerCfg := range cfg.Analysis.Analyzers {
+				if _, ok := chainIDs[analyzerCfg.ChainID]; !ok {
+					return fmt.Errorf("analysis: analyzer '%s' references unknown chain id '%s'", analyzerCfg.Name, analyzerCfg.ChainID)
+				}
+			}
+		}
 	}
 	if cfg.Server != nil {
 		if err := cfg.Server.Validate(); err != nil {
@@ -47,6 +67,75 @@ func (cfg *Config) Validate() error {
 	return nil
 }
 
+// ChainConfig is the configuration for a chain in the chain registry.
+type ChainConfig struct {
+	// ChainID is the chain ID of the chain, e.g. `oasis-3`.
+	ChainID string `koanf:"chain_id"`
+
+	// ChainContext is the domain separation context of the chain.
+	ChainContext string `koanf:"chaincontext"`
+
+	// Schema is the target storage schema in which data for this chain
+	// is stored. If omitted, it is derived from the chain ID.
+	Schema string `koanf:"schema"`
+
+	// MigrationsTable is the table in which migration progress for this
+	// chain's schema is tracked. If omitted, it is derived from the schema.
+	MigrationsTable string `koanf:"migrations_table"`
+
+	// From is the (inclusive) first block height of the chain.
+	From int64 `koanf:"from"`
+
+	// To is the (inclusive) last block height of the chain.
+	// Omitting this parameter means the chain has not yet
+	// been superseded by a breaking upgrade.
+	To int64 `koanf:"to"`
+}
+
+// Validate validates the chain configuration.
+func (cfg *ChainConfig) Validate() error {
+	if cfg.ChainID == "" {
+		return fmt.Errorf("malformed chain id '%s'", cfg.ChainID)
+	}
+	if (cfg.To != 0 && cfg.From > cfg.To) || cfg.To < 0 || cfg.From < 0 {
+		return fmt.Errorf("malformed chain range from %d to %d", cfg.From, cfg.To)
+	}
+	return nil
+}
+
+// analyzerNameRegexp restricts analyzer names, which are used in
+// storage and metric names.
+var analyzerNameRegexp = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// AnalyzerSchema describes the configuration accepted by a type of analyzer.
+type AnalyzerSchema struct {
+	// RequiresRPC is true iff analyzers of this type fetch data from a node.
+	RequiresRPC bool
+
+	// RequiresInterval is true iff analyzers of this type run once per interval.
+	RequiresInterval bool
+
+	// Options are the type-specific options accepted by analyzers of this type.
+	Options []string
+
+	// ValidateOptions validates the values of type-specific options, so
+	// that malformed options are rejected at startup. If omitted, only
+	// option names are validated.
+	ValidateOptions func(cfg *AnalyzerConfig) error
+}
+
+// analyzerSchemas are the schemas of registered analyzer types.
+var analyzerSchemas = make(map[string]AnalyzerSchema)
+
+// RegisterAnalyzerSchema registers the config schema for an analyzer type.
+// It is not safe for concurrent use and is intended to be called from init.
+func RegisterAnalyzerSchema(analyzerType string, schema AnalyzerSchema) {
+	if _, ok := analyzerSchemas[analyzerType]; ok {
+		panic(fmt.Sprintf("config: analyzer type '%s' registered twice", analyzerType))
+	}
+	analyzerSchemas[analyzerType] = schema
+}
+
 // AnalysisConfig is the configuration for chain analyzers.
 type AnalysisConfig struct {
 	// Analyzers is the analyzer configs.
@@ -55,6 +144,23 @@ type AnalysisConfig struct {
 	// Migrations is the directory containing storage migrations.
 	Migrations string `koanf:"migrations"`
 
+	// Coordination is the configuration for coordinating analyzers across
+	// multiple indexer instances sharing the same storage. If omitted,
+	// analyzers assume they are the only instance.
+	Coordination *CoordinationConfig `koanf:"coordination"`
+
+	// Quarantine is the policy for blocks which repeatedly fail to process.
+	// If omitted, analyzers retry failing blocks indefinitely.
+	Quarantine *QuarantineConfig `koanf:"quarantine"`
+
+	// Invariants is the policy for checking accounting invariants
+	// of indexed state. If omitted, invariants are not checked.
+	Invariants *InvariantsConfig `koanf:"invariants"`
+
+	// Retention is the policy for pruning indexed history of the
+	// analyzed chains. If omitted, all history is kept.
+	Retention *RetentionConfig `koanf:"retention"`
+
 	Storage *StorageConfig `koanf:"storage"`
 }
 
@@ -70,14 +176,210 @@ func (cfg *AnalysisConfig) Validate() error {
 		}
 		names[analyzerCfg.Name] = true
 	}
+	if cfg.Coordination != nil {
+		if err := cfg.Coordination.Validate(); err != nil {
+			return fmt.Errorf("coordination: %w", err)
+		}
+	}
+	if cfg.Quarantine != nil {
+		if err := cfg.Quarantine.Validate(); err != nil {
+			return fmt.Errorf("quarantine: %w", err)
+		}
+	}
+	if cfg.Invariants != nil {
+		if err := cfg.Invariants.Validate(); err != nil {
+			return fmt.Errorf("invariants: %w", err)
+		}
+	}
+	if cfg.Retention != nil {
+		if err := cfg.Retention.Validate(); err != nil {
+			return fmt.Errorf("retention: %w", err)
+		}
+		// Compaction relies on PostgreSQL queries and triggers.
+		var sb StorageBackend
+		if cfg.Retention.CompactProcessedBlocks && cfg.Storage != nil && sb.Set(cfg.Storage.Backend) == nil && sb != BackendPostgres {
+			return fmt.Errorf("retention: compacting processed blocks not supported by backend '%s'", cfg.Storage.Backend)
+		}
+	}
+	// Analyzers read their own writes, which replicas may lag behind.
+	if cfg.Storage != nil && len(cfg.Storage.Replicas) > 0 {
+		return fmt.Errorf("storage: replicas not supported for analysis")
+	}
 	return cfg.Storage.Validate()
 }
 
+// QuarantineConfig is the policy for blocks which repeatedly fail to process.
+type QuarantineConfig struct {
+	// MaxAttempts is the number of attempts to process a block
+	// before it is quarantined.
+	MaxAttempts int `koanf:"max_attempts"`
+
+	// Halt is true iff analyzers should stop once a block is quarantined.
+	// Otherwise, analyzers continue with the next block.
+	Halt bool `koanf:"halt"`
+}
+
+// Validate validates the quarantine configuration.
+func (cfg *QuarantineConfig) Validate() error {
+	if cfg.MaxAttempts <= 0 {
+		return fmt.Errorf("malformed max attempts %d", cfg.MaxAttempts)
+	}
+	return nil
+}
+
+// Frequencies at which invariants may be checked.
+const (
+	InvariantsFrequencyBlock = "block"
+	InvariantsFrequencyEpoch = "epoch"
+)
+
+// InvariantsConfig is the policy for checking accounting invariants
+// of indexed state.
+type InvariantsConfig struct {
+	// Frequency is the frequency at which invariants are checked,
+	// either "block" or "epoch". If omitted, invariants are checked
+	// after every block.
+	Frequency string `koanf:"frequency"`
+
+	// Halt is true iff analyzers should stop once an invariant is violated.
+	// Otherwise, violations are only reported.
+	Halt bool `koanf:"halt"`
+}
+
+// Validate validates the invariants configuration.
+func (cfg *InvariantsConfig) Validate() error {
+	switch cfg.Frequency {
+	case "", InvariantsFrequencyBlock, InvariantsFrequencyEpoch:
+		return nil
+	default:
+		return fmt.Errorf("malformed frequency '%s'", cfg.Frequency)
+	}
+}
+
+// RetentionTables are the tables of indexed history which may be pruned.
+var RetentionTables = []string{"blocks", "transactions", "events"}
+
+// RetentionConfig is the policy for pruning indexed history.
+type RetentionConfig struct {
+	// Interval is the interval at which history is pruned.
+	// It should be specified as a string compliant with time.ParseDuration.
+	// If omitted, DefaultRetentionInterval is used.
+	Interval string `koanf:"interval"`
+
+	// Rules are the retention rules of pruned tables. Tables
+	// without a rule are only pruned as required by the rules of
+	// tables they reference, e.g. transactions of pruned blocks.
+	Rules []*RetentionRule `koanf:"rules"`
+
+	// CompactProcessedBlocks is true iff processed_blocks bookkeeping
+	// is compacted to ranges of processed heights.
+	CompactProcessedBlocks bool `koanf:"compact_processed_blocks"`
+}
+
+// DefaultRetentionInterval is the default interval at which history is pruned.
+const DefaultRetentionInterval = time.Hour
+
+// Duration returns the configured pruning interval.
+func (cfg *RetentionConfig) Duration() (time.Duration, error) {
+	if cfg.Interval == "" {
+		return DefaultRetentionInterval, nil
+	}
+	return time.ParseDuration(cfg.Interval)
+}
+
+// Validate validates the retention configuration.
+func (cfg *RetentionConfig) Validate() error {
+	d, err := cfg.Duration()
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("malformed interval '%s'", cfg.Interval)
+	}
+	tables := make(map[string]bool, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+		if tables[rule.Table] {
+			return fmt.Errorf("repeated rule for table '%s'", rule.Table)
+		}
+		tables[rule.Table] = true
+	}
+	return nil
+}
+
+// RetentionRule is the retention rule of a table of indexed history.
+// Exactly one of KeepBlocks and KeepEpochs must be set.
+type RetentionRule struct {
+	// Table is the pruned table, one of RetentionTables.
+	Table string `koanf:"table"`
+
+	// KeepBlocks is the number of most recent blocks for which rows are kept.
+	KeepBlocks int64 `koanf:"keep_blocks"`
+
+	// KeepEpochs is the number of most recent epochs for which rows are kept.
+	KeepEpochs int64 `koanf:"keep_epochs"`
+}
+
+// Validate validates the retention rule.
+func (cfg *RetentionRule) Validate() error {
+	if !contains(RetentionTables, cfg.Table) {
+		return fmt.Errorf("unsupported table '%s'", cfg.Table)
+	}
+	if cfg.KeepBlocks < 0 || cfg.KeepEpochs < 0 || (cfg.KeepBlocks == 0) == (cfg.KeepEpochs == 0) {
+		return fmt.Errorf("table '%s' must keep either a positive number of blocks or of epochs", cfg.Table)
+	}
+	return nil
+}
+
+// CoordinationConfig is the configuration for coordinating analyzers
+// across multiple indexer instances.
+type CoordinationConfig struct {
+	// Holder is the identity of this instance. If omitted, it is
+	// derived from the hostname and process ID.
+	Holder string `koanf:"holder"`
+
+	// LeaseDuration is the duration for which an analyzer lease is held
+	// without being renewed, after which a standby instance takes over.
+	// It should be specified as a string compliant with time.ParseDuration.
+	// If omitted, DefaultLeaseDuration is used.
+	LeaseDuration string `koanf:"lease_duration"`
+}
+
+// DefaultLeaseDuration is the default duration of analyzer leases.
+const DefaultLeaseDuration = 30 * time.Second
+
+// Duration returns the configured lease duration.
+func (cfg *CoordinationConfig) Duration() (time.Duration, error) {
+	if cfg.LeaseDuration == "" {
+		return DefaultLeaseDuration, nil
+	}
+	return time.ParseDuration(cfg.LeaseDuration)
+}
+
+// Validate validates the coordination configuration.
+func (cfg *CoordinationConfig) Validate() error {
+	d, err := cfg.Duration()
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("malformed lease duration '%s'", cfg.LeaseDuration)
+	}
+	return nil
+}
+
 // AnalyzerConfig is the configuration for a chain analyzer.
 type AnalyzerConfig struct {
-	// Name is the name of the analyzer.
+	// Name is the name of the analyzer instance. It must be unique
+	// and consist of lowercase letters, digits and underscores.
 	Name string `koanf:"name"`
 
+	// Type is the type of the analyzer, e.g. `consensus_main_damask`.
+	// If omitted, the name is used as the type.
+	Type string `koanf:"type"`
+
 	// ChainID is the chain ID of the chain this analyzer will process.
 	ChainID string `koanf:"chain_id"`
 
@@ -85,9 +387,11 @@ type AnalyzerConfig struct {
 	RPC string `koanf:"rpc"`
 
 	// ChainContext is the domain separation context.
+	// If omitted, the chain context from the chain registry is used.
 	ChainContext string `koanf:"chaincontext"`
 
 	// From is the (inclusive) starting block for this analyzer.
+	// If omitted, the first block of the chain is used.
 	From int64 `koanf:"from"`
 
 	// To is the (inclusive) ending block for this analyzer.
@@ -100,33 +404,80 @@ type AnalyzerConfig struct {
 	// It should be specified as a string compliant with
 	// time.ParseDuration (https://pkg.go.dev/time#ParseDuration).
 	Interval string `koanf:"interval"`
+
+	// Options are type-specific options for the analyzer.
+	Options map[string]interface{} `koanf:"options"`
+}
+
+// AnalyzerType returns the type of the analyzer.
+func (cfg *AnalyzerConfig) AnalyzerType() string {
+	if cfg.Type == "" {
+		return cfg.Name
+	}
+	return cfg.Type
 }
 
 // Validate validates the analysis configuration.
 func (cfg *AnalyzerConfig) Validate() error {
-	if cfg.Name == "" {
+	if !analyzerNameRegexp.MatchString(cfg.Name) {
 		return fmt.Errorf("malformed analyzer name '%s'", cfg.Name)
 	}
+	schema, ok := analyzerSchemas[cfg.AnalyzerType()]
+	if !ok {
+		return fmt.Errorf("analyzer '%s' has unknown type '%s'", cfg.Name, cfg.AnalyzerType())
+	}
 	if cfg.ChainID == "" {
 		return fmt.Errorf("malformed chain id '%s'", cfg.ChainID)
 	}
-	if cfg.RPC == "" {
+	if schema.RequiresRPC && cfg.RPC == "" {
 		return fmt.Errorf("malformed RPC endpoint '%s'", cfg.RPC)
 	}
-	if cfg.ChainContext == "" {
-		return fmt.Errorf("malformed chain context '%s'", cfg.ChainContext)
+	if schema.RequiresInterval && cfg.Interval == "" {
+		return fmt.Errorf("analyzer '%s' requires an interval", cfg.Name)
 	}
 	if (cfg.To != 0 && cfg.From > cfg.To) || cfg.To < 0 || cfg.From < 0 {
 		return fmt.Errorf("malformed analysis range from %d to %d", cfg.From, cfg.To)
 	}
+	for option := range cfg.Options {
+		if !contains(schema.Options, option) {
+			return fmt.Errorf("analyzer '%s' has unknown option '%s'", cfg.Name, option)
+		}
+	}
+	if schema.ValidateOptions != nil {
+		if err := schema.ValidateOptions(cfg); err != nil {
+			return fmt.Errorf("analyzer '%s' has malformed options: %w", cfg.Name, err)
+		}
+	}
 	return nil
 }
 
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // ServerConfig contains the API server configuration.
 type ServerConfig struct {
 	// Endpoint is the service endpoint from which to serve the API.
 	Endpoint string `koanf:"endpoint"`
 
+	// Admin is true iff the admin API is served under `/admin`.
+	// It should only be enabled on endpoints not exposed publicly.
+	Admin bool `koanf:"admin"`
+
+	// GraphQL is the configuration of the GraphQL API served under
+	// `/graphql`. If omitted, the GraphQL API is not served.
+	GraphQL *GraphQLConfig `koanf:"graphql"`
+
+	// Migrations is the directory containing storage migrations. If set,
+	// the API refuses to start unless the schema of each migrated chain
+	// is at the latest migration version.
+	Migrations string `koanf:"migrations"`
+
 	Storage *StorageConfig `koanf:"storage"`
 }
 
@@ -138,9 +489,63 @@ func (cfg *ServerConfig) Validate() error {
 	if cfg.Storage == nil {
 		return fmt.Errorf("no storage config provided")
 	}
+	if cfg.GraphQL != nil {
+		if err := cfg.GraphQL.Validate(); err != nil {
+			return err
+		}
+	}
 	return cfg.Storage.Validate()
 }
 
+// GraphQLConfig is the configuration of the GraphQL API.
+type GraphQLConfig struct {
+	// MaxDepth is the maximum depth of fields of a query.
+	// If omitted, DefaultGraphQLMaxDepth is used.
+	MaxDepth int `koanf:"max_depth"`
+
+	// MaxComplexity is the maximum complexity of a query, where each
+	// lookup costs one, and each list the number of items requested.
+	// Nested lists cost as much per item of their parent list.
+	// If omitted, DefaultGraphQLMaxComplexity is used.
+	MaxComplexity int64 `koanf:"max_complexity"`
+}
+
+const (
+	// DefaultGraphQLMaxDepth is the default maximum depth of GraphQL queries.
+	DefaultGraphQLMaxDepth = 10
+
+	// DefaultGraphQLMaxComplexity is the default maximum complexity of
+	// GraphQL queries.
+	DefaultGraphQLMaxComplexity = 10000
+)
+
+// Depth returns the configured maximum depth.
+func (cfg *GraphQLConfig) Depth() int {
+	if cfg.MaxDepth == 0 {
+		return DefaultGraphQLMaxDepth
+	}
+	return cfg.MaxDepth
+}
+
+// Complexity returns the configured maximum complexity.
+func (cfg *GraphQLConfig) Complexity() int64 {
+	if cfg.MaxComplexity == 0 {
+		return DefaultGraphQLMaxComplexity
+	}
+	return cfg.MaxComplexity
+}
+
+// Validate validates the GraphQL configuration.
+func (cfg *GraphQLConfig) Validate() error {
+	if cfg.MaxDepth < 0 {
+		return fmt.Errorf("malformed max depth %d", cfg.MaxDepth)
+	}
+	if cfg.MaxComplexity < 0 {
+		return fmt.Errorf("malformed max complexity %d", cfg.MaxComplexity)
+	}
+	return nil
+}
+
 // StorageBackend is a storage backend.
 type StorageBackend uint
 
@@ -151,6 +556,8 @@ const (
 	BackendPostgres
 	// BackendInMemory is the in-memory storage backend.
 	BackendInMemory
+	// BackendSQLite is the SQLite storage backend.
+	BackendSQLite
 )
 
 // String returns the string representation of a StorageBackend.
@@ -162,6 +569,8 @@ func (sb *StorageBackend) String() string {
 		return "postgres"
 	case BackendInMemory:
 		return "inmemory"
+	case BackendSQLite:
+		return "sqlite"
 	default:
 		panic("config: unsupported storage backend")
 	}
@@ -176,6 +585,8 @@ func (sb *StorageBackend) Set(s string) error {
 		*sb = BackendPostgres
 	case "inmemory":
 		*sb = BackendInMemory
+	case "sqlite":
+		*sb = BackendSQLite
 	default:
 		return fmt.Errorf("config: invalid storage backend: '%s'", s)
 	}
@@ -185,16 +596,32 @@ func (sb *StorageBackend) Set(s string) error {
 
 // Type returns the list of supported StorageBackends.
 func (sb *StorageBackend) Type() string {
-	return "[cockroach,postgres,inmemory]"
+	return "[cockroach,postgres,inmemory,sqlite]"
 }
 
 // StorageConfig contains the storage layer configuration.
 type StorageConfig struct {
 	// Endpoint is the storage endpoint from which to read/write indexed data.
+	// For the SQLite backend, this is a `sqlite://` URL of the directory
+	// containing a database file per chain schema.
 	Endpoint string `koanf:"endpoint"`
 
 	// Backend is the storage backend to select.
 	Backend string `koanf:"backend"`
+
+	// MaxConns is the maximum size of the connection pool to the endpoint.
+	// If omitted, a default is used.
+	MaxConns int32 `koanf:"max_conns"`
+
+	// Replicas are read replica endpoints, which are only supported by the
+	// PostgreSQL backend. Writes are sent to the endpoint, and reads are
+	// routed to replicas unless these are behind the height required by
+	// the read.
+	Replicas []string `koanf:"replicas"`
+
+	// ReplicaMaxConns is the maximum size of the connection pool to each
+	// replica. If omitted, a default is used.
+	ReplicaMaxConns int32 `koanf:"replica_max_conns"`
 }
 
 // Validate validates the storage configuration.
@@ -203,7 +630,24 @@ func (cfg *StorageConfig) Validate() error {
 		return fmt.Errorf("malformed storage endpoint '%s'", cfg.Endpoint)
 	}
 	var sb StorageBackend
-	return sb.Set(cfg.Backend)
+	if err := sb.Set(cfg.Backend); err != nil {
+		return err
+	}
+	if cfg.MaxConns < 0 || cfg.ReplicaMaxConns < 0 {
+		return fmt.Errorf("malformed storage pool size")
+	}
+	for _, replica := range cfg.Replicas {
+		if replica == "" {
+			return fmt.Errorf("malformed storage replica endpoint '%s'", replica)
+		}
+	}
+	if len(cfg.Replicas) > 0 && sb != BackendPostgres {
+		return fmt.Errorf("storage replicas not supported by backend '%s'", cfg.Backend)
+	}
+	if sb == BackendSQLite && !strings.HasPrefix(cfg.Endpoint, "sqlite://") {
+		return fmt.Errorf("malformed sqlite storage endpoint '%s'", cfg.Endpoint)
+	}
+	return nil
 }
 
 // LogConfig contains the logging configuration.
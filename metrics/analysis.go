@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default service metrics for block analysis.
+type AnalysisMetrics struct {
+	// Counts of blocks quarantined after repeatedly failing to process.
+	QuarantinedBlocks prometheus.Counter
+
+	// Counts of accounting invariant violations, by invariant.
+	InvariantViolations *prometheus.CounterVec
+
+	// Height of the latest block at which invariants were violated.
+	InvariantViolationHeight prometheus.Gauge
+}
+
+// NewDefaultAnalysisMetrics creates Prometheus metric instrumentation
+// for basic metrics common to block analysis. Default metrics include:
+//
+// 1. Counts of quarantined blocks.
+// 2. Counts of invariant violations.
+// 3. Height of the latest invariant violation.
+func NewDefaultAnalysisMetrics(pkg string) AnalysisMetrics {
+	metrics := AnalysisMetrics{
+		QuarantinedBlocks: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_quarantined_blocks", pkg),
+				Help: "How many blocks were quarantined after repeatedly failing to process.",
+			},
+		),
+		InvariantViolations: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: fmt.Sprintf("%s_invariant_violations", pkg),
+				Help: "How many accounting invariant violations were detected, by invariant.",
+			},
+			[]string{"invariant"},
+		),
+		InvariantViolationHeight: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_invariant_violation_height", pkg),
+				Help: "Height of the latest block at which accounting invariants were violated.",
+			},
+		),
+	}
+	prometheus.MustRegister(metrics.QuarantinedBlocks)
+	prometheus.MustRegister(metrics.InvariantViolations)
+	prometheus.MustRegister(metrics.InvariantViolationHeight)
+	return metrics
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Default service metrics for verifying indexed state.
+type VerificationMetrics struct {
+	// Whether the latest verification passed, as 1 or 0.
+	Passed prometheus.Gauge
+
+	// Height at which the latest verification was performed.
+	Height prometheus.Gauge
+
+	// Counts of mismatches found by the latest verification, by kind.
+	Mismatches *prometheus.GaugeVec
+}
+
+// NewDefaultVerificationMetrics creates Prometheus metric instrumentation
+// for verifying indexed state. Default metrics include:
+//
+// 1. Whether the latest verification passed.
+// 2. Height of the latest verification.
+// 3. Counts of mismatches found by the latest verification.
+func NewDefaultVerificationMetrics(pkg string) VerificationMetrics {
+	metrics := VerificationMetrics{
+		Passed: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_verification_passed", pkg),
+				Help: "Whether the latest verification of indexed state against node state passed.",
+			},
+		),
+		Height: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_verification_height", pkg),
+				Help: "Height at which indexed state was last verified.",
+			},
+		),
+		Mismatches: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: fmt.Sprintf("%s_verification_mismatches", pkg),
+				Help: "How many mismatches the latest verification found, partitioned by kind.",
+			},
+			[]string{"kind"},
+		),
+	}
+	prometheus.MustRegister(metrics.Passed)
+	prometheus.MustRegister(metrics.Height)
+	prometheus.MustRegister(metrics.Mismatches)
+	return metrics
+}
@@ -4,27 +4,19 @@ package storage
 import (
 	"context"
 
-	"github.com/jackc/pgx/v4"
 	beacon "github.com/oasisprotocol/oasis-core/go/beacon/api"
 	"github.com/oasisprotocol/oasis-core/go/common"
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
 	consensus "github.com/oasisprotocol/oasis-core/go/consensus/api"
 	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction"
 	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction/results"
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
 	governance "github.com/oasisprotocol/oasis-core/go/governance/api"
 	registry "github.com/oasisprotocol/oasis-core/go/registry/api"
 	scheduler "github.com/oasisprotocol/oasis-core/go/scheduler/api"
 	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
 )
 
-// QueryBatch represents a batch of queries to be executed atomically.
-type QueryBatch = pgx.Batch
-
-// QueryResults represents the results from a read query.
-type QueryResults = pgx.Rows
-
-// QueryResult represents the result from a read query.
-type QueryResult = pgx.Row
-
 // SourceStorage defines an interface for retrieving raw block data.
 type SourceStorage interface {
 	// BlockData gets block data at the specified height. This includes all
@@ -52,6 +44,17 @@ type SourceStorage interface {
 	// includes all proposals, their respective statuses and voting responses.
 	GovernanceData(ctx context.Context, height int64) (*GovernanceData, error)
 
+	// SupplyData gets token supply data at the specified height. This
+	// includes the total supply and the balances of the reserved pools.
+	SupplyData(ctx context.Context, height int64) (*SupplyData, error)
+
+	// LatestHeight returns the height of the latest block available.
+	LatestHeight(ctx context.Context) (int64, error)
+
+	// GenesisDocumentAtHeight gets the full consensus state at the specified
+	// height, in the form of a genesis document.
+	GenesisDocumentAtHeight(ctx context.Context, height int64) (*genesis.Document, error)
+
 	// TODO: Extend this interface to include a GetRoothashData to pull
 	// runtime blocks. This is only relevant when we begin to build runtime
 	// analyzers.
@@ -60,17 +63,22 @@ type SourceStorage interface {
 	Name() string
 }
 
-// TargetStorage defines an interface for reading and writing
-// processed block data.
-type TargetStorage interface {
-	// SendBatch sends a batch of queries to be applied to target storage.
-	SendBatch(ctx context.Context, batch *QueryBatch) error
-
+// Querier defines an interface for reading processed block data.
+type Querier interface {
 	// Query submits a query to fetch data from target storage.
 	Query(ctx context.Context, sql string, args ...interface{}) (QueryResults, error)
 
 	// QueryRow submits a query to fetch a single row of data from target storage.
 	QueryRow(ctx context.Context, sql string, args ...interface{}) QueryResult
+}
+
+// TargetStorage defines an interface for reading and writing
+// processed block data.
+type TargetStorage interface {
+	Querier
+
+	// SendBatch sends a batch of queries to be applied to target storage.
+	SendBatch(ctx context.Context, batch *QueryBatch) error
 
 	// Shutdown shuts down the target storage client.
 	Shutdown()
@@ -79,6 +87,65 @@ type TargetStorage interface {
 	Name() string
 }
 
+// HeightPartitioner is implemented by target storage which partitions
+// blocks, transactions and events by height range.
+type HeightPartitioner interface {
+	// PartitionsByHeight returns true iff blocks, transactions and events
+	// are partitioned by height range, so that the ensure_partitions and
+	// drop_partitions_below functions of each chain schema exist.
+	PartitionsByHeight() bool
+}
+
+// PartitionsByHeight returns true iff the provided target storage
+// partitions blocks, transactions and events by height range.
+func PartitionsByHeight(target TargetStorage) bool {
+	p, ok := target.(HeightPartitioner)
+	return ok && p.PartitionsByHeight()
+}
+
+// SnapshotReader is implemented by target storage which can serve
+// several reads from a single consistent snapshot.
+type SnapshotReader interface {
+	// ReadSnapshot calls the provided function with a querier whose
+	// reads are all served from the same read-only snapshot.
+	ReadSnapshot(ctx context.Context, read func(Querier) error) error
+}
+
+// ReadSnapshot calls the provided function with a querier whose reads are
+// served from a single snapshot of the provided target storage, and returns
+// true, if the target storage supports snapshots. Otherwise, reads are served
+// by the target storage as is, and false is returned.
+func ReadSnapshot(ctx context.Context, target TargetStorage, read func(Querier) error) (bool, error) {
+	if r, ok := target.(SnapshotReader); ok {
+		return true, r.ReadSnapshot(ctx, read)
+	}
+	return false, read(target)
+}
+
+// MinHeight is the height up to which a schema must be indexed
+// in target storage for a read to be served from it.
+type MinHeight struct {
+	Schema string
+	Height int64
+}
+
+type minHeightContextKey struct{}
+
+// WithMinHeight returns a context with which reads are only served from
+// target storage in which the provided schema is indexed up to the provided
+// height, e.g. when routing reads to replicas. Reads without a minimum
+// height are never routed to replicas.
+func WithMinHeight(ctx context.Context, schema string, height int64) context.Context {
+	return context.WithValue(ctx, minHeightContextKey{}, MinHeight{schema, height})
+}
+
+// MinHeightFromContext returns the height up to which target storage must be
+// indexed to serve reads with the provided context, if any.
+func MinHeightFromContext(ctx context.Context) (MinHeight, bool) {
+	h, ok := ctx.Value(minHeightContextKey{}).(MinHeight)
+	return h, ok
+}
+
 // BlockData represents data for a block at a given height.
 type BlockData struct {
 	Height int64
@@ -126,6 +193,16 @@ type StakingData struct {
 	AllowanceChanges []*staking.AllowanceChangeEvent
 }
 
+// SupplyData represents token supply data at a given height.
+type SupplyData struct {
+	Height int64
+
+	TotalSupply        quantity.Quantity
+	CommonPool         quantity.Quantity
+	LastBlockFees      quantity.Quantity
+	GovernanceDeposits quantity.Quantity
+}
+
 // SchedulerData represents data for elected committees and validators at a given height.
 type SchedulerData struct {
 	Height int64
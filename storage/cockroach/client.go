@@ -4,12 +4,14 @@ package cockroach
 
 import (
 	"context"
+	"errors"
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbpgx"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
 )
 
 const (
@@ -26,11 +28,20 @@ type Client struct {
 
 // NewClient creates a new CockroachDB client.
 func NewClient(connString string, l *log.Logger) (*Client, error) {
+	return NewClientWithMaxConns(connString, 0, l)
+}
+
+// NewClientWithMaxConns creates a new CockroachDB client with a connection
+// pool of the provided maximum size. If zero, a default is used.
+func NewClientWithMaxConns(connString string, maxConns int32, l *log.Logger) (*Client, error) {
 	config, err := pgxpool.ParseConfig(connString)
 	if err != nil {
 		return nil, err
 	}
 	config.MaxConns = defaultMaxConns
+	if maxConns > 0 {
+		config.MaxConns = maxConns
+	}
 
 	pool, err := pgxpool.ConnectConfig(context.Background(), config)
 	if err != nil {
@@ -43,11 +54,13 @@ func NewClient(connString string, l *log.Logger) (*Client, error) {
 }
 
 // SendBatch submits a new transaction batch to CockroachDB.
-func (c *Client) SendBatch(ctx context.Context, batch *pgx.Batch) error {
+// Copied rows are bulk-inserted using multi-row inserts.
+func (c *Client) SendBatch(ctx context.Context, batch *storage.QueryBatch) error {
+	b := pgxBatch(batch)
 	if err := crdbpgx.ExecuteTx(ctx, c.pool, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		batchResults := tx.SendBatch(ctx, batch)
+		batchResults := tx.SendBatch(ctx, b)
 		defer batchResults.Close()
-		for i := 0; i < batch.Len(); i++ {
+		for i := 0; i < b.Len(); i++ {
 			if _, err := batchResults.Exec(); err != nil {
 				return err
 			}
@@ -65,7 +78,7 @@ func (c *Client) SendBatch(ctx context.Context, batch *pgx.Batch) error {
 }
 
 // Query submits a new query to CockroachDB.
-func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
 	rows, err := c.pool.Query(ctx, sql, args...)
 	if err != nil {
 		c.logger.Error("failed to query db",
@@ -77,8 +90,17 @@ func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (pg
 }
 
 // QueryRow submits a new query for a single row to CockroachDB.
-func (c *Client) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
-	return c.pool.QueryRow(ctx, sql, args...)
+func (c *Client) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	return row{c.pool.QueryRow(ctx, sql, args...)}
+}
+
+// ReadSnapshot calls the provided function with a querier whose reads
+// are served from a single read-only transaction, which is serializable
+// in CockroachDB. The function is retried if the transaction is.
+func (c *Client) ReadSnapshot(ctx context.Context, read func(storage.Querier) error) error {
+	return crdbpgx.ExecuteTx(ctx, c.pool, pgx.TxOptions{AccessMode: pgx.ReadOnly}, func(tx pgx.Tx) error {
+		return read(txQuerier{tx})
+	})
 }
 
 // Shutdown shuts down the target storage client.
@@ -90,3 +112,49 @@ func (c *Client) Shutdown() {
 func (c *Client) Name() string {
 	return moduleName
 }
+
+// pgxBatch returns the queries of the provided batch as a pgx batch,
+// preceded by multi-row inserts of its copied rows.
+func pgxBatch(batch *storage.QueryBatch) *pgx.Batch {
+	b := &pgx.Batch{}
+	for _, rows := range batch.Copies() {
+		for _, item := range rows.Inserts() {
+			b.Queue(item.SQL, item.Args...)
+		}
+	}
+	for _, item := range batch.Items() {
+		b.Queue(item.SQL, item.Args...)
+	}
+	return b
+}
+
+// txQuerier serves reads from a transaction.
+type txQuerier struct {
+	tx pgx.Tx
+}
+
+// Query implements storage.Querier.
+func (q txQuerier) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	return q.tx.Query(ctx, sql, args...)
+}
+
+// QueryRow implements storage.Querier.
+func (q txQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	return row{q.tx.QueryRow(ctx, sql, args...)}
+}
+
+// row is a pgx row which returns storage errors.
+type row struct {
+	pgx.Row
+}
+
+// Scan implements storage.QueryResult.
+func (r row) Scan(dest ...interface{}) error {
+	if err := r.Row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
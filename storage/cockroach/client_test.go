@@ -208,3 +208,62 @@ func TestInvalidSendBatch(t *testing.T) {
 	err = client.SendBatch(context.Background(), invalid)
 	require.NotNil(t, err)
 }
+
+func TestSendBatchCopy(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping testing in short mode")
+	}
+
+	client, err := newClient(t)
+	require.Nil(t, err)
+	defer client.Shutdown()
+
+	create := &storage.QueryBatch{}
+	create.Queue(`
+		CREATE TABLE copied_films (
+			fid  INTEGER PRIMARY KEY,
+			name TEXT
+		);
+	`)
+	err = client.SendBatch(context.Background(), create)
+	require.Nil(t, err)
+	defer func() {
+		destroy := &storage.QueryBatch{}
+		destroy.Queue(`
+			DROP TABLE copied_films;
+		`)
+		err := client.SendBatch(context.Background(), destroy)
+		require.Nil(t, err)
+	}()
+
+	// Copied rows are inserted before queued queries.
+	batch := &storage.QueryBatch{}
+	for i, film := range []string{"Gone with the Wind", "Avatar", "Titanic"} {
+		batch.Copy("copied_films", []string{"fid", "name"}, i, film)
+	}
+	batch.Queue(`
+		UPDATE copied_films SET name = 'Star Wars' WHERE fid = 1;
+	`)
+	err = client.SendBatch(context.Background(), batch)
+	require.Nil(t, err)
+
+	var count int64
+	err = client.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM copied_films WHERE name != 'Avatar';
+	`).Scan(&count)
+	require.Nil(t, err)
+	require.Equal(t, int64(3), count)
+
+	// Failed copies roll back the batch.
+	duplicate := &storage.QueryBatch{}
+	duplicate.Copy("copied_films", []string{"fid", "name"}, 3, "Avengers: Endgame")
+	duplicate.Copy("copied_films", []string{"fid", "name"}, 0, "Gone with the Wind")
+	err = client.SendBatch(context.Background(), duplicate)
+	require.NotNil(t, err)
+
+	err = client.QueryRow(context.Background(), `
+		SELECT COUNT(*) FROM copied_films;
+	`).Scan(&count)
+	require.Nil(t, err)
+	require.Equal(t, int64(3), count)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxInsertParams is the maximum number of parameters bound
+// to a single multi-row insert.
+const maxInsertParams = 65535
+
+// CopyRows are rows to be bulk-inserted into a table.
+type CopyRows struct {
+	// Table is the schema-qualified name of the table.
+	Table string
+
+	// Columns are the columns into which rows are inserted.
+	Columns []string
+
+	// Rows are the values of the rows, in column order.
+	Rows [][]interface{}
+}
+
+// Identifier returns the parts of the schema-qualified table name.
+func (c *CopyRows) Identifier() []string {
+	return strings.Split(c.Table, ".")
+}
+
+// Inserts returns the rows as multi-row inserts, for backends which do not
+// support copying. Rows are split over as few inserts as the parameter limit
+// allows.
+func (c *CopyRows) Inserts() []*BatchItem {
+	return c.InsertsWithMaxParams(maxInsertParams)
+}
+
+// InsertsWithMaxParams is like Inserts, for backends which bind at most the
+// provided number of parameters to a single query.
+func (c *CopyRows) InsertsWithMaxParams(maxParams int) []*BatchItem {
+	if len(c.Columns) == 0 {
+		return nil
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", c.Table, strings.Join(c.Columns, ", "))
+	rowsPerInsert := maxParams / len(c.Columns)
+	if rowsPerInsert < 1 {
+		rowsPerInsert = 1
+	}
+
+	var items []*BatchItem
+	for from := 0; from < len(c.Rows); from += rowsPerInsert {
+		to := from + rowsPerInsert
+		if to > len(c.Rows) {
+			to = len(c.Rows)
+		}
+		args := make([]interface{}, 0, (to-from)*len(c.Columns))
+		for _, row := range c.Rows[from:to] {
+			args = append(args, row...)
+		}
+		items = append(items, &BatchItem{
+			SQL:  prefix + Placeholders(to-from, len(c.Columns)),
+			Args: args,
+		})
+	}
+	return items
+}
+
+// Placeholders returns the parameter placeholders for the provided
+// number of rows and columns, e.g. `($1, $2), ($3, $4)`.
+func Placeholders(rows, columns int) string {
+	var sb strings.Builder
+	for i := 0; i < rows; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("(")
+		for j := 0; j < columns; j++ {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			fmt.Fprintf(&sb, "$%d", i*columns+j+1)
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestPlaceholders tests generating parameter placeholders for bulk inserts.
+func TestPlaceholders(t *testing.T) {
+	require.Equal(t, "($1)", Placeholders(1, 1))
+	require.Equal(t, "($1, $2), ($3, $4), ($5, $6)", Placeholders(3, 2))
+}
+
+// TestCopyRowsInserts tests that copied rows are split into
+// multi-row inserts within the parameter limit.
+func TestCopyRowsInserts(t *testing.T) {
+	columns := []string{"a", "b", "c"}
+	rowsPerInsert := maxInsertParams / len(columns)
+
+	rows := &CopyRows{
+		Table:   "oasis_3.events",
+		Columns: columns,
+	}
+	require.Empty(t, rows.Inserts())
+
+	for i := 0; i < rowsPerInsert+1; i++ {
+		rows.Rows = append(rows.Rows, []interface{}{i, i, i})
+	}
+	inserts := rows.Inserts()
+	require.Len(t, inserts, 2)
+	require.True(t, strings.HasPrefix(inserts[0].SQL, "INSERT INTO oasis_3.events (a, b, c) VALUES ($1, $2, $3), "))
+	require.Len(t, inserts[0].Args, rowsPerInsert*len(columns))
+	require.Equal(t, "INSERT INTO oasis_3.events (a, b, c) VALUES ($1, $2, $3)", inserts[1].SQL)
+	require.Equal(t, []interface{}{rowsPerInsert, rowsPerInsert, rowsPerInsert}, inserts[1].Args)
+
+	require.Equal(t, []string{"oasis_3", "events"}, rows.Identifier())
+
+	inserts = rows.InsertsWithMaxParams(7)
+	require.Len(t, inserts, (rowsPerInsert+2)/2)
+	require.Equal(t, "INSERT INTO oasis_3.events (a, b, c) VALUES ($1, $2, $3), ($4, $5, $6)", inserts[0].SQL)
+	require.Len(t, rows.InsertsWithMaxParams(1), rowsPerInsert+1)
+}
+
+// TestQueryBatchCopy tests that copied rows are grouped by table,
+// in the order in which tables were first copied to.
+func TestQueryBatchCopy(t *testing.T) {
+	batch := &QueryBatch{}
+	batch.Copy("blocks", []string{"height"}, 1)
+	batch.Copy("transactions", []string{"block", "hash"}, 1, "a")
+	batch.Copy("blocks", []string{"height"}, 2)
+	batch.Queue("INSERT INTO processed_blocks VALUES ($1)", 2)
+
+	copies := batch.Copies()
+	require.Len(t, copies, 2)
+	require.Equal(t, "blocks", copies[0].Table)
+	require.Equal(t, [][]interface{}{{1}, {2}}, copies[0].Rows)
+	require.Equal(t, "transactions", copies[1].Table)
+	require.Equal(t, [][]interface{}{{1, "a"}}, copies[1].Rows)
+	require.Equal(t, 1, batch.Len())
+
+	// Copies returns a copy.
+	copies[0].Rows[0] = nil
+	require.NotNil(t, batch.Copies()[0].Rows[0])
+}
+
+// TestQueryBatchAppend tests that appending batches preserves
+// the order of queries and copied rows.
+func TestQueryBatchAppend(t *testing.T) {
+	first := &QueryBatch{}
+	first.Copy("blocks", []string{"height"}, 1)
+	first.Queue("QUERY 1")
+
+	second := &QueryBatch{}
+	second.Copy("events", []string{"block"}, 2)
+	second.Copy("blocks", []string{"height"}, 2)
+	second.Queue("QUERY 2")
+
+	batch := &QueryBatch{}
+	batch.Append(first)
+	batch.Append(second)
+
+	items := batch.Items()
+	require.Len(t, items, 2)
+	require.Equal(t, "QUERY 1", items[0].SQL)
+	require.Equal(t, "QUERY 2", items[1].SQL)
+
+	copies := batch.Copies()
+	require.Len(t, copies, 2)
+	require.Equal(t, "blocks", copies[0].Table)
+	require.Equal(t, [][]interface{}{{1}, {2}}, copies[0].Rows)
+	require.Equal(t, "events", copies[1].Table)
+}
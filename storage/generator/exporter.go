@@ -0,0 +1,698 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	beacon "github.com/oasisprotocol/oasis-core/go/beacon/api"
+	"github.com/oasisprotocol/oasis-core/go/common"
+	"github.com/oasisprotocol/oasis-core/go/common/cbor"
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	"github.com/oasisprotocol/oasis-core/go/common/entity"
+	"github.com/oasisprotocol/oasis-core/go/common/node"
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
+	"github.com/oasisprotocol/oasis-core/go/common/version"
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+	governance "github.com/oasisprotocol/oasis-core/go/governance/api"
+	registry "github.com/oasisprotocol/oasis-core/go/registry/api"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+	upgrade "github.com/oasisprotocol/oasis-core/go/upgrade/api"
+
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// GenesisExporter exports indexed state as a genesis document.
+type GenesisExporter struct {
+	target storage.TargetStorage
+	schema string
+	logger *log.Logger
+}
+
+// NewGenesisExporter creates a new exporter of the state indexed
+// in the provided schema.
+func NewGenesisExporter(target storage.TargetStorage, schema string, logger *log.Logger) *GenesisExporter {
+	return &GenesisExporter{
+		target: target,
+		schema: schema,
+		logger: logger,
+	}
+}
+
+// Export returns a genesis document with the registry, staking and
+// governance state indexed as of the provided height, as recorded under the
+// provided analyzer name. Indexed state must be at that height, e.g. because
+// analysis was stopped there.
+//
+// Sections and parameters which are not indexed are copied from the base
+// document if provided, and are left empty otherwise. Indexed state does not
+// include registry descriptor signatures, so exported entity and node
+// descriptors are unsigned.
+func (e *GenesisExporter) Export(ctx context.Context, height int64, progress string, base *genesis.Document) (*genesis.Document, error) {
+	if err := e.checkHeight(ctx, height, progress); err != nil {
+		return nil, err
+	}
+
+	document := &genesis.Document{}
+	if base != nil {
+		*document = *base
+	}
+	document.Height = height
+
+	for _, f := range []func(context.Context, *genesis.Document) error{
+		e.exportTime,
+		e.exportRegistry,
+		e.exportStaking,
+		e.exportBeacon,
+		e.exportGovernance,
+	} {
+		if err := f(ctx, document); err != nil {
+			return nil, err
+		}
+	}
+	setSupply(&document.Staking, document.Governance.Proposals)
+
+	// State is exported with several queries, so analysis must
+	// not progress in the meantime.
+	if err := e.checkHeight(ctx, height, progress); err != nil {
+		return nil, fmt.Errorf("analysis progressed during export: %w", err)
+	}
+
+	e.logger.Info("exported indexed state",
+		"height", height,
+		"entities", len(document.Registry.Entities),
+		"nodes", len(document.Registry.Nodes),
+		"accounts", len(document.Staking.Ledger),
+		"proposals", len(document.Governance.Proposals),
+	)
+	return document, nil
+}
+
+// checkHeight checks that indexed state is at the provided height.
+func (e *GenesisExporter) checkHeight(ctx context.Context, height int64, progress string) error {
+	var latest int64
+	switch err := e.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT height FROM %s.processed_blocks
+			WHERE analyzer = $1
+			ORDER BY height DESC LIMIT 1
+	`, e.schema),
+		progress,
+	).Scan(&latest); err {
+	case nil:
+	case storage.ErrNoRows:
+		return fmt.Errorf("no progress recorded for analyzer '%s'", progress)
+	default:
+		return err
+	}
+	if latest != height {
+		return fmt.Errorf("indexed state is at height %d, not %d", latest, height)
+	}
+	return nil
+}
+
+func (e *GenesisExporter) exportTime(ctx context.Context, document *genesis.Document) error {
+	// The block may not be indexed if analysis was bootstrapped at this height.
+	var t time.Time
+	switch err := e.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT time FROM %s.blocks WHERE height = $1
+	`, e.schema),
+		document.Height,
+	).Scan(&t); err {
+	case nil:
+		document.Time = t
+	case storage.ErrNoRows:
+	default:
+		return err
+	}
+	return nil
+}
+
+func (e *GenesisExporter) exportRegistry(ctx context.Context, document *genesis.Document) error {
+	document.Registry.Entities = nil
+	document.Registry.Nodes = nil
+	document.Registry.Runtimes = nil
+	document.Registry.SuspendedRuntimes = nil
+	document.Registry.NodeStatuses = nil
+
+	// Export entities and the nodes they claim.
+	rows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT e.id, COALESCE(array_agg(c.node_id ORDER BY c.node_id) FILTER (WHERE c.node_id IS NOT NULL), '{}')
+			FROM %[1]s.entities e
+			LEFT JOIN %[1]s.claimed_nodes c ON c.entity_id = e.id
+			GROUP BY e.id
+			ORDER BY e.id
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var nodeIDs []string
+		if err := rows.Scan(
+			&id,
+			&nodeIDs,
+		); err != nil {
+			return err
+		}
+
+		ent := entity.Entity{
+			Versioned: cbor.NewVersioned(entity.LatestDescriptorVersion),
+		}
+		if err := ent.ID.UnmarshalText([]byte(id)); err != nil {
+			return err
+		}
+		for _, nodeID := range nodeIDs {
+			var pk signature.PublicKey
+			if err := pk.UnmarshalText([]byte(nodeID)); err != nil {
+				return err
+			}
+			ent.Nodes = append(ent.Nodes, pk)
+		}
+		document.Registry.Entities = append(document.Registry.Entities, &entity.SignedEntity{
+			Signed: signature.Signed{Blob: cbor.Marshal(ent)},
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Export nodes.
+	nodeRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, entity_id, expiration, tls_pubkey, COALESCE(tls_next_pubkey, ''), p2p_pubkey,
+				consensus_pubkey, COALESCE(vrf_pubkey, ''), COALESCE(roles, ''), COALESCE(software_version, '')
+			FROM %s.nodes
+			ORDER BY id
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer nodeRows.Close()
+
+	for nodeRows.Next() {
+		var id, entityID, tlsPubkey, tlsNextPubkey, p2pPubkey, consensusPubkey, vrfPubkey, roles string
+		n := node.Node{
+			Versioned: cbor.NewVersioned(node.LatestNodeDescriptorVersion),
+		}
+		if err := nodeRows.Scan(
+			&id,
+			&entityID,
+			&n.Expiration,
+			&tlsPubkey,
+			&tlsNextPubkey,
+			&p2pPubkey,
+			&consensusPubkey,
+			&vrfPubkey,
+			&roles,
+			&n.SoftwareVersion,
+		); err != nil {
+			return err
+		}
+
+		for _, k := range []struct {
+			dst *signature.PublicKey
+			src string
+		}{
+			{&n.ID, id},
+			{&n.EntityID, entityID},
+			{&n.TLS.PubKey, tlsPubkey},
+			{&n.TLS.NextPubKey, tlsNextPubkey},
+			{&n.P2P.ID, p2pPubkey},
+			{&n.Consensus.ID, consensusPubkey},
+		} {
+			if err := parsePublicKey(k.dst, k.src); err != nil {
+				return err
+			}
+		}
+		if vrfPubkey != "" {
+			n.VRF = &node.VRFInfo{}
+			if err := parsePublicKey(&n.VRF.ID, vrfPubkey); err != nil {
+				return err
+			}
+		}
+		if n.Roles, err = parseRoles(roles); err != nil {
+			return err
+		}
+		document.Registry.Nodes = append(document.Registry.Nodes, &node.MultiSignedNode{
+			MultiSigned: signature.MultiSigned{Blob: cbor.Marshal(n)},
+		})
+	}
+	if err := nodeRows.Err(); err != nil {
+		return err
+	}
+
+	// Export runtimes.
+	runtimeRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, suspended, kind, tee_hardware, COALESCE(key_manager, 'none')
+			FROM %s.runtimes
+			ORDER BY id
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer runtimeRows.Close()
+
+	for runtimeRows.Next() {
+		var id, kind, teeHardware, keyManager string
+		var suspended bool
+		if err := runtimeRows.Scan(
+			&id,
+			&suspended,
+			&kind,
+			&teeHardware,
+			&keyManager,
+		); err != nil {
+			return err
+		}
+
+		rt := registry.Runtime{
+			Versioned: cbor.NewVersioned(registry.LatestRuntimeDescriptorVersion),
+		}
+		if err := rt.ID.UnmarshalText([]byte(id)); err != nil {
+			return err
+		}
+		if err := rt.Kind.FromString(kind); err != nil {
+			return err
+		}
+		if err := rt.TEEHardware.FromString(teeHardware); err != nil {
+			return err
+		}
+		if keyManager != "none" {
+			rt.KeyManager = &common.Namespace{}
+			if err := rt.KeyManager.UnmarshalText([]byte(keyManager)); err != nil {
+				return err
+			}
+		}
+
+		if suspended {
+			document.Registry.SuspendedRuntimes = append(document.Registry.SuspendedRuntimes, &rt)
+		} else {
+			document.Registry.Runtimes = append(document.Registry.Runtimes, &rt)
+		}
+	}
+	return runtimeRows.Err()
+}
+
+func (e *GenesisExporter) exportStaking(ctx context.Context, document *genesis.Document) error {
+	document.Staking.Ledger = make(map[staking.Address]*staking.Account)
+	document.Staking.Delegations = make(map[staking.Address]map[staking.Address]*staking.Delegation)
+	document.Staking.DebondingDelegations = make(map[staking.Address]map[staking.Address][]*staking.DebondingDelegation)
+
+	// Reserved addresses only receive transfers in indexed state,
+	// and are not part of the ledger.
+	rows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT a.address, a.nonce, a.general_balance::TEXT,
+				a.escrow_balance_active::TEXT, a.escrow_total_shares_active::TEXT,
+				a.escrow_balance_debonding::TEXT, a.escrow_total_shares_debonding::TEXT,
+				COALESCE(c.schedule::TEXT, '')
+			FROM %[1]s.accounts a
+			LEFT JOIN %[1]s.commissions c ON c.address = a.address
+			WHERE a.address NOT IN ($1, $2, $3)
+	`, e.schema),
+		staking.CommonPoolAddress.String(),
+		staking.FeeAccumulatorAddress.String(),
+		staking.GovernanceDepositsAddress.String(),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var address, balance, escrowActive, sharesActive, escrowDebonding, sharesDebonding, schedule string
+		var account staking.Account
+		if err := rows.Scan(
+			&address,
+			&account.General.Nonce,
+			&balance,
+			&escrowActive,
+			&sharesActive,
+			&escrowDebonding,
+			&sharesDebonding,
+			&schedule,
+		); err != nil {
+			return err
+		}
+
+		for _, q := range []struct {
+			dst *quantity.Quantity
+			src string
+		}{
+			{&account.General.Balance, balance},
+			{&account.Escrow.Active.Balance, escrowActive},
+			{&account.Escrow.Active.TotalShares, sharesActive},
+			{&account.Escrow.Debonding.Balance, escrowDebonding},
+			{&account.Escrow.Debonding.TotalShares, sharesDebonding},
+		} {
+			if err := q.dst.UnmarshalText([]byte(q.src)); err != nil {
+				return err
+			}
+		}
+		if schedule != "" {
+			if err := json.Unmarshal([]byte(schedule), &account.Escrow.CommissionSchedule); err != nil {
+				return err
+			}
+		}
+
+		var a staking.Address
+		if err := a.UnmarshalText([]byte(address)); err != nil {
+			return err
+		}
+		document.Staking.Ledger[a] = &account
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Export allowances.
+	allowanceRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT owner, beneficiary, allowance::TEXT
+			FROM %s.allowances
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer allowanceRows.Close()
+
+	for allowanceRows.Next() {
+		var ownerAddress, beneficiaryAddress, amount string
+		if err := allowanceRows.Scan(
+			&ownerAddress,
+			&beneficiaryAddress,
+			&amount,
+		); err != nil {
+			return err
+		}
+
+		var owner, beneficiary staking.Address
+		if err := owner.UnmarshalText([]byte(ownerAddress)); err != nil {
+			return err
+		}
+		if err := beneficiary.UnmarshalText([]byte(beneficiaryAddress)); err != nil {
+			return err
+		}
+		var allowance quantity.Quantity
+		if err := allowance.UnmarshalText([]byte(amount)); err != nil {
+			return err
+		}
+
+		account, ok := document.Staking.Ledger[owner]
+		if !ok {
+			account = &staking.Account{}
+			document.Staking.Ledger[owner] = account
+		}
+		if account.General.Allowances == nil {
+			account.General.Allowances = make(map[staking.Address]quantity.Quantity)
+		}
+		account.General.Allowances[beneficiary] = allowance
+	}
+	if err := allowanceRows.Err(); err != nil {
+		return err
+	}
+
+	// Export delegations.
+	delegationRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT delegatee, delegator, shares::TEXT
+			FROM %s.delegations
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer delegationRows.Close()
+
+	for delegationRows.Next() {
+		var delegateeAddress, delegatorAddress, shares string
+		if err := delegationRows.Scan(
+			&delegateeAddress,
+			&delegatorAddress,
+			&shares,
+		); err != nil {
+			return err
+		}
+
+		var delegatee, delegator staking.Address
+		if err := delegatee.UnmarshalText([]byte(delegateeAddress)); err != nil {
+			return err
+		}
+		if err := delegator.UnmarshalText([]byte(delegatorAddress)); err != nil {
+			return err
+		}
+		var delegation staking.Delegation
+		if err := delegation.Shares.UnmarshalText([]byte(shares)); err != nil {
+			return err
+		}
+
+		escrows, ok := document.Staking.Delegations[delegatee]
+		if !ok {
+			escrows = make(map[staking.Address]*staking.Delegation)
+			document.Staking.Delegations[delegatee] = escrows
+		}
+		escrows[delegator] = &delegation
+	}
+	if err := delegationRows.Err(); err != nil {
+		return err
+	}
+
+	// Export debonding delegations.
+	debondingRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT delegatee, delegator, shares::TEXT, debond_end
+			FROM %s.debonding_delegations
+			ORDER BY debond_end
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer debondingRows.Close()
+
+	for debondingRows.Next() {
+		var delegateeAddress, delegatorAddress, shares string
+		var debondEnd uint64
+		if err := debondingRows.Scan(
+			&delegateeAddress,
+			&delegatorAddress,
+			&shares,
+			&debondEnd,
+		); err != nil {
+			return err
+		}
+
+		var delegatee, delegator staking.Address
+		if err := delegatee.UnmarshalText([]byte(delegateeAddress)); err != nil {
+			return err
+		}
+		if err := delegator.UnmarshalText([]byte(delegatorAddress)); err != nil {
+			return err
+		}
+		debondingDelegation := staking.DebondingDelegation{
+			DebondEndTime: beacon.EpochTime(debondEnd),
+		}
+		if err := debondingDelegation.Shares.UnmarshalText([]byte(shares)); err != nil {
+			return err
+		}
+
+		escrows, ok := document.Staking.DebondingDelegations[delegatee]
+		if !ok {
+			escrows = make(map[staking.Address][]*staking.DebondingDelegation)
+			document.Staking.DebondingDelegations[delegatee] = escrows
+		}
+		escrows[delegator] = append(escrows[delegator], &debondingDelegation)
+	}
+	return debondingRows.Err()
+}
+
+func (e *GenesisExporter) exportBeacon(ctx context.Context, document *genesis.Document) error {
+	var epoch uint64
+	switch err := e.target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT id FROM %s.epochs
+			WHERE start_height <= $1
+			ORDER BY start_height DESC LIMIT 1
+	`, e.schema),
+		document.Height,
+	).Scan(&epoch); err {
+	case nil:
+		document.Beacon.Base = beacon.EpochTime(epoch)
+	case storage.ErrNoRows:
+	default:
+		return err
+	}
+	return nil
+}
+
+func (e *GenesisExporter) exportGovernance(ctx context.Context, document *genesis.Document) error {
+	document.Governance.Proposals = nil
+	document.Governance.VoteEntries = make(map[uint64][]*governance.VoteEntry)
+
+	rows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT id, submitter, state, deposit::TEXT,
+				handler, cp_target_version, rhp_target_version, rcp_target_version, upgrade_epoch, cancels,
+				created_at, closes_at, invalid_votes::TEXT
+			FROM %s.proposals
+			ORDER BY id
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var submitter, state, deposit, invalidVotes string
+		var handler, cpTargetVersion, rhpTargetVersion, rcpTargetVersion *string
+		var upgradeEpoch, cancels *uint64
+		var createdAt, closesAt uint64
+		var p governance.Proposal
+		if err := rows.Scan(
+			&p.ID,
+			&submitter,
+			&state,
+			&deposit,
+			&handler,
+			&cpTargetVersion,
+			&rhpTargetVersion,
+			&rcpTargetVersion,
+			&upgradeEpoch,
+			&cancels,
+			&createdAt,
+			&closesAt,
+			&invalidVotes,
+		); err != nil {
+			return err
+		}
+
+		if err := p.Submitter.UnmarshalText([]byte(submitter)); err != nil {
+			return err
+		}
+		if err := p.State.UnmarshalText([]byte(state)); err != nil {
+			return err
+		}
+		if err := p.Deposit.UnmarshalText([]byte(deposit)); err != nil {
+			return err
+		}
+		if p.InvalidVotes, err = strconv.ParseUint(invalidVotes, 10, 64); err != nil {
+			return err
+		}
+		p.CreatedAt = beacon.EpochTime(createdAt)
+		p.ClosesAt = beacon.EpochTime(closesAt)
+
+		switch {
+		case handler != nil:
+			u := governance.UpgradeProposal{
+				Descriptor: upgrade.Descriptor{
+					Versioned: cbor.NewVersioned(upgrade.LatestDescriptorVersion),
+					Handler:   upgrade.HandlerName(*handler),
+				},
+			}
+			for _, v := range []struct {
+				dst *version.Version
+				src *string
+			}{
+				{&u.Target.ConsensusProtocol, cpTargetVersion},
+				{&u.Target.RuntimeHostProtocol, rhpTargetVersion},
+				{&u.Target.RuntimeCommitteeProtocol, rcpTargetVersion},
+			} {
+				if v.src == nil {
+					continue
+				}
+				if *v.dst, err = version.FromString(*v.src); err != nil {
+					return err
+				}
+			}
+			if upgradeEpoch != nil {
+				u.Epoch = beacon.EpochTime(*upgradeEpoch)
+			}
+			p.Content.Upgrade = &u
+		case cancels != nil:
+			p.Content.CancelUpgrade = &governance.CancelUpgradeProposal{
+				ProposalID: *cancels,
+			}
+		default:
+			return fmt.Errorf("malformed proposal %d", p.ID)
+		}
+
+		document.Governance.Proposals = append(document.Governance.Proposals, &p)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	voteRows, err := e.target.Query(ctx, fmt.Sprintf(`
+		SELECT proposal, voter, vote
+			FROM %s.votes
+			WHERE vote IS NOT NULL
+			ORDER BY proposal, voter
+	`, e.schema))
+	if err != nil {
+		return err
+	}
+	defer voteRows.Close()
+
+	for voteRows.Next() {
+		var proposal uint64
+		var voter, vote string
+		if err := voteRows.Scan(
+			&proposal,
+			&voter,
+			&vote,
+		); err != nil {
+			return err
+		}
+
+		var entry governance.VoteEntry
+		if err := entry.Voter.UnmarshalText([]byte(voter)); err != nil {
+			return err
+		}
+		if err := entry.Vote.UnmarshalText([]byte(vote)); err != nil {
+			return err
+		}
+		document.Governance.VoteEntries[proposal] = append(document.Governance.VoteEntries[proposal], &entry)
+	}
+	return voteRows.Err()
+}
+
+// setSupply sets the governance deposits to the deposits of active
+// proposals, and the total supply to the sum of all ledger balances
+// and pools.
+func setSupply(st *staking.Genesis, proposals []*governance.Proposal) {
+	st.GovernanceDeposits = *quantity.NewQuantity()
+	for _, p := range proposals {
+		if p.State == governance.StateActive {
+			_ = st.GovernanceDeposits.Add(&p.Deposit)
+		}
+	}
+
+	total := quantity.NewQuantity()
+	_ = total.Add(&st.CommonPool)
+	_ = total.Add(&st.LastBlockFees)
+	_ = total.Add(&st.GovernanceDeposits)
+
+	for _, account := range st.Ledger {
+		_ = total.Add(&account.General.Balance)
+		_ = total.Add(&account.Escrow.Active.Balance)
+		_ = total.Add(&account.Escrow.Debonding.Balance)
+	}
+	st.TotalSupply = *total
+}
+
+// parsePublicKey parses the provided public key, leaving the
+// destination unset if it is empty.
+func parsePublicKey(dst *signature.PublicKey, src string) error {
+	if src == "" {
+		return nil
+	}
+	return dst.UnmarshalText([]byte(src))
+}
+
+// parseRoles parses the provided node roles.
+func parseRoles(roles string) (node.RolesMask, error) {
+	var m node.RolesMask
+	if roles == "" {
+		return m, nil
+	}
+	if err := m.UnmarshalText([]byte(roles)); err != nil {
+		return 0, err
+	}
+	return m, nil
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	"github.com/oasisprotocol/oasis-core/go/common/node"
+	"github.com/oasisprotocol/oasis-core/go/common/quantity"
+	governance "github.com/oasisprotocol/oasis-core/go/governance/api"
+	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
+	"github.com/stretchr/testify/require"
+)
+
+func q(n uint64) quantity.Quantity {
+	return *quantity.NewFromUint64(n)
+}
+
+// TestSetSupply tests that the exported total supply accounts for
+// all balances and pools.
+func TestSetSupply(t *testing.T) {
+	var a, b staking.Address
+	require.Nil(t, a.UnmarshalText([]byte("oasis1qrvsa8ukfw3p6kw2vcs0fk9t59mceqq7fyttwqgx")))
+	require.Nil(t, b.UnmarshalText([]byte("oasis1qq3xrq0urs8qcffhvmhfhz4p0mu7ewc8rscnlwxe")))
+
+	st := staking.Genesis{
+		CommonPool:    q(1000),
+		LastBlockFees: q(1),
+		Ledger: map[staking.Address]*staking.Account{
+			a: {
+				General: staking.GeneralAccount{Balance: q(10)},
+			},
+			b: {
+				Escrow: staking.EscrowAccount{
+					Active:    staking.SharePool{Balance: q(20)},
+					Debonding: staking.SharePool{Balance: q(30)},
+				},
+			},
+		},
+	}
+	proposals := []*governance.Proposal{
+		{ID: 1, State: governance.StateActive, Deposit: q(100)},
+		{ID: 2, State: governance.StatePassed, Deposit: q(200)},
+	}
+
+	setSupply(&st, proposals)
+	require.Equal(t, "100", st.GovernanceDeposits.String())
+	require.Equal(t, "1161", st.TotalSupply.String())
+}
+
+// TestParseRoles tests parsing indexed node roles.
+func TestParseRoles(t *testing.T) {
+	for _, roles := range []node.RolesMask{
+		0,
+		node.RoleValidator,
+		node.RoleComputeWorker | node.RoleKeyManager,
+	} {
+		parsed, err := parseRoles(roles.String())
+		require.Nil(t, err)
+		require.Equal(t, roles, parsed)
+	}
+
+	_, err := parseRoles("unknown")
+	require.NotNil(t, err)
+}
+
+// TestParsePublicKey tests parsing indexed public keys.
+func TestParsePublicKey(t *testing.T) {
+	var pk signature.PublicKey
+	require.Nil(t, parsePublicKey(&pk, ""))
+	require.Equal(t, signature.PublicKey{}, pk)
+
+	expected := signature.NewPublicKey("4a3fcc4f1d1fc0b5de4c42b3db7d1d2b3f8a1ec6c5e5a9b1c2d3e4f5a6b7c8d9")
+	require.Nil(t, parsePublicKey(&pk, expected.String()))
+	require.Equal(t, expected, pk)
+}
@@ -6,12 +6,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"strings"
+	"sort"
 
-	"github.com/iancoleman/strcase"
+	"github.com/oasisprotocol/oasis-core/go/common/cbor"
 	"github.com/oasisprotocol/oasis-core/go/common/entity"
 	"github.com/oasisprotocol/oasis-core/go/common/node"
 	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+	governance "github.com/oasisprotocol/oasis-core/go/governance/api"
 	registry "github.com/oasisprotocol/oasis-core/go/registry/api"
 	staking "github.com/oasisprotocol/oasis-core/go/staking/api"
 
@@ -31,21 +32,29 @@ func NewMigrationGenerator(logger *log.Logger) *MigrationGenerator {
 	return &MigrationGenerator{logger}
 }
 
-// WriteGenesisDocumentMigrationOasis3 creates a new migration that re-initializes all
-// height-dependent state as per the provided genesis document.
-func (mg *MigrationGenerator) WriteGenesisDocumentMigrationOasis3(w io.Writer, document *genesis.Document) error {
+// WriteGenesisDocumentMigration creates a new migration that re-initializes all
+// height-dependent state in the provided schema as per the provided genesis document.
+func (mg *MigrationGenerator) WriteGenesisDocumentMigration(w io.Writer, schema string, document *genesis.Document) error {
 	if _, err := io.WriteString(w, `-- DO NOT MODIFY
 -- This file was autogenerated by the oasis-indexer migration generator.
 `); err != nil {
 		return err
 	}
 
-	for _, f := range []func(io.Writer, *genesis.Document) error{
-		mg.addRegistryBackendMigrations,
-		mg.addStakingBackendMigrations,
-		mg.addGovernanceBackendMigrations,
+	return mg.writeGenesisDocument(&sqlWriter{w}, schema, document)
+}
+
+// writeGenesisDocument writes statements re-initializing all height-dependent
+// state in the provided schema as per the provided genesis document.
+func (mg *MigrationGenerator) writeGenesisDocument(sw stateWriter, schema string, document *genesis.Document) error {
+	for _, f := range []func(stateWriter, string, *genesis.Document) error{
+		mg.writeRegistryBackendState,
+		mg.writeStakingBackendState,
+		mg.writeBeaconBackendState,
+		mg.writeSchedulerBackendState,
+		mg.writeGovernanceBackendState,
 	} {
-		if err := f(w, document); err != nil {
+		if err := f(sw, schema, document); err != nil {
 			return err
 		}
 	}
@@ -53,64 +62,59 @@ func (mg *MigrationGenerator) WriteGenesisDocumentMigrationOasis3(w io.Writer, d
 	return nil
 }
 
-func (mg *MigrationGenerator) addRegistryBackendMigrations(w io.Writer, document *genesis.Document) error {
-	chainID := strcase.ToSnake(document.ChainID)
-
-	// Populate entities.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
--- Registry Backend Data
-TRUNCATE %s.entities CASCADE;`, chainID)); err != nil {
-		return err
-	}
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.entities (id, address)
-VALUES
-`, chainID)); err != nil {
+func (mg *MigrationGenerator) writeRegistryBackendState(sw stateWriter, schema string, document *genesis.Document) error {
+	if err := sw.Exec(fmt.Sprintf(`-- Registry Backend Data
+TRUNCATE %[1]s.entities, %[1]s.claimed_nodes, %[1]s.nodes, %[1]s.runtimes CASCADE;`, schema)); err != nil {
 		return err
 	}
-	for i, signedEntity := range document.Registry.Entities {
+
+	// Populate entities and the nodes they claim. Descriptor signatures are
+	// not verified, as documents exported from indexed state are unsigned.
+	entities := sw.Table(schema+".entities", []string{"id", "address"}, "")
+	claimedNodes := sw.Table(schema+".claimed_nodes", []string{"entity_id", "node_id"}, "\nON CONFLICT (entity_id, node_id) DO NOTHING")
+	for _, signedEntity := range document.Registry.Entities {
 		var entity entity.Entity
-		if err := signedEntity.Open(registry.RegisterEntitySignatureContext, &entity); err != nil {
+		if err := cbor.Unmarshal(signedEntity.Blob, &entity); err != nil {
 			return err
 		}
 
-		if _, err := io.WriteString(w, fmt.Sprintf(
-			"\t('%s', '%s')",
+		if err := entities.Add(
 			entity.ID.String(),
 			staking.NewAddress(entity.ID).String(),
-		)); err != nil {
+		); err != nil {
 			return err
 		}
-
-		if i != len(document.Registry.Entities)-1 {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
+		for _, nodeID := range entity.Nodes {
+			if err := claimedNodes.Add(
+				entity.ID.String(),
+				nodeID.String(),
+			); err != nil {
 				return err
 			}
 		}
 	}
-	if _, err := io.WriteString(w, ";\n"); err != nil {
-		return err
-	}
-
-	// Populate nodes.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.nodes CASCADE;`, chainID)); err != nil {
+	if err := entities.Flush(); err != nil {
 		return err
 	}
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.nodes (id, entity_id, expiration, tls_pubkey, tls_next_pubkey, p2p_pubkey, consensus_pubkey, roles)
-VALUES
-`, chainID)); err != nil {
+	if err := claimedNodes.Flush(); err != nil {
 		return err
 	}
-	for i, signedNode := range document.Registry.Nodes {
+
+	// Populate nodes.
+	nodes := sw.Table(schema+".nodes", []string{
+		"id", "entity_id", "expiration", "tls_pubkey", "tls_next_pubkey", "p2p_pubkey", "consensus_pubkey", "vrf_pubkey", "roles", "software_version",
+	}, "")
+	for _, signedNode := range document.Registry.Nodes {
 		var node node.Node
-		if err := signedNode.Open(registry.RegisterNodeSignatureContext, &node); err != nil {
+		if err := cbor.Unmarshal(signedNode.Blob, &node); err != nil {
 			return err
 		}
 
-		if _, err := io.WriteString(w, fmt.Sprintf(
-			"\t('%s', '%s', %d, '%s', '%s', '%s', '%s', '%s')",
+		vrfPubkey := ""
+		if node.VRF != nil {
+			vrfPubkey = node.VRF.ID.String()
+		}
+		if err := nodes.Add(
 			node.ID.String(),
 			node.EntityID.String(),
 			node.Expiration,
@@ -118,461 +122,244 @@ VALUES
 			node.TLS.NextPubKey.String(),
 			node.P2P.ID.String(),
 			node.Consensus.ID.String(),
+			vrfPubkey,
 			node.Roles.String(),
-		)); err != nil {
+			node.SoftwareVersion,
+		); err != nil {
 			return err
 		}
-
-		if i != len(document.Registry.Nodes)-1 {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
-				return err
-			}
-		}
 	}
-	if _, err := io.WriteString(w, ";\n"); err != nil {
+	if err := nodes.Flush(); err != nil {
 		return err
 	}
 
 	// Populate runtimes.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.runtimes CASCADE;`, chainID)); err != nil {
-		return err
-	}
-
-	if len(document.Registry.Runtimes) > 0 {
-		if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.runtimes (id, suspended, kind, tee_hardware, key_manager)
-VALUES
-`, chainID)); err != nil {
-			return err
-		}
-		for i, runtime := range document.Registry.Runtimes {
+	runtimes := sw.Table(schema+".runtimes", []string{"id", "suspended", "kind", "tee_hardware", "key_manager"}, "")
+	for _, rs := range []struct {
+		suspended bool
+		runtimes  []*registry.Runtime
+	}{
+		{false, document.Registry.Runtimes},
+		{true, document.Registry.SuspendedRuntimes},
+	} {
+		for _, runtime := range rs.runtimes {
 			keyManager := "none"
 			if runtime.KeyManager != nil {
 				keyManager = runtime.KeyManager.String()
 			}
-			if _, err := io.WriteString(w, fmt.Sprintf(
-				"\t('%s', %t, '%s', '%s', '%s')",
+			if err := runtimes.Add(
 				runtime.ID.String(),
-				false,
+				rs.suspended,
 				runtime.Kind.String(),
 				runtime.TEEHardware.String(),
 				keyManager,
-
-				// TODO(ennsharma): Add extra_data.
-			)); err != nil {
+			); err != nil {
 				return err
 			}
-
-			if i != len(document.Registry.Runtimes)-1 {
-				if _, err := io.WriteString(w, ",\n"); err != nil {
-					return err
-				}
-			}
-		}
-		if _, err := io.WriteString(w, ";\n"); err != nil {
-			return err
 		}
 	}
-
-	if len(document.Registry.SuspendedRuntimes) > 0 {
-		if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.runtimes (id, suspended, kind, tee_hardware, key_manager)
-VALUES
-`, chainID)); err != nil {
-			return err
-		}
-
-		for i, runtime := range document.Registry.SuspendedRuntimes {
-			keyManager := "none"
-			if runtime.KeyManager != nil {
-				keyManager = runtime.KeyManager.Hex()
-			}
-			if _, err := io.WriteString(w, fmt.Sprintf(
-				"\t('%s', %t, '%s', '%s', '%s')",
-				runtime.ID.String(),
-				true,
-				runtime.Kind.String(),
-				runtime.TEEHardware.String(),
-				keyManager,
-
-				// TODO(ennsharma): Add extra_data.
-			)); err != nil {
-				return err
-			}
-
-			if i != len(document.Registry.SuspendedRuntimes)-1 {
-				if _, err := io.WriteString(w, ",\n"); err != nil {
-					return err
-				}
-			}
-		}
-		if _, err := io.WriteString(w, ";\n"); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return runtimes.Flush()
 }
 
-func (mg *MigrationGenerator) addStakingBackendMigrations(w io.Writer, document *genesis.Document) error {
-	chainID := strcase.ToSnake(document.ChainID)
-
-	// Populate accounts.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
--- Staking Backend Data
-TRUNCATE %s.accounts CASCADE;`, chainID)); err != nil {
-		return err
-	}
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.accounts (address, general_balance, nonce, escrow_balance_active, escrow_total_shares_active, escrow_balance_debonding, escrow_total_shares_debonding)
-VALUES
-`, chainID)); err != nil {
+func (mg *MigrationGenerator) writeStakingBackendState(sw stateWriter, schema string, document *genesis.Document) error {
+	if err := sw.Exec(fmt.Sprintf(`-- Staking Backend Data
+TRUNCATE %[1]s.accounts, %[1]s.commissions, %[1]s.allowances, %[1]s.delegations, %[1]s.debonding_delegations CASCADE;`, schema)); err != nil {
 		return err
 	}
 
-	i := 0
-	for address, account := range document.Staking.Ledger {
-		if _, err := io.WriteString(w, fmt.Sprintf(
-			"\t('%s', %d, %d, %d, %d, %d, %d)",
+	// Populate accounts, their commission schedules and allowances.
+	// Iterate in order for deterministic output.
+	addresses := make([]staking.Address, 0, len(document.Staking.Ledger))
+	for address := range document.Staking.Ledger {
+		addresses = append(addresses, address)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].String() < addresses[j].String()
+	})
+
+	accounts := sw.Table(schema+".accounts", []string{
+		"address", "general_balance", "nonce", "escrow_balance_active", "escrow_total_shares_active", "escrow_balance_debonding", "escrow_total_shares_debonding",
+	}, "")
+	commissions := sw.Table(schema+".commissions", []string{"address", "schedule"}, "")
+	allowances := sw.Table(schema+".allowances", []string{"owner", "beneficiary", "allowance"}, "")
+	for _, address := range addresses {
+		account := document.Staking.Ledger[address]
+		if err := accounts.Add(
 			address.String(),
-			account.General.Balance.ToBigInt(),
+			account.General.Balance.String(),
 			account.General.Nonce,
-			account.Escrow.Active.Balance.ToBigInt(),
-			account.Escrow.Active.TotalShares.ToBigInt(),
-			account.Escrow.Debonding.Balance.ToBigInt(),
-			account.Escrow.Debonding.TotalShares.ToBigInt(),
-		)); err != nil {
+			account.Escrow.Active.Balance.String(),
+			account.Escrow.Active.TotalShares.String(),
+			account.Escrow.Debonding.Balance.String(),
+			account.Escrow.Debonding.TotalShares.String(),
+		); err != nil {
 			return err
 		}
-		i++
 
-		if i%bulkInsertBatchSize == 0 {
-			if _, err := io.WriteString(w, ";\n"); err != nil {
-				return err
-			}
-			if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.accounts (address, general_balance, nonce, escrow_balance_active, escrow_total_shares_active, escrow_balance_debonding, escrow_total_shares_debonding)
-VALUES
-`, chainID)); err != nil {
-				return err
-			}
-		} else if i != len(document.Staking.Ledger) {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
-				return err
-			}
-		}
-	}
-	if _, err := io.WriteString(w, ";\n"); err != nil {
-		return err
-	}
-
-	// Populate commissions.
-	// This likely won't overflow batch limit.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.commissions CASCADE;`, chainID)); err != nil {
-		return err
-	}
-
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.commissions (address, schedule) VALUES
-`, chainID)); err != nil {
-		return err
-	}
-
-	commissions := make([]string, 0)
-
-	for address, account := range document.Staking.Ledger {
 		if len(account.Escrow.CommissionSchedule.Rates) > 0 || len(account.Escrow.CommissionSchedule.Bounds) > 0 {
 			schedule, err := json.Marshal(account.Escrow.CommissionSchedule)
 			if err != nil {
 				return err
 			}
-
-			commissions = append(commissions, fmt.Sprintf(
-				"\t('%s', '%s')",
+			if err := commissions.Add(
 				address.String(),
 				string(schedule),
-			))
-		}
-	}
-
-	for index, commission := range commissions {
-		if _, err := io.WriteString(w, commission); err != nil {
-			return err
-		}
-
-		if index != len(commissions)-1 {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
-				return err
-			}
-		} else {
-			if _, err := io.WriteString(w, ";\n"); err != nil {
-				return err
-			}
-		}
-	}
-
-	// Populate allowances.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.allowances CASCADE;`, chainID)); err != nil {
-		return err
-	}
-
-	foundAllowances := false // in case allowances are empty
-
-	i = 0
-	for owner, account := range document.Staking.Ledger {
-		if len(account.General.Allowances) > 0 && foundAllowances {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
+			); err != nil {
 				return err
 			}
 		}
 
-		ownerAllowances := make([]string, len(account.General.Allowances))
-		j := 0
 		for beneficiary, allowance := range account.General.Allowances {
-			ownerAllowances[j] = fmt.Sprintf(
-				"\t('%s', '%s', %d)",
-				owner.String(),
+			if err := allowances.Add(
+				address.String(),
 				beneficiary.String(),
-				allowance.ToBigInt(),
-			)
-			j++
-		}
-		if len(account.General.Allowances) > 0 && !foundAllowances {
-			if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.allowances (owner, beneficiary, allowance)
-VALUES
-`, chainID)); err != nil {
+				allowance.String(),
+			); err != nil {
 				return err
 			}
-			foundAllowances = true
-		}
-
-		if _, err := io.WriteString(w, strings.Join(ownerAllowances, ",\n")); err != nil {
-			return err
 		}
-		i++
 	}
-	if foundAllowances {
-		if _, err := io.WriteString(w, ";\n"); err != nil {
+	// Commissions reference accounts.
+	for _, t := range []tableWriter{accounts, commissions, allowances} {
+		if err := t.Flush(); err != nil {
 			return err
 		}
 	}
 
 	// Populate delegations.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.delegations CASCADE;`, chainID)); err != nil {
-		return err
-	}
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.delegations (delegatee, delegator, shares)
-VALUES
-`, chainID)); err != nil {
-		return err
-	}
-	i = 0
-	j := 0
+	delegations := sw.Table(schema+".delegations", []string{"delegatee", "delegator", "shares"}, "")
 	for delegatee, escrows := range document.Staking.Delegations {
-		k := 0
 		for delegator, delegation := range escrows {
-			if _, err := io.WriteString(w, fmt.Sprintf(
-				"\t('%s', '%s', %d)",
+			if err := delegations.Add(
 				delegatee.String(),
 				delegator.String(),
-				delegation.Shares.ToBigInt(),
-			)); err != nil {
+				delegation.Shares.String(),
+			); err != nil {
 				return err
 			}
-			i++
-
-			if i%bulkInsertBatchSize == 0 {
-				if _, err := io.WriteString(w, ";\n"); err != nil {
-					return err
-				}
-				if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.delegations (delegatee, delegator, shares)
-VALUES
-`, chainID)); err != nil {
-					return err
-				}
-			} else if !(k == len(escrows)-1 && j == len(document.Staking.Delegations)-1) {
-				if _, err := io.WriteString(w, ",\n"); err != nil {
-					return err
-				}
-			}
-			k++
 		}
-		j++
 	}
-	if _, err := io.WriteString(w, ";\n"); err != nil {
+	if err := delegations.Flush(); err != nil {
 		return err
 	}
 
 	// Populate debonding delegations.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.debonding_delegations CASCADE;`, chainID)); err != nil {
-		return err
-	}
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.debonding_delegations (delegatee, delegator, shares, debond_end)
-VALUES
-`, chainID)); err != nil {
-		return err
-	}
-	i = 0
+	debondingDelegations := sw.Table(schema+".debonding_delegations", []string{"delegatee", "delegator", "shares", "debond_end"}, "")
 	for delegatee, escrows := range document.Staking.DebondingDelegations {
-		delegateeDebondingDelegations := make([]string, 0)
-		j := 0
-		for delegator, debondingDelegations := range escrows {
-			delegatorDebondingDelegations := make([]string, len(debondingDelegations))
-			for k, debondingDelegation := range debondingDelegations {
-				delegatorDebondingDelegations[k] = fmt.Sprintf(
-					"\t('%s', '%s', %d, %d)",
+		for delegator, dds := range escrows {
+			for _, debondingDelegation := range dds {
+				if err := debondingDelegations.Add(
 					delegatee.String(),
 					delegator.String(),
-					debondingDelegation.Shares.ToBigInt(),
+					debondingDelegation.Shares.String(),
 					debondingDelegation.DebondEndTime,
-				)
-			}
-			delegateeDebondingDelegations = append(delegateeDebondingDelegations, delegatorDebondingDelegations...)
-			j++
-		}
-		if _, err := io.WriteString(w, strings.Join(delegateeDebondingDelegations, ",\n")); err != nil {
-			return err
-		}
-		i++
-
-		if i != len(document.Staking.DebondingDelegations) && len(escrows) > 0 {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
-				return err
+				); err != nil {
+					return err
+				}
 			}
 		}
 	}
-	if _, err := io.WriteString(w, ";\n"); err != nil {
+	if err := debondingDelegations.Flush(); err != nil {
 		return err
 	}
 
-	return nil
+	// Record the balances of all accounts as of the genesis height.
+	return sw.Exec(fmt.Sprintf(`INSERT INTO %[1]s.account_balance_history (address, height, general_balance, escrow_balance_active, escrow_balance_debonding)
+SELECT address, %[2]d, general_balance, escrow_balance_active, escrow_balance_debonding FROM %[1]s.accounts WHERE true
+ON CONFLICT (address, height) DO UPDATE SET
+	general_balance = excluded.general_balance,
+	escrow_balance_active = excluded.escrow_balance_active,
+	escrow_balance_debonding = excluded.escrow_balance_debonding;`, schema, document.Height))
 }
 
-func (mg *MigrationGenerator) addGovernanceBackendMigrations(w io.Writer, document *genesis.Document) error {
-	chainID := strcase.ToSnake(document.ChainID)
-
-	// Populate proposals.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
--- Governance Backend Data
-TRUNCATE %s.proposals CASCADE;`, chainID)); err != nil {
+func (mg *MigrationGenerator) writeBeaconBackendState(sw stateWriter, schema string, document *genesis.Document) error {
+	// The genesis document only includes the current epoch. Earlier epochs
+	// are kept, so that state can be re-initialized at any height.
+	epochs := sw.Table(schema+".epochs", []string{"id", "start_height"}, "\nON CONFLICT (id) DO NOTHING")
+	if err := epochs.Add(
+		document.Beacon.Base,
+		document.Height,
+	); err != nil {
 		return err
 	}
+	return epochs.Flush()
+}
 
-	if len(document.Governance.Proposals) > 0 {
-
-		// TODO(ennsharma): Extract `executed` for proposal.
-		if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.proposals (id, submitter, state, deposit, handler, cp_target_version, rhp_target_version, rcp_target_version, upgrade_epoch, cancels, created_at, closes_at, invalid_votes)
-VALUES
-`, chainID)); err != nil {
-			return err
-		}
+func (mg *MigrationGenerator) writeSchedulerBackendState(sw stateWriter, schema string, document *genesis.Document) error {
+	// Committees and validator voting power are elected by the scheduler at
+	// each epoch, and are not part of the genesis document. These are
+	// populated by the analyzer from the first processed block.
+	return sw.Exec(fmt.Sprintf(`-- Scheduler Backend Data
+TRUNCATE %s.committee_members;`, schema))
+}
 
-		for i, proposal := range document.Governance.Proposals {
-			if proposal.Content.Upgrade != nil {
-				if _, err := io.WriteString(w, fmt.Sprintf(
-					"\t(%d, '%s', '%s', %d, '%s', '%s', '%s', '%s', %d, %s, %d, %d, %d)",
-					proposal.ID,
-					proposal.Submitter.String(),
-					proposal.State.String(),
-					proposal.Deposit.ToBigInt(),
-					proposal.Content.Upgrade.Handler,
-					proposal.Content.Upgrade.Target.ConsensusProtocol.String(),
-					proposal.Content.Upgrade.Target.RuntimeHostProtocol.String(),
-					proposal.Content.Upgrade.Target.RuntimeCommitteeProtocol.String(),
-					proposal.Content.Upgrade.Epoch,
-					"null",
-					proposal.CreatedAt,
-					proposal.ClosesAt,
-					proposal.InvalidVotes,
-				)); err != nil {
-					return err
-				}
-			} else if proposal.Content.CancelUpgrade != nil {
-				if _, err := io.WriteString(w, fmt.Sprintf(
-					"\t(%d, '%s', '%s', %d, '%s', '%s', '%s', '%s', '%s', %d, %d, %d, %d)",
-					proposal.ID,
-					proposal.Submitter.String(),
-					proposal.State.String(),
-					proposal.Deposit.ToBigInt(),
-					"",
-					"",
-					"",
-					"",
-					"",
-					proposal.Content.CancelUpgrade.ProposalID,
-					proposal.CreatedAt,
-					proposal.ClosesAt,
-					proposal.InvalidVotes,
-				)); err != nil {
-					return err
-				}
-			}
+func (mg *MigrationGenerator) writeGovernanceBackendState(sw stateWriter, schema string, document *genesis.Document) error {
+	if err := sw.Exec(fmt.Sprintf(`-- Governance Backend Data
+TRUNCATE %[1]s.proposals, %[1]s.votes CASCADE;`, schema)); err != nil {
+		return err
+	}
 
-			if i != len(document.Governance.Proposals)-1 {
-				if _, err := io.WriteString(w, ",\n"); err != nil {
-					return err
-				}
-			}
-		}
-		if _, err := io.WriteString(w, ";\n"); err != nil {
+	// Populate proposals. Cancellations reference the proposal they cancel,
+	// which always has a lower ID.
+	ps := make([]*governance.Proposal, len(document.Governance.Proposals))
+	copy(ps, document.Governance.Proposals)
+	sort.Slice(ps, func(i, j int) bool {
+		return ps[i].ID < ps[j].ID
+	})
+
+	// TODO(ennsharma): Extract `executed` for proposal.
+	proposals := sw.Table(schema+".proposals", []string{
+		"id", "submitter", "state", "deposit", "handler", "cp_target_version", "rhp_target_version", "rcp_target_version", "upgrade_epoch", "cancels", "created_at", "closes_at", "invalid_votes",
+	}, "")
+	for _, proposal := range ps {
+		var handler, cpTargetVersion, rhpTargetVersion, rcpTargetVersion, upgradeEpoch, cancels interface{}
+		switch {
+		case proposal.Content.Upgrade != nil:
+			handler = string(proposal.Content.Upgrade.Handler)
+			cpTargetVersion = proposal.Content.Upgrade.Target.ConsensusProtocol.String()
+			rhpTargetVersion = proposal.Content.Upgrade.Target.RuntimeHostProtocol.String()
+			rcpTargetVersion = proposal.Content.Upgrade.Target.RuntimeCommitteeProtocol.String()
+			upgradeEpoch = uint64(proposal.Content.Upgrade.Epoch)
+		case proposal.Content.CancelUpgrade != nil:
+			cancels = proposal.Content.CancelUpgrade.ProposalID
+		default:
+			return fmt.Errorf("malformed proposal %d", proposal.ID)
+		}
+
+		if err := proposals.Add(
+			proposal.ID,
+			proposal.Submitter.String(),
+			proposal.State.String(),
+			proposal.Deposit.String(),
+			handler,
+			cpTargetVersion,
+			rhpTargetVersion,
+			rcpTargetVersion,
+			upgradeEpoch,
+			cancels,
+			uint64(proposal.CreatedAt),
+			uint64(proposal.ClosesAt),
+			proposal.InvalidVotes,
+		); err != nil {
 			return err
 		}
 	}
-
-	// Populate votes.
-	if _, err := io.WriteString(w, fmt.Sprintf(`
-TRUNCATE %s.votes CASCADE;`, chainID)); err != nil {
+	if err := proposals.Flush(); err != nil {
 		return err
 	}
 
-	foundVotes := false // in case votes are empty
-
-	i := 0
+	// Populate votes.
+	votes := sw.Table(schema+".votes", []string{"proposal", "voter", "vote"}, "")
 	for proposalID, voteEntries := range document.Governance.VoteEntries {
-		if len(voteEntries) > 0 && !foundVotes {
-			if _, err := io.WriteString(w, fmt.Sprintf(`
-INSERT INTO %s.votes (proposal, voter, vote)
-VALUES
-`, chainID)); err != nil {
-				return err
-			}
-			foundVotes = true
-		}
-		votes := make([]string, len(voteEntries))
-		for j, voteEntry := range voteEntries {
-			votes[j] = fmt.Sprintf(
-				"\t(%d, '%s', '%s')",
+		for _, voteEntry := range voteEntries {
+			if err := votes.Add(
 				proposalID,
 				voteEntry.Voter.String(),
 				voteEntry.Vote.String(),
-			)
-		}
-		if _, err := io.WriteString(w, strings.Join(votes, ",\n")); err != nil {
-			return err
-		}
-		i++
-
-		if i != len(document.Governance.VoteEntries) && len(voteEntries) > 0 {
-			if _, err := io.WriteString(w, ",\n"); err != nil {
+			); err != nil {
 				return err
 			}
 		}
 	}
-	if foundVotes {
-		if _, err := io.WriteString(w, ";\n"); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return votes.Flush()
 }
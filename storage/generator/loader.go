@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+
+	genesis "github.com/oasisprotocol/oasis-core/go/genesis/api"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// LoadGenesisDocument loads the state in the provided genesis document into
+// the provided schema of target storage, and records the genesis height.
+// Loading is atomic, and is skipped if the document was already loaded.
+func (mg *MigrationGenerator) LoadGenesisDocument(ctx context.Context, target storage.TargetStorage, schema string, document *genesis.Document) error {
+	var loaded int64
+	switch err := target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT height FROM %s.genesis_heights WHERE chain_id = $1
+	`, schema),
+		document.ChainID,
+	).Scan(&loaded); {
+	case err == nil && loaded == document.Height:
+		mg.logger.Info("genesis state already loaded, skipping",
+			"chain_id", document.ChainID,
+			"height", loaded,
+		)
+		return nil
+	case err != nil && err != storage.ErrNoRows:
+		return err
+	}
+
+	batch := &storage.QueryBatch{}
+	if err := mg.QueueGenesisDocument(batch, schema, document); err != nil {
+		return err
+	}
+	batch.Queue(fmt.Sprintf(`
+		INSERT INTO %s.genesis_heights (chain_id, height, loaded_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (chain_id) DO
+			UPDATE SET
+				height = excluded.height,
+				loaded_time = excluded.loaded_time;
+	`, schema),
+		document.ChainID,
+		document.Height,
+	)
+
+	if err := target.SendBatch(ctx, batch); err != nil {
+		return err
+	}
+
+	mg.logger.Info("loaded genesis state",
+		"chain_id", document.ChainID,
+		"height", document.Height,
+		"queries", batch.Len(),
+	)
+	return nil
+}
+
+// QueueGenesisDocument adds queries to the batch that re-initialize all
+// height-dependent state in the provided schema as per the provided genesis
+// document. Unlike WriteGenesisDocumentMigration, values are bound as query
+// parameters so that the batch can be sent directly to target storage.
+func (mg *MigrationGenerator) QueueGenesisDocument(batch *storage.QueryBatch, schema string, document *genesis.Document) error {
+	return mg.writeGenesisDocument(&batchWriter{batch}, schema, document)
+}
@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// stateWriter writes statements initializing state, either as SQL text
+// or as queries queued to a batch.
+type stateWriter interface {
+	// Exec writes a statement without values.
+	Exec(sql string) error
+
+	// Table returns a writer of rows to insert into the provided table
+	// and columns. The suffix, e.g. an ON CONFLICT clause, is appended
+	// to each insert.
+	Table(table string, columns []string, suffix string) tableWriter
+}
+
+// tableWriter writes rows to insert into a table.
+type tableWriter interface {
+	// Add adds a row to insert.
+	Add(values ...interface{}) error
+
+	// Flush writes inserts for all remaining rows.
+	Flush() error
+}
+
+// sqlWriter writes statements as SQL text, with values as escaped literals.
+type sqlWriter struct {
+	w io.Writer
+}
+
+// Exec implements stateWriter.
+func (s *sqlWriter) Exec(sql string) error {
+	_, err := io.WriteString(s.w, "\n"+sql+"\n")
+	return err
+}
+
+// Table implements stateWriter.
+func (s *sqlWriter) Table(table string, columns []string, suffix string) tableWriter {
+	return &sqlTableWriter{
+		w:      s.w,
+		prefix: fmt.Sprintf("\nINSERT INTO %s (%s)\nVALUES\n", table, strings.Join(columns, ", ")),
+		suffix: suffix,
+	}
+}
+
+type sqlTableWriter struct {
+	w      io.Writer
+	prefix string
+	suffix string
+
+	rows []string
+}
+
+// Add implements tableWriter.
+func (t *sqlTableWriter) Add(values ...interface{}) error {
+	literals := make([]string, len(values))
+	for i, v := range values {
+		literal, err := sqlLiteral(v)
+		if err != nil {
+			return err
+		}
+		literals[i] = literal
+	}
+	t.rows = append(t.rows, "\t("+strings.Join(literals, ", ")+")")
+	if len(t.rows) >= bulkInsertBatchSize {
+		return t.Flush()
+	}
+	return nil
+}
+
+// Flush implements tableWriter.
+func (t *sqlTableWriter) Flush() error {
+	if len(t.rows) == 0 {
+		return nil
+	}
+	_, err := io.WriteString(t.w, t.prefix+strings.Join(t.rows, ",\n")+t.suffix+";\n")
+	t.rows = nil
+	return err
+}
+
+// sqlLiteral returns the provided value as an SQL literal.
+func sqlLiteral(v interface{}) (string, error) {
+	if v == nil {
+		return "NULL", nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return "'" + strings.ReplaceAll(rv.String(), "'", "''") + "'", nil
+	case reflect.Bool:
+		if rv.Bool() {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf("%d", rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf("%d", rv.Uint()), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
+
+// batchWriter queues statements to a batch, with values bound as
+// query parameters.
+type batchWriter struct {
+	batch *storage.QueryBatch
+}
+
+// Exec implements stateWriter.
+func (b *batchWriter) Exec(sql string) error {
+	b.batch.Queue(sql)
+	return nil
+}
+
+// Table implements stateWriter.
+func (b *batchWriter) Table(table string, columns []string, suffix string) tableWriter {
+	return newBulkInsert(b.batch, table, columns, suffix)
+}
+
+// bulkInsert queues multi-row inserts into a table, with values
+// bound as query parameters.
+type bulkInsert struct {
+	batch   *storage.QueryBatch
+	prefix  string
+	suffix  string
+	columns int
+
+	args []interface{}
+}
+
+// newBulkInsert creates a new bulk insert into the provided table and columns.
+// The suffix, e.g. an ON CONFLICT clause, is appended to each insert.
+func newBulkInsert(batch *storage.QueryBatch, table string, columns []string, suffix string) *bulkInsert {
+	return &bulkInsert{
+		batch:   batch,
+		prefix:  fmt.Sprintf("INSERT INTO %s (%s) VALUES ", table, strings.Join(columns, ", ")),
+		suffix:  suffix,
+		columns: len(columns),
+	}
+}
+
+// Add implements tableWriter. An insert is queued once enough
+// rows have been added.
+func (b *bulkInsert) Add(values ...interface{}) error {
+	if len(values) != b.columns {
+		return fmt.Errorf("expected %d values, got %d", b.columns, len(values))
+	}
+	b.args = append(b.args, values...)
+	if len(b.args) >= bulkInsertBatchSize*b.columns {
+		return b.Flush()
+	}
+	return nil
+}
+
+// Flush implements tableWriter.
+func (b *bulkInsert) Flush() error {
+	if len(b.args) == 0 {
+		return nil
+	}
+	b.batch.Queue(b.prefix+storage.Placeholders(len(b.args)/b.columns, b.columns)+b.suffix, b.args...)
+	b.args = nil
+	return nil
+}
@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// TestBulkInsert tests that rows are split into inserts
+// of at most bulkInsertBatchSize rows.
+func TestBulkInsert(t *testing.T) {
+	batch := &storage.QueryBatch{}
+	b := newBulkInsert(batch, "oasis_3.delegations", []string{"delegatee", "delegator", "shares"}, "")
+
+	require.Nil(t, b.Flush())
+	require.Equal(t, 0, batch.Len())
+
+	for i := 0; i < bulkInsertBatchSize+1; i++ {
+		require.Nil(t, b.Add("delegatee", "delegator", "1"))
+	}
+	require.Equal(t, 1, batch.Len())
+	require.Nil(t, b.Flush())
+	require.Equal(t, 2, batch.Len())
+	require.Nil(t, b.Flush())
+	require.Equal(t, 2, batch.Len())
+
+	require.NotNil(t, b.Add("delegatee", "delegator"))
+}
+
+// TestSQLLiteral tests formatting values as SQL literals.
+func TestSQLLiteral(t *testing.T) {
+	type epoch uint64
+
+	for _, tc := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{nil, "NULL"},
+		{"oasis1", "'oasis1'"},
+		{"it's", "'it''s'"},
+		{true, "TRUE"},
+		{false, "FALSE"},
+		{int64(-1), "-1"},
+		{epoch(42), "42"},
+	} {
+		literal, err := sqlLiteral(tc.value)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, literal)
+	}
+
+	_, err := sqlLiteral(1.5)
+	require.NotNil(t, err)
+}
+
+// TestSQLWriter tests that the SQL writer omits inserts without rows.
+func TestSQLWriter(t *testing.T) {
+	var sb strings.Builder
+	w := &sqlWriter{&sb}
+
+	epochs := w.Table("oasis_3.epochs", []string{"id", "start_height"}, "\nON CONFLICT (id) DO NOTHING")
+	require.Nil(t, epochs.Flush())
+	require.Equal(t, "", sb.String())
+
+	require.Nil(t, epochs.Add(uint64(1), int64(100)))
+	require.Nil(t, epochs.Add(uint64(2), nil))
+	require.Nil(t, epochs.Flush())
+	require.Equal(t, `
+INSERT INTO oasis_3.epochs (id, start_height)
+VALUES
+	(1, 100),
+	(2, NULL)
+ON CONFLICT (id) DO NOTHING;
+`, sb.String())
+}
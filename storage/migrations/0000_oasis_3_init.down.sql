@@ -0,0 +1,7 @@
+-- Drops the chain's schema, and all indexed data with it.
+
+BEGIN;
+
+DROP SCHEMA IF EXISTS {{ .Schema }} CASCADE;
+
+COMMIT;
@@ -1,4 +1,4 @@
-CREATE TABLE IF NOT EXISTS oasis_3.checkpointed_heights
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.checkpointed_heights
 (
   height BIGINT PRIMARY KEY,
   checkpoint_time TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
@@ -0,0 +1,9 @@
+-- Reverts the entity node relationship migration.
+--
+-- Nodes may reference entities which are not indexed, so the restored
+-- constraint is not validated against existing rows.
+
+DROP TABLE IF EXISTS {{ .Schema }}.claimed_nodes;
+
+ALTER TABLE {{ .Schema }}.nodes ADD CONSTRAINT nodes_entity_id_fkey
+  FOREIGN KEY (entity_id) REFERENCES {{ .Schema }}.entities(id) NOT VALID;
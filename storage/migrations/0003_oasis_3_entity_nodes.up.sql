@@ -4,9 +4,9 @@
 -- themselves with any entity. So this relationship may not necessarily
 -- be perfectly associated as is currently assumed.
 
-ALTER TABLE oasis_3.nodes DROP CONSTRAINT nodes_entity_id_fkey;
+ALTER TABLE {{ .Schema }}.nodes DROP CONSTRAINT nodes_entity_id_fkey;
 
-CREATE TABLE IF NOT EXISTS oasis_3.claimed_nodes
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.claimed_nodes
 (
   entity_id TEXT NOT NULL,
   node_id   TEXT NOT NULL,
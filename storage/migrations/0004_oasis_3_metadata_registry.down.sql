@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE {{ .Schema }}.entities DROP COLUMN IF EXISTS meta;
+
+COMMIT;
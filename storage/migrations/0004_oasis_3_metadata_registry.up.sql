@@ -5,6 +5,6 @@ BEGIN;
 
 -- Metadata Registry
 
-ALTER TABLE oasis_3.entities ADD meta JSON;
+ALTER TABLE {{ .Schema }}.entities ADD meta JSON;
 
 COMMIT;
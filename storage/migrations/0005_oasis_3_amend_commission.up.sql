@@ -4,14 +4,17 @@ BEGIN;
 
 -- Commissions Amendments
 
-CREATE TABLE IF NOT EXISTS oasis_3.commissions
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.commissions
 (
-  address  TEXT PRIMARY KEY NOT NULL REFERENCES oasis_3.accounts(address),
+  address  TEXT PRIMARY KEY NOT NULL REFERENCES {{ .Schema }}.accounts(address),
   schedule JSON
 );
 
-TRUNCATE oasis_3.commissions CASCADE;
-INSERT INTO oasis_3.commissions (address, schedule) VALUES
+{{ if eq .ChainID "oasis-3" -}}
+-- Commission schedules at the Damask Upgrade, as these are not
+-- available from events.
+TRUNCATE {{ .Schema }}.commissions CASCADE;
+INSERT INTO {{ .Schema }}.commissions (address, schedule) VALUES
 	('oasis1qq4f2h225gv6g8w8w23fm740aze9lke4qun72n59', '{"rates":[{"start":354,"rate":"13000"},{"start":1510,"rate":"17000"}],"bounds":[{"rate_min":"0","rate_max":"20000"}]}'),
 	('oasis1qzjp96xfmfauvpe6sz99r06q9tah8utlc5lkqr9w', '{"rates":[{"start":11650,"rate":"5000"},{"start":11744,"rate":"2500"}],"bounds":[{"start":11650,"rate_min":"0","rate_max":"7000"}]}'),
 	('oasis1qqrv4g5wu543wa7fcae76eucqfn2uc77zgqw8fxk', '{"rates":[{"start":9438,"rate":"19000"},{"start":10188,"rate":"20000"}],"bounds":[{"start":9438,"rate_min":"0","rate_max":"20000"}]}'),
@@ -160,5 +163,6 @@ INSERT INTO oasis_3.commissions (address, schedule) VALUES
 	('oasis1qqdd4nmtcmarf4u9gdg24swxhec52du43cxzf302', '{"rates":[{"rate":"5000"}],"bounds":[{"rate_min":"0","rate_max":"20000"}]}'),
 	('oasis1qpgl52u29wy4hjla89f46ntkn2qsa6zpdvhv6s6n', '{"rates":[{"start":1641,"rate":"19000"}],"bounds":[{"start":1641,"rate_min":"19000","rate_max":"19500"}]}'),
 	('oasis1qrtq873ddwnnjqyv66ezdc9ql2a07l37d5vae9k0', '{"rates":[{"start":28,"rate":"15000"},{"start":3148,"rate":"20000"}],"bounds":[{"rate_min":"0","rate_max":"20000"}]}');
+{{- end }}
 
 COMMIT;
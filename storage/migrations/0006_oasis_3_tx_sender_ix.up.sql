@@ -0,0 +1,3 @@
+-- Creates an index on transaction sender. This is a very common
+-- query, and is unusably slow otherwise.
+CREATE INDEX IF NOT EXISTS ix_transactions_sender ON {{ .Schema }}.transactions (sender);
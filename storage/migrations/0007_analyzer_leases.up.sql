@@ -0,0 +1,13 @@
+-- Leases coordinating analyzers across multiple indexer instances,
+-- such that only a single instance processes blocks for an analyzer.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.leases
+(
+  name       TEXT PRIMARY KEY,
+  holder     TEXT NOT NULL,
+  expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,12 @@
+-- Bookkeeping of genesis state loaded directly into target storage.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.genesis_heights
+(
+  chain_id    TEXT PRIMARY KEY,
+  height      BIGINT NOT NULL,
+  loaded_time TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+COMMIT;
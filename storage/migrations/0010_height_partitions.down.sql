@@ -0,0 +1,46 @@
+-- Reverts height partitioning of blocks, transactions and events,
+-- moving rows of all partitions back into unpartitioned tables.
+
+BEGIN;
+{{ if .Partitioned }}
+
+DROP FUNCTION IF EXISTS {{ .Schema }}.ensure_partitions(BIGINT);
+
+CREATE TABLE {{ .Schema }}.blocks_unpartitioned
+  (LIKE {{ .Schema }}.blocks INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+INSERT INTO {{ .Schema }}.blocks_unpartitioned SELECT * FROM {{ .Schema }}.blocks;
+
+CREATE TABLE {{ .Schema }}.transactions_unpartitioned
+  (LIKE {{ .Schema }}.transactions INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+INSERT INTO {{ .Schema }}.transactions_unpartitioned SELECT * FROM {{ .Schema }}.transactions;
+
+CREATE TABLE {{ .Schema }}.events_unpartitioned
+  (LIKE {{ .Schema }}.events INCLUDING DEFAULTS INCLUDING CONSTRAINTS);
+INSERT INTO {{ .Schema }}.events_unpartitioned SELECT * FROM {{ .Schema }}.events;
+
+-- Dropping partitioned tables drops their partitions.
+DROP TABLE {{ .Schema }}.events;
+DROP TABLE {{ .Schema }}.transactions;
+DROP TABLE {{ .Schema }}.blocks;
+
+DROP FUNCTION IF EXISTS {{ .Schema }}.partition_size();
+
+ALTER TABLE {{ .Schema }}.blocks_unpartitioned RENAME TO blocks;
+ALTER TABLE {{ .Schema }}.blocks ADD PRIMARY KEY (height);
+
+ALTER TABLE {{ .Schema }}.transactions_unpartitioned RENAME TO transactions;
+ALTER TABLE {{ .Schema }}.transactions ADD PRIMARY KEY (block, txn_hash, txn_index);
+ALTER TABLE {{ .Schema }}.transactions
+  ADD FOREIGN KEY (block) REFERENCES {{ .Schema }}.blocks(height);
+
+ALTER TABLE {{ .Schema }}.events_unpartitioned RENAME TO events;
+ALTER TABLE {{ .Schema }}.events
+  ADD FOREIGN KEY (txn_block, txn_hash, txn_index) REFERENCES {{ .Schema }}.transactions(block, txn_hash, txn_index);
+
+CREATE INDEX IF NOT EXISTS ix_transactions_sender ON {{ .Schema }}.transactions (sender);
+{{ else }}
+DROP INDEX IF EXISTS {{ .Schema }}.ix_events_type;
+DROP INDEX IF EXISTS {{ .Schema }}.ix_events_txn;
+DROP INDEX IF EXISTS {{ .Schema }}.ix_transactions_hash;
+{{ end }}
+COMMIT;
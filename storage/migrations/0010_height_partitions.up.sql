@@ -0,0 +1,103 @@
+-- Partitions blocks, transactions and events by height range, so that
+-- queries filtered by height only scan the relevant partitions, and
+-- partitions are vacuumed independently.
+--
+-- Existing rows are kept in a legacy partition of each table, covering all
+-- heights below the first partition boundary above the latest indexed block.
+-- Partitions for later heights are created by ensure_partitions as the
+-- analyzer advances. Requires PostgreSQL 12 or later.
+--
+-- CockroachDB does not support partitioning tables by range of a column
+-- this way, so blocks, transactions and events are kept in single tables,
+-- and only the indexes declared on the partitioned tables are created.
+
+BEGIN;
+{{ if .Partitioned }}
+
+-- The number of heights covered by each partition.
+CREATE OR REPLACE FUNCTION {{ .Schema }}.partition_size() RETURNS BIGINT
+  LANGUAGE SQL IMMUTABLE AS 'SELECT 1000000::BIGINT';
+
+-- Foreign keys are recreated on the partitioned tables.
+ALTER TABLE {{ .Schema }}.events DROP CONSTRAINT IF EXISTS events_txn_block_txn_hash_txn_index_fkey;
+ALTER TABLE {{ .Schema }}.transactions DROP CONSTRAINT IF EXISTS transactions_block_fkey;
+
+ALTER TABLE {{ .Schema }}.blocks RENAME TO blocks_legacy;
+ALTER TABLE {{ .Schema }}.blocks_legacy RENAME CONSTRAINT blocks_pkey TO blocks_legacy_pkey;
+ALTER TABLE {{ .Schema }}.transactions RENAME TO transactions_legacy;
+ALTER TABLE {{ .Schema }}.transactions_legacy RENAME CONSTRAINT transactions_pkey TO transactions_legacy_pkey;
+ALTER INDEX IF EXISTS {{ .Schema }}.ix_transactions_sender RENAME TO ix_transactions_legacy_sender;
+ALTER TABLE {{ .Schema }}.events RENAME TO events_legacy;
+
+CREATE TABLE {{ .Schema }}.blocks
+  (LIKE {{ .Schema }}.blocks_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+  PARTITION BY RANGE (height);
+ALTER TABLE {{ .Schema }}.blocks ADD PRIMARY KEY (height);
+
+CREATE TABLE {{ .Schema }}.transactions
+  (LIKE {{ .Schema }}.transactions_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+  PARTITION BY RANGE (block);
+ALTER TABLE {{ .Schema }}.transactions ADD PRIMARY KEY (block, txn_hash, txn_index);
+
+CREATE TABLE {{ .Schema }}.events
+  (LIKE {{ .Schema }}.events_legacy INCLUDING DEFAULTS INCLUDING CONSTRAINTS)
+  PARTITION BY RANGE (txn_block);
+
+DO $$
+DECLARE
+  bound BIGINT;
+BEGIN
+  SELECT (COALESCE(MAX(height), 0) / {{ .Schema }}.partition_size() + 1) * {{ .Schema }}.partition_size()
+    INTO bound
+    FROM {{ .Schema }}.blocks_legacy;
+
+  EXECUTE format('ALTER TABLE {{ .Schema }}.blocks ATTACH PARTITION {{ .Schema }}.blocks_legacy FOR VALUES FROM (MINVALUE) TO (%s)', bound);
+  EXECUTE format('ALTER TABLE {{ .Schema }}.transactions ATTACH PARTITION {{ .Schema }}.transactions_legacy FOR VALUES FROM (MINVALUE) TO (%s)', bound);
+  EXECUTE format('ALTER TABLE {{ .Schema }}.events ATTACH PARTITION {{ .Schema }}.events_legacy FOR VALUES FROM (MINVALUE) TO (%s)', bound);
+END;
+$$;
+
+ALTER TABLE {{ .Schema }}.transactions
+  ADD FOREIGN KEY (block) REFERENCES {{ .Schema }}.blocks(height);
+ALTER TABLE {{ .Schema }}.events
+  ADD FOREIGN KEY (txn_block, txn_hash, txn_index) REFERENCES {{ .Schema }}.transactions(block, txn_hash, txn_index);
+
+-- Indexes on partitioned tables are created on each partition,
+-- including partitions created later.
+CREATE INDEX IF NOT EXISTS ix_transactions_sender ON {{ .Schema }}.transactions (sender);
+CREATE INDEX IF NOT EXISTS ix_transactions_hash ON {{ .Schema }}.transactions (txn_hash);
+CREATE INDEX IF NOT EXISTS ix_events_txn ON {{ .Schema }}.events (txn_block, txn_hash, txn_index);
+CREATE INDEX IF NOT EXISTS ix_events_type ON {{ .Schema }}.events (type, txn_block);
+
+-- Creates the partitions of blocks, transactions and events containing the
+-- provided height and the following partition, unless already covered.
+CREATE OR REPLACE FUNCTION {{ .Schema }}.ensure_partitions(height BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+DECLARE
+  size CONSTANT BIGINT := {{ .Schema }}.partition_size();
+  start BIGINT;
+  tbl TEXT;
+BEGIN
+  FOR start IN SELECT generate_series(height / size * size, (height / size + 1) * size, size) LOOP
+    FOREACH tbl IN ARRAY ARRAY['blocks', 'transactions', 'events'] LOOP
+      IF to_regclass(format('{{ .Schema }}.%s_p%s', tbl, start)) IS NULL THEN
+        BEGIN
+          EXECUTE format('CREATE TABLE {{ .Schema }}.%I PARTITION OF {{ .Schema }}.%I FOR VALUES FROM (%s) TO (%s)',
+            tbl || '_p' || start, tbl, start, start + size);
+        EXCEPTION
+          -- The range is covered by the legacy partition.
+          WHEN invalid_object_definition THEN NULL;
+          -- The partition was created concurrently.
+          WHEN duplicate_table THEN NULL;
+        END;
+      END IF;
+    END LOOP;
+  END LOOP;
+END;
+$$;
+{{ else }}
+CREATE INDEX IF NOT EXISTS ix_transactions_hash ON {{ .Schema }}.transactions (txn_hash);
+CREATE INDEX IF NOT EXISTS ix_events_txn ON {{ .Schema }}.events (txn_block, txn_hash, txn_index);
+CREATE INDEX IF NOT EXISTS ix_events_type ON {{ .Schema }}.events (type, txn_block);
+{{ end }}
+COMMIT;
@@ -0,0 +1,16 @@
+BEGIN;
+{{ if .Partitioned }}
+DROP FUNCTION IF EXISTS {{ .Schema }}.drop_partitions_below(TEXT, BIGINT);
+DROP TRIGGER IF EXISTS processed_blocks_compacted ON {{ .Schema }}.processed_blocks;
+DROP FUNCTION IF EXISTS {{ .Schema }}.check_processed_block();
+{{ end }}
+-- Compacted ranges are expanded back into processed blocks.
+INSERT INTO {{ .Schema }}.processed_blocks (height, analyzer, processed_time)
+  SELECT generate_series(from_height, to_height), analyzer, CURRENT_TIMESTAMP
+    FROM {{ .Schema }}.processed_block_ranges
+ON CONFLICT (height, analyzer) DO NOTHING;
+
+DROP TABLE IF EXISTS {{ .Schema }}.processed_block_ranges;
+DROP TABLE IF EXISTS {{ .Schema }}.retention_horizons;
+
+COMMIT;
@@ -0,0 +1,71 @@
+-- Bookkeeping for pruning indexed history according to retention rules.
+
+BEGIN;
+
+-- The lowest retained height of each pruned table.
+-- Rows below the horizon have been pruned.
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.retention_horizons
+(
+  table_name  TEXT PRIMARY KEY,
+  height      BIGINT NOT NULL,
+  pruned_time TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Ranges of processed blocks compacted from processed_blocks.
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.processed_block_ranges
+(
+  analyzer    TEXT NOT NULL,
+  from_height BIGINT NOT NULL,
+  to_height   BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_processed_block_ranges_analyzer ON {{ .Schema }}.processed_block_ranges (analyzer, to_height);
+{{ if .Partitioned }}
+-- Compaction deletes processed_blocks rows, so the primary key alone no
+-- longer guards against applying a block twice. Inserting the progress of
+-- a height covered by a compacted range fails like a primary key conflict.
+CREATE OR REPLACE FUNCTION {{ .Schema }}.check_processed_block() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM {{ .Schema }}.processed_block_ranges
+      WHERE analyzer = NEW.analyzer AND NEW.height BETWEEN from_height AND to_height
+  ) THEN
+    RAISE EXCEPTION 'block % already processed by %', NEW.height, NEW.analyzer
+      USING ERRCODE = 'unique_violation';
+  END IF;
+  RETURN NEW;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS processed_blocks_compacted ON {{ .Schema }}.processed_blocks;
+CREATE TRIGGER processed_blocks_compacted BEFORE INSERT ON {{ .Schema }}.processed_blocks
+  FOR EACH ROW EXECUTE FUNCTION {{ .Schema }}.check_processed_block();
+
+-- Drops the height partitions of the provided table which lie entirely
+-- below the provided height. The legacy partition is never dropped.
+CREATE OR REPLACE FUNCTION {{ .Schema }}.drop_partitions_below(tbl TEXT, height BIGINT) RETURNS VOID
+LANGUAGE plpgsql AS $$
+DECLARE
+  part TEXT;
+BEGIN
+  FOR part IN
+    SELECT c.relname
+      FROM pg_inherits i
+      JOIN pg_class c ON c.oid = i.inhrelid
+      JOIN pg_class p ON p.oid = i.inhparent
+      JOIN pg_namespace n ON n.oid = p.relnamespace
+      WHERE n.nspname = '{{ .Schema }}' AND p.relname = tbl
+        AND c.relname ~ ('^' || tbl || '_p[0-9]+$')
+        AND substring(c.relname FROM '[0-9]+$')::BIGINT + {{ .Schema }}.partition_size() <= height
+  LOOP
+    EXECUTE format('ALTER TABLE {{ .Schema }}.%I DETACH PARTITION {{ .Schema }}.%I', tbl, part);
+    EXECUTE format('DROP TABLE {{ .Schema }}.%I', part);
+  END LOOP;
+END;
+$$;
+{{ else }}
+-- CockroachDB does not support the trigger guarding compacted ranges, so
+-- processed_blocks is not compacted, and height partitions do not exist.
+{{ end }}
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE IF EXISTS {{ .Schema }}.account_balance_history;
+
+COMMIT;
@@ -0,0 +1,38 @@
+-- History of account balances, for lookups of balances at past heights.
+--
+-- A row is recorded for each account whose balances change in a block,
+-- with its balances after the block, and for all accounts when state is
+-- loaded from a genesis document or node state. The balances of an account
+-- at a height are those of its latest row at or below the height.
+
+BEGIN;
+
+CREATE TABLE IF NOT EXISTS {{ .Schema }}.account_balance_history
+(
+  address TEXT NOT NULL,
+  height  BIGINT NOT NULL,
+
+  general_balance          NUMERIC NOT NULL DEFAULT 0,
+  escrow_balance_active    NUMERIC NOT NULL DEFAULT 0,
+  escrow_balance_debonding NUMERIC NOT NULL DEFAULT 0,
+
+  PRIMARY KEY (address, height)
+);
+
+-- Heights are scanned when rolling back, and to find the oldest balances.
+CREATE INDEX IF NOT EXISTS ix_account_balance_history_height ON {{ .Schema }}.account_balance_history (height);
+
+-- Balances of accounts indexed before the history was introduced are
+-- recorded as of the latest processed height.
+INSERT INTO {{ .Schema }}.account_balance_history (address, height, general_balance, escrow_balance_active, escrow_balance_debonding)
+  SELECT a.address, p.height, COALESCE(a.general_balance, 0), COALESCE(a.escrow_balance_active, 0), COALESCE(a.escrow_balance_debonding, 0)
+    FROM {{ .Schema }}.accounts AS a, (
+      SELECT MAX(height) AS height FROM (
+        SELECT height FROM {{ .Schema }}.processed_blocks
+        UNION ALL
+        SELECT to_height FROM {{ .Schema }}.processed_block_ranges
+      ) AS processed
+    ) AS p
+    WHERE p.height IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX IF EXISTS {{ .Schema }}.ix_events_related_accounts;
+ALTER TABLE {{ .Schema }}.events DROP COLUMN IF EXISTS related_accounts;
+
+COMMIT;
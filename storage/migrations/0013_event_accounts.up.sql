@@ -0,0 +1,27 @@
+-- Indexes events by the staking addresses mentioned in their bodies,
+-- so that the events related to an account can be looked up directly.
+
+BEGIN;
+
+ALTER TABLE {{ .Schema }}.events ADD COLUMN IF NOT EXISTS related_accounts TEXT[];
+
+-- Backfill the addresses of events indexed before the column was introduced.
+UPDATE {{ .Schema }}.events
+  SET related_accounts = ARRAY(
+    SELECT DISTINCT a
+      FROM UNNEST(ARRAY[
+        body->>'from', body->>'to', body->>'owner', body->>'escrow', body->>'beneficiary', body->>'submitter'
+      ]) AS a
+      WHERE a IS NOT NULL
+  )
+  WHERE backend IN ('staking', 'governance');
+
+-- Proposal finalization events were previously indexed as executions.
+-- Only finalization events have a state in their bodies.
+UPDATE {{ .Schema }}.events
+  SET type = 'ProposalFinalized'
+  WHERE backend = 'governance' AND type = 'ProposalExecuted' AND body->>'state' IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS ix_events_related_accounts ON {{ .Schema }}.events USING GIN (related_accounts);
+
+COMMIT;
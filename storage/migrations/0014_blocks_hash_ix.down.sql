@@ -0,0 +1 @@
+DROP INDEX IF EXISTS {{ .Schema }}.ix_blocks_block_hash;
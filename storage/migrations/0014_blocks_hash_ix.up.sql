@@ -0,0 +1,3 @@
+-- Creates an index on block hash, which /v1/search looks up blocks by.
+-- As blocks are partitioned by height, the index is created on every partition.
+CREATE INDEX IF NOT EXISTS ix_blocks_block_hash ON {{ .Schema }}.blocks (block_hash);
@@ -0,0 +1,18 @@
+-- Drops all indexed data of the chain. Each chain's schema is a separate
+-- database file, which is kept.
+
+DROP TABLE IF EXISTS processed_blocks;
+DROP TABLE IF EXISTS votes;
+DROP TABLE IF EXISTS proposals;
+DROP TABLE IF EXISTS committee_members;
+DROP TABLE IF EXISTS debonding_delegations;
+DROP TABLE IF EXISTS delegations;
+DROP TABLE IF EXISTS allowances;
+DROP TABLE IF EXISTS accounts;
+DROP TABLE IF EXISTS runtimes;
+DROP TABLE IF EXISTS nodes;
+DROP TABLE IF EXISTS entities;
+DROP TABLE IF EXISTS epochs;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS blocks;
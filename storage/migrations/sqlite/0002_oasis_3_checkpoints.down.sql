@@ -0,0 +1 @@
+DROP TABLE IF EXISTS checkpointed_heights;
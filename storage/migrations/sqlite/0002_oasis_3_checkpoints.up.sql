@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS checkpointed_heights
+(
+  height BIGINT PRIMARY KEY,
+  checkpoint_time TIMESTAMP DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+);
@@ -0,0 +1,44 @@
+-- Reverts the entity node relationship migration.
+--
+-- The nodes table is rebuilt with the constraint, which SQLite only
+-- enforces if foreign keys are enabled.
+
+DROP TABLE IF EXISTS claimed_nodes;
+
+CREATE TABLE nodes_new
+(
+  id         TEXT PRIMARY KEY,
+  entity_id  TEXT NOT NULL REFERENCES entities(id),
+  expiration BIGINT NOT NULL,
+
+  -- TLS Info
+  tls_pubkey      TEXT NOT NULL,
+  tls_next_pubkey TEXT,
+  tls_addresses   TEXT,
+
+  -- P2P Info
+  p2p_pubkey    TEXT NOT NULL,
+  p2p_addresses TEXT,
+
+  -- Consensus Info
+  consensus_pubkey  TEXT NOT NULL,
+  consensus_address TEXT,
+
+  -- VRF Info
+  vrf_pubkey TEXT,
+
+  roles            TEXT,
+  software_version TEXT,
+
+  -- Voting power should only be nonzero for consensus validator nodes.
+  voting_power     BIGINT DEFAULT 0,
+
+  -- TODO: Track node status.
+
+  -- Arbitrary additional data.
+  extra_data TEXT
+);
+
+INSERT INTO nodes_new SELECT * FROM nodes;
+DROP TABLE nodes;
+ALTER TABLE nodes_new RENAME TO nodes;
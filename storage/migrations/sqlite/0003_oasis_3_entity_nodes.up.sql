@@ -0,0 +1,53 @@
+-- Entity Node relationship migration.
+--
+-- Entities are free to claim any node, and nodes are free to associate
+-- themselves with any entity. So this relationship may not necessarily
+-- be perfectly associated as is currently assumed.
+--
+-- SQLite cannot drop constraints, so the nodes table is rebuilt without it.
+
+CREATE TABLE nodes_new
+(
+  id         TEXT PRIMARY KEY,
+  entity_id  TEXT NOT NULL,
+  expiration BIGINT NOT NULL,
+
+  -- TLS Info
+  tls_pubkey      TEXT NOT NULL,
+  tls_next_pubkey TEXT,
+  tls_addresses   TEXT,
+
+  -- P2P Info
+  p2p_pubkey    TEXT NOT NULL,
+  p2p_addresses TEXT,
+
+  -- Consensus Info
+  consensus_pubkey  TEXT NOT NULL,
+  consensus_address TEXT,
+
+  -- VRF Info
+  vrf_pubkey TEXT,
+
+  roles            TEXT,
+  software_version TEXT,
+
+  -- Voting power should only be nonzero for consensus validator nodes.
+  voting_power     BIGINT DEFAULT 0,
+
+  -- TODO: Track node status.
+
+  -- Arbitrary additional data.
+  extra_data TEXT
+);
+
+INSERT INTO nodes_new SELECT * FROM nodes;
+DROP TABLE nodes;
+ALTER TABLE nodes_new RENAME TO nodes;
+
+CREATE TABLE IF NOT EXISTS claimed_nodes
+(
+  entity_id TEXT NOT NULL,
+  node_id   TEXT NOT NULL,
+
+  PRIMARY KEY (entity_id, node_id)
+);
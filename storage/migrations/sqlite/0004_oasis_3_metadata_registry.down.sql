@@ -0,0 +1 @@
+ALTER TABLE entities DROP COLUMN meta;
@@ -0,0 +1,6 @@
+-- Cache registry of signed statements about various entities that may be on the Oasis Network
+-- https://github.com/oasisprotocol/metadata-registry
+
+-- Metadata Registry
+
+ALTER TABLE entities ADD meta TEXT;
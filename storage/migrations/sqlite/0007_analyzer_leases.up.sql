@@ -0,0 +1,9 @@
+-- Leases coordinating analyzers across multiple indexer instances,
+-- such that only a single instance processes blocks for an analyzer.
+
+CREATE TABLE IF NOT EXISTS leases
+(
+  name       TEXT PRIMARY KEY,
+  holder     TEXT NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,14 @@
+-- Blocks that repeatedly failed to process, quarantined such that
+-- they can be inspected and re-driven.
+
+CREATE TABLE IF NOT EXISTS quarantined_blocks
+(
+  height            BIGINT NOT NULL,
+  analyzer          TEXT NOT NULL,
+  error             TEXT NOT NULL,
+  attempts          INTEGER NOT NULL,
+  quarantined_time  TIMESTAMP NOT NULL,
+  redrive_requested BOOLEAN NOT NULL DEFAULT FALSE,
+
+  PRIMARY KEY (height, analyzer)
+);
@@ -0,0 +1,8 @@
+-- Bookkeeping of genesis state loaded directly into target storage.
+
+CREATE TABLE IF NOT EXISTS genesis_heights
+(
+  chain_id    TEXT PRIMARY KEY,
+  height      BIGINT NOT NULL,
+  loaded_time TIMESTAMP NOT NULL
+);
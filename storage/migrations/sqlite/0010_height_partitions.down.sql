@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ix_events_type;
+DROP INDEX IF EXISTS ix_events_txn;
+DROP INDEX IF EXISTS ix_transactions_hash;
@@ -0,0 +1,7 @@
+-- SQLite does not support partitioning, so blocks, transactions and events
+-- are kept in single tables, and analyzers do not manage partitions. Only
+-- the indexes declared on the partitioned tables are created.
+
+CREATE INDEX IF NOT EXISTS ix_transactions_hash ON transactions (txn_hash);
+CREATE INDEX IF NOT EXISTS ix_events_txn ON events (txn_block, txn_hash, txn_index);
+CREATE INDEX IF NOT EXISTS ix_events_type ON events (type, txn_block);
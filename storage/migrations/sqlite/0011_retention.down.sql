@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS processed_blocks_compacted;
+DROP TABLE IF EXISTS processed_block_ranges;
+DROP TABLE IF EXISTS retention_horizons;
@@ -0,0 +1,32 @@
+-- Bookkeeping for pruning indexed history according to retention rules.
+
+-- The lowest retained height of each pruned table.
+-- Rows below the horizon have been pruned.
+CREATE TABLE IF NOT EXISTS retention_horizons
+(
+  table_name  TEXT PRIMARY KEY,
+  height      BIGINT NOT NULL,
+  pruned_time TIMESTAMP NOT NULL
+);
+
+-- Ranges of processed blocks compacted from processed_blocks. Compaction is
+-- not supported by SQLite, but the table is read when resuming analysis.
+CREATE TABLE IF NOT EXISTS processed_block_ranges
+(
+  analyzer    TEXT NOT NULL,
+  from_height BIGINT NOT NULL,
+  to_height   BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ix_processed_block_ranges_analyzer ON processed_block_ranges (analyzer, to_height);
+
+-- Inserting the progress of a height covered by a compacted range fails
+-- like a primary key conflict.
+CREATE TRIGGER IF NOT EXISTS processed_blocks_compacted BEFORE INSERT ON processed_blocks
+  WHEN EXISTS (
+    SELECT 1 FROM processed_block_ranges
+      WHERE analyzer = NEW.analyzer AND NEW.height BETWEEN from_height AND to_height
+  )
+BEGIN
+  SELECT RAISE(ABORT, 'block already processed');
+END;
@@ -0,0 +1,18 @@
+-- Related accounts are stored as a JSON array, which cannot be indexed,
+-- so lookups by account scan the events.
+ALTER TABLE events ADD COLUMN related_accounts TEXT;
+
+UPDATE events
+  SET related_accounts = (
+    SELECT json_group_array(DISTINCT value)
+      FROM json_each(json_array(
+        json_extract(body, '$.from'), json_extract(body, '$.to'), json_extract(body, '$.owner'),
+        json_extract(body, '$.escrow'), json_extract(body, '$.beneficiary'), json_extract(body, '$.submitter')
+      ))
+      WHERE value IS NOT NULL
+  )
+  WHERE backend IN ('staking', 'governance');
+
+UPDATE events
+  SET type = 'ProposalFinalized'
+  WHERE backend = 'governance' AND type = 'ProposalExecuted' AND json_extract(body, '$.state') IS NOT NULL;
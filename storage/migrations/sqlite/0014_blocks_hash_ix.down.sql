@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ix_blocks_block_hash;
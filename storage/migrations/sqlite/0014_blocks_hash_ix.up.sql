@@ -0,0 +1,2 @@
+-- Creates an index on block hash, which /v1/search looks up blocks by.
+CREATE INDEX IF NOT EXISTS ix_blocks_block_hash ON blocks (block_hash);
@@ -0,0 +1,361 @@
+// Package migrator runs target storage migrations for each chain
+// in the chain registry.
+package migrator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	migrate "github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" // postgres driver for golang_migrate
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"   // sqlite driver for golang_migrate
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"   // support file scheme for golang_migrate
+	_ "github.com/golang-migrate/migrate/v4/source/github" // support github scheme for golang_migrate
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/config"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+const (
+	moduleName = "migrator"
+
+	// migrationsTableParam is the database URL parameter used by
+	// golang_migrate to select the migrations table.
+	migrationsTableParam = "x-migrations-table"
+)
+
+// TemplateParams are the parameters with which migration
+// templates are rendered.
+type TemplateParams struct {
+	// ChainID is the chain ID of the chain, e.g. `oasis-3`.
+	ChainID string
+
+	// Schema is the target storage schema of the chain.
+	Schema string
+
+	// Partitioned is true iff the storage backend supports partitioning
+	// tables by height range, which CockroachDB does not.
+	Partitioned bool
+}
+
+// Migrator applies templated migrations to target storage.
+type Migrator struct {
+	sourceURL   string
+	databaseURL string
+	partitioned bool
+	logger      *log.Logger
+}
+
+// NewMigrator creates a new migrator for the migrations at the provided
+// source URL, to be applied to the PostgreSQL or SQLite database at the
+// provided URL.
+func NewMigrator(sourceURL, databaseURL string, logger *log.Logger) *Migrator {
+	return NewMigratorForBackend(sourceURL, databaseURL, config.BackendPostgres, logger)
+}
+
+// NewMigratorForBackend creates a new migrator for the migrations at the
+// provided source URL, to be applied to the database of the provided
+// storage backend at the provided URL.
+func NewMigratorForBackend(sourceURL, databaseURL string, backend config.StorageBackend, logger *log.Logger) *Migrator {
+	return &Migrator{
+		sourceURL:   sourceURL,
+		databaseURL: databaseURL,
+		partitioned: backend != config.BackendCockroach,
+		logger:      logger.WithModule(moduleName),
+	}
+}
+
+// ErrNoVersion is returned when no migrations have been applied for a chain.
+var ErrNoVersion = errors.New("no migrations applied")
+
+// Status is the migration status of a chain.
+type Status struct {
+	// ChainID is the chain ID of the chain.
+	ChainID string `json:"chain_id"`
+
+	// Version is the version of the last applied migration,
+	// or nil if no migrations have been applied.
+	Version *uint `json:"version"`
+
+	// Dirty is true iff the last migration failed, and the
+	// schema must be fixed manually and forced to a version.
+	Dirty bool `json:"dirty"`
+
+	// Latest is the version of the latest available migration.
+	Latest uint `json:"latest"`
+}
+
+// Up applies all pending migrations for the provided chain.
+func (m *Migrator) Up(chain *analyzer.Chain) error {
+	return m.run(chain, "migrations", func(mg *migrate.Migrate) error {
+		return mg.Up()
+	})
+}
+
+// Down reverts the provided number of applied migrations for the
+// provided chain, or all applied migrations if steps is zero.
+func (m *Migrator) Down(chain *analyzer.Chain, steps int) error {
+	return m.run(chain, "down migrations", func(mg *migrate.Migrate) error {
+		if steps == 0 {
+			return mg.Down()
+		}
+		return mg.Steps(-steps)
+	})
+}
+
+// Goto applies or reverts migrations for the provided chain until
+// the provided version is reached.
+func (m *Migrator) Goto(chain *analyzer.Chain, version uint) error {
+	return m.run(chain, "migrations", func(mg *migrate.Migrate) error {
+		return mg.Migrate(version)
+	})
+}
+
+// Force sets the migration version of the provided chain without
+// applying any migrations, and clears the dirty flag. A version
+// of -1 records that no migrations have been applied.
+func (m *Migrator) Force(chain *analyzer.Chain, version int) error {
+	return m.run(chain, "forcing version", func(mg *migrate.Migrate) error {
+		return mg.Force(version)
+	})
+}
+
+// Status returns the migration status of the provided chain.
+func (m *Migrator) Status(chain *analyzer.Chain) (*Status, error) {
+	latest, err := LatestVersion(m.sourceURL)
+	if err != nil {
+		return nil, err
+	}
+	status := Status{
+		ChainID: chain.ID.String(),
+		Latest:  latest,
+	}
+	if err := m.withMigrate(chain, func(mg *migrate.Migrate) error {
+		version, dirty, err := mg.Version()
+		switch err {
+		case nil:
+			status.Version = &version
+			status.Dirty = dirty
+			return nil
+		case migrate.ErrNilVersion:
+			return nil
+		default:
+			return err
+		}
+	}); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// run runs the provided migration operation for the provided chain,
+// logging its outcome.
+func (m *Migrator) run(chain *analyzer.Chain, op string, f func(*migrate.Migrate) error) error {
+	return m.withMigrate(chain, func(mg *migrate.Migrate) error {
+		switch err := f(mg); {
+		case err == migrate.ErrNoChange:
+			m.logger.Info(op+" made no changes",
+				"chain_id", chain.ID,
+			)
+		case err != nil:
+			return err
+		default:
+			m.logger.Info(op+" completed",
+				"chain_id", chain.ID,
+			)
+		}
+		return nil
+	})
+}
+
+// withMigrate renders migrations for the provided chain, and calls the
+// provided function with a migration instance for the chain's schema.
+func (m *Migrator) withMigrate(chain *analyzer.Chain, f func(*migrate.Migrate) error) error {
+	dir, err := ioutil.TempDir("", fmt.Sprintf("oasis-indexer-migrations-%s", chain.Schema))
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	params := TemplateParams{
+		ChainID:     chain.ID.String(),
+		Schema:      chain.Schema,
+		Partitioned: m.partitioned,
+	}
+	if err = Render(m.sourceURL, dir, params); err != nil {
+		return err
+	}
+
+	databaseURL, err := chainDatabaseURL(m.databaseURL, chain)
+	if err != nil {
+		return err
+	}
+	mg, err := migrate.New(fmt.Sprintf("file://%s", dir), databaseURL)
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	return f(mg)
+}
+
+// CheckVersion checks that the schema of the provided chain is at the
+// latest migration version at the provided source URL. Unlike other
+// operations, it only reads from target storage. It returns ErrNoVersion
+// if no migrations have been applied.
+func CheckVersion(ctx context.Context, target storage.TargetStorage, sourceURL string, chain *analyzer.Chain) error {
+	latest, err := LatestVersion(sourceURL)
+	if err != nil {
+		return err
+	}
+
+	var exists bool
+	if err := target.QueryRow(ctx, `
+		SELECT to_regclass($1) IS NOT NULL
+	`, chain.MigrationsTable).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNoVersion
+	}
+
+	var version int64
+	var dirty bool
+	switch err := target.QueryRow(ctx, fmt.Sprintf(`
+		SELECT version, dirty FROM %s LIMIT 1
+	`, chain.MigrationsTable)).Scan(&version, &dirty); err {
+	case nil:
+	case storage.ErrNoRows:
+		return ErrNoVersion
+	default:
+		return err
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("schema is dirty at version %d", version)
+	case version != int64(latest):
+		return fmt.Errorf("schema is at version %d, expected %d", version, latest)
+	}
+	return nil
+}
+
+// LatestVersion returns the version of the latest migration
+// at the provided source URL.
+func LatestVersion(sourceURL string) (uint, error) {
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	latest, err := src.First()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		version, err := src.Next(latest)
+		if errors.Is(err, os.ErrNotExist) {
+			return latest, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		latest = version
+	}
+}
+
+// Render renders all migration templates at the provided source URL
+// into the provided directory.
+func Render(sourceURL, dir string, params TemplateParams) error {
+	src, err := source.Open(sourceURL)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	for err == nil {
+		if err = renderVersion(src, version, dir, params); err != nil {
+			return err
+		}
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func renderVersion(src source.Driver, version uint, dir string, params TemplateParams) error {
+	for direction, read := range map[string]func(uint) (io.ReadCloser, string, error){
+		"up":   src.ReadUp,
+		"down": src.ReadDown,
+	} {
+		r, identifier, err := read(version)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		raw, err := ioutil.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+
+		name := fmt.Sprintf("%04d_%s.%s.sql", version, identifier, direction)
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(string(raw))
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, params); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), buf.Bytes(), 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainDatabaseURL returns the URL of the provided chain's database, with the
+// chain's migrations table. SQLite endpoints are directories containing a
+// database file per chain schema, in which tables are not schema-qualified.
+func chainDatabaseURL(databaseURL string, chain *analyzer.Chain) (string, error) {
+	if strings.HasPrefix(databaseURL, sqlite.Scheme) {
+		path := sqlite.SchemaPath(databaseURL, chain.Schema)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return "", err
+		}
+		databaseURL = sqlite.Scheme + path
+	}
+	return withMigrationsTable(databaseURL, chain.MigrationsTable)
+}
+
+// withMigrationsTable sets the migrations table of the provided database URL.
+func withMigrationsTable(databaseURL, table string) (string, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set(migrationsTableParam, table)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
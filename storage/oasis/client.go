@@ -8,6 +8,7 @@ import (
 	"github.com/oasisprotocol/oasis-core/go/common"
 	"github.com/oasisprotocol/oasis-core/go/common/cbor"
 	"github.com/oasisprotocol/oasis-core/go/common/crypto/signature"
+	consensusAPI "github.com/oasisprotocol/oasis-core/go/consensus/api"
 	"github.com/oasisprotocol/oasis-core/go/consensus/api/transaction"
 	genesisAPI "github.com/oasisprotocol/oasis-core/go/genesis/api"
 	governanceAPI "github.com/oasisprotocol/oasis-core/go/governance/api"
@@ -130,6 +131,17 @@ func (c *Client) Name() string {
 	return moduleName
 }
 
+// LatestHeight returns the height of the latest block available.
+func (c *Client) LatestHeight(ctx context.Context) (int64, error) {
+	connection := *c.connection
+	block, err := connection.Consensus().GetBlock(ctx, consensusAPI.HeightLatest)
+	if err != nil {
+		return 0, err
+	}
+
+	return block.Height, nil
+}
+
 // BlockData retrieves data about a block at the provided block height.
 func (c *Client) BlockData(ctx context.Context, height int64) (*storage.BlockData, error) {
 	connection := *c.connection
@@ -320,6 +332,38 @@ func (c *Client) StakingData(ctx context.Context, height int64) (*storage.Stakin
 	}, nil
 }
 
+// SupplyData retrieves the token supply and reserved pool balances at the provided block height.
+func (c *Client) SupplyData(ctx context.Context, height int64) (*storage.SupplyData, error) {
+	connection := *c.connection
+	totalSupply, err := connection.Consensus().Staking().TotalSupply(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	commonPool, err := connection.Consensus().Staking().CommonPool(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	lastBlockFees, err := connection.Consensus().Staking().LastBlockFees(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	governanceDeposits, err := connection.Consensus().Staking().GovernanceDeposits(ctx, height)
+	if err != nil {
+		return nil, err
+	}
+
+	return &storage.SupplyData{
+		Height:             height,
+		TotalSupply:        *totalSupply,
+		CommonPool:         *commonPool,
+		LastBlockFees:      *lastBlockFees,
+		GovernanceDeposits: *governanceDeposits,
+	}, nil
+}
+
 // SchedulerData retrieves validators and runtime committees at the provided block height.
 func (c *Client) SchedulerData(ctx context.Context, height int64) (*storage.SchedulerData, error) {
 	connection := *c.connection
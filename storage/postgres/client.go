@@ -4,11 +4,13 @@ package postgres
 
 import (
 	"context"
+	"errors"
 
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 
 	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
 )
 
 const (
@@ -17,36 +19,96 @@ const (
 
 var defaultMaxConns = int32(32)
 
+// Options are options of a PostgreSQL client.
+type Options struct {
+	// MaxConns is the maximum size of the connection pool to the primary.
+	// If zero, a default is used.
+	MaxConns int32
+
+	// Replicas are the connection strings of read replicas.
+	Replicas []string
+
+	// ReplicaMaxConns is the maximum size of the connection pool to each
+	// replica. If zero, a default is used.
+	ReplicaMaxConns int32
+}
+
 // Client is a PostgreSQL client.
 type Client struct {
-	pool   *pgxpool.Pool
-	logger *log.Logger
+	pool     *pgxpool.Pool
+	replicas *replicaSet
+	logger   *log.Logger
 }
 
 // NewClient creates a new PostgreSQL client.
 func NewClient(connString string, l *log.Logger) (*Client, error) {
-	config, err := pgxpool.ParseConfig(connString)
+	return NewClientWithOptions(connString, Options{}, l)
+}
+
+// NewClientWithOptions creates a new PostgreSQL client with the provided
+// options. Writes are sent to the primary, and reads with a minimum height
+// are routed to replicas if configured.
+func NewClientWithOptions(connString string, opts Options, l *log.Logger) (*Client, error) {
+	logger := l.WithModule(moduleName)
+
+	pool, err := connect(connString, opts.MaxConns)
 	if err != nil {
 		return nil, err
 	}
-	config.MaxConns = defaultMaxConns
 
-	pool, err := pgxpool.ConnectConfig(context.Background(), config)
-	if err != nil {
-		return nil, err
+	replicas := &replicaSet{
+		logger: logger,
+	}
+	for _, replicaConnString := range opts.Replicas {
+		replicaPool, err := connect(replicaConnString, opts.ReplicaMaxConns)
+		if err != nil {
+			replicas.close()
+			pool.Close()
+			return nil, err
+		}
+		replicas.add(replicaPool)
 	}
+
 	return &Client{
-		pool:   pool,
-		logger: l.WithModule(moduleName),
+		pool:     pool,
+		replicas: replicas,
+		logger:   logger,
 	}, nil
 }
 
+// connect creates a new connection pool of the provided maximum size.
+func connect(connString string, maxConns int32) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	config.MaxConns = defaultMaxConns
+	if maxConns > 0 {
+		config.MaxConns = maxConns
+	}
+
+	return pgxpool.ConnectConfig(context.Background(), config)
+}
+
 // SendBatch submits a new transaction batch to PostgreSQL.
-func (c *Client) SendBatch(ctx context.Context, batch *pgx.Batch) error {
+// Copied rows are bulk-inserted using COPY.
+func (c *Client) SendBatch(ctx context.Context, batch *storage.QueryBatch) error {
+	b := pgxBatch(batch)
 	if err := c.pool.BeginTxFunc(ctx, pgx.TxOptions{}, func(tx pgx.Tx) error {
-		batchResults := tx.SendBatch(ctx, batch)
+		for _, rows := range batch.Copies() {
+			if _, err := tx.CopyFrom(
+				ctx,
+				pgx.Identifier(rows.Identifier()),
+				rows.Columns,
+				pgx.CopyFromRows(rows.Rows),
+			); err != nil {
+				return err
+			}
+		}
+
+		batchResults := tx.SendBatch(ctx, b)
 		defer batchResults.Close()
-		for i := 0; i < batch.Len(); i++ {
+		for i := 0; i < b.Len(); i++ {
 			if _, err := batchResults.Exec(); err != nil {
 				return err
 			}
@@ -64,8 +126,8 @@ func (c *Client) SendBatch(ctx context.Context, batch *pgx.Batch) error {
 }
 
 // Query submits a new query to PostgreSQL.
-func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
-	rows, err := c.pool.Query(ctx, sql, args...)
+func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	rows, err := c.reader(ctx).Query(ctx, sql, args...)
 	if err != nil {
 		c.logger.Error("failed to query db",
 			"error", err,
@@ -76,16 +138,85 @@ func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (pg
 }
 
 // QueryRow submits a new query for a single row to PostgreSQL.
-func (c *Client) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
-	return c.pool.QueryRow(ctx, sql, args...)
+func (c *Client) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	return row{c.reader(ctx).QueryRow(ctx, sql, args...)}
+}
+
+// ReadSnapshot calls the provided function with a querier whose reads
+// are served from a single read-only, repeatable read transaction on
+// the primary.
+func (c *Client) ReadSnapshot(ctx context.Context, read func(storage.Querier) error) error {
+	return c.pool.BeginTxFunc(ctx, pgx.TxOptions{
+		IsoLevel:   pgx.RepeatableRead,
+		AccessMode: pgx.ReadOnly,
+	}, func(tx pgx.Tx) error {
+		return read(txQuerier{tx})
+	})
+}
+
+// reader returns the pool from which to serve a read with the provided
+// context. Reads with a minimum height are served from a replica which is
+// indexed up to that height, if any, and all other reads from the primary.
+func (c *Client) reader(ctx context.Context) *pgxpool.Pool {
+	if pool := c.replicas.pick(ctx); pool != nil {
+		return pool
+	}
+	return c.pool
 }
 
 // Shutdown shuts down the target storage client.
 func (c *Client) Shutdown() {
+	c.replicas.close()
 	c.pool.Close()
 }
 
+// PartitionsByHeight returns true, as blocks, transactions and events
+// are partitioned by height range in PostgreSQL.
+func (c *Client) PartitionsByHeight() bool {
+	return true
+}
+
 // Name returns the name of the PostgreSQL client.
 func (c *Client) Name() string {
 	return moduleName
 }
+
+// pgxBatch returns the queries of the provided batch as a pgx batch.
+func pgxBatch(batch *storage.QueryBatch) *pgx.Batch {
+	b := &pgx.Batch{}
+	for _, item := range batch.Items() {
+		b.Queue(item.SQL, item.Args...)
+	}
+	return b
+}
+
+// txQuerier serves reads from a transaction.
+type txQuerier struct {
+	tx pgx.Tx
+}
+
+// Query implements storage.Querier.
+func (q txQuerier) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	return q.tx.Query(ctx, sql, args...)
+}
+
+// QueryRow implements storage.Querier.
+func (q txQuerier) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	return row{q.tx.QueryRow(ctx, sql, args...)}
+}
+
+// row is a pgx row which returns storage errors.
+type row struct {
+	pgx.Row
+}
+
+// Scan implements storage.QueryResult.
+func (r row) Scan(dest ...interface{}) error {
+	if err := r.Row.Scan(dest...); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// replicaHeightTTL is the duration for which the indexed height
+// of a replica is cached.
+var replicaHeightTTL = time.Second
+
+// replicaHeight is the cached indexed height of a schema in a replica.
+type replicaHeight struct {
+	height    int64
+	fetchedAt time.Time
+}
+
+// replica is a read replica.
+type replica struct {
+	pool *pgxpool.Pool
+
+	// fetchHeight returns the height up to which the provided
+	// schema is indexed in the replica.
+	fetchHeight func(ctx context.Context, schema string) (int64, error)
+
+	mu      sync.Mutex
+	heights map[string]replicaHeight
+}
+
+// height returns the height up to which the provided schema is indexed in
+// the replica, which may lag behind by up to replicaHeightTTL.
+func (r *replica) height(ctx context.Context, schema string, now time.Time) (int64, error) {
+	r.mu.Lock()
+	h, ok := r.heights[schema]
+	r.mu.Unlock()
+	if ok && now.Sub(h.fetchedAt) < replicaHeightTTL {
+		return h.height, nil
+	}
+
+	// The lock is not held while fetching, so that reads are not blocked
+	// on a slow replica. Concurrent reads may fetch the height as well.
+	height, err := r.fetchHeight(ctx, schema)
+	if err != nil {
+		return 0, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h, ok := r.heights[schema]; !ok || h.fetchedAt.Before(now) {
+		r.heights[schema] = replicaHeight{
+			height:    height,
+			fetchedAt: now,
+		}
+	}
+	return height, nil
+}
+
+// replicaSet routes reads to read replicas, taking turns.
+type replicaSet struct {
+	replicas []*replica
+	next     uint32
+
+	logger *log.Logger
+}
+
+// add adds a replica with the provided connection pool.
+func (s *replicaSet) add(pool *pgxpool.Pool) {
+	s.replicas = append(s.replicas, &replica{
+		pool: pool,
+		fetchHeight: func(ctx context.Context, schema string) (int64, error) {
+			var height int64
+			err := pool.QueryRow(ctx, fmt.Sprintf(`
+				SELECT COALESCE(MAX(height), 0) FROM %s.processed_blocks
+					WHERE analyzer NOT LIKE $1 ESCAPE '\'
+			`, schema), analyzer.ShardProgressPattern).Scan(&height)
+			return height, err
+		},
+		heights: make(map[string]replicaHeight),
+	})
+}
+
+// pick returns the pool of a replica which can serve a read with the
+// provided context, or nil if there is none. Only reads with a minimum
+// height are served from replicas, so that writes and reads which must
+// observe preceding writes are sent to the primary.
+func (s *replicaSet) pick(ctx context.Context) *pgxpool.Pool {
+	if r := s.pickReplica(ctx, time.Now()); r != nil {
+		return r.pool
+	}
+	return nil
+}
+
+func (s *replicaSet) pickReplica(ctx context.Context, now time.Time) *replica {
+	n := len(s.replicas)
+	minHeight, ok := storage.MinHeightFromContext(ctx)
+	if n == 0 || !ok {
+		return nil
+	}
+	start := int(atomic.AddUint32(&s.next, 1) % uint32(n))
+
+	for i := 0; i < n; i++ {
+		r := s.replicas[(start+i)%n]
+		height, err := r.height(ctx, minHeight.Schema, now)
+		if err != nil {
+			s.logger.Warn("failed to fetch replica height",
+				"schema", minHeight.Schema,
+				"err", err.Error(),
+			)
+			continue
+		}
+		if height >= minHeight.Height {
+			return r
+		}
+	}
+
+	s.logger.Debug("replicas behind requested height, reading from primary",
+		"schema", minHeight.Schema,
+		"height", minHeight.Height,
+	)
+	return nil
+}
+
+// close closes the connection pools of all replicas.
+func (s *replicaSet) close() {
+	for _, r := range s.replicas {
+		r.pool.Close()
+	}
+}
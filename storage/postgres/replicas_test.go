@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+func newTestReplicaSet(t *testing.T, heights ...int64) (*replicaSet, []int) {
+	logger, err := log.NewLogger("postgres-test", ioutil.Discard, log.FmtJSON, log.LevelInfo)
+	require.Nil(t, err)
+
+	s := &replicaSet{logger: logger}
+	fetches := make([]int, len(heights))
+	for i, height := range heights {
+		i, height := i, height
+		s.replicas = append(s.replicas, &replica{
+			fetchHeight: func(ctx context.Context, schema string) (int64, error) {
+				fetches[i]++
+				if height < 0 {
+					return 0, errors.New("replica unavailable")
+				}
+				return height, nil
+			},
+			heights: make(map[string]replicaHeight),
+		})
+	}
+	return s, fetches
+}
+
+func TestPickReplicaRoundRobin(t *testing.T) {
+	s, fetches := newTestReplicaSet(t, 100, 100)
+	now := time.Now()
+	ctx := storage.WithMinHeight(context.Background(), "oasis_3", 0)
+
+	first := s.pickReplica(ctx, now)
+	second := s.pickReplica(ctx, now)
+	require.NotNil(t, first)
+	require.NotNil(t, second)
+	require.NotSame(t, first, second)
+	require.Same(t, first, s.pickReplica(ctx, now))
+	require.Equal(t, []int{1, 1}, fetches)
+}
+
+func TestPickReplicaWithoutMinHeight(t *testing.T) {
+	s, fetches := newTestReplicaSet(t, 100, 100)
+
+	// Reads without a height requirement are served by the primary.
+	require.Nil(t, s.pickReplica(context.Background(), time.Now()))
+	require.Equal(t, []int{0, 0}, fetches)
+}
+
+func TestPickReplicaNone(t *testing.T) {
+	s, _ := newTestReplicaSet(t)
+	require.Nil(t, s.pickReplica(context.Background(), time.Now()))
+}
+
+func TestPickReplicaMinHeight(t *testing.T) {
+	s, _ := newTestReplicaSet(t, 90, 100, -1)
+	now := time.Now()
+
+	// Only the caught up replica serves the read.
+	ctx := storage.WithMinHeight(context.Background(), "oasis_3", 95)
+	for i := 0; i < 3; i++ {
+		require.Same(t, s.replicas[1], s.pickReplica(ctx, now))
+	}
+
+	// Reads fall back to the primary if all replicas are behind.
+	ctx = storage.WithMinHeight(context.Background(), "oasis_3", 101)
+	require.Nil(t, s.pickReplica(ctx, now))
+}
+
+func TestReplicaHeightCache(t *testing.T) {
+	s, fetches := newTestReplicaSet(t, 100)
+	now := time.Now()
+	ctx := storage.WithMinHeight(context.Background(), "oasis_3", 100)
+
+	require.NotNil(t, s.pickReplica(ctx, now))
+	require.NotNil(t, s.pickReplica(ctx, now.Add(replicaHeightTTL/2)))
+	require.Equal(t, 1, fetches[0])
+
+	require.NotNil(t, s.pickReplica(ctx, now.Add(replicaHeightTTL)))
+	require.Equal(t, 2, fetches[0])
+
+	// Heights are cached per schema.
+	require.NotNil(t, s.pickReplica(storage.WithMinHeight(context.Background(), "oasis_2", 100), now))
+	require.Equal(t, 3, fetches[0])
+}
+
+// TestReplicaHeightFetchUnlocked tests that cached heights are served
+// while the height of another schema is being fetched.
+func TestReplicaHeightFetchUnlocked(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := &replica{
+		fetchHeight: func(ctx context.Context, schema string) (int64, error) {
+			if schema == "oasis_2" {
+				close(started)
+				<-release
+			}
+			return 100, nil
+		},
+		heights: make(map[string]replicaHeight),
+	}
+	now := time.Now()
+	_, err := r.height(ctx, "oasis_3", now)
+	require.Nil(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = r.height(ctx, "oasis_2", now)
+	}()
+	<-started
+
+	height, err := r.height(ctx, "oasis_3", now)
+	require.Nil(t, err)
+	require.Equal(t, int64(100), height)
+
+	close(release)
+	<-done
+}
+
+// TestShardProgressPattern tests that the indexed height of replicas
+// ignores the progress of shards.
+func TestShardProgressPattern(t *testing.T) {
+	require.Equal(t, `%\_shard\_%`, analyzer.ShardProgressPattern)
+}
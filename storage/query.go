@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrNoRows is returned by QueryResult.Scan when the query returned no rows.
+var ErrNoRows = errors.New("no rows in result set")
+
+// BatchItem is a query in a batch.
+type BatchItem struct {
+	// SQL is the SQL statement of the query.
+	SQL string
+
+	// Args are the arguments bound to the query's parameters.
+	Args []interface{}
+}
+
+// QueryBatch represents a batch of queries to be executed atomically.
+// Queries may be queued concurrently.
+//
+// Rows of append-only tables may instead be copied to the batch, to be
+// bulk-inserted before the batch's queries are executed.
+type QueryBatch struct {
+	mu     sync.Mutex
+	items  []*BatchItem
+	copies []*CopyRows
+}
+
+// Queue queues a query to the batch.
+func (b *QueryBatch) Queue(sql string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.items = append(b.items, &BatchItem{
+		SQL:  sql,
+		Args: args,
+	})
+}
+
+// Len returns the number of queued queries.
+func (b *QueryBatch) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return len(b.items)
+}
+
+// Items returns the queued queries, in order.
+func (b *QueryBatch) Items() []*BatchItem {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := make([]*BatchItem, len(b.items))
+	copy(items, b.items)
+	return items
+}
+
+// Copy adds a row to bulk-insert into the provided table and columns.
+func (b *QueryBatch) Copy(table string, columns []string, values ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	rows := b.copyRows(table, columns)
+	rows.Rows = append(rows.Rows, values)
+}
+
+// copyRows returns the rows to bulk-insert into the provided table and
+// columns, adding them if there are none yet. The caller must hold the lock.
+func (b *QueryBatch) copyRows(table string, columns []string) *CopyRows {
+	for _, c := range b.copies {
+		if c.Table == table && strings.Join(c.Columns, ",") == strings.Join(columns, ",") {
+			return c
+		}
+	}
+	c := &CopyRows{
+		Table:   table,
+		Columns: columns,
+	}
+	b.copies = append(b.copies, c)
+	return c
+}
+
+// Copies returns the rows to bulk-insert per table, in the order
+// in which the tables were first copied to.
+func (b *QueryBatch) Copies() []*CopyRows {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	copies := make([]*CopyRows, len(b.copies))
+	for i, c := range b.copies {
+		copies[i] = &CopyRows{
+			Table:   c.Table,
+			Columns: c.Columns,
+			Rows:    append([][]interface{}(nil), c.Rows...),
+		}
+	}
+	return copies
+}
+
+// Append appends the queries and copied rows of the provided batch,
+// so that both are executed in a single transaction.
+func (b *QueryBatch) Append(other *QueryBatch) {
+	items := other.Items()
+	copies := other.Copies()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.items = append(b.items, items...)
+	for _, c := range copies {
+		rows := b.copyRows(c.Table, c.Columns)
+		rows.Rows = append(rows.Rows, c.Rows...)
+	}
+}
+
+// QueryResults represents the results from a read query,
+// iterated over with Next.
+type QueryResults interface {
+	// Next prepares the next row for reading. It returns false if there
+	// are no more rows, or if an error occurred.
+	Next() bool
+
+	// Scan reads the values of the current row into the provided destinations.
+	Scan(dest ...interface{}) error
+
+	// Err returns any error that occurred while reading rows.
+	Err() error
+
+	// Close closes the results, releasing any resources.
+	Close()
+}
+
+// QueryResult represents the result from a read query for a single row.
+type QueryResult interface {
+	// Scan reads the values of the row into the provided destinations.
+	// It returns ErrNoRows if the query returned no rows.
+	Scan(dest ...interface{}) error
+}
+
+// ScanRows calls the provided function for each row of the provided
+// results, and closes them.
+func ScanRows(rows QueryResults, f func(QueryResults) error) error {
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := f(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// QueryStrings returns the values of the single string column
+// returned by the provided query.
+func QueryStrings(ctx context.Context, db Querier, sql string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []string
+	if err := ScanRows(rows, func(rows QueryResults) error {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return err
+		}
+		values = append(values, value)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// QueryInt64 returns the value of the single integer column of the single
+// row returned by the provided query. It returns ErrNoRows if there is none.
+func QueryInt64(ctx context.Context, db Querier, sql string, args ...interface{}) (int64, error) {
+	var value int64
+	if err := db.QueryRow(ctx, sql, args...).Scan(&value); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// QueryBool returns the value of the single boolean column of the single
+// row returned by the provided query. It returns ErrNoRows if there is none.
+func QueryBool(ctx context.Context, db Querier, sql string, args ...interface{}) (bool, error) {
+	var value bool
+	if err := db.QueryRow(ctx, sql, args...).Scan(&value); err != nil {
+		return false, err
+	}
+	return value, nil
+}
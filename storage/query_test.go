@@ -0,0 +1,107 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/storagetest"
+)
+
+func TestQueryBatchConcurrentQueue(t *testing.T) {
+	batch := &storage.QueryBatch{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			batch.Queue("INSERT INTO t VALUES ($1)", i)
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, 100, batch.Len())
+	seen := make(map[int]bool)
+	for _, item := range batch.Items() {
+		seen[item.Args[0].(int)] = true
+	}
+	require.Len(t, seen, 100)
+}
+
+func TestQueryBatchItemsOrder(t *testing.T) {
+	batch := &storage.QueryBatch{}
+	for i := 0; i < 3; i++ {
+		batch.Queue(fmt.Sprintf("QUERY %d", i), i)
+	}
+
+	items := batch.Items()
+	require.Len(t, items, 3)
+	for i, item := range items {
+		require.Equal(t, fmt.Sprintf("QUERY %d", i), item.SQL)
+		require.Equal(t, []interface{}{i}, item.Args)
+	}
+
+	// Items returns a copy.
+	items[0] = nil
+	require.NotNil(t, batch.Items()[0])
+}
+
+func TestScanRows(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.NewStorage()
+	db.AddRows("FROM pairs",
+		[]interface{}{"a", int64(1)},
+		[]interface{}{"b", int64(2)},
+	)
+
+	rows, err := db.Query(ctx, "SELECT key, value FROM pairs")
+	require.Nil(t, err)
+
+	values := make(map[string]int64)
+	require.Nil(t, storage.ScanRows(rows, func(rows storage.QueryResults) error {
+		var key string
+		var value int64
+		if err := rows.Scan(&key, &value); err != nil {
+			return err
+		}
+		values[key] = value
+		return nil
+	}))
+	require.Equal(t, map[string]int64{"a": 1, "b": 2}, values)
+
+	rows, err = db.Query(ctx, "SELECT key, value FROM pairs")
+	require.Nil(t, err)
+	errStop := errors.New("stop")
+	require.Equal(t, errStop, storage.ScanRows(rows, func(storage.QueryResults) error {
+		return errStop
+	}))
+}
+
+func TestQueryHelpers(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.NewStorage()
+	db.AddRows("SELECT name FROM", []interface{}{"alice"}, []interface{}{"bob"})
+	db.AddRows("COUNT", []interface{}{int64(42)})
+	db.AddRows("EXISTS", []interface{}{true})
+
+	names, err := storage.QueryStrings(ctx, db, "SELECT name FROM names")
+	require.Nil(t, err)
+	require.Equal(t, []string{"alice", "bob"}, names)
+
+	count, err := storage.QueryInt64(ctx, db, "SELECT COUNT(*) FROM names")
+	require.Nil(t, err)
+	require.Equal(t, int64(42), count)
+
+	exists, err := storage.QueryBool(ctx, db, "SELECT EXISTS (SELECT 1 FROM names)")
+	require.Nil(t, err)
+	require.True(t, exists)
+
+	_, err = storage.QueryInt64(ctx, db, "SELECT height FROM blocks")
+	require.Equal(t, storage.ErrNoRows, err)
+}
@@ -0,0 +1,297 @@
+// Package sqlite implements the target storage interface
+// backed by SQLite, for running the indexer as a single process.
+//
+// Each chain schema is a separate database file in the endpoint directory,
+// attached to every connection under the schema's name, so that queries
+// written for PostgreSQL address tables as `<schema>.<table>`.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite" // sqlite driver for database/sql
+
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+const (
+	moduleName = "sqlite"
+
+	// Scheme is the URL scheme of SQLite endpoints.
+	Scheme = "sqlite://"
+
+	// maxParams is the maximum number of parameters bound to a
+	// single query, which is SQLite's default limit.
+	maxParams = 32766
+
+	// busyTimeoutMs is how long a connection waits for another
+	// connection's write to complete before failing.
+	busyTimeoutMs = 10000
+)
+
+// Dir returns the directory of the provided endpoint.
+func Dir(endpoint string) string {
+	return strings.TrimPrefix(endpoint, Scheme)
+}
+
+// SchemaPath returns the path of the database file of the provided schema
+// in the directory of the provided endpoint.
+func SchemaPath(endpoint, schema string) string {
+	return filepath.Join(Dir(endpoint), schema+".db")
+}
+
+// Client is a SQLite client.
+type Client struct {
+	db     *sql.DB
+	logger *log.Logger
+}
+
+// NewClient creates a new SQLite client for the provided endpoint,
+// attaching the database files of the provided schemas. The endpoint
+// directory is created if it does not exist.
+func NewClient(endpoint string, schemas []string, l *log.Logger) (*Client, error) {
+	if err := os.MkdirAll(Dir(endpoint), 0o700); err != nil {
+		return nil, err
+	}
+
+	// Opening does not connect; it provides the registered driver.
+	db, err := sql.Open("sqlite", "")
+	if err != nil {
+		return nil, err
+	}
+	drv := db.Driver()
+	db.Close()
+
+	return &Client{
+		db: sql.OpenDB(&connector{
+			driver:   drv,
+			endpoint: endpoint,
+			schemas:  schemas,
+		}),
+		logger: l.WithModule(moduleName),
+	}, nil
+}
+
+// SendBatch submits a new transaction batch to SQLite.
+// Copied rows are bulk-inserted using multi-row inserts.
+func (c *Client) SendBatch(ctx context.Context, batch *storage.QueryBatch) error {
+	for _, item := range batch.Items() {
+		if err := checkSupported(item.SQL); err != nil {
+			return err
+		}
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		c.logger.Error("failed to begin tx",
+			"error", err,
+		)
+		return err
+	}
+
+	var items []*storage.BatchItem
+	for _, rows := range batch.Copies() {
+		items = append(items, rows.InsertsWithMaxParams(maxParams)...)
+	}
+	items = append(items, batch.Items()...)
+	for _, item := range items {
+		args, err := bindArgs(item.Args)
+		if err == nil {
+			_, err = tx.ExecContext(ctx, rewrite(item.SQL), args...)
+		}
+		if err != nil {
+			c.logger.Error("failed to execute tx batch",
+				"error", err,
+			)
+			if rbErr := tx.Rollback(); rbErr != nil {
+				c.logger.Error("failed to roll back tx batch",
+					"error", rbErr,
+				)
+			}
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		c.logger.Error("failed to commit tx batch",
+			"error", err,
+		)
+		return err
+	}
+	return nil
+}
+
+// Query submits a new query to SQLite.
+func (c *Client) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	return querier{c.db, c.logger}.Query(ctx, sql, args...)
+}
+
+// QueryRow submits a new query for a single row to SQLite.
+func (c *Client) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	return querier{c.db, c.logger}.QueryRow(ctx, sql, args...)
+}
+
+// ReadSnapshot calls the provided function with a querier whose reads
+// are served from a single read transaction. Transactions otherwise take
+// the write lock upfront, so a deferred transaction is begun explicitly,
+// which reads a snapshot of the database without blocking writers.
+func (c *Client) ReadSnapshot(ctx context.Context, read func(storage.Querier) error) error {
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "BEGIN DEFERRED"); err != nil {
+		return err
+	}
+	err = read(querier{conn, c.logger})
+	if _, rbErr := conn.ExecContext(ctx, "ROLLBACK"); err == nil {
+		err = rbErr
+	}
+	return err
+}
+
+// Shutdown shuts down the target storage client.
+func (c *Client) Shutdown() {
+	c.db.Close()
+}
+
+// Name returns the name of the SQLite client.
+func (c *Client) Name() string {
+	return moduleName
+}
+
+// queryer is a database or a connection to it.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// querier serves reads from a database or a connection to it.
+type querier struct {
+	db     queryer
+	logger *log.Logger
+}
+
+// Query implements storage.Querier.
+func (q querier) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	if err := checkSupported(sql); err != nil {
+		return nil, err
+	}
+	bound, err := bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	r, err := q.db.QueryContext(ctx, rewrite(sql), bound...)
+	if err != nil {
+		q.logger.Error("failed to query db",
+			"error", err,
+		)
+		return nil, err
+	}
+	return rows{r}, nil
+}
+
+// QueryRow implements storage.Querier.
+func (q querier) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	if err := checkSupported(sql); err != nil {
+		return row{err: err}
+	}
+	bound, err := bindArgs(args)
+	if err != nil {
+		return row{err: err}
+	}
+	return row{Row: q.db.QueryRowContext(ctx, rewrite(sql), bound...)}
+}
+
+// connector opens connections with the schema database files attached.
+type connector struct {
+	driver   driver.Driver
+	endpoint string
+	schemas  []string
+}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	// The main database is unused, as all tables are in attached databases.
+	// Transactions take the write lock upfront, so that concurrent writers
+	// wait for each other instead of failing to upgrade their locks.
+	conn, err := c.driver.Open(fmt.Sprintf(":memory:?_txlock=immediate&_pragma=busy_timeout(%d)", busyTimeoutMs))
+	if err != nil {
+		return nil, err
+	}
+	execer, ok := conn.(driver.ExecerContext)
+	if !ok {
+		conn.Close()
+		return nil, errors.New("sqlite: driver does not support exec")
+	}
+	for _, schema := range c.schemas {
+		for _, stmt := range []struct {
+			sql  string
+			args []driver.NamedValue
+		}{
+			{
+				sql:  fmt.Sprintf(`ATTACH DATABASE $1 AS "%s"`, schema),
+				args: []driver.NamedValue{{Ordinal: 1, Value: SchemaPath(c.endpoint, schema)}},
+			},
+			// Readers do not block the writer in write-ahead logging mode.
+			{sql: fmt.Sprintf(`PRAGMA "%s".journal_mode = WAL`, schema)},
+		} {
+			if _, err := execer.ExecContext(ctx, stmt.sql, stmt.args); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+	}
+	return conn, nil
+}
+
+// Driver implements driver.Connector.
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// rows are database/sql rows which parse stored timestamps.
+type rows struct {
+	*sql.Rows
+}
+
+// Scan implements storage.QueryResults.
+func (r rows) Scan(dest ...interface{}) error {
+	return r.Rows.Scan(scanners(dest)...)
+}
+
+// Close implements storage.QueryResults.
+func (r rows) Close() {
+	r.Rows.Close()
+}
+
+// row is a database/sql row which parses stored timestamps,
+// and returns storage errors.
+type row struct {
+	*sql.Row
+	err error
+}
+
+// Scan implements storage.QueryResult.
+func (r row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if err := r.Row.Scan(scanners(dest)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.ErrNoRows
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+package sqlite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/analyzer"
+	"github.com/oasislabs/oasis-indexer/analyzer/coordination"
+	"github.com/oasislabs/oasis-indexer/log"
+	"github.com/oasislabs/oasis-indexer/storage"
+	"github.com/oasislabs/oasis-indexer/storage/migrator"
+	"github.com/oasislabs/oasis-indexer/storage/sqlite"
+)
+
+const migrations = "file://../migrations/sqlite"
+
+func newChain() *analyzer.Chain {
+	return &analyzer.Chain{
+		ID:              "oasis-3",
+		Schema:          "oasis_3",
+		MigrationsTable: "schema_migrations",
+	}
+}
+
+// newClient migrates a new SQLite database for the provided chain,
+// and returns a client to it.
+func newClient(t *testing.T, chain *analyzer.Chain) (*sqlite.Client, *migrator.Migrator) {
+	logger := log.NewDefaultLogger("sqlite-test")
+	endpoint := sqlite.Scheme + t.TempDir()
+
+	m := migrator.NewMigrator(migrations, endpoint, logger)
+	require.Nil(t, m.Up(chain))
+
+	client, err := sqlite.NewClient(endpoint, []string{chain.Schema}, logger)
+	require.Nil(t, err)
+	t.Cleanup(client.Shutdown)
+	return client, m
+}
+
+// TestMigrations tests that migrations are applied to and reverted from
+// the chain's database file, and that the API's version check passes.
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	chain := newChain()
+	client, m := newClient(t, chain)
+
+	require.Nil(t, migrator.CheckVersion(ctx, client, migrations, chain))
+
+	var commissions int64
+	require.Nil(t, client.QueryRow(ctx, `
+		SELECT COUNT(*) FROM oasis_3.commissions
+	`).Scan(&commissions))
+	require.NotZero(t, commissions)
+
+	require.Nil(t, m.Down(chain, 0))
+	status, err := m.Status(chain)
+	require.Nil(t, err)
+	require.Nil(t, status.Version)
+}
+
+// TestSendBatch tests that copied rows and queries are applied in a single
+// transaction, and read back with PostgreSQL syntax.
+func TestSendBatch(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, newChain())
+
+	blockTime := time.Date(2022, 4, 11, 8, 30, 0, 0, time.UTC)
+	batch := &storage.QueryBatch{}
+	batch.Copy("oasis_3.blocks", []string{"height", "block_hash", "time", "namespace", "version", "type", "root_hash"},
+		int64(8048956), "hash", blockTime, "ns", uint64(1), "type", "root")
+	batch.Queue(`
+		INSERT INTO oasis_3.accounts (address, general_balance) VALUES ($1, $2)
+			ON CONFLICT (address) DO
+				UPDATE SET general_balance = oasis_3.accounts.general_balance + $2;
+	`, "oasis1", "100")
+	batch.Queue(`
+		INSERT INTO oasis_3.accounts (address, general_balance) VALUES ($1, $2)
+			ON CONFLICT (address) DO
+				UPDATE SET general_balance = oasis_3.accounts.general_balance + $2;
+	`, "oasis1", "50")
+	batch.Queue(`
+		INSERT INTO oasis_3.processed_blocks (height, analyzer, processed_time)
+			VALUES ($1, $2, CURRENT_TIMESTAMP);
+	`, int64(8048956), "consensus")
+	require.Nil(t, client.SendBatch(ctx, batch))
+
+	var height int64
+	var hash string
+	var ts time.Time
+	require.Nil(t, client.QueryRow(ctx, `
+		SELECT height, block_hash, time FROM oasis_3.blocks
+			WHERE height = $1::bigint
+	`, int64(8048956)).Scan(&height, &hash, &ts))
+	require.Equal(t, int64(8048956), height)
+	require.Equal(t, "hash", hash)
+	require.True(t, blockTime.Equal(ts))
+
+	var balance uint64
+	require.Nil(t, client.QueryRow(ctx, `
+		SELECT general_balance FROM oasis_3.accounts WHERE address = $1::text
+	`, "oasis1").Scan(&balance))
+	require.Equal(t, uint64(150), balance)
+
+	var processed *time.Time
+	require.Nil(t, client.QueryRow(ctx, `
+		SELECT MAX(processed_time) FROM oasis_3.processed_blocks
+	`).Scan(&processed))
+	require.NotNil(t, processed)
+	require.WithinDuration(t, time.Now(), *processed, time.Minute)
+
+	err := client.QueryRow(ctx, `
+		SELECT height FROM oasis_3.blocks WHERE height = $1
+	`, int64(1)).Scan(&height)
+	require.Equal(t, storage.ErrNoRows, err)
+
+	// A failed batch is rolled back.
+	batch = &storage.QueryBatch{}
+	batch.Queue(`TRUNCATE oasis_3.accounts`)
+	batch.Queue(`INSERT INTO oasis_3.missing (x) VALUES (1)`)
+	require.NotNil(t, client.SendBatch(ctx, batch))
+
+	accounts, err := storage.QueryStrings(ctx, client, `SELECT address FROM oasis_3.accounts`)
+	require.Nil(t, err)
+	require.Equal(t, []string{"oasis1"}, accounts)
+
+	// Multiple tables are truncated at once.
+	batch = &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.allowances (owner, beneficiary, allowance) VALUES ('oasis1', 'oasis2', 1)`)
+	batch.Queue(`TRUNCATE oasis_3.accounts, oasis_3.allowances CASCADE;`)
+	require.Nil(t, client.SendBatch(ctx, batch))
+	for _, table := range []string{"accounts", "allowances"} {
+		var n int64
+		require.Nil(t, client.QueryRow(ctx, `SELECT COUNT(*) FROM oasis_3.`+table).Scan(&n))
+		require.Equal(t, int64(0), n, table)
+	}
+}
+
+// TestUnsupported tests that statements without a SQLite equivalent fail
+// with a clear error, without applying the rest of the batch.
+func TestUnsupported(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, newChain())
+
+	batch := &storage.QueryBatch{}
+	batch.Queue(`INSERT INTO oasis_3.accounts (address, general_balance) VALUES ('oasis1', 1)`)
+	batch.Queue(`SET TRANSACTION ISOLATION LEVEL REPEATABLE READ;`)
+	batch.Queue(`CREATE TABLE oasis_3.accounts_checkpoint AS TABLE oasis_3.accounts;`)
+	err := client.SendBatch(ctx, batch)
+	require.ErrorIs(t, err, sqlite.ErrUnsupported)
+	require.Contains(t, err.Error(), "SET TRANSACTION ISOLATION LEVEL is unsupported on sqlite")
+
+	accounts, err := storage.QueryStrings(ctx, client, `SELECT address FROM oasis_3.accounts`)
+	require.Nil(t, err)
+	require.Empty(t, accounts)
+}
+
+// TestReadSnapshot tests that reads of a snapshot do not observe
+// concurrent writes, which are not blocked.
+func TestReadSnapshot(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, newChain())
+
+	insert := func(address string) {
+		batch := &storage.QueryBatch{}
+		batch.Queue(`INSERT INTO oasis_3.accounts (address, general_balance) VALUES ($1, 1)`, address)
+		require.Nil(t, client.SendBatch(ctx, batch))
+	}
+	insert("oasis1")
+
+	require.Nil(t, client.ReadSnapshot(ctx, func(q storage.Querier) error {
+		accounts, err := storage.QueryStrings(ctx, q, `SELECT address FROM oasis_3.accounts`)
+		require.Nil(t, err)
+		require.Equal(t, []string{"oasis1"}, accounts)
+
+		insert("oasis2")
+
+		accounts, err = storage.QueryStrings(ctx, q, `SELECT address FROM oasis_3.accounts`)
+		require.Nil(t, err)
+		require.Equal(t, []string{"oasis1"}, accounts)
+		return nil
+	}))
+
+	accounts, err := storage.QueryStrings(ctx, client, `SELECT address FROM oasis_3.accounts ORDER BY address`)
+	require.Nil(t, err)
+	require.Equal(t, []string{"oasis1", "oasis2"}, accounts)
+}
+
+// TestLease tests that leases expire with timestamps written by SQLite.
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	client, _ := newClient(t, newChain())
+
+	a := coordination.NewLease(client, "oasis_3", "consensus", "a", time.Hour)
+	b := coordination.NewLease(client, "oasis_3", "consensus", "b", time.Hour)
+	require.Nil(t, a.Acquire(ctx))
+	require.Nil(t, a.Acquire(ctx))
+	require.Equal(t, coordination.ErrLeaseHeld, b.Acquire(ctx))
+
+	require.Nil(t, a.Release(ctx))
+	require.Nil(t, b.Acquire(ctx))
+}
@@ -0,0 +1,279 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// timeFormat is the format in which timestamps are stored, which is that of
+// `strftime('%Y-%m-%d %H:%M:%f')` in UTC, so that stored timestamps compare
+// correctly with the current timestamp.
+const timeFormat = "2006-01-02 15:04:05.000"
+
+// timeParseFormats are the formats in which stored timestamps are parsed.
+var timeParseFormats = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+}
+
+// rewrites are the rewrites from PostgreSQL to SQLite syntax, applied to
+// queries in order. Queries are otherwise written in the common subset
+// of the two dialects.
+var rewrites = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	// Intervals are only added to the current timestamp, e.g. for leases.
+	{
+		regexp.MustCompile(`CURRENT_TIMESTAMP\s*\+\s*(\$\d+)\s*\*\s*INTERVAL\s*'1 millisecond'`),
+		`strftime('%Y-%m-%d %H:%M:%f', 'now', ($1 / 1000.0) || ' seconds')`,
+	},
+	{
+		regexp.MustCompile(`\bCURRENT_TIMESTAMP\b`),
+		`strftime('%Y-%m-%d %H:%M:%f', 'now')`,
+	},
+	{
+		regexp.MustCompile(`\bTIMESTAMP\s*('[^']*')`),
+		`strftime('%Y-%m-%d %H:%M:%f', $1)`,
+	},
+	// Arrays are stored as JSON arrays, and are only tested for
+	// containing a single element.
+	{
+		regexp.MustCompile(`([\w.]+)\s*@>\s*ARRAY\[(\$\d+)\]`),
+		`EXISTS (SELECT 1 FROM json_each($1) WHERE value = $2)`,
+	},
+	{
+		regexp.MustCompile(`(?i)\bGREATEST\(`),
+		`MAX(`,
+	},
+	{
+		regexp.MustCompile(`(?i)\bLEAST\(`),
+		`MIN(`,
+	},
+	{
+		regexp.MustCompile(`to_regclass\((\$\d+)\) IS NOT NULL`),
+		`EXISTS (SELECT 1 FROM pragma_table_list WHERE name = $1)`,
+	},
+}
+
+// literalPattern matches single-quoted string literals.
+var literalPattern = regexp.MustCompile(`'(?:[^']|'')*'`)
+
+// castPattern matches casts, which are dropped outside of string literals,
+// as columns are dynamically typed.
+var castPattern = regexp.MustCompile(`::\s*[A-Za-z]+(\s*\[\])?`)
+
+// ErrUnsupported is returned for queries using PostgreSQL features
+// which have no SQLite equivalent.
+var ErrUnsupported = errors.New("unsupported on sqlite")
+
+// unsupported are the PostgreSQL statements which are not rewritten,
+// e.g. those used by the verifier to checkpoint indexed state.
+var unsupported = []struct {
+	pattern   *regexp.Regexp
+	statement string
+}{
+	{regexp.MustCompile(`(?i)\bSET\s+TRANSACTION\s+ISOLATION\s+LEVEL\b`), "SET TRANSACTION ISOLATION LEVEL"},
+	{regexp.MustCompile(`(?i)\bCREATE\s+TABLE\s+[\w.]+\s+AS\s+TABLE\b`), "CREATE TABLE ... AS TABLE"},
+}
+
+// truncatePattern matches truncations of one or more tables, which are
+// rewritten to a deletion per table.
+var truncatePattern = regexp.MustCompile(`(?i)\bTRUNCATE\s+(?:TABLE\s+)?([\w.]+(?:\s*,\s*[\w.]+)*)(?:\s+CASCADE)?`)
+
+// outsideLiterals applies the provided function to the parts of the
+// provided query outside of string literals.
+func outsideLiterals(sql string, f func(string) string) string {
+	var b strings.Builder
+	last := 0
+	for _, loc := range literalPattern.FindAllStringIndex(sql, -1) {
+		b.WriteString(f(sql[last:loc[0]]))
+		b.WriteString(sql[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(f(sql[last:]))
+	return b.String()
+}
+
+// checkSupported returns an error if the provided PostgreSQL query
+// has no SQLite equivalent.
+func checkSupported(sql string) error {
+	sql = literalPattern.ReplaceAllString(sql, "''")
+	for _, u := range unsupported {
+		if u.pattern.MatchString(sql) {
+			return fmt.Errorf("sqlite: %s is %w", u.statement, ErrUnsupported)
+		}
+	}
+	return nil
+}
+
+// rewrite returns the SQLite equivalent of the provided PostgreSQL query.
+func rewrite(sql string) string {
+	sql = outsideLiterals(sql, func(s string) string {
+		return castPattern.ReplaceAllString(s, "")
+	})
+	for _, r := range rewrites {
+		sql = r.pattern.ReplaceAllString(sql, r.repl)
+	}
+	return truncatePattern.ReplaceAllStringFunc(sql, func(truncate string) string {
+		tables := strings.Split(truncatePattern.FindStringSubmatch(truncate)[1], ",")
+		deletes := make([]string, len(tables))
+		for i, table := range tables {
+			deletes[i] = "DELETE FROM " + strings.TrimSpace(table)
+		}
+		return strings.Join(deletes, "; ")
+	})
+}
+
+// bindArgs returns the provided query arguments as stored by SQLite.
+func bindArgs(args []interface{}) ([]interface{}, error) {
+	bound := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case time.Time:
+			bound[i] = v.UTC().Format(timeFormat)
+		case []string:
+			if v == nil {
+				v = []string{}
+			}
+			// Marshaling strings cannot fail.
+			b, _ := json.Marshal(v)
+			bound[i] = string(b)
+		case uint64:
+			// Integers are signed 64-bit. Larger values would be
+			// stored as text, which neither sums nor compares as a
+			// number, so they are rejected.
+			if v > math.MaxInt64 {
+				return nil, fmt.Errorf("sqlite: argument %d out of range: %d", i+1, v)
+			}
+			bound[i] = int64(v)
+		default:
+			bound[i] = arg
+		}
+	}
+	return bound, nil
+}
+
+// scanners returns the provided scan destinations, with timestamps
+// parsed from stored text and JSON values decoded from stored text.
+func scanners(dest []interface{}) []interface{} {
+	s := make([]interface{}, len(dest))
+	for i, d := range dest {
+		switch v := d.(type) {
+		case *time.Time:
+			s[i] = &timeScanner{dest: v}
+		case **time.Time:
+			s[i] = &nullTimeScanner{dest: v}
+		case sql.Scanner:
+			s[i] = d
+		default:
+			if isJSON(d) {
+				s[i] = &jsonScanner{dest: d}
+			} else {
+				s[i] = d
+			}
+		}
+	}
+	return s
+}
+
+// isJSON returns true if the provided scan destination is decoded from
+// JSON, as PostgreSQL does for JSON columns. Byte slices are scanned as is.
+func isJSON(dest interface{}) bool {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return false
+	}
+	switch t.Elem().Kind() {
+	case reflect.Struct, reflect.Map:
+		return true
+	case reflect.Slice:
+		return t.Elem().Elem().Kind() != reflect.Uint8
+	default:
+		return false
+	}
+}
+
+// jsonScanner scans a stored JSON value.
+type jsonScanner struct {
+	dest interface{}
+}
+
+// Scan implements sql.Scanner.
+func (s *jsonScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), s.dest)
+	case []byte:
+		return json.Unmarshal(v, s.dest)
+	default:
+		return fmt.Errorf("sqlite: cannot scan %T into %T", src, s.dest)
+	}
+}
+
+// timeScanner scans a stored timestamp.
+type timeScanner struct {
+	dest *time.Time
+}
+
+// Scan implements sql.Scanner.
+func (s *timeScanner) Scan(src interface{}) error {
+	t, err := parseTime(src)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return fmt.Errorf("sqlite: cannot scan NULL into *time.Time")
+	}
+	*s.dest = *t
+	return nil
+}
+
+// nullTimeScanner scans a nullable stored timestamp.
+type nullTimeScanner struct {
+	dest **time.Time
+}
+
+// Scan implements sql.Scanner.
+func (s *nullTimeScanner) Scan(src interface{}) error {
+	t, err := parseTime(src)
+	if err != nil {
+		return err
+	}
+	*s.dest = t
+	return nil
+}
+
+// parseTime parses the provided stored timestamp, which is nil if NULL.
+func parseTime(src interface{}) (*time.Time, error) {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		return nil, nil
+	case time.Time:
+		return &v, nil
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return nil, fmt.Errorf("sqlite: cannot scan %T into *time.Time", src)
+	}
+	for _, format := range timeParseFormats {
+		if t, err := time.ParseInLocation(format, text, time.UTC); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("sqlite: malformed timestamp '%s'", text)
+}
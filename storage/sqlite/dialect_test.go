@@ -0,0 +1,110 @@
+package sqlite
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestRewrite tests rewriting PostgreSQL syntax to SQLite syntax.
+func TestRewrite(t *testing.T) {
+	for sql, expected := range map[string]string{
+		"SELECT * FROM oasis_3.blocks WHERE height = $1::bigint":     "SELECT * FROM oasis_3.blocks WHERE height = $1",
+		"SELECT SUM(shares)::TEXT FROM oasis_3.delegations":          "SELECT SUM(shares) FROM oasis_3.delegations",
+		"SET height = GREATEST(h.height, excluded.height)":           "SET height = MAX(h.height, excluded.height)",
+		"TRUNCATE oasis_3.committee_members;":                        "DELETE FROM oasis_3.committee_members;",
+		"TRUNCATE TABLE oasis_3.commissions CASCADE":                 "DELETE FROM oasis_3.commissions",
+		"TRUNCATE oasis_3.proposals, oasis_3.votes CASCADE;":         "DELETE FROM oasis_3.proposals; DELETE FROM oasis_3.votes;",
+		"VALUES ($1, CURRENT_TIMESTAMP)":                             "VALUES ($1, strftime('%Y-%m-%d %H:%M:%f', 'now'))",
+		"WHERE time >= TIMESTAMP '2022-04-11T00:00:00Z'":             "WHERE time >= strftime('%Y-%m-%d %H:%M:%f', '2022-04-11T00:00:00Z')",
+		"VALUES (CURRENT_TIMESTAMP + $3 * INTERVAL '1 millisecond')": "VALUES (strftime('%Y-%m-%d %H:%M:%f', 'now', ($3 / 1000.0) || ' seconds'))",
+		"SELECT to_regclass($1) IS NOT NULL":                         "SELECT EXISTS (SELECT 1 FROM pragma_table_list WHERE name = $1)",
+		"WHERE related_accounts @> ARRAY[$2::TEXT]":                  "WHERE EXISTS (SELECT 1 FROM json_each(related_accounts) WHERE value = $2)",
+		"WHERE body->>'type' = 'a::b' AND x = $1::TEXT":              "WHERE body->>'type' = 'a::b' AND x = $1",
+		"SELECT 'it''s::TEXT'::TEXT":                                 "SELECT 'it''s::TEXT'",
+	} {
+		require.Equal(t, expected, rewrite(sql), sql)
+	}
+}
+
+// TestCheckSupported tests that PostgreSQL statements without a SQLite
+// equivalent are rejected.
+func TestCheckSupported(t *testing.T) {
+	for _, sql := range []string{
+		"SET TRANSACTION ISOLATION LEVEL REPEATABLE READ;",
+		"CREATE TABLE oasis_3.accounts_checkpoint AS TABLE oasis_3.accounts;",
+	} {
+		require.ErrorIs(t, checkSupported(sql), ErrUnsupported, sql)
+	}
+	for _, sql := range []string{
+		"SELECT * FROM oasis_3.accounts",
+		"SELECT 'SET TRANSACTION ISOLATION LEVEL'",
+	} {
+		require.Nil(t, checkSupported(sql), sql)
+	}
+}
+
+// TestBindArgs tests converting query arguments to stored values.
+func TestBindArgs(t *testing.T) {
+	ts := time.Date(2022, 4, 11, 10, 30, 0, 500000000, time.FixedZone("CEST", 2*60*60))
+	bound, err := bindArgs([]interface{}{ts, uint64(1), uint64(math.MaxInt64), "text", []string{"a", "b"}, []string(nil)})
+	require.Nil(t, err)
+	require.Equal(t, []interface{}{
+		"2022-04-11 08:30:00.500",
+		int64(1),
+		int64(math.MaxInt64),
+		"text",
+		`["a","b"]`,
+		"[]",
+	}, bound)
+
+	// Integers which do not fit in a signed 64-bit integer are rejected.
+	_, err = bindArgs([]interface{}{uint64(math.MaxUint64)})
+	require.NotNil(t, err)
+}
+
+// TestParseTime tests parsing stored timestamps.
+func TestParseTime(t *testing.T) {
+	expected := time.Date(2022, 4, 11, 8, 30, 0, 500000000, time.UTC)
+	for _, src := range []interface{}{
+		"2022-04-11 08:30:00.500",
+		[]byte("2022-04-11T08:30:00.5"),
+		"2022-04-11T10:30:00.5+02:00",
+		expected,
+	} {
+		ts, err := parseTime(src)
+		require.Nil(t, err)
+		require.True(t, expected.Equal(*ts), src)
+	}
+
+	ts, err := parseTime(nil)
+	require.Nil(t, err)
+	require.Nil(t, ts)
+
+	_, err = parseTime("yesterday")
+	require.NotNil(t, err)
+}
+
+// TestScanners tests that JSON values are decoded from stored text.
+func TestScanners(t *testing.T) {
+	var meta struct {
+		Name string `json:"name"`
+	}
+	var accounts []string
+	var body []byte
+	var ts time.Time
+	s := scanners([]interface{}{&meta, &accounts, &body, &ts})
+
+	require.Nil(t, s[0].(*jsonScanner).Scan(`{"name":"validator"}`))
+	require.Equal(t, "validator", meta.Name)
+	require.Nil(t, s[1].(*jsonScanner).Scan([]byte(`["a","b"]`)))
+	require.Equal(t, []string{"a", "b"}, accounts)
+	require.Equal(t, &body, s[2])
+	require.IsType(t, &timeScanner{}, s[3])
+
+	// NULL leaves the destination unchanged.
+	require.Nil(t, s[0].(*jsonScanner).Scan(nil))
+	require.Equal(t, "validator", meta.Name)
+}
@@ -0,0 +1,249 @@
+// Package storagetest implements an in-memory fake of storage.TargetStorage
+// for use in tests.
+package storagetest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+// response is a registered response to queries containing a given SQL fragment.
+type response struct {
+	fragment string
+	rows     [][]interface{}
+	err      error
+}
+
+// Storage is an in-memory fake of storage.TargetStorage. It records sent
+// batches and answers read queries with registered responses.
+type Storage struct {
+	mu        sync.Mutex
+	name      string
+	batches   []*storage.QueryBatch
+	responses []response
+	batchErr  error
+
+	// unpartitioned is true iff the fake does not partition by height.
+	unpartitioned bool
+}
+
+// NewStorage creates a new fake storage.
+func NewStorage() *Storage {
+	return &Storage{name: "storagetest"}
+}
+
+// AddRows registers the provided rows as the response to queries whose SQL
+// contains the provided fragment. Responses are matched in the order in
+// which they were registered.
+func (s *Storage) AddRows(fragment string, rows ...[]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = append(s.responses, response{
+		fragment: fragment,
+		rows:     rows,
+	})
+}
+
+// AddError registers the provided error as the response to queries whose
+// SQL contains the provided fragment.
+func (s *Storage) AddError(fragment string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses = append(s.responses, response{
+		fragment: fragment,
+		err:      err,
+	})
+}
+
+// SetBatchError sets the error returned by subsequent calls to SendBatch.
+func (s *Storage) SetBatchError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batchErr = err
+}
+
+// SetPartitionsByHeight sets whether the fake reports partitioning blocks,
+// transactions and events by height range, which it does by default.
+func (s *Storage) SetPartitionsByHeight(partitioned bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.unpartitioned = !partitioned
+}
+
+// Batches returns the batches sent so far, in order.
+func (s *Storage) Batches() []*storage.QueryBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batches := make([]*storage.QueryBatch, len(s.batches))
+	copy(batches, s.batches)
+	return batches
+}
+
+// Items returns the queries of all batches sent so far, in order.
+func (s *Storage) Items() []*storage.BatchItem {
+	var items []*storage.BatchItem
+	for _, b := range s.Batches() {
+		items = append(items, b.Items()...)
+	}
+	return items
+}
+
+// SendBatch records the provided batch.
+func (s *Storage) SendBatch(ctx context.Context, batch *storage.QueryBatch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.batchErr != nil {
+		return s.batchErr
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+// Query returns the rows registered for the provided query.
+func (s *Storage) Query(ctx context.Context, sql string, args ...interface{}) (storage.QueryResults, error) {
+	r, err := s.lookup(sql)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{rows: r, cur: -1}, nil
+}
+
+// QueryRow returns the first row registered for the provided query.
+func (s *Storage) QueryRow(ctx context.Context, sql string, args ...interface{}) storage.QueryResult {
+	r, err := s.lookup(sql)
+	return &row{rows: r, err: err}
+}
+
+// Shutdown is a no-op.
+func (s *Storage) Shutdown() {}
+
+// PartitionsByHeight returns true unless disabled with SetPartitionsByHeight.
+func (s *Storage) PartitionsByHeight() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return !s.unpartitioned
+}
+
+// Name returns the name of the fake storage.
+func (s *Storage) Name() string {
+	return s.name
+}
+
+func (s *Storage) lookup(sql string) ([][]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.responses {
+		if strings.Contains(sql, r.fragment) {
+			return r.rows, r.err
+		}
+	}
+	return nil, nil
+}
+
+// rows implements storage.QueryResults over registered rows.
+type rows struct {
+	rows [][]interface{}
+	cur  int
+	err  error
+}
+
+func (r *rows) Next() bool {
+	if r.err != nil || r.cur+1 >= len(r.rows) {
+		return false
+	}
+	r.cur++
+	return true
+}
+
+func (r *rows) Scan(dest ...interface{}) error {
+	if r.cur < 0 || r.cur >= len(r.rows) {
+		return fmt.Errorf("storagetest: scan called without a current row")
+	}
+	if err := scan(r.rows[r.cur], dest); err != nil {
+		r.err = err
+		return err
+	}
+	return nil
+}
+
+func (r *rows) Err() error {
+	return r.err
+}
+
+func (r *rows) Close() {}
+
+// row implements storage.QueryResult over registered rows.
+type row struct {
+	rows [][]interface{}
+	err  error
+}
+
+func (r *row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.rows) == 0 {
+		return storage.ErrNoRows
+	}
+	return scan(r.rows[0], dest)
+}
+
+// scan copies the provided values into the provided destinations,
+// converting between compatible types.
+func scan(values []interface{}, dest []interface{}) error {
+	if len(values) != len(dest) {
+		return fmt.Errorf("storagetest: %d values but %d destinations", len(values), len(dest))
+	}
+	for i, d := range dest {
+		dv := reflect.ValueOf(d)
+		if dv.Kind() != reflect.Ptr || dv.IsNil() {
+			return fmt.Errorf("storagetest: destination %d is not a non-nil pointer", i)
+		}
+		target := dv.Elem()
+		if values[i] == nil {
+			target.Set(reflect.Zero(target.Type()))
+			continue
+		}
+
+		v := reflect.ValueOf(values[i])
+		// Scanning into a pointer destination allocates the pointee,
+		// as with nullable columns.
+		if target.Kind() == reflect.Ptr {
+			p := reflect.New(target.Type().Elem())
+			if err := assign(p.Elem(), v, i); err != nil {
+				return err
+			}
+			target.Set(p)
+			continue
+		}
+		if err := assign(target, v, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func assign(target reflect.Value, v reflect.Value, i int) error {
+	switch {
+	case v.Type().AssignableTo(target.Type()):
+		target.Set(v)
+	case v.Type().ConvertibleTo(target.Type()) && v.Kind() != reflect.String && target.Kind() != reflect.String:
+		target.Set(v.Convert(target.Type()))
+	default:
+		return fmt.Errorf("storagetest: cannot scan %s into %s for value %d", v.Type(), target.Type(), i)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/oasislabs/oasis-indexer/storage"
+)
+
+func TestSendBatch(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorage()
+
+	batch := &storage.QueryBatch{}
+	batch.Queue("INSERT INTO t VALUES ($1)", 1)
+	batch.Queue("INSERT INTO t VALUES ($1)", 2)
+	require.Nil(t, s.SendBatch(ctx, batch))
+	require.Len(t, s.Batches(), 1)
+	require.Len(t, s.Items(), 2)
+
+	errBatch := errors.New("batch failed")
+	s.SetBatchError(errBatch)
+	require.Equal(t, errBatch, s.SendBatch(ctx, &storage.QueryBatch{}))
+	require.Len(t, s.Batches(), 1)
+}
+
+func TestQueryRow(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorage()
+	s.AddRows("FROM blocks", []interface{}{int32(7), "hash", nil})
+	errQuery := errors.New("query failed")
+	s.AddError("FROM broken", errQuery)
+
+	var height int64
+	var hash string
+	var parent *string
+	require.Nil(t, s.QueryRow(ctx, "SELECT height, hash, parent FROM blocks").Scan(&height, &hash, &parent))
+	require.Equal(t, int64(7), height)
+	require.Equal(t, "hash", hash)
+	require.Nil(t, parent)
+
+	require.Equal(t, storage.ErrNoRows, s.QueryRow(ctx, "SELECT 1 FROM missing").Scan(&height))
+	require.Equal(t, errQuery, s.QueryRow(ctx, "SELECT 1 FROM broken").Scan(&height))
+	_, err := s.Query(ctx, "SELECT 1 FROM broken")
+	require.Equal(t, errQuery, err)
+
+	// Mismatched destinations are reported.
+	require.NotNil(t, s.QueryRow(ctx, "SELECT height FROM blocks").Scan(&height))
+	require.NotNil(t, s.QueryRow(ctx, "SELECT height, hash, parent FROM blocks").Scan(&hash, &hash, &parent))
+}
+
+func TestQueryPointerDestination(t *testing.T) {
+	ctx := context.Background()
+	s := NewStorage()
+	s.AddRows("FROM nodes", []interface{}{"v1"})
+
+	var version *string
+	require.Nil(t, s.QueryRow(ctx, "SELECT version FROM nodes").Scan(&version))
+	require.NotNil(t, version)
+	require.Equal(t, "v1", *version)
+}